@@ -0,0 +1,15 @@
+// Package db embeds the SQL migrations so the binary can apply them without
+// the source tree.
+package db
+
+import "embed"
+
+// Migrations holds db/migrations/*.sql.
+//
+//go:embed migrations/*.sql
+var Migrations embed.FS
+
+// SQLiteSchema holds db/sqlite/*.sql, applied in order by the SQLite backend.
+//
+//go:embed sqlite/*.sql
+var SQLiteSchema embed.FS
@@ -0,0 +1,13 @@
+DROP INDEX IF EXISTS idx_rate_samples_status;
+-- Errored and partial rows cannot satisfy the restored NOT NULL constraints.
+DELETE FROM rate_samples
+WHERE official_susde_per_usde IS NULL
+   OR market_susde_per_usde IS NULL
+   OR deviation_pct IS NULL
+   OR cow_quote IS NULL;
+ALTER TABLE rate_samples
+    DROP COLUMN IF EXISTS error_phase,
+    ALTER COLUMN official_susde_per_usde SET NOT NULL,
+    ALTER COLUMN market_susde_per_usde SET NOT NULL,
+    ALTER COLUMN deviation_pct SET NOT NULL,
+    ALTER COLUMN cow_quote SET NOT NULL;
@@ -0,0 +1,8 @@
+ALTER TABLE rate_samples
+    ALTER COLUMN official_susde_per_usde DROP NOT NULL,
+    ALTER COLUMN market_susde_per_usde DROP NOT NULL,
+    ALTER COLUMN deviation_pct DROP NOT NULL,
+    ALTER COLUMN cow_quote DROP NOT NULL,
+    ADD COLUMN error_phase TEXT;
+
+CREATE INDEX idx_rate_samples_status ON rate_samples (status) WHERE status <> 'complete';
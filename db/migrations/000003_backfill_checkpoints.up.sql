@@ -0,0 +1,10 @@
+CREATE TABLE backfill_checkpoints (
+    job_key      TEXT         PRIMARY KEY,
+    range_from   timestamptz  NOT NULL,
+    range_to     timestamptz  NOT NULL,
+    interval_sec BIGINT       NOT NULL,
+    watermark    timestamptz  NOT NULL,
+    processed    BIGINT       NOT NULL DEFAULT 0,
+    failed       BIGINT       NOT NULL DEFAULT 0,
+    updated_at   timestamptz  NOT NULL DEFAULT now()
+);
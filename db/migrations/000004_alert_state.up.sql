@@ -0,0 +1,13 @@
+CREATE TABLE alert_state (
+    state_key            TEXT         PRIMARY KEY,
+    state                TEXT         NOT NULL DEFAULT 'ok',
+    direction            TEXT,
+    consecutive_breaches INTEGER      NOT NULL DEFAULT 0,
+    pending_since        timestamptz,
+    firing_since         timestamptz,
+    notified             BOOLEAN      NOT NULL DEFAULT false,
+    last_bucket          timestamptz,
+    last_alert_up_at     timestamptz,
+    last_alert_down_at   timestamptz,
+    updated_at           timestamptz  NOT NULL DEFAULT now()
+);
@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS idx_alerts_state;
+
+DELETE FROM alerts WHERE state IN ('pending', 'cancelled');
+
+ALTER TABLE alerts
+    DROP COLUMN IF EXISTS confirmed_at,
+    DROP COLUMN IF EXISTS state;
+
+ALTER TABLE alert_state DROP COLUMN IF EXISTS breach_window;
+ALTER TABLE alert_state RENAME COLUMN breach_count TO consecutive_breaches;
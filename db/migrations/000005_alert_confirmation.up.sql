@@ -0,0 +1,10 @@
+ALTER TABLE alert_state RENAME COLUMN consecutive_breaches TO breach_count;
+ALTER TABLE alert_state ADD COLUMN breach_window BIGINT NOT NULL DEFAULT 0;
+
+ALTER TABLE alerts
+    ADD COLUMN state        TEXT         NOT NULL DEFAULT 'firing',
+    ADD COLUMN confirmed_at timestamptz;
+
+UPDATE alerts SET confirmed_at = sample_ts WHERE confirmed_at IS NULL;
+
+CREATE INDEX idx_alerts_state ON alerts (state);
@@ -0,0 +1,5 @@
+ALTER TABLE alert_state DROP COLUMN IF EXISTS peak_deviation_pct;
+
+ALTER TABLE alerts
+    DROP COLUMN IF EXISTS peak_deviation_pct,
+    DROP COLUMN IF EXISTS resolved_at;
@@ -0,0 +1,7 @@
+ALTER TABLE alerts
+    ADD COLUMN resolved_at        timestamptz,
+    ADD COLUMN peak_deviation_pct NUMERIC(12, 8);
+
+UPDATE alerts SET peak_deviation_pct = deviation_pct WHERE peak_deviation_pct IS NULL;
+
+ALTER TABLE alert_state ADD COLUMN peak_deviation_pct NUMERIC(12, 8);
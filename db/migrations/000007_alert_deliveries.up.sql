@@ -0,0 +1,12 @@
+CREATE TABLE alert_deliveries (
+    id         BIGSERIAL    PRIMARY KEY,
+    alert_id   BIGINT       NOT NULL REFERENCES alerts(id) ON DELETE CASCADE,
+    kind       TEXT         NOT NULL,
+    channel    TEXT         NOT NULL,
+    status     TEXT         NOT NULL,
+    attempts   INTEGER      NOT NULL,
+    error      TEXT,
+    created_at timestamptz  NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_alert_deliveries_alert ON alert_deliveries (alert_id, created_at);
@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS rate_sample_rollups;
+
+DELETE FROM alerts a
+WHERE NOT EXISTS (SELECT 1 FROM rate_samples s WHERE s.bucket_ts = a.sample_ts);
+
+ALTER TABLE alerts
+    ADD CONSTRAINT alerts_sample_ts_fkey
+    FOREIGN KEY (sample_ts) REFERENCES rate_samples(bucket_ts) ON DELETE CASCADE;
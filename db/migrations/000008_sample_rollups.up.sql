@@ -0,0 +1,23 @@
+-- Alerts outlive raw samples once retention prunes rate_samples.
+ALTER TABLE alerts DROP CONSTRAINT IF EXISTS alerts_sample_ts_fkey;
+
+CREATE TABLE rate_sample_rollups (
+    resolution      TEXT             NOT NULL,
+    bucket_ts       timestamptz      NOT NULL,
+    samples         INTEGER          NOT NULL,
+    complete        INTEGER          NOT NULL,
+    official_min    NUMERIC(38, 18),
+    official_max    NUMERIC(38, 18),
+    official_avg    NUMERIC(38, 18),
+    official_last   NUMERIC(38, 18),
+    market_min      NUMERIC(38, 18),
+    market_max      NUMERIC(38, 18),
+    market_avg      NUMERIC(38, 18),
+    market_last     NUMERIC(38, 18),
+    deviation_min   NUMERIC(12, 8),
+    deviation_max   NUMERIC(12, 8),
+    deviation_avg   NUMERIC(12, 8),
+    deviation_last  NUMERIC(12, 8),
+    updated_at      timestamptz      NOT NULL DEFAULT now(),
+    PRIMARY KEY (resolution, bucket_ts)
+);
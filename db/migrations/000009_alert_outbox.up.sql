@@ -0,0 +1,13 @@
+CREATE TABLE alert_outbox (
+    id               BIGSERIAL    PRIMARY KEY,
+    episode_ts       timestamptz,
+    kind             TEXT         NOT NULL,
+    payload          JSONB        NOT NULL,
+    status           TEXT         NOT NULL DEFAULT 'pending',
+    attempts         INTEGER      NOT NULL DEFAULT 0,
+    last_error       TEXT,
+    created_at       timestamptz  NOT NULL DEFAULT now(),
+    last_attempt_at  timestamptz
+);
+
+CREATE INDEX idx_alert_outbox_pending ON alert_outbox (id) WHERE status = 'pending';
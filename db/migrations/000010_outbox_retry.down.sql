@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_alert_outbox_due;
+CREATE INDEX idx_alert_outbox_pending ON alert_outbox (id) WHERE status = 'pending';
+
+UPDATE alert_outbox SET status = 'failed' WHERE status = 'dead';
+
+ALTER TABLE alert_outbox
+    DROP COLUMN IF EXISTS channels,
+    DROP COLUMN IF EXISTS next_attempt_at;
@@ -0,0 +1,8 @@
+ALTER TABLE alert_outbox
+    ADD COLUMN next_attempt_at timestamptz NOT NULL DEFAULT now(),
+    ADD COLUMN channels        TEXT[];
+
+UPDATE alert_outbox SET status = 'dead' WHERE status = 'failed';
+
+DROP INDEX IF EXISTS idx_alert_outbox_pending;
+CREATE INDEX idx_alert_outbox_due ON alert_outbox (next_attempt_at) WHERE status = 'pending';
@@ -0,0 +1,18 @@
+-- Only the original pair fits the single-pair schema; rows of other pairs
+-- are dropped.
+DELETE FROM rate_sample_rollups WHERE pair_id <> 'usde-susde';
+ALTER TABLE rate_sample_rollups DROP CONSTRAINT rate_sample_rollups_pkey;
+ALTER TABLE rate_sample_rollups DROP COLUMN pair_id;
+ALTER TABLE rate_sample_rollups ADD PRIMARY KEY (resolution, bucket_ts);
+
+DELETE FROM alerts WHERE pair_id <> 'usde-susde';
+ALTER TABLE alerts DROP CONSTRAINT alerts_pair_sample_ts_key;
+ALTER TABLE alerts DROP COLUMN pair_id;
+ALTER TABLE alerts ADD CONSTRAINT alerts_sample_ts_key UNIQUE (sample_ts);
+
+DELETE FROM rate_samples WHERE pair_id <> 'usde-susde';
+DROP INDEX IF EXISTS idx_rate_samples_pair_ts_desc;
+ALTER TABLE rate_samples DROP CONSTRAINT rate_samples_pkey;
+ALTER TABLE rate_samples DROP COLUMN pair_id;
+ALTER TABLE rate_samples ADD PRIMARY KEY (bucket_ts);
+CREATE INDEX idx_rate_samples_ts_desc ON rate_samples (bucket_ts DESC);
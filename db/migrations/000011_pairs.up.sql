@@ -0,0 +1,20 @@
+-- Samples, rollups and alerts are keyed per monitored pair. Existing rows
+-- belong to the original USDe/sUSDe pair; the rate columns keep their names
+-- and hold vault shares per underlying token for every pair.
+ALTER TABLE rate_samples ADD COLUMN pair_id TEXT NOT NULL DEFAULT 'usde-susde';
+ALTER TABLE rate_samples ALTER COLUMN pair_id DROP DEFAULT;
+ALTER TABLE rate_samples DROP CONSTRAINT rate_samples_pkey;
+ALTER TABLE rate_samples ADD PRIMARY KEY (pair_id, bucket_ts);
+
+DROP INDEX IF EXISTS idx_rate_samples_ts_desc;
+CREATE INDEX idx_rate_samples_pair_ts_desc ON rate_samples (pair_id, bucket_ts DESC);
+
+ALTER TABLE alerts ADD COLUMN pair_id TEXT NOT NULL DEFAULT 'usde-susde';
+ALTER TABLE alerts ALTER COLUMN pair_id DROP DEFAULT;
+ALTER TABLE alerts DROP CONSTRAINT alerts_sample_ts_key;
+ALTER TABLE alerts ADD CONSTRAINT alerts_pair_sample_ts_key UNIQUE (pair_id, sample_ts);
+
+ALTER TABLE rate_sample_rollups ADD COLUMN pair_id TEXT NOT NULL DEFAULT 'usde-susde';
+ALTER TABLE rate_sample_rollups ALTER COLUMN pair_id DROP DEFAULT;
+ALTER TABLE rate_sample_rollups DROP CONSTRAINT rate_sample_rollups_pkey;
+ALTER TABLE rate_sample_rollups ADD PRIMARY KEY (pair_id, resolution, bucket_ts);
@@ -0,0 +1 @@
+ALTER TABLE rate_samples DROP COLUMN IF EXISTS official_method;
@@ -0,0 +1,4 @@
+-- Records which ERC-4626 view each official rate was quoted from. Rows
+-- written before the method was configurable used previewDeposit.
+ALTER TABLE rate_samples ADD COLUMN official_method TEXT;
+UPDATE rate_samples SET official_method = 'previewDeposit' WHERE official_susde_per_usde IS NOT NULL;
@@ -0,0 +1,3 @@
+ALTER TABLE rate_samples
+    DROP COLUMN IF EXISTS block_time,
+    DROP COLUMN IF EXISTS block_hash;
@@ -0,0 +1,5 @@
+-- Identify the block each official rate was read at, so samples can be
+-- checked against reorgs and matched with on-chain events.
+ALTER TABLE rate_samples
+    ADD COLUMN block_hash TEXT,
+    ADD COLUMN block_time TIMESTAMPTZ;
@@ -0,0 +1,3 @@
+ALTER TABLE rate_sample_rollups
+    DROP COLUMN IF EXISTS market_count,
+    DROP COLUMN IF EXISTS official_count;
@@ -0,0 +1,7 @@
+-- Official and market stats now also cover partial and errored samples that
+-- carry that leg, so each leg counts its own samples to weight daily
+-- averages. Earlier rollups only aggregated complete samples.
+ALTER TABLE rate_sample_rollups
+    ADD COLUMN official_count INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN market_count   INTEGER NOT NULL DEFAULT 0;
+UPDATE rate_sample_rollups SET official_count = complete, market_count = complete;
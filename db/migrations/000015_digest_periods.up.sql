@@ -0,0 +1,7 @@
+-- The end of the last digest period mailed per pair, so an instance whose
+-- digest timer fires late skips a period another instance already sent.
+CREATE TABLE digest_periods (
+    pair_id    TEXT         PRIMARY KEY,
+    period_end timestamptz  NOT NULL,
+    sent_at    timestamptz  NOT NULL DEFAULT now()
+);
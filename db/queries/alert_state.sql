@@ -0,0 +1,50 @@
+-- name: GetAlertState :one
+SELECT
+    state_key,
+    state,
+    direction,
+    breach_count,
+    pending_since,
+    firing_since,
+    notified,
+    last_bucket,
+    last_alert_up_at,
+    last_alert_down_at,
+    updated_at,
+    breach_window,
+    peak_deviation_pct
+FROM alert_state
+WHERE state_key = $1;
+
+-- name: SaveAlertState :exec
+INSERT INTO alert_state (
+    state_key,
+    state,
+    direction,
+    breach_count,
+    breach_window,
+    pending_since,
+    firing_since,
+    notified,
+    last_bucket,
+    last_alert_up_at,
+    last_alert_down_at,
+    peak_deviation_pct,
+    updated_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, now()
+)
+ON CONFLICT (state_key) DO UPDATE
+SET
+    state                = EXCLUDED.state,
+    direction            = EXCLUDED.direction,
+    breach_count         = EXCLUDED.breach_count,
+    breach_window        = EXCLUDED.breach_window,
+    pending_since        = EXCLUDED.pending_since,
+    firing_since         = EXCLUDED.firing_since,
+    notified             = EXCLUDED.notified,
+    last_bucket          = EXCLUDED.last_bucket,
+    last_alert_up_at     = EXCLUDED.last_alert_up_at,
+    last_alert_down_at   = EXCLUDED.last_alert_down_at,
+    peak_deviation_pct   = EXCLUDED.peak_deviation_pct,
+    updated_at           = now();
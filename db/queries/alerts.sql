@@ -1,20 +1,27 @@
 -- name: InsertAlert :one
 INSERT INTO alerts (
+    pair_id,
     sample_ts,
     deviation_pct,
     threshold_pct,
     direction,
-    channels
+    channels,
+    state,
+    confirmed_at,
+    peak_deviation_pct
 ) VALUES (
-    $1, $2, $3, $4, $5
+    $1, $2, $3, $4, $5, $6, $7, $8, $9
 )
-ON CONFLICT (sample_ts) DO UPDATE
+ON CONFLICT (pair_id, sample_ts) DO UPDATE
 SET
     deviation_pct = EXCLUDED.deviation_pct,
     threshold_pct = EXCLUDED.threshold_pct,
     direction     = EXCLUDED.direction,
-    channels      = EXCLUDED.channels
-RETURNING id, sample_ts, deviation_pct, threshold_pct, direction, channels, created_at;
+    channels      = EXCLUDED.channels,
+    state         = EXCLUDED.state,
+    confirmed_at  = EXCLUDED.confirmed_at,
+    peak_deviation_pct = EXCLUDED.peak_deviation_pct
+RETURNING id, sample_ts, deviation_pct, threshold_pct, direction, channels, created_at, state, confirmed_at, resolved_at, peak_deviation_pct, pair_id;
 
 -- name: ListRecentAlerts :many
 SELECT
@@ -24,11 +31,50 @@ SELECT
     threshold_pct,
     direction,
     channels,
-    created_at
+    created_at,
+    state,
+    confirmed_at,
+    resolved_at,
+    peak_deviation_pct,
+    pair_id
 FROM alerts
 ORDER BY created_at DESC
 LIMIT $1;
 
+-- name: ListAlertsBetween :many
+SELECT
+    id,
+    sample_ts,
+    deviation_pct,
+    threshold_pct,
+    direction,
+    channels,
+    created_at,
+    state,
+    confirmed_at,
+    resolved_at,
+    peak_deviation_pct,
+    pair_id
+FROM alerts
+WHERE sample_ts >= $1
+  AND sample_ts < $2
+ORDER BY sample_ts;
+
 -- name: DeleteAlertsBefore :exec
 DELETE FROM alerts
 WHERE created_at < $1;
+
+-- name: UpdateAlertState :exec
+UPDATE alerts
+SET state = $3
+WHERE pair_id = $1
+  AND sample_ts = $2;
+
+-- name: ResolveAlert :exec
+UPDATE alerts
+SET
+    state              = 'resolved',
+    resolved_at        = $3,
+    peak_deviation_pct = $4
+WHERE pair_id = $1
+  AND sample_ts = $2;
@@ -0,0 +1,32 @@
+-- name: GetBackfillCheckpoint :one
+SELECT
+    job_key,
+    range_from,
+    range_to,
+    interval_sec,
+    watermark,
+    processed,
+    failed,
+    updated_at
+FROM backfill_checkpoints
+WHERE job_key = $1;
+
+-- name: SaveBackfillCheckpoint :exec
+INSERT INTO backfill_checkpoints (
+    job_key,
+    range_from,
+    range_to,
+    interval_sec,
+    watermark,
+    processed,
+    failed,
+    updated_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, now()
+)
+ON CONFLICT (job_key) DO UPDATE
+SET
+    watermark  = EXCLUDED.watermark,
+    processed  = EXCLUDED.processed,
+    failed     = EXCLUDED.failed,
+    updated_at = now();
@@ -0,0 +1,27 @@
+-- name: InsertAlertDelivery :exec
+INSERT INTO alert_deliveries (
+    alert_id,
+    kind,
+    channel,
+    status,
+    attempts,
+    error
+)
+SELECT a.id, $3, $4, $5, $6, $7
+FROM alerts a
+WHERE a.pair_id = $1
+  AND a.sample_ts = $2;
+
+-- name: ListAlertDeliveries :many
+SELECT
+    id,
+    alert_id,
+    kind,
+    channel,
+    status,
+    attempts,
+    error,
+    created_at
+FROM alert_deliveries
+WHERE alert_id = $1
+ORDER BY created_at, id;
@@ -0,0 +1,20 @@
+-- name: GetDigestPeriod :one
+SELECT
+    pair_id,
+    period_end,
+    sent_at
+FROM digest_periods
+WHERE pair_id = $1;
+
+-- name: MarkDigestSent :exec
+INSERT INTO digest_periods (
+    pair_id,
+    period_end,
+    sent_at
+) VALUES (
+    $1, $2, now()
+)
+ON CONFLICT (pair_id) DO UPDATE
+SET
+    period_end = GREATEST(digest_periods.period_end, EXCLUDED.period_end),
+    sent_at    = now();
@@ -0,0 +1,76 @@
+-- name: EnqueueOutbox :one
+INSERT INTO alert_outbox (
+    episode_ts,
+    kind,
+    payload,
+    next_attempt_at,
+    channels
+) VALUES (
+    $1, $2, $3, $4, $5
+)
+RETURNING id;
+
+-- name: ListDueOutbox :many
+SELECT
+    id,
+    episode_ts,
+    kind,
+    payload,
+    status,
+    attempts,
+    last_error,
+    created_at,
+    last_attempt_at,
+    next_attempt_at,
+    channels
+FROM alert_outbox
+WHERE status = 'pending'
+  AND next_attempt_at <= $1
+ORDER BY next_attempt_at, id
+LIMIT $2;
+
+-- name: ListOutbox :many
+SELECT
+    id,
+    episode_ts,
+    kind,
+    payload,
+    status,
+    attempts,
+    last_error,
+    created_at,
+    last_attempt_at,
+    next_attempt_at,
+    channels
+FROM alert_outbox
+WHERE sqlc.arg(status)::text = '' OR status = sqlc.arg(status)::text
+ORDER BY id DESC
+LIMIT sqlc.arg(row_limit);
+
+-- name: MarkOutboxAttempt :exec
+UPDATE alert_outbox
+SET
+    status          = $2,
+    attempts        = attempts + 1,
+    last_error      = $3,
+    channels        = $4,
+    next_attempt_at = $5,
+    last_attempt_at = now()
+WHERE id = $1;
+
+-- name: ReplayDeadOutbox :execrows
+UPDATE alert_outbox
+SET
+    status          = 'pending',
+    attempts        = 0,
+    next_attempt_at = now()
+WHERE status = 'dead';
+
+-- name: ReplayOutbox :execrows
+UPDATE alert_outbox
+SET
+    status          = 'pending',
+    attempts        = 0,
+    next_attempt_at = now()
+WHERE id = $1
+  AND status <> 'dispatched';
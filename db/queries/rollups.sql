@@ -0,0 +1,199 @@
+-- name: RollupHourly :execrows
+INSERT INTO rate_sample_rollups (
+    pair_id,
+    resolution,
+    bucket_ts,
+    samples,
+    complete,
+    official_count,
+    market_count,
+    official_min,
+    official_max,
+    official_avg,
+    official_last,
+    market_min,
+    market_max,
+    market_avg,
+    market_last,
+    deviation_min,
+    deviation_max,
+    deviation_avg,
+    deviation_last
+)
+SELECT
+    pair_id,
+    '1h',
+    date_trunc('hour', bucket_ts AT TIME ZONE 'UTC') AT TIME ZONE 'UTC',
+    COUNT(*),
+    COUNT(*) FILTER (WHERE status = 'complete'),
+    COUNT(official_susde_per_usde),
+    COUNT(market_susde_per_usde),
+    MIN(official_susde_per_usde) FILTER (WHERE official_susde_per_usde IS NOT NULL),
+    MAX(official_susde_per_usde) FILTER (WHERE official_susde_per_usde IS NOT NULL),
+    AVG(official_susde_per_usde) FILTER (WHERE official_susde_per_usde IS NOT NULL),
+    (array_agg(official_susde_per_usde ORDER BY bucket_ts DESC) FILTER (WHERE official_susde_per_usde IS NOT NULL))[1],
+    MIN(market_susde_per_usde) FILTER (WHERE market_susde_per_usde IS NOT NULL),
+    MAX(market_susde_per_usde) FILTER (WHERE market_susde_per_usde IS NOT NULL),
+    AVG(market_susde_per_usde) FILTER (WHERE market_susde_per_usde IS NOT NULL),
+    (array_agg(market_susde_per_usde ORDER BY bucket_ts DESC) FILTER (WHERE market_susde_per_usde IS NOT NULL))[1],
+    MIN(deviation_pct) FILTER (WHERE status = 'complete'),
+    MAX(deviation_pct) FILTER (WHERE status = 'complete'),
+    AVG(deviation_pct) FILTER (WHERE status = 'complete'),
+    (array_agg(deviation_pct ORDER BY bucket_ts DESC) FILTER (WHERE status = 'complete'))[1]
+FROM rate_samples
+WHERE bucket_ts < $1
+GROUP BY 1, 3
+ON CONFLICT (pair_id, resolution, bucket_ts) DO UPDATE
+SET
+    samples        = EXCLUDED.samples,
+    complete       = EXCLUDED.complete,
+    official_count = EXCLUDED.official_count,
+    market_count   = EXCLUDED.market_count,
+    official_min   = EXCLUDED.official_min,
+    official_max   = EXCLUDED.official_max,
+    official_avg   = EXCLUDED.official_avg,
+    official_last  = EXCLUDED.official_last,
+    market_min     = EXCLUDED.market_min,
+    market_max     = EXCLUDED.market_max,
+    market_avg     = EXCLUDED.market_avg,
+    market_last    = EXCLUDED.market_last,
+    deviation_min  = EXCLUDED.deviation_min,
+    deviation_max  = EXCLUDED.deviation_max,
+    deviation_avg  = EXCLUDED.deviation_avg,
+    deviation_last = EXCLUDED.deviation_last,
+    updated_at     = now();
+
+-- name: RollupDaily :execrows
+INSERT INTO rate_sample_rollups (
+    pair_id,
+    resolution,
+    bucket_ts,
+    samples,
+    complete,
+    official_count,
+    market_count,
+    official_min,
+    official_max,
+    official_avg,
+    official_last,
+    market_min,
+    market_max,
+    market_avg,
+    market_last,
+    deviation_min,
+    deviation_max,
+    deviation_avg,
+    deviation_last
+)
+SELECT
+    pair_id,
+    '1d',
+    date_trunc('day', bucket_ts AT TIME ZONE 'UTC') AT TIME ZONE 'UTC',
+    SUM(samples),
+    SUM(complete),
+    SUM(official_count),
+    SUM(market_count),
+    MIN(official_min),
+    MAX(official_max),
+    SUM(official_avg * official_count) / NULLIF(SUM(official_count), 0),
+    (array_agg(official_last ORDER BY bucket_ts DESC) FILTER (WHERE official_count > 0))[1],
+    MIN(market_min),
+    MAX(market_max),
+    SUM(market_avg * market_count) / NULLIF(SUM(market_count), 0),
+    (array_agg(market_last ORDER BY bucket_ts DESC) FILTER (WHERE market_count > 0))[1],
+    MIN(deviation_min),
+    MAX(deviation_max),
+    SUM(deviation_avg * complete) / NULLIF(SUM(complete), 0),
+    (array_agg(deviation_last ORDER BY bucket_ts DESC) FILTER (WHERE complete > 0))[1]
+FROM rate_sample_rollups h
+WHERE h.resolution = '1h'
+  AND h.bucket_ts < $1
+  AND h.bucket_ts >= (
+      SELECT date_trunc('day', MIN(s.bucket_ts) AT TIME ZONE 'UTC') AT TIME ZONE 'UTC'
+      FROM rate_samples s
+      WHERE s.pair_id = h.pair_id
+  )
+GROUP BY 1, 3
+ON CONFLICT (pair_id, resolution, bucket_ts) DO UPDATE
+SET
+    samples        = EXCLUDED.samples,
+    complete       = EXCLUDED.complete,
+    official_count = EXCLUDED.official_count,
+    market_count   = EXCLUDED.market_count,
+    official_min   = EXCLUDED.official_min,
+    official_max   = EXCLUDED.official_max,
+    official_avg   = EXCLUDED.official_avg,
+    official_last  = EXCLUDED.official_last,
+    market_min     = EXCLUDED.market_min,
+    market_max     = EXCLUDED.market_max,
+    market_avg     = EXCLUDED.market_avg,
+    market_last    = EXCLUDED.market_last,
+    deviation_min  = EXCLUDED.deviation_min,
+    deviation_max  = EXCLUDED.deviation_max,
+    deviation_avg  = EXCLUDED.deviation_avg,
+    deviation_last = EXCLUDED.deviation_last,
+    updated_at     = now();
+
+-- name: ListRollupsBetween :many
+SELECT
+    resolution,
+    bucket_ts,
+    samples,
+    complete,
+    official_min,
+    official_max,
+    official_avg,
+    official_last,
+    market_min,
+    market_max,
+    market_avg,
+    market_last,
+    deviation_min,
+    deviation_max,
+    deviation_avg,
+    deviation_last,
+    updated_at,
+    pair_id,
+    official_count,
+    market_count
+FROM rate_sample_rollups
+WHERE pair_id = $1
+  AND resolution = $2
+  AND bucket_ts >= $3
+  AND bucket_ts < $4
+ORDER BY bucket_ts;
+
+-- name: ListRollupsPage :many
+SELECT
+    resolution,
+    bucket_ts,
+    samples,
+    complete,
+    official_min,
+    official_max,
+    official_avg,
+    official_last,
+    market_min,
+    market_max,
+    market_avg,
+    market_last,
+    deviation_min,
+    deviation_max,
+    deviation_avg,
+    deviation_last,
+    updated_at,
+    pair_id,
+    official_count,
+    market_count
+FROM rate_sample_rollups
+WHERE pair_id = sqlc.arg(pair_id)
+  AND resolution = sqlc.arg(resolution)
+  AND bucket_ts >= sqlc.arg(from_ts)
+  AND bucket_ts < sqlc.arg(to_ts)
+ORDER BY bucket_ts
+LIMIT sqlc.arg(row_limit);
+
+-- name: DeleteRollupsBefore :execrows
+DELETE FROM rate_sample_rollups
+WHERE resolution = $1
+  AND bucket_ts < $2;
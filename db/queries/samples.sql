@@ -1,5 +1,6 @@
 -- name: UpsertRateSample :exec
 INSERT INTO rate_samples (
+    pair_id,
     bucket_ts,
     official_susde_per_usde,
     market_susde_per_usde,
@@ -9,11 +10,15 @@ INSERT INTO rate_samples (
     cow_quote,
     block_number,
     status,
-    error
+    error,
+    error_phase,
+    official_method,
+    block_hash,
+    block_time
 ) VALUES (
-    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15
 )
-ON CONFLICT (bucket_ts) DO UPDATE
+ON CONFLICT (pair_id, bucket_ts) DO UPDATE
 SET
     official_susde_per_usde = EXCLUDED.official_susde_per_usde,
     market_susde_per_usde   = EXCLUDED.market_susde_per_usde,
@@ -23,7 +28,11 @@ SET
     cow_quote               = EXCLUDED.cow_quote,
     block_number            = EXCLUDED.block_number,
     status                  = EXCLUDED.status,
-    error                   = EXCLUDED.error;
+    error                   = EXCLUDED.error,
+    error_phase             = EXCLUDED.error_phase,
+    official_method         = EXCLUDED.official_method,
+    block_hash              = EXCLUDED.block_hash,
+    block_time              = EXCLUDED.block_time;
 
 -- name: ListSamplesBetween :many
 SELECT
@@ -37,10 +46,16 @@ SELECT
     block_number,
     status,
     error,
-    created_at
+    created_at,
+    error_phase,
+    pair_id,
+    official_method,
+    block_hash,
+    block_time
 FROM rate_samples
-WHERE bucket_ts >= $1
-  AND bucket_ts < $2
+WHERE pair_id = $1
+  AND bucket_ts >= $2
+  AND bucket_ts < $3
 ORDER BY bucket_ts;
 
 -- name: ListRecentSamples :many
@@ -55,19 +70,84 @@ SELECT
     block_number,
     status,
     error,
-    created_at
+    created_at,
+    error_phase,
+    pair_id,
+    official_method,
+    block_hash,
+    block_time
 FROM rate_samples
+WHERE pair_id = $1
 ORDER BY bucket_ts DESC
-LIMIT $1;
+LIMIT $2;
+
+-- name: ListSamplesByDeviation :many
+SELECT
+    bucket_ts,
+    official_susde_per_usde,
+    market_susde_per_usde,
+    deviation_pct,
+    notional_usde,
+    cow_quality,
+    cow_quote,
+    block_number,
+    status,
+    error,
+    created_at,
+    error_phase,
+    pair_id,
+    official_method,
+    block_hash,
+    block_time
+FROM rate_samples
+WHERE pair_id = sqlc.arg(pair_id)
+  AND bucket_ts >= sqlc.arg(from_ts)
+  AND bucket_ts < sqlc.arg(to_ts)
+  AND abs(deviation_pct) >= sqlc.arg(min_abs_deviation_pct)::numeric
+ORDER BY abs(deviation_pct) DESC, bucket_ts
+LIMIT sqlc.arg(row_limit);
+
+-- name: ListSamplesPage :many
+SELECT
+    bucket_ts,
+    official_susde_per_usde,
+    market_susde_per_usde,
+    deviation_pct,
+    notional_usde,
+    cow_quality,
+    cow_quote,
+    block_number,
+    status,
+    error,
+    created_at,
+    error_phase,
+    pair_id,
+    official_method,
+    block_hash,
+    block_time
+FROM rate_samples
+WHERE pair_id = sqlc.arg(pair_id)
+  AND bucket_ts >= sqlc.arg(from_ts)
+  AND bucket_ts < sqlc.arg(to_ts)
+ORDER BY bucket_ts
+LIMIT sqlc.arg(row_limit);
 
--- name: MarkSampleErrored :exec
+-- name: MarkSampleErrored :execrows
 UPDATE rate_samples
-SET status = 'errored', error = $2
-WHERE bucket_ts = $1;
+SET status = 'errored', error = $3, error_phase = $4
+WHERE pair_id = $1
+  AND bucket_ts = $2;
 
--- name: DeleteSamplesBefore :exec
-DELETE FROM rate_samples
-WHERE bucket_ts < $1;
+-- name: DeleteSamplesBefore :execrows
+DELETE FROM rate_samples s
+WHERE s.bucket_ts < $1
+  AND EXISTS (
+      SELECT 1
+      FROM rate_sample_rollups r
+      WHERE r.pair_id = s.pair_id
+        AND r.resolution = '1h'
+        AND r.bucket_ts = date_trunc('hour', s.bucket_ts AT TIME ZONE 'UTC') AT TIME ZONE 'UTC'
+  );
 
 -- name: CountSamples :one
 SELECT COUNT(*)
@@ -0,0 +1,111 @@
+-- SQLite schema for single-node deployments, equivalent to PostgreSQL
+-- migrations 000001-000010. Timestamps are unix microseconds (UTC); decimals
+-- are stored as TEXT so they keep their exact value.
+
+CREATE TABLE rate_samples (
+    bucket_ts               INTEGER  PRIMARY KEY,
+    official_susde_per_usde TEXT,
+    market_susde_per_usde   TEXT,
+    deviation_pct           TEXT,
+    notional_usde           TEXT     NOT NULL,
+    cow_quality             TEXT     NOT NULL,
+    cow_quote               TEXT,
+    block_number            INTEGER,
+    status                  TEXT     NOT NULL DEFAULT 'complete',
+    error                   TEXT,
+    error_phase             TEXT,
+    created_at              INTEGER  NOT NULL
+);
+
+CREATE TABLE alerts (
+    id                 INTEGER  PRIMARY KEY AUTOINCREMENT,
+    sample_ts          INTEGER  NOT NULL UNIQUE,
+    deviation_pct      TEXT     NOT NULL,
+    threshold_pct      TEXT     NOT NULL,
+    direction          TEXT     NOT NULL,
+    channels           TEXT     NOT NULL DEFAULT '[]',
+    state              TEXT     NOT NULL DEFAULT 'firing',
+    confirmed_at       INTEGER,
+    resolved_at        INTEGER,
+    peak_deviation_pct TEXT,
+    created_at         INTEGER  NOT NULL
+);
+
+CREATE INDEX idx_alerts_created_at ON alerts (created_at);
+
+CREATE TABLE alert_deliveries (
+    id         INTEGER  PRIMARY KEY AUTOINCREMENT,
+    alert_id   INTEGER  NOT NULL REFERENCES alerts(id) ON DELETE CASCADE,
+    kind       TEXT     NOT NULL,
+    channel    TEXT     NOT NULL,
+    status     TEXT     NOT NULL,
+    attempts   INTEGER  NOT NULL,
+    error      TEXT,
+    created_at INTEGER  NOT NULL
+);
+
+CREATE INDEX idx_alert_deliveries_alert ON alert_deliveries (alert_id, created_at);
+
+CREATE TABLE alert_state (
+    state_key          TEXT     PRIMARY KEY,
+    state              TEXT     NOT NULL DEFAULT 'ok',
+    direction          TEXT,
+    breach_count       INTEGER  NOT NULL DEFAULT 0,
+    breach_window      INTEGER  NOT NULL DEFAULT 0,
+    pending_since      INTEGER,
+    firing_since       INTEGER,
+    notified           INTEGER  NOT NULL DEFAULT 0,
+    last_bucket        INTEGER,
+    last_alert_up_at   INTEGER,
+    last_alert_down_at INTEGER,
+    peak_deviation_pct TEXT,
+    updated_at         INTEGER  NOT NULL
+);
+
+CREATE TABLE backfill_checkpoints (
+    job_key      TEXT     PRIMARY KEY,
+    range_from   INTEGER  NOT NULL,
+    range_to     INTEGER  NOT NULL,
+    interval_sec INTEGER  NOT NULL,
+    watermark    INTEGER  NOT NULL,
+    processed    INTEGER  NOT NULL DEFAULT 0,
+    failed       INTEGER  NOT NULL DEFAULT 0,
+    updated_at   INTEGER  NOT NULL
+);
+
+CREATE TABLE rate_sample_rollups (
+    resolution     TEXT     NOT NULL,
+    bucket_ts      INTEGER  NOT NULL,
+    samples        INTEGER  NOT NULL,
+    complete       INTEGER  NOT NULL,
+    official_min   TEXT,
+    official_max   TEXT,
+    official_avg   TEXT,
+    official_last  TEXT,
+    market_min     TEXT,
+    market_max     TEXT,
+    market_avg     TEXT,
+    market_last    TEXT,
+    deviation_min  TEXT,
+    deviation_max  TEXT,
+    deviation_avg  TEXT,
+    deviation_last TEXT,
+    updated_at     INTEGER  NOT NULL,
+    PRIMARY KEY (resolution, bucket_ts)
+);
+
+CREATE TABLE alert_outbox (
+    id              INTEGER  PRIMARY KEY AUTOINCREMENT,
+    episode_ts      INTEGER,
+    kind            TEXT     NOT NULL,
+    payload         TEXT     NOT NULL,
+    status          TEXT     NOT NULL DEFAULT 'pending',
+    attempts        INTEGER  NOT NULL DEFAULT 0,
+    last_error      TEXT,
+    created_at      INTEGER  NOT NULL,
+    last_attempt_at INTEGER,
+    next_attempt_at INTEGER  NOT NULL,
+    channels        TEXT
+);
+
+CREATE INDEX idx_alert_outbox_due ON alert_outbox (status, next_attempt_at);
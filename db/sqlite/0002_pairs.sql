@@ -0,0 +1,121 @@
+-- Key samples, rollups and alerts by pair, equivalent to PostgreSQL migration
+-- 000011. SQLite cannot alter primary keys or drop UNIQUE constraints, so the
+-- tables are rebuilt; existing rows belong to the original USDe/sUSDe pair.
+
+CREATE TABLE rate_samples_new (
+    pair_id                 TEXT     NOT NULL,
+    bucket_ts               INTEGER  NOT NULL,
+    official_susde_per_usde TEXT,
+    market_susde_per_usde   TEXT,
+    deviation_pct           TEXT,
+    notional_usde           TEXT     NOT NULL,
+    cow_quality             TEXT     NOT NULL,
+    cow_quote               TEXT,
+    block_number            INTEGER,
+    status                  TEXT     NOT NULL DEFAULT 'complete',
+    error                   TEXT,
+    error_phase             TEXT,
+    created_at              INTEGER  NOT NULL,
+    PRIMARY KEY (pair_id, bucket_ts)
+);
+
+INSERT INTO rate_samples_new (
+    pair_id, bucket_ts, official_susde_per_usde, market_susde_per_usde, deviation_pct,
+    notional_usde, cow_quality, cow_quote, block_number, status, error, error_phase, created_at
+)
+SELECT 'usde-susde', bucket_ts, official_susde_per_usde, market_susde_per_usde, deviation_pct,
+    notional_usde, cow_quality, cow_quote, block_number, status, error, error_phase, created_at
+FROM rate_samples;
+
+DROP TABLE rate_samples;
+ALTER TABLE rate_samples_new RENAME TO rate_samples;
+
+-- Dropping alerts would cascade into alert_deliveries, so deliveries are set
+-- aside and restored once alerts is rebuilt with the same ids.
+CREATE TABLE alert_deliveries_old AS SELECT * FROM alert_deliveries;
+DROP TABLE alert_deliveries;
+
+CREATE TABLE alerts_new (
+    id                 INTEGER  PRIMARY KEY AUTOINCREMENT,
+    pair_id            TEXT     NOT NULL,
+    sample_ts          INTEGER  NOT NULL,
+    deviation_pct      TEXT     NOT NULL,
+    threshold_pct      TEXT     NOT NULL,
+    direction          TEXT     NOT NULL,
+    channels           TEXT     NOT NULL DEFAULT '[]',
+    state              TEXT     NOT NULL DEFAULT 'firing',
+    confirmed_at       INTEGER,
+    resolved_at        INTEGER,
+    peak_deviation_pct TEXT,
+    created_at         INTEGER  NOT NULL,
+    UNIQUE (pair_id, sample_ts)
+);
+
+INSERT INTO alerts_new (
+    id, pair_id, sample_ts, deviation_pct, threshold_pct, direction, channels,
+    state, confirmed_at, resolved_at, peak_deviation_pct, created_at
+)
+SELECT id, 'usde-susde', sample_ts, deviation_pct, threshold_pct, direction, channels,
+    state, confirmed_at, resolved_at, peak_deviation_pct, created_at
+FROM alerts;
+
+DROP TABLE alerts;
+ALTER TABLE alerts_new RENAME TO alerts;
+CREATE INDEX idx_alerts_created_at ON alerts (created_at);
+
+CREATE TABLE alert_deliveries (
+    id         INTEGER  PRIMARY KEY AUTOINCREMENT,
+    alert_id   INTEGER  NOT NULL REFERENCES alerts(id) ON DELETE CASCADE,
+    kind       TEXT     NOT NULL,
+    channel    TEXT     NOT NULL,
+    status     TEXT     NOT NULL,
+    attempts   INTEGER  NOT NULL,
+    error      TEXT,
+    created_at INTEGER  NOT NULL
+);
+
+INSERT INTO alert_deliveries (id, alert_id, kind, channel, status, attempts, error, created_at)
+SELECT id, alert_id, kind, channel, status, attempts, error, created_at
+FROM alert_deliveries_old;
+
+DROP TABLE alert_deliveries_old;
+CREATE INDEX idx_alert_deliveries_alert ON alert_deliveries (alert_id, created_at);
+
+CREATE TABLE rate_sample_rollups_new (
+    pair_id        TEXT     NOT NULL,
+    resolution     TEXT     NOT NULL,
+    bucket_ts      INTEGER  NOT NULL,
+    samples        INTEGER  NOT NULL,
+    complete       INTEGER  NOT NULL,
+    official_min   TEXT,
+    official_max   TEXT,
+    official_avg   TEXT,
+    official_last  TEXT,
+    market_min     TEXT,
+    market_max     TEXT,
+    market_avg     TEXT,
+    market_last    TEXT,
+    deviation_min  TEXT,
+    deviation_max  TEXT,
+    deviation_avg  TEXT,
+    deviation_last TEXT,
+    updated_at     INTEGER  NOT NULL,
+    PRIMARY KEY (pair_id, resolution, bucket_ts)
+);
+
+INSERT INTO rate_sample_rollups_new (
+    pair_id, resolution, bucket_ts, samples, complete,
+    official_min, official_max, official_avg, official_last,
+    market_min, market_max, market_avg, market_last,
+    deviation_min, deviation_max, deviation_avg, deviation_last,
+    updated_at
+)
+SELECT 'usde-susde', resolution, bucket_ts, samples, complete,
+    official_min, official_max, official_avg, official_last,
+    market_min, market_max, market_avg, market_last,
+    deviation_min, deviation_max, deviation_avg, deviation_last,
+    updated_at
+FROM rate_sample_rollups;
+
+DROP TABLE rate_sample_rollups;
+ALTER TABLE rate_sample_rollups_new RENAME TO rate_sample_rollups;
@@ -0,0 +1,5 @@
+-- Record the ERC-4626 view behind each official rate, equivalent to
+-- PostgreSQL migration 000012.
+
+ALTER TABLE rate_samples ADD COLUMN official_method TEXT;
+UPDATE rate_samples SET official_method = 'previewDeposit' WHERE official_susde_per_usde IS NOT NULL;
@@ -0,0 +1,5 @@
+-- Record the hash and timestamp of the block behind each official rate,
+-- equivalent to PostgreSQL migration 000013.
+
+ALTER TABLE rate_samples ADD COLUMN block_hash TEXT;
+ALTER TABLE rate_samples ADD COLUMN block_time INTEGER;
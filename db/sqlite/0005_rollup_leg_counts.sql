@@ -0,0 +1,6 @@
+-- Count the samples behind the official and market stats of each rollup,
+-- equivalent to PostgreSQL migration 000014.
+
+ALTER TABLE rate_sample_rollups ADD COLUMN official_count INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE rate_sample_rollups ADD COLUMN market_count INTEGER NOT NULL DEFAULT 0;
+UPDATE rate_sample_rollups SET official_count = complete, market_count = complete;
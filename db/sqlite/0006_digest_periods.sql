@@ -0,0 +1,8 @@
+-- Record the last digest period mailed per pair, equivalent to PostgreSQL
+-- migration 000015.
+
+CREATE TABLE digest_periods (
+    pair_id    TEXT     PRIMARY KEY,
+    period_end INTEGER  NOT NULL,
+    sent_at    INTEGER  NOT NULL
+);
@@ -0,0 +1,86 @@
+package alerting
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// DiscordOptions 描述 Discord webhook 参数。
+type DiscordOptions struct {
+	WebhookURL string
+	// Username 非空时覆盖 webhook 的默认显示名。
+	Username string
+	Timeout  time.Duration
+}
+
+// DiscordNotifier 通过 Discord webhook 推送带颜色的 embed 消息。
+type DiscordNotifier struct {
+	opts   DiscordOptions
+	client *http.Client
+	logger zerolog.Logger
+}
+
+// NewDiscordNotifier 构造 Discord 告警器。
+func NewDiscordNotifier(opts DiscordOptions, logger zerolog.Logger) *DiscordNotifier {
+	if opts.Timeout <= 0 {
+		opts.Timeout = 10 * time.Second
+	}
+	return &DiscordNotifier{
+		opts:   opts,
+		client: &http.Client{Timeout: opts.Timeout},
+		logger: logger.With().Str("component", "alert_discord").Logger(),
+	}
+}
+
+// Notify 推送一条 embed 消息。
+func (n *DiscordNotifier) Notify(ctx context.Context, note Notification) error {
+	if err := postJSON(ctx, n.client, n.opts.WebhookURL, "discord", n.payload(note)); err != nil {
+		return err
+	}
+	n.logger.Info().Time("bucket", note.Bucket).
+		Str("kind", kindOf(note)).
+		Str("direction", note.Direction).
+		Msg("告警已发送 (Discord)")
+	return nil
+}
+
+type discordPayload struct {
+	Username string         `json:"username,omitempty"`
+	Embeds   []discordEmbed `json:"embeds"`
+}
+
+type discordEmbed struct {
+	Title       string         `json:"title"`
+	Description string         `json:"description,omitempty"`
+	Color       int            `json:"color"`
+	Timestamp   string         `json:"timestamp"`
+	Fields      []discordField `json:"fields"`
+}
+
+type discordField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline"`
+}
+
+func (n *DiscordNotifier) payload(note Notification) discordPayload {
+	fields := make([]discordField, 0, len(messageFields(note)))
+	for _, f := range messageFields(note) {
+		fields = append(fields, discordField{Name: f.Name, Value: f.Value, Inline: true})
+	}
+	return discordPayload{
+		Username: n.opts.Username,
+		Embeds: []discordEmbed{{
+			Title:       messageTitle(note),
+			Description: note.AdditionalMsg,
+			Color:       messageColor(note),
+			Timestamp:   note.Bucket.UTC().Format(time.RFC3339),
+			Fields:      fields,
+		}},
+	}
+}
+
+var _ Notifier = (*DiscordNotifier)(nil)
@@ -0,0 +1,259 @@
+package alerting
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/shopspring/decimal"
+)
+
+// STARTTLS 策略。
+const (
+	StartTLSAuto   = "auto"
+	StartTLSAlways = "always"
+	StartTLSNever  = "never"
+)
+
+// EmailOptions 描述 SMTP 告警参数。
+type EmailOptions struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+	To       []string
+	// StartTLS 取 auto（服务端支持时升级）、always 或 never。
+	StartTLS      string
+	SubjectPrefix string
+	Timeout       time.Duration
+	// TLSConfig 仅供测试覆盖，默认按 Host 校验证书。
+	TLSConfig *tls.Config
+}
+
+// Digest 汇总单个交易对一段时间内的告警与偏离区间。
+type Digest struct {
+	// Pair 是交易对展示名，如 USDe-sUSDe。
+	Pair    string
+	From    time.Time
+	To      time.Time
+	Samples int
+	// Unhealthy 是 errored/partial/缺失的 bucket 数。
+	Unhealthy       int
+	MinDeviationPct decimal.NullDecimal
+	MaxDeviationPct decimal.NullDecimal
+	Alerts          []DigestAlert
+}
+
+// DigestAlert 是摘要中的单个告警周期。
+type DigestAlert struct {
+	StartedAt        time.Time
+	Direction        string
+	State            string
+	DeviationPct     decimal.Decimal
+	PeakDeviationPct decimal.NullDecimal
+	ResolvedAt       *time.Time
+}
+
+// DigestSender 由支持摘要投递的渠道实现。
+type DigestSender interface {
+	SendDigest(ctx context.Context, digest Digest) error
+}
+
+// EmailNotifier 通过 SMTP（可选 STARTTLS）发送告警邮件。
+type EmailNotifier struct {
+	opts   EmailOptions
+	logger zerolog.Logger
+}
+
+// NewEmailNotifier 构造邮件告警器。
+func NewEmailNotifier(opts EmailOptions, logger zerolog.Logger) *EmailNotifier {
+	if opts.Port == 0 {
+		opts.Port = 587
+	}
+	if opts.StartTLS == "" {
+		opts.StartTLS = StartTLSAuto
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 10 * time.Second
+	}
+	if opts.SubjectPrefix == "" {
+		opts.SubjectPrefix = "[usdewatcher]"
+	}
+	return &EmailNotifier{
+		opts:   opts,
+		logger: logger.With().Str("component", "alert_email").Logger(),
+	}
+}
+
+// Notify 立即发送单条告警邮件。
+func (n *EmailNotifier) Notify(ctx context.Context, note Notification) error {
+	subject := fmt.Sprintf("%s %s", n.opts.SubjectPrefix, messageTitle(note))
+	if err := n.send(ctx, subject, renderMessage(note)); err != nil {
+		return err
+	}
+	n.logger.Info().Time("bucket", note.Bucket).
+		Str("kind", kindOf(note)).
+		Str("direction", note.Direction).
+		Msg("告警已发送 (Email)")
+	return nil
+}
+
+// SendDigest 发送周期摘要邮件。
+func (n *EmailNotifier) SendDigest(ctx context.Context, digest Digest) error {
+	subject := fmt.Sprintf("%s %s Digest %s – %s (%d alerts)", n.opts.SubjectPrefix, digest.Pair,
+		digest.From.UTC().Format(time.RFC3339), digest.To.UTC().Format(time.RFC3339), len(digest.Alerts))
+	if err := n.send(ctx, subject, renderDigest(digest)); err != nil {
+		return err
+	}
+	n.logger.Info().Time("from", digest.From).
+		Time("to", digest.To).
+		Int("alerts", len(digest.Alerts)).
+		Msg("摘要已发送 (Email)")
+	return nil
+}
+
+func (n *EmailNotifier) send(ctx context.Context, subject, body string) error {
+	addr := net.JoinHostPort(n.opts.Host, strconv.Itoa(n.opts.Port))
+	if deadline, ok := ctx.Deadline(); !ok || time.Until(deadline) > n.opts.Timeout {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, n.opts.Timeout)
+		defer cancel()
+	}
+
+	dialer := net.Dialer{}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return fmt.Errorf("dial smtp %s: %w", addr, err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, n.opts.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("smtp handshake: %w", err)
+	}
+	defer client.Close()
+
+	if err := n.startTLS(client); err != nil {
+		return err
+	}
+	if n.opts.Username != "" {
+		auth := smtp.PlainAuth("", n.opts.Username, n.opts.Password, n.opts.Host)
+		if err := client.Auth(auth); err != nil {
+			return fmt.Errorf("smtp auth: %w", err)
+		}
+	}
+
+	if err := client.Mail(n.opts.From); err != nil {
+		return fmt.Errorf("smtp MAIL FROM: %w", err)
+	}
+	for _, rcpt := range n.opts.To {
+		if err := client.Rcpt(rcpt); err != nil {
+			return fmt.Errorf("smtp RCPT TO %s: %w", rcpt, err)
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("smtp DATA: %w", err)
+	}
+	if _, err := w.Write(n.buildMessage(subject, body)); err != nil {
+		w.Close()
+		return fmt.Errorf("write smtp message: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("finish smtp message: %w", err)
+	}
+	return client.Quit()
+}
+
+func (n *EmailNotifier) startTLS(client *smtp.Client) error {
+	if n.opts.StartTLS == StartTLSNever {
+		return nil
+	}
+	if ok, _ := client.Extension("STARTTLS"); !ok {
+		if n.opts.StartTLS == StartTLSAlways {
+			return fmt.Errorf("smtp 服务端不支持 STARTTLS")
+		}
+		return nil
+	}
+	cfg := n.opts.TLSConfig
+	if cfg == nil {
+		cfg = &tls.Config{ServerName: n.opts.Host, MinVersion: tls.VersionTLS12}
+	}
+	if err := client.StartTLS(cfg); err != nil {
+		return fmt.Errorf("smtp STARTTLS: %w", err)
+	}
+	return nil
+}
+
+func (n *EmailNotifier) buildMessage(subject, body string) []byte {
+	var buf bytes.Buffer
+	header := func(key, value string) {
+		buf.WriteString(key + ": " + value + "\r\n")
+	}
+	to := make([]string, len(n.opts.To))
+	for i, addr := range n.opts.To {
+		to[i] = encodeAddress(addr)
+	}
+	header("From", encodeAddress(n.opts.From))
+	header("To", strings.Join(to, ", "))
+	header("Subject", mime.QEncoding.Encode("utf-8", subject))
+	header("Date", time.Now().UTC().Format(time.RFC1123Z))
+	header("MIME-Version", "1.0")
+	header("Content-Type", "text/plain; charset=UTF-8")
+	header("Content-Transfer-Encoding", "8bit")
+	buf.WriteString("\r\n")
+	buf.WriteString(strings.ReplaceAll(strings.ReplaceAll(body, "\r\n", "\n"), "\n", "\r\n"))
+	return buf.Bytes()
+}
+
+// encodeAddress 按 RFC 2047 编码地址中的非 ASCII 显示名；无法解析的地址原样返回。
+func encodeAddress(addr string) string {
+	parsed, err := mail.ParseAddress(addr)
+	if err != nil || parsed.Name == "" {
+		return addr
+	}
+	return parsed.String()
+}
+
+func renderDigest(d Digest) string {
+	builder := strings.Builder{}
+	builder.WriteString(fmt.Sprintf("[%s Digest]\n", d.Pair))
+	builder.WriteString(fmt.Sprintf("Period: %s – %s UTC\n", d.From.UTC().Format(time.RFC3339), d.To.UTC().Format(time.RFC3339)))
+	builder.WriteString(fmt.Sprintf("Samples: %d (unhealthy %d)\n", d.Samples, d.Unhealthy))
+	if d.MinDeviationPct.Valid && d.MaxDeviationPct.Valid {
+		builder.WriteString(fmt.Sprintf("Deviation range: %s%% .. %s%%\n", d.MinDeviationPct.Decimal.StringFixed(3), d.MaxDeviationPct.Decimal.StringFixed(3)))
+	} else {
+		builder.WriteString("Deviation range: n/a\n")
+	}
+	builder.WriteString(fmt.Sprintf("Alerts: %d\n", len(d.Alerts)))
+	for _, a := range d.Alerts {
+		line := fmt.Sprintf("- %s %s %s deviation %s%%", a.StartedAt.UTC().Format(time.RFC3339), a.Direction, a.State, a.DeviationPct.StringFixed(3))
+		if a.PeakDeviationPct.Valid {
+			line += fmt.Sprintf(" peak %s%%", a.PeakDeviationPct.Decimal.StringFixed(3))
+		}
+		if a.ResolvedAt != nil {
+			line += fmt.Sprintf(" resolved %s", a.ResolvedAt.UTC().Format(time.RFC3339))
+		}
+		builder.WriteString(line + "\n")
+	}
+	return builder.String()
+}
+
+var (
+	_ Notifier     = (*EmailNotifier)(nil)
+	_ DigestSender = (*EmailNotifier)(nil)
+)
@@ -0,0 +1,179 @@
+package alerting
+
+import (
+	"bufio"
+	"context"
+	"mime"
+	"net"
+	netmail "net/mail"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type capturedMail struct {
+	from string
+	to   []string
+	data string
+}
+
+// startSMTPStandIn 启动一个只实现 EHLO/MAIL/RCPT/DATA/QUIT 的进程内 SMTP 服务。
+func startSMTPStandIn(t *testing.T) (string, int, <-chan capturedMail) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("监听失败: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	mails := make(chan capturedMail, 4)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serveSMTP(conn, mails)
+		}
+	}()
+
+	addr := ln.Addr().(*net.TCPAddr)
+	return addr.IP.String(), addr.Port, mails
+}
+
+func serveSMTP(conn net.Conn, mails chan<- capturedMail) {
+	defer conn.Close()
+	tp := textproto.NewReader(bufio.NewReader(conn))
+	reply := func(line string) { _, _ = conn.Write([]byte(line + "\r\n")) }
+
+	reply("220 standin ESMTP")
+	var mail capturedMail
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		verb := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		switch verb {
+		case "EHLO", "HELO":
+			reply("250-standin")
+			reply("250 8BITMIME")
+		case "MAIL":
+			mail = capturedMail{from: angleAddr(line)}
+			reply("250 OK")
+		case "RCPT":
+			mail.to = append(mail.to, angleAddr(line))
+			reply("250 OK")
+		case "DATA":
+			reply("354 go ahead")
+			data, err := tp.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			mail.data = string(data)
+			mails <- mail
+			reply("250 queued")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
+
+func angleAddr(line string) string {
+	start := strings.Index(line, "<")
+	end := strings.Index(line, ">")
+	if start < 0 || end < start {
+		return ""
+	}
+	return line[start+1 : end]
+}
+
+func receiveMail(t *testing.T, mails <-chan capturedMail) capturedMail {
+	t.Helper()
+	select {
+	case mail := <-mails:
+		return mail
+	case <-time.After(2 * time.Second):
+		t.Fatal("未收到邮件")
+		return capturedMail{}
+	}
+}
+
+func TestEmailNotifierSendsImmediateMail(t *testing.T) {
+	host, port, mails := startSMTPStandIn(t)
+	notifier := NewEmailNotifier(EmailOptions{Host: host, Port: port, From: "watcher@example.com", To: []string{"desk@example.com", "ops@example.com"}}, testLogger())
+
+	note := Notification{Pair: "USDe-sUSDe", Bucket: time.Now(), OfficialRate: decimal.NewFromInt(1), MarketRate: decimal.RequireFromString("1.01"), DeviationPct: decimal.NewFromInt(1), ThresholdPct: decimal.RequireFromString("0.4"), Direction: "up", Notional: decimal.NewFromInt(1)}
+	if err := notifier.Notify(context.Background(), note); err != nil {
+		t.Fatalf("Email Notify 应成功: %v", err)
+	}
+
+	mail := receiveMail(t, mails)
+	if mail.from != "watcher@example.com" || len(mail.to) != 2 {
+		t.Fatalf("信封不正确: %+v", mail)
+	}
+	if !strings.Contains(mail.data, "Subject: [usdewatcher] USDe-sUSDe Alert (up)") || !strings.Contains(mail.data, "Deviation: 1.000%") {
+		t.Fatalf("邮件内容不正确:\n%s", mail.data)
+	}
+}
+
+func TestEmailNotifierSendsDigest(t *testing.T) {
+	host, port, mails := startSMTPStandIn(t)
+	notifier := NewEmailNotifier(EmailOptions{Host: host, Port: port, From: "watcher@example.com", To: []string{"desk@example.com"}}, testLogger())
+
+	from := time.Date(2025, 9, 22, 0, 0, 0, 0, time.UTC)
+	resolved := from.Add(2 * time.Hour)
+	digest := Digest{
+		Pair:            "USDe-sUSDe",
+		From:            from,
+		To:              from.Add(24 * time.Hour),
+		Samples:         288,
+		Unhealthy:       3,
+		MinDeviationPct: decimal.NewNullDecimal(decimal.RequireFromString("-0.21")),
+		MaxDeviationPct: decimal.NewNullDecimal(decimal.RequireFromString("0.93")),
+		Alerts: []DigestAlert{{
+			StartedAt:        from.Add(time.Hour),
+			Direction:        "up",
+			State:            "resolved",
+			DeviationPct:     decimal.RequireFromString("0.5"),
+			PeakDeviationPct: decimal.NewNullDecimal(decimal.RequireFromString("0.93")),
+			ResolvedAt:       &resolved,
+		}},
+	}
+	if err := notifier.SendDigest(context.Background(), digest); err != nil {
+		t.Fatalf("SendDigest 应成功: %v", err)
+	}
+
+	mail := receiveMail(t, mails)
+	msg, err := netmail.ReadMessage(strings.NewReader(mail.data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw := msg.Header.Get("Subject")
+	if strings.ContainsFunc(raw, func(r rune) bool { return r > 0x7f }) {
+		t.Fatalf("Subject 头应只含 ASCII: %q", raw)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(raw)
+	if err != nil || !strings.Contains(subject, "2025-09-22T00:00:00Z – 2025-09-23T00:00:00Z (1 alerts)") {
+		t.Fatalf("Subject 解码后应保留原文: %q err=%v", subject, err)
+	}
+	for _, want := range []string{"USDe-sUSDe Digest", "Samples: 288 (unhealthy 3)", "Deviation range: -0.210% .. 0.930%", "up resolved deviation 0.500% peak 0.930%"} {
+		if !strings.Contains(mail.data, want) {
+			t.Fatalf("摘要缺少 %q:\n%s", want, mail.data)
+		}
+	}
+}
+
+func TestEmailNotifierRequiresStartTLSWhenAlways(t *testing.T) {
+	host, port, _ := startSMTPStandIn(t)
+	notifier := NewEmailNotifier(EmailOptions{Host: host, Port: port, From: "a@example.com", To: []string{"b@example.com"}, StartTLS: StartTLSAlways}, testLogger())
+	if err := notifier.Notify(context.Background(), Notification{}); err == nil {
+		t.Fatal("服务端不支持 STARTTLS 时应报错")
+	}
+}
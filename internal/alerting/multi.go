@@ -0,0 +1,176 @@
+package alerting
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// Channel 描述一个投递渠道及其重试/超时策略。
+type Channel struct {
+	Name     string
+	Notifier Notifier
+	// Retries 是首次失败后的额外尝试次数。
+	Retries int
+	// Timeout 限制单次尝试的耗时，0 表示不额外限制。
+	Timeout time.Duration
+	// Backoff 是首次重试前的等待时间，之后每次翻倍。
+	Backoff time.Duration
+}
+
+// DeliveryResult 记录单个渠道的投递结果。
+type DeliveryResult struct {
+	Channel  string
+	Attempts int
+	Err      error
+	Elapsed  time.Duration
+}
+
+// Delivered 表示该渠道最终投递成功。
+func (r DeliveryResult) Delivered() bool {
+	return r.Err == nil
+}
+
+// DeliveryReporter 由能返回逐渠道结果的告警器实现。
+type DeliveryReporter interface {
+	Deliver(ctx context.Context, notification Notification) []DeliveryResult
+}
+
+// ChannelDeliverer 由能只向部分渠道重投的告警器实现，用于重试此前失败的渠道。
+type ChannelDeliverer interface {
+	DeliverTo(ctx context.Context, notification Notification, channels []string) []DeliveryResult
+}
+
+// MultiNotifier 将通知并发投递到所有渠道，单个渠道失败不影响其他渠道。
+type MultiNotifier struct {
+	channels []Channel
+	logger   zerolog.Logger
+}
+
+// NewMultiNotifier 构造多渠道告警器。
+func NewMultiNotifier(logger zerolog.Logger, channels ...Channel) *MultiNotifier {
+	return &MultiNotifier{
+		channels: channels,
+		logger:   logger.With().Str("component", "alert_multi").Logger(),
+	}
+}
+
+// Channels 返回已配置的渠道名。
+func (m *MultiNotifier) Channels() []string {
+	names := make([]string, 0, len(m.channels))
+	for _, ch := range m.channels {
+		names = append(names, ch.Name)
+	}
+	return names
+}
+
+// Deliver 投递到所有渠道并按配置顺序返回结果。
+func (m *MultiNotifier) Deliver(ctx context.Context, note Notification) []DeliveryResult {
+	return m.DeliverTo(ctx, note, nil)
+}
+
+// DeliverTo 只投递到 channels 中列出的渠道；为空时投递到全部渠道。
+// 未配置的渠道名会被忽略。
+func (m *MultiNotifier) DeliverTo(ctx context.Context, note Notification, channels []string) []DeliveryResult {
+	selected := m.channels
+	if len(channels) > 0 {
+		want := make(map[string]bool, len(channels))
+		for _, name := range channels {
+			want[name] = true
+		}
+		selected = nil
+		for _, ch := range m.channels {
+			if want[ch.Name] {
+				selected = append(selected, ch)
+			}
+		}
+	}
+
+	results := make([]DeliveryResult, len(selected))
+	var wg sync.WaitGroup
+	for i, ch := range selected {
+		wg.Add(1)
+		go func(i int, ch Channel) {
+			defer wg.Done()
+			results[i] = m.deliverOne(ctx, ch, note)
+		}(i, ch)
+	}
+	wg.Wait()
+	return results
+}
+
+// Notify 实现 Notifier；任一渠道失败时返回汇总错误。
+func (m *MultiNotifier) Notify(ctx context.Context, note Notification) error {
+	var errs []error
+	for _, res := range m.Deliver(ctx, note) {
+		if res.Err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", res.Channel, res.Err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (m *MultiNotifier) deliverOne(ctx context.Context, ch Channel, note Notification) DeliveryResult {
+	start := time.Now()
+	res := DeliveryResult{Channel: ch.Name}
+	backoff := ch.Backoff
+
+	for attempt := 0; attempt <= ch.Retries; attempt++ {
+		if attempt > 0 {
+			if err := sleepContext(ctx, backoff); err != nil {
+				res.Err = err
+				break
+			}
+			backoff *= 2
+		}
+
+		res.Attempts++
+		res.Err = notifyWithTimeout(ctx, ch, note)
+		if res.Err == nil {
+			break
+		}
+		m.logger.Warn().Err(res.Err).
+			Str("channel", ch.Name).
+			Int("attempt", res.Attempts).
+			Msg("告警投递失败")
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	res.Elapsed = time.Since(start)
+	return res
+}
+
+func notifyWithTimeout(ctx context.Context, ch Channel, note Notification) error {
+	if ch.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, ch.Timeout)
+		defer cancel()
+	}
+	return ch.Notifier.Notify(ctx, note)
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+var (
+	_ Notifier         = (*MultiNotifier)(nil)
+	_ DeliveryReporter = (*MultiNotifier)(nil)
+	_ ChannelDeliverer = (*MultiNotifier)(nil)
+)
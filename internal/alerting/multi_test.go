@@ -0,0 +1,73 @@
+package alerting
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type flakyNotifier struct {
+	failures int
+	calls    int
+}
+
+func (f *flakyNotifier) Notify(ctx context.Context, note Notification) error {
+	f.calls++
+	if f.calls <= f.failures {
+		return errors.New("boom")
+	}
+	return nil
+}
+
+type blockingNotifier struct{}
+
+func (blockingNotifier) Notify(ctx context.Context, note Notification) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestMultiNotifierRetriesAndIsolatesChannels(t *testing.T) {
+	flaky := &flakyNotifier{failures: 1}
+	broken := &flakyNotifier{failures: 10}
+	multi := NewMultiNotifier(testLogger(),
+		Channel{Name: "flaky", Notifier: flaky, Retries: 2},
+		Channel{Name: "broken", Notifier: broken, Retries: 1},
+		Channel{Name: "slow", Notifier: blockingNotifier{}, Timeout: 10 * time.Millisecond},
+	)
+
+	results := multi.Deliver(context.Background(), Notification{})
+	if len(results) != 3 {
+		t.Fatalf("应返回 3 个渠道结果, 实际 %d", len(results))
+	}
+	if !results[0].Delivered() || results[0].Attempts != 2 {
+		t.Fatalf("flaky 应在第二次尝试成功: %+v", results[0])
+	}
+	if results[1].Delivered() || results[1].Attempts != 2 {
+		t.Fatalf("broken 应在重试耗尽后失败: %+v", results[1])
+	}
+	if !errors.Is(results[2].Err, context.DeadlineExceeded) {
+		t.Fatalf("slow 应超时: %+v", results[2])
+	}
+
+	if err := multi.Notify(context.Background(), Notification{}); err == nil {
+		t.Fatal("存在失败渠道时 Notify 应返回错误")
+	}
+}
+
+func TestMultiNotifierDeliverToSelectedChannels(t *testing.T) {
+	first := &flakyNotifier{}
+	second := &flakyNotifier{}
+	multi := NewMultiNotifier(testLogger(),
+		Channel{Name: "first", Notifier: first},
+		Channel{Name: "second", Notifier: second},
+	)
+
+	results := multi.DeliverTo(context.Background(), Notification{}, []string{"second", "unknown"})
+	if len(results) != 1 || results[0].Channel != "second" || !results[0].Delivered() {
+		t.Fatalf("只应投递到 second: %+v", results)
+	}
+	if first.calls != 0 || second.calls != 1 {
+		t.Fatalf("未选中的渠道不应被调用, first=%d second=%d", first.calls, second.calls)
+	}
+}
@@ -13,8 +13,22 @@ import (
 	"github.com/shopspring/decimal"
 )
 
-// Notification 封装告警上下文。
+// Notification kinds；零值视为 firing。
+const (
+	KindFiring   = "firing"
+	KindResolved = "resolved"
+)
+
+// Notification 封装告警上下文。汇率单位为每个底层代币可换得的金库份额，
+// Notional 以底层代币计。
 type Notification struct {
+	Kind string
+	// PairID 是交易对的存储键，Pair 是展示名（如 USDe-sUSDe）。
+	PairID           string
+	Pair             string
+	VaultSymbol      string
+	UnderlyingSymbol string
+
 	Bucket        time.Time
 	OfficialRate  decimal.Decimal
 	MarketRate    decimal.Decimal
@@ -22,8 +36,23 @@ type Notification struct {
 	ThresholdPct  decimal.Decimal
 	Direction     string
 	Channels      []string
-	NotionalUSDE  decimal.Decimal
+	Notional      decimal.Decimal
 	AdditionalMsg string
+
+	// 以下字段仅在 resolved 通知中填充。
+	StartedAt        time.Time
+	Duration         time.Duration
+	PeakDeviationPct decimal.Decimal
+}
+
+// rateUnit 返回汇率单位，如 sUSDe/USDe。
+func rateUnit(note Notification) string {
+	return note.VaultSymbol + "/" + note.UnderlyingSymbol
+}
+
+// Resolved 表示该通知是告警恢复。
+func (n Notification) Resolved() bool {
+	return n.Kind == KindResolved
 }
 
 // Notifier 定义告警输送接口。
@@ -97,21 +126,52 @@ func (n *TelegramNotifier) Notify(ctx context.Context, note Notification) error
 	}
 
 	n.logger.Info().Time("bucket", note.Bucket).
+		Str("kind", kindOf(note)).
 		Str("direction", note.Direction).
 		Str("channels", strings.Join(note.Channels, ",")).
 		Msg("告警已发送 (Telegram)")
 	return nil
 }
 
+func kindOf(note Notification) string {
+	if note.Kind == "" {
+		return KindFiring
+	}
+	return note.Kind
+}
+
 func renderMessage(note Notification) string {
+	if note.Resolved() {
+		return renderResolvedMessage(note)
+	}
 	builder := strings.Builder{}
-	builder.WriteString("[USDe-sUSDe Alert]\n")
+	builder.WriteString(fmt.Sprintf("[%s Alert]\n", note.Pair))
 	builder.WriteString(fmt.Sprintf("Bucket: %s UTC\n", note.Bucket.UTC().Format(time.RFC3339)))
-	builder.WriteString(fmt.Sprintf("Official: %s sUSDe/USDe\n", note.OfficialRate.StringFixed(3)))
-	builder.WriteString(fmt.Sprintf("Market: %s sUSDe/USDe\n", note.MarketRate.StringFixed(3)))
+	builder.WriteString(fmt.Sprintf("Official: %s %s\n", note.OfficialRate.StringFixed(3), rateUnit(note)))
+	builder.WriteString(fmt.Sprintf("Market: %s %s\n", note.MarketRate.StringFixed(3), rateUnit(note)))
 	builder.WriteString(fmt.Sprintf("Deviation: %s%% (threshold %s%%)\n", note.DeviationPct.StringFixed(3), note.ThresholdPct.StringFixed(3)))
 	builder.WriteString(fmt.Sprintf("Direction: %s\n", note.Direction))
-	builder.WriteString(fmt.Sprintf("Notional: %s USDe\n", note.NotionalUSDE.String()))
+	builder.WriteString(fmt.Sprintf("Notional: %s %s\n", note.Notional.String(), note.UnderlyingSymbol))
+	if len(note.Channels) > 0 {
+		builder.WriteString(fmt.Sprintf("Channels: %s\n", strings.Join(note.Channels, ",")))
+	}
+	if note.AdditionalMsg != "" {
+		builder.WriteString(note.AdditionalMsg)
+	}
+	return builder.String()
+}
+
+func renderResolvedMessage(note Notification) string {
+	builder := strings.Builder{}
+	builder.WriteString(fmt.Sprintf("[%s Resolved]\n", note.Pair))
+	builder.WriteString(fmt.Sprintf("Bucket: %s UTC\n", note.Bucket.UTC().Format(time.RFC3339)))
+	builder.WriteString(fmt.Sprintf("Direction: %s\n", note.Direction))
+	builder.WriteString(fmt.Sprintf("Started: %s UTC\n", note.StartedAt.UTC().Format(time.RFC3339)))
+	builder.WriteString(fmt.Sprintf("Duration: %s\n", note.Duration.String()))
+	builder.WriteString(fmt.Sprintf("Peak deviation: %s%%\n", note.PeakDeviationPct.StringFixed(3)))
+	builder.WriteString(fmt.Sprintf("Deviation: %s%% (threshold %s%%)\n", note.DeviationPct.StringFixed(3), note.ThresholdPct.StringFixed(3)))
+	builder.WriteString(fmt.Sprintf("Official: %s %s\n", note.OfficialRate.StringFixed(3), rateUnit(note)))
+	builder.WriteString(fmt.Sprintf("Market: %s %s\n", note.MarketRate.StringFixed(3), rateUnit(note)))
 	if len(note.Channels) > 0 {
 		builder.WriteString(fmt.Sprintf("Channels: %s\n", strings.Join(note.Channels, ",")))
 	}
@@ -27,7 +27,7 @@ func TestTelegramNotifierSuccess(t *testing.T) {
 	defer srv.Close()
 
 	notifier := NewTelegramNotifier("token", "chat", srv.URL, time.Second, testLogger())
-	note := Notification{Bucket: time.Now(), OfficialRate: decimal.NewFromInt(1), MarketRate: decimal.NewFromInt(1), DeviationPct: decimal.NewFromInt(1), ThresholdPct: decimal.NewFromInt(1), NotionalUSDE: decimal.NewFromInt(1)}
+	note := Notification{Bucket: time.Now(), OfficialRate: decimal.NewFromInt(1), MarketRate: decimal.NewFromInt(1), DeviationPct: decimal.NewFromInt(1), ThresholdPct: decimal.NewFromInt(1), Notional: decimal.NewFromInt(1)}
 
 	if err := notifier.Notify(context.Background(), note); err != nil {
 		t.Fatalf("Telegram Notify 应成功: %v", err)
@@ -49,13 +49,51 @@ func TestTelegramNotifierError(t *testing.T) {
 	defer srv.Close()
 
 	notifier := NewTelegramNotifier("token", "chat", srv.URL, time.Second, testLogger())
-	note := Notification{Bucket: time.Now(), OfficialRate: decimal.NewFromInt(1), MarketRate: decimal.NewFromInt(1), DeviationPct: decimal.NewFromInt(1), ThresholdPct: decimal.NewFromInt(1), NotionalUSDE: decimal.NewFromInt(1)}
+	note := Notification{Bucket: time.Now(), OfficialRate: decimal.NewFromInt(1), MarketRate: decimal.NewFromInt(1), DeviationPct: decimal.NewFromInt(1), ThresholdPct: decimal.NewFromInt(1), Notional: decimal.NewFromInt(1)}
 
 	if err := notifier.Notify(context.Background(), note); err == nil {
 		t.Fatal("ok=false 应报错")
 	}
 }
 
+func TestRenderResolvedMessage(t *testing.T) {
+	note := Notification{
+		Kind:             KindResolved,
+		Bucket:           time.Date(2025, 9, 22, 11, 0, 0, 0, time.UTC),
+		StartedAt:        time.Date(2025, 9, 22, 10, 0, 0, 0, time.UTC),
+		Duration:         time.Hour,
+		PeakDeviationPct: decimal.RequireFromString("-0.8123"),
+		Direction:        "down",
+	}
+
+	text := renderMessage(note)
+	for _, want := range []string{"Resolved", "Duration: 1h0m0s", "Peak deviation: -0.812%", "Direction: down"} {
+		if !strings.Contains(text, want) {
+			t.Fatalf("恢复消息缺少 %q:\n%s", want, text)
+		}
+	}
+}
+
+func TestRenderMessageUsesPairUnits(t *testing.T) {
+	note := Notification{
+		Pair:             "DAI-sDAI",
+		VaultSymbol:      "sDAI",
+		UnderlyingSymbol: "DAI",
+		Bucket:           time.Date(2025, 9, 22, 10, 0, 0, 0, time.UTC),
+		OfficialRate:     decimal.RequireFromString("0.9"),
+		MarketRate:       decimal.RequireFromString("0.905"),
+		Notional:         decimal.NewFromInt(50000),
+		Direction:        "up",
+	}
+
+	text := renderMessage(note)
+	for _, want := range []string{"[DAI-sDAI Alert]", "Official: 0.900 sDAI/DAI", "Market: 0.905 sDAI/DAI", "Notional: 50000 DAI"} {
+		if !strings.Contains(text, want) {
+			t.Fatalf("告警消息缺少 %q:\n%s", want, text)
+		}
+	}
+}
+
 func testLogger() zerolog.Logger {
 	return zerolog.Nop()
 }
@@ -0,0 +1,94 @@
+package alerting
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// 告警颜色：按方向区分，恢复统一为绿色。
+const (
+	colorUp       = 0xD9534F
+	colorDown     = 0xF0AD4E
+	colorResolved = 0x5CB85C
+	colorNeutral  = 0x777777
+)
+
+// messageField 是富文本渠道（Slack/Discord）展示的一行键值。
+type messageField struct {
+	Name  string
+	Value string
+}
+
+// messageTitle 返回通知标题，与 renderMessage 的首行一致。
+func messageTitle(note Notification) string {
+	if note.Resolved() {
+		return fmt.Sprintf("%s Resolved (%s)", note.Pair, note.Direction)
+	}
+	return fmt.Sprintf("%s Alert (%s)", note.Pair, note.Direction)
+}
+
+// messageFields 返回与 renderMessage 相同的字段。
+func messageFields(note Notification) []messageField {
+	fields := []messageField{
+		{Name: "Official", Value: fmt.Sprintf("%s %s", note.OfficialRate.StringFixed(3), rateUnit(note))},
+		{Name: "Market", Value: fmt.Sprintf("%s %s", note.MarketRate.StringFixed(3), rateUnit(note))},
+		{Name: "Deviation", Value: fmt.Sprintf("%s%%", note.DeviationPct.StringFixed(3))},
+		{Name: "Threshold", Value: fmt.Sprintf("%s%%", note.ThresholdPct.StringFixed(3))},
+		{Name: "Direction", Value: note.Direction},
+		{Name: "Notional", Value: fmt.Sprintf("%s %s", note.Notional.String(), note.UnderlyingSymbol)},
+	}
+	if note.Resolved() {
+		fields = append(fields,
+			messageField{Name: "Started", Value: note.StartedAt.UTC().Format(time.RFC3339)},
+			messageField{Name: "Duration", Value: note.Duration.String()},
+			messageField{Name: "Peak deviation", Value: fmt.Sprintf("%s%%", note.PeakDeviationPct.StringFixed(3))},
+		)
+	}
+	return fields
+}
+
+// messageColor 按方向返回 RGB 颜色。
+func messageColor(note Notification) int {
+	if note.Resolved() {
+		return colorResolved
+	}
+	switch note.Direction {
+	case "up":
+		return colorUp
+	case "down":
+		return colorDown
+	default:
+		return colorNeutral
+	}
+}
+
+// postJSON 将 payload 以 JSON POST 到 url，非 2xx 响应视为失败。
+func postJSON(ctx context.Context, client *http.Client, url, channel string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("marshal %s payload: %w", channel, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("create %s request: %w", channel, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("send %s request: %w", channel, err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s 响应码异常: %d", channel, resp.StatusCode)
+	}
+	return nil
+}
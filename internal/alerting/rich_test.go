@@ -0,0 +1,101 @@
+package alerting
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func richNote(direction string) Notification {
+	return Notification{
+		PairID:           "usde-susde",
+		Pair:             "USDe-sUSDe",
+		VaultSymbol:      "sUSDe",
+		UnderlyingSymbol: "USDe",
+		Bucket:           time.Date(2025, 9, 22, 10, 0, 0, 0, time.UTC),
+		OfficialRate:     decimal.RequireFromString("1.1"),
+		MarketRate:       decimal.RequireFromString("1.105"),
+		DeviationPct:     decimal.RequireFromString("0.4545"),
+		ThresholdPct:     decimal.RequireFromString("0.4"),
+		Direction:        direction,
+		Notional:         decimal.NewFromInt(10000),
+	}
+}
+
+func TestSlackNotifierRendersBlocksWithDirectionColor(t *testing.T) {
+	var got slackPayload
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Fatalf("解析请求体失败: %v", err)
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	notifier := NewSlackNotifier(SlackOptions{WebhookURL: srv.URL, Channel: "#alerts"}, testLogger())
+	if err := notifier.Notify(context.Background(), richNote("up")); err != nil {
+		t.Fatalf("Slack Notify 应成功: %v", err)
+	}
+
+	if got.Channel != "#alerts" || len(got.Attachments) != 1 {
+		t.Fatalf("payload 结构不正确: %+v", got)
+	}
+	if got.Attachments[0].Color != "#D9534F" {
+		t.Fatalf("up 方向颜色不正确: %s", got.Attachments[0].Color)
+	}
+	var texts []string
+	for _, block := range got.Attachments[0].Blocks {
+		for _, f := range block.Fields {
+			texts = append(texts, f.Text)
+		}
+	}
+	joined := strings.Join(texts, "\n")
+	for _, want := range []string{"*Official*\n1.100 sUSDe/USDe", "*Market*\n1.105 sUSDe/USDe", "*Deviation*\n0.455%", "*Threshold*\n0.400%", "*Direction*\nup", "*Notional*\n10000 USDe"} {
+		if !strings.Contains(joined, want) {
+			t.Fatalf("缺少字段 %q: %s", want, joined)
+		}
+	}
+}
+
+func TestDiscordNotifierRendersEmbed(t *testing.T) {
+	var got discordPayload
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Fatalf("解析请求体失败: %v", err)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	notifier := NewDiscordNotifier(DiscordOptions{WebhookURL: srv.URL}, testLogger())
+	if err := notifier.Notify(context.Background(), richNote("down")); err != nil {
+		t.Fatalf("Discord Notify 应成功: %v", err)
+	}
+
+	if len(got.Embeds) != 1 || got.Embeds[0].Color != colorDown || len(got.Embeds[0].Fields) != 6 {
+		t.Fatalf("embed 不正确: %+v", got)
+	}
+	if got.Embeds[0].Timestamp != "2025-09-22T10:00:00Z" {
+		t.Fatalf("timestamp 不正确: %s", got.Embeds[0].Timestamp)
+	}
+}
+
+func TestRichNotifiersReportHTTPErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	if err := NewSlackNotifier(SlackOptions{WebhookURL: srv.URL}, testLogger()).Notify(context.Background(), richNote("up")); err == nil {
+		t.Fatal("Slack 非 2xx 应报错")
+	}
+	if err := NewDiscordNotifier(DiscordOptions{WebhookURL: srv.URL}, testLogger()).Notify(context.Background(), richNote("up")); err == nil {
+		t.Fatal("Discord 非 2xx 应报错")
+	}
+}
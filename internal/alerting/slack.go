@@ -0,0 +1,115 @@
+package alerting
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// SlackOptions 描述 Slack incoming webhook 参数。
+type SlackOptions struct {
+	WebhookURL string
+	// Channel/Username 非空时覆盖 webhook 的默认频道与显示名。
+	Channel  string
+	Username string
+	Timeout  time.Duration
+}
+
+// SlackNotifier 通过 Slack incoming webhook 推送带颜色的 Block Kit 消息。
+type SlackNotifier struct {
+	opts   SlackOptions
+	client *http.Client
+	logger zerolog.Logger
+}
+
+// NewSlackNotifier 构造 Slack 告警器。
+func NewSlackNotifier(opts SlackOptions, logger zerolog.Logger) *SlackNotifier {
+	if opts.Timeout <= 0 {
+		opts.Timeout = 10 * time.Second
+	}
+	return &SlackNotifier{
+		opts:   opts,
+		client: &http.Client{Timeout: opts.Timeout},
+		logger: logger.With().Str("component", "alert_slack").Logger(),
+	}
+}
+
+// Notify 推送一条消息。
+func (n *SlackNotifier) Notify(ctx context.Context, note Notification) error {
+	if err := postJSON(ctx, n.client, n.opts.WebhookURL, "slack", n.payload(note)); err != nil {
+		return err
+	}
+	n.logger.Info().Time("bucket", note.Bucket).
+		Str("kind", kindOf(note)).
+		Str("direction", note.Direction).
+		Msg("告警已发送 (Slack)")
+	return nil
+}
+
+type slackPayload struct {
+	Channel     string            `json:"channel,omitempty"`
+	Username    string            `json:"username,omitempty"`
+	Text        string            `json:"text"`
+	Attachments []slackAttachment `json:"attachments"`
+}
+
+type slackAttachment struct {
+	Color  string       `json:"color"`
+	Blocks []slackBlock `json:"blocks"`
+}
+
+type slackBlock struct {
+	Type   string      `json:"type"`
+	Text   *slackText  `json:"text,omitempty"`
+	Fields []slackText `json:"fields,omitempty"`
+}
+
+type slackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+func (n *SlackNotifier) payload(note Notification) slackPayload {
+	title := messageTitle(note)
+	fields := make([]slackText, 0, len(messageFields(note)))
+	for _, f := range messageFields(note) {
+		fields = append(fields, slackText{Type: "mrkdwn", Text: fmt.Sprintf("*%s*\n%s", f.Name, f.Value)})
+	}
+
+	blocks := []slackBlock{
+		{Type: "header", Text: &slackText{Type: "plain_text", Text: title}},
+		{Type: "section", Text: &slackText{Type: "mrkdwn", Text: fmt.Sprintf("Bucket: `%s UTC`", note.Bucket.UTC().Format(time.RFC3339))}},
+	}
+	// Slack section 最多 10 个字段。
+	for start := 0; start < len(fields); start += 10 {
+		end := start + 10
+		if end > len(fields) {
+			end = len(fields)
+		}
+		blocks = append(blocks, slackBlock{Type: "section", Fields: fields[start:end]})
+	}
+	if note.AdditionalMsg != "" {
+		blocks = append(blocks, slackBlock{Type: "section", Text: &slackText{Type: "mrkdwn", Text: slackEscape(note.AdditionalMsg)}})
+	}
+
+	return slackPayload{
+		Channel:  n.opts.Channel,
+		Username: n.opts.Username,
+		Text:     title,
+		Attachments: []slackAttachment{{
+			Color:  fmt.Sprintf("#%06X", messageColor(note)),
+			Blocks: blocks,
+		}},
+	}
+}
+
+// slackEscape 转义 Slack mrkdwn 的控制字符。
+func slackEscape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
+
+var _ Notifier = (*SlackNotifier)(nil)
@@ -0,0 +1,217 @@
+package alerting
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/shopspring/decimal"
+)
+
+// DefaultSignatureHeader 是未配置时携带 HMAC 签名的请求头。
+const DefaultSignatureHeader = "X-Signature-256"
+
+// WebhookOptions 描述通用 Webhook 告警参数。
+type WebhookOptions struct {
+	URL string
+	// Template 是 text/template 格式的 JSON 请求体；为空时使用默认结构。
+	Template string
+	Headers  map[string]string
+	// Secret 非空时对请求体做 HMAC-SHA256 签名。
+	Secret          string
+	SignatureHeader string
+	Timeout         time.Duration
+}
+
+// WebhookNotifier 将通知渲染为 JSON 并 POST 到指定地址。
+type WebhookNotifier struct {
+	url             string
+	tmpl            *template.Template
+	headers         map[string]string
+	secret          []byte
+	signatureHeader string
+	client          *http.Client
+	logger          zerolog.Logger
+}
+
+// NewWebhookNotifier 构造 Webhook 告警器，模板解析失败时返回错误。
+func NewWebhookNotifier(opts WebhookOptions, logger zerolog.Logger) (*WebhookNotifier, error) {
+	if opts.URL == "" {
+		return nil, fmt.Errorf("webhook url 不能为空")
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 10 * time.Second
+	}
+	if opts.SignatureHeader == "" {
+		opts.SignatureHeader = DefaultSignatureHeader
+	}
+
+	tmpl, err := ParseWebhookTemplate(opts.Template)
+	if err != nil {
+		return nil, err
+	}
+
+	return &WebhookNotifier{
+		url:             opts.URL,
+		tmpl:            tmpl,
+		headers:         opts.Headers,
+		secret:          []byte(opts.Secret),
+		signatureHeader: opts.SignatureHeader,
+		client:          &http.Client{Timeout: opts.Timeout},
+		logger:          logger.With().Str("component", "alert_webhook").Logger(),
+	}, nil
+}
+
+// Notify 渲染请求体并 POST，非 2xx 响应视为失败。
+func (n *WebhookNotifier) Notify(ctx context.Context, note Notification) error {
+	body, err := n.render(note)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range n.headers {
+		req.Header.Set(key, value)
+	}
+	if len(n.secret) > 0 {
+		req.Header.Set(n.signatureHeader, Sign(n.secret, body))
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("send webhook request: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook 响应码异常: %d", resp.StatusCode)
+	}
+
+	n.logger.Info().Time("bucket", note.Bucket).
+		Str("kind", kindOf(note)).
+		Str("direction", note.Direction).
+		Msg("告警已发送 (Webhook)")
+	return nil
+}
+
+// Sign 返回 "sha256=<hex>" 形式的 HMAC-SHA256 签名。
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func (n *WebhookNotifier) render(note Notification) ([]byte, error) {
+	if n.tmpl == nil {
+		body, err := json.Marshal(newWebhookPayload(note))
+		if err != nil {
+			return nil, fmt.Errorf("marshal webhook payload: %w", err)
+		}
+		return body, nil
+	}
+
+	var buf bytes.Buffer
+	if err := n.tmpl.Execute(&buf, note); err != nil {
+		return nil, fmt.Errorf("render webhook template: %w", err)
+	}
+	if !json.Valid(buf.Bytes()) {
+		return nil, fmt.Errorf("webhook template 渲染结果不是合法 JSON")
+	}
+	return buf.Bytes(), nil
+}
+
+// webhookPayload 是未配置模板时的默认请求体。
+type webhookPayload struct {
+	Kind             string   `json:"kind"`
+	PairID           string   `json:"pair_id"`
+	Pair             string   `json:"pair"`
+	Bucket           string   `json:"bucket"`
+	Direction        string   `json:"direction"`
+	OfficialRate     string   `json:"official_rate"`
+	MarketRate       string   `json:"market_rate"`
+	DeviationPct     string   `json:"deviation_pct"`
+	ThresholdPct     string   `json:"threshold_pct"`
+	Notional         string   `json:"notional"`
+	NotionalSymbol   string   `json:"notional_symbol"`
+	Channels         []string `json:"channels,omitempty"`
+	StartedAt        string   `json:"started_at,omitempty"`
+	DurationSeconds  float64  `json:"duration_seconds,omitempty"`
+	PeakDeviationPct string   `json:"peak_deviation_pct,omitempty"`
+	Message          string   `json:"message"`
+}
+
+func newWebhookPayload(note Notification) webhookPayload {
+	payload := webhookPayload{
+		Kind:           kindOf(note),
+		PairID:         note.PairID,
+		Pair:           note.Pair,
+		Bucket:         formatTime(note.Bucket),
+		Direction:      note.Direction,
+		OfficialRate:   note.OfficialRate.String(),
+		MarketRate:     note.MarketRate.String(),
+		DeviationPct:   note.DeviationPct.String(),
+		ThresholdPct:   note.ThresholdPct.String(),
+		Notional:       note.Notional.String(),
+		NotionalSymbol: note.UnderlyingSymbol,
+		Channels:       note.Channels,
+		Message:        renderMessage(note),
+	}
+	if note.Resolved() {
+		payload.StartedAt = formatTime(note.StartedAt)
+		payload.DurationSeconds = note.Duration.Seconds()
+		payload.PeakDeviationPct = note.PeakDeviationPct.String()
+	}
+	return payload
+}
+
+// ParseWebhookTemplate 解析 Webhook 请求体模板；模板为空时返回 nil，表示使用默认结构。
+func ParseWebhookTemplate(text string) (*template.Template, error) {
+	if strings.TrimSpace(text) == "" {
+		return nil, nil
+	}
+	tmpl, err := template.New("webhook").Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("parse webhook template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// templateFuncs 供 Webhook 模板使用：json 输出转义后的 JSON 值，
+// rfc3339 格式化时间，fixed 按位数格式化 decimal，message 为默认文本。
+// 增删函数时需同步 config.WebhookTemplateFuncs，加载配置时按其校验模板。
+var templateFuncs = template.FuncMap{
+	"json": func(v any) (string, error) {
+		raw, err := json.Marshal(v)
+		return string(raw), err
+	},
+	"rfc3339": formatTime,
+	"fixed": func(places int32, d decimal.Decimal) string {
+		return d.StringFixed(places)
+	},
+	"kind":    kindOf,
+	"message": renderMessage,
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+var _ Notifier = (*WebhookNotifier)(nil)
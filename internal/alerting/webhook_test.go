@@ -0,0 +1,135 @@
+package alerting
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"price-diff-alerts/internal/config"
+)
+
+func TestWebhookNotifierTemplateHeadersAndSignature(t *testing.T) {
+	var (
+		body      []byte
+		signature string
+		apiKey    string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		signature = r.Header.Get(DefaultSignatureHeader)
+		apiKey = r.Header.Get("X-Api-Key")
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	notifier, err := NewWebhookNotifier(WebhookOptions{
+		URL:      srv.URL,
+		Template: `{"summary": {{ json (printf "USDe %s" .Direction) }}, "deviation": {{ fixed 2 .DeviationPct }}, "at": {{ json (rfc3339 .Bucket) }}, "kind": {{ json (kind .) }}}`,
+		Headers:  map[string]string{"X-Api-Key": "k1"},
+		Secret:   "s3cret",
+	}, testLogger())
+	if err != nil {
+		t.Fatalf("构造 Webhook 失败: %v", err)
+	}
+
+	note := Notification{Bucket: time.Date(2025, 9, 22, 10, 0, 0, 0, time.UTC), DeviationPct: decimal.RequireFromString("0.456"), Direction: "up"}
+	if err := notifier.Notify(context.Background(), note); err != nil {
+		t.Fatalf("Webhook Notify 应成功: %v", err)
+	}
+
+	var got map[string]any
+	if err := json.Unmarshal(body, &got); err != nil {
+		t.Fatalf("请求体应为合法 JSON: %v (%s)", err, body)
+	}
+	if got["summary"] != "USDe up" || got["deviation"] != 0.46 || got["at"] != "2025-09-22T10:00:00Z" || got["kind"] != KindFiring {
+		t.Fatalf("模板渲染结果不正确: %s", body)
+	}
+	if apiKey != "k1" {
+		t.Fatalf("自定义请求头缺失: %q", apiKey)
+	}
+	if signature != Sign([]byte("s3cret"), body) {
+		t.Fatalf("签名不匹配: %s", signature)
+	}
+}
+
+func TestWebhookNotifierDefaultPayload(t *testing.T) {
+	var got map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(DefaultSignatureHeader) != "" {
+			t.Fatal("未配置 secret 时不应签名")
+		}
+		_ = json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer srv.Close()
+
+	notifier, err := NewWebhookNotifier(WebhookOptions{URL: srv.URL}, testLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	note := Notification{Kind: KindResolved, PairID: "dai-sdai", Pair: "DAI-sDAI", UnderlyingSymbol: "DAI", Bucket: time.Now(), Duration: time.Minute, PeakDeviationPct: decimal.NewFromInt(1), Direction: "down"}
+	if err := notifier.Notify(context.Background(), note); err != nil {
+		t.Fatal(err)
+	}
+	if got["kind"] != KindResolved || got["duration_seconds"] != 60.0 || got["peak_deviation_pct"] != "1" || got["pair_id"] != "dai-sdai" || got["notional_symbol"] != "DAI" {
+		t.Fatalf("默认请求体不正确: %#v", got)
+	}
+}
+
+func TestWebhookNotifierErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	notifier, err := NewWebhookNotifier(WebhookOptions{URL: srv.URL}, testLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := notifier.Notify(context.Background(), Notification{}); err == nil {
+		t.Fatal("非 2xx 响应应报错")
+	}
+
+	invalid, err := NewWebhookNotifier(WebhookOptions{URL: srv.URL, Template: `{"direction": {{ .Direction }}}`}, testLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := invalid.Notify(context.Background(), Notification{Direction: "up"}); err == nil {
+		t.Fatal("渲染结果不是 JSON 时应报错")
+	}
+
+	if _, err := NewWebhookNotifier(WebhookOptions{URL: srv.URL, Template: `{{ .Missing`}, testLogger()); err == nil {
+		t.Fatal("模板语法错误应在构造时报错")
+	}
+}
+
+func TestParseWebhookTemplate(t *testing.T) {
+	if tmpl, err := ParseWebhookTemplate("  "); err != nil || tmpl != nil {
+		t.Fatalf("空模板应使用默认结构: tmpl=%v err=%v", tmpl, err)
+	}
+	if _, err := ParseWebhookTemplate(`{"text": {{json (message .)}}, "at": "{{rfc3339 .StartedAt}}"}`); err != nil {
+		t.Fatalf("使用内置函数的模板应能解析: %v", err)
+	}
+	for _, text := range []string{`{"text": {{.Pair}`, `{"text": {{unknown .}}}`} {
+		if _, err := ParseWebhookTemplate(text); err == nil {
+			t.Fatalf("模板 %q 应解析失败", text)
+		}
+	}
+}
+
+func TestTemplateFuncsMatchConfig(t *testing.T) {
+	var names []string
+	for name := range templateFuncs {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	if !slices.Equal(names, config.WebhookTemplateFuncs) {
+		t.Fatalf("config.WebhookTemplateFuncs 应与模板函数一致: %v != %v", config.WebhookTemplateFuncs, names)
+	}
+}
@@ -0,0 +1,263 @@
+// Package api serves a read-only JSON view of recorded samples and alerts for
+// dashboards and bots. Decimal values are encoded as strings so clients never
+// lose precision to float parsing.
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"price-diff-alerts/internal/storage"
+)
+
+// ResolutionRaw selects rate_samples; the other resolutions read rollups.
+const ResolutionRaw = "raw"
+
+const (
+	defaultSampleWindow = 24 * time.Hour
+	defaultSampleLimit  = 500
+	maxSampleLimit      = 5000
+	defaultAlertLimit   = 50
+	maxAlertLimit       = 500
+)
+
+// Store is the read side the API needs. Rollup resolutions are served when
+// the store also implements storage.RollupStore.
+type Store interface {
+	ListSamplesPage(ctx context.Context, pairID string, from, to time.Time, limit int) ([]storage.RateSample, error)
+	ListRecentSamples(ctx context.Context, pairID string, limit int) ([]storage.RateSample, error)
+	ListRecentAlerts(ctx context.Context, limit int) ([]storage.AlertRecord, error)
+}
+
+type handler struct {
+	store   Store
+	rollups storage.RollupStore
+	pairs   []string
+	logger  zerolog.Logger
+	now     func() time.Time
+}
+
+// New returns a handler for /api/samples, /api/samples/latest and /api/alerts.
+// Sample endpoints take ?pair= naming one of pairs and default to the first.
+func New(store Store, pairs []string, logger zerolog.Logger) http.Handler {
+	h := &handler{store: store, pairs: pairs, logger: logger, now: time.Now}
+	if r, ok := store.(storage.RollupStore); ok {
+		h.rollups = r
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/samples", h.samples)
+	mux.HandleFunc("GET /api/samples/latest", h.latestSample)
+	mux.HandleFunc("GET /api/alerts", h.alerts)
+	return mux
+}
+
+// samples lists samples or rollups of one pair in [from, to), oldest first. A
+// page holds at most limit entries; next repeats the query from the first
+// entry left out. The store reads one extra entry to find it.
+func (h *handler) samples(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	pairID, err := h.pair(query.Get("pair"))
+	if err != nil {
+		h.fail(w, http.StatusBadRequest, err)
+		return
+	}
+	to := h.now().UTC()
+	if v := query.Get("to"); v != "" {
+		t, err := time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			h.fail(w, http.StatusBadRequest, fmt.Errorf("invalid to: %w", err))
+			return
+		}
+		to = t.UTC()
+	}
+	from := to.Add(-defaultSampleWindow)
+	if v := query.Get("from"); v != "" {
+		t, err := time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			h.fail(w, http.StatusBadRequest, fmt.Errorf("invalid from: %w", err))
+			return
+		}
+		from = t.UTC()
+	}
+	if !from.Before(to) {
+		h.fail(w, http.StatusBadRequest, errors.New("from must be before to"))
+		return
+	}
+	limit, err := parseLimit(query.Get("limit"), defaultSampleLimit, maxSampleLimit)
+	if err != nil {
+		h.fail(w, http.StatusBadRequest, err)
+		return
+	}
+	resolution := query.Get("resolution")
+	if resolution == "" {
+		resolution = ResolutionRaw
+	}
+
+	var (
+		data  any
+		nextT *time.Time
+	)
+	switch resolution {
+	case ResolutionRaw:
+		samples, err := h.store.ListSamplesPage(r.Context(), pairID, from, to, limit+1)
+		if err != nil {
+			h.fail(w, http.StatusInternalServerError, err)
+			return
+		}
+		if len(samples) > limit {
+			nextT = &samples[limit].Bucket
+			samples = samples[:limit]
+		}
+		out := make([]sampleJSON, 0, len(samples))
+		for _, s := range samples {
+			out = append(out, newSampleJSON(s))
+		}
+		data = out
+	case storage.RollupHourly, storage.RollupDaily:
+		if h.rollups == nil {
+			h.fail(w, http.StatusNotImplemented, fmt.Errorf("resolution %s not supported by this store", resolution))
+			return
+		}
+		rollups, err := h.rollups.ListRollupsPage(r.Context(), pairID, resolution, from, to, limit+1)
+		if err != nil {
+			h.fail(w, http.StatusInternalServerError, err)
+			return
+		}
+		if len(rollups) > limit {
+			nextT = &rollups[limit].Bucket
+			rollups = rollups[:limit]
+		}
+		out := make([]rollupJSON, 0, len(rollups))
+		for _, ru := range rollups {
+			out = append(out, newRollupJSON(ru))
+		}
+		data = out
+	default:
+		h.fail(w, http.StatusBadRequest, fmt.Errorf("resolution must be %s, %s or %s", ResolutionRaw, storage.RollupHourly, storage.RollupDaily))
+		return
+	}
+
+	page := pageJSON{Data: data}
+	if nextT != nil {
+		next := url.Values{}
+		next.Set("pair", pairID)
+		next.Set("from", nextT.Format(time.RFC3339Nano))
+		next.Set("to", to.Format(time.RFC3339Nano))
+		next.Set("resolution", resolution)
+		next.Set("limit", strconv.Itoa(limit))
+		page.Next = "/api/samples?" + next.Encode()
+	}
+	h.write(w, http.StatusOK, page)
+}
+
+func (h *handler) latestSample(w http.ResponseWriter, r *http.Request) {
+	pairID, err := h.pair(r.URL.Query().Get("pair"))
+	if err != nil {
+		h.fail(w, http.StatusBadRequest, err)
+		return
+	}
+	samples, err := h.store.ListRecentSamples(r.Context(), pairID, 1)
+	if err != nil {
+		h.fail(w, http.StatusInternalServerError, err)
+		return
+	}
+	if len(samples) == 0 {
+		h.fail(w, http.StatusNotFound, errors.New("no samples recorded yet"))
+		return
+	}
+	h.write(w, http.StatusOK, newSampleJSON(samples[0]))
+}
+
+// alerts lists alert episodes of every pair, newest first, paged by offset.
+func (h *handler) alerts(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	limit, err := parseLimit(query.Get("limit"), defaultAlertLimit, maxAlertLimit)
+	if err != nil {
+		h.fail(w, http.StatusBadRequest, err)
+		return
+	}
+	offset := 0
+	if v := query.Get("offset"); v != "" {
+		offset, err = strconv.Atoi(v)
+		if err != nil || offset < 0 {
+			h.fail(w, http.StatusBadRequest, fmt.Errorf("invalid offset %q", v))
+			return
+		}
+	}
+
+	// One extra row tells whether another page exists.
+	alerts, err := h.store.ListRecentAlerts(r.Context(), offset+limit+1)
+	if err != nil {
+		h.fail(w, http.StatusInternalServerError, err)
+		return
+	}
+	page := pageJSON{}
+	if len(alerts) > offset+limit {
+		next := url.Values{}
+		next.Set("offset", strconv.Itoa(offset+limit))
+		next.Set("limit", strconv.Itoa(limit))
+		page.Next = "/api/alerts?" + next.Encode()
+		alerts = alerts[:offset+limit]
+	}
+	if offset > len(alerts) {
+		offset = len(alerts)
+	}
+	out := make([]alertJSON, 0, len(alerts)-offset)
+	for _, a := range alerts[offset:] {
+		out = append(out, newAlertJSON(a))
+	}
+	page.Data = out
+	h.write(w, http.StatusOK, page)
+}
+
+// pair resolves the ?pair= parameter; empty selects the first pair.
+func (h *handler) pair(v string) (string, error) {
+	if v == "" && len(h.pairs) > 0 {
+		return h.pairs[0], nil
+	}
+	for _, id := range h.pairs {
+		if id == v {
+			return id, nil
+		}
+	}
+	return "", fmt.Errorf("unknown pair %q", v)
+}
+
+func parseLimit(v string, def, max int) (int, error) {
+	if v == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n <= 0 || n > max {
+		return 0, fmt.Errorf("limit must be between 1 and %d", max)
+	}
+	return n, nil
+}
+
+func (h *handler) write(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		h.logger.Debug().Err(err).Msg("write api response")
+	}
+}
+
+// fail answers with {"error": ...}; server-side errors are logged and not
+// echoed, since they may carry connection details.
+func (h *handler) fail(w http.ResponseWriter, status int, err error) {
+	msg := err.Error()
+	if status >= http.StatusInternalServerError && status != http.StatusNotImplemented {
+		h.logger.Error().Err(err).Msg("api request failed")
+		msg = http.StatusText(status)
+	}
+	h.write(w, status, errorJSON{Error: msg})
+}
@@ -0,0 +1,181 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/shopspring/decimal"
+
+	"price-diff-alerts/internal/storage"
+	"price-diff-alerts/internal/storage/memory"
+)
+
+func get(t *testing.T, h http.Handler, target string, body any) int {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+	if body != nil {
+		if err := json.Unmarshal(rec.Body.Bytes(), body); err != nil {
+			t.Fatalf("响应不是合法 JSON: %v: %s", err, rec.Body.String())
+		}
+	}
+	return rec.Code
+}
+
+var testPairs = []string{"usde-susde", "dai-sdai"}
+
+func seedSamples(t *testing.T, store *memory.Store, pairID string, base time.Time, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		err := store.UpsertRateSample(context.Background(), storage.RateSample{
+			PairID:       pairID,
+			Bucket:       base.Add(time.Duration(i) * 5 * time.Minute),
+			OfficialRate: decimal.NewNullDecimal(decimal.RequireFromString("0.833333333333333333")),
+			MarketRate:   decimal.NewNullDecimal(decimal.RequireFromString("0.84")),
+			DeviationPct: decimal.NewNullDecimal(decimal.RequireFromString("0.80000000000000008")),
+			Notional:     decimal.NewFromInt(10000),
+			Status:       storage.SampleStatusComplete,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+type samplePage struct {
+	Data []sampleJSON `json:"data"`
+	Next string       `json:"next"`
+}
+
+func TestSamplesPaginateAndKeepPrecision(t *testing.T) {
+	store := memory.New()
+	base := time.Date(2025, 9, 22, 10, 0, 0, 0, time.UTC)
+	seedSamples(t, store, "usde-susde", base, 5)
+	seedSamples(t, store, "dai-sdai", base, 1)
+	h := New(store, testPairs, zerolog.Nop())
+
+	var page samplePage
+	code := get(t, h, "/api/samples?from=2025-09-22T10:00:00Z&to=2025-09-22T11:00:00Z&limit=2", &page)
+	if code != http.StatusOK || len(page.Data) != 2 || page.Next == "" {
+		t.Fatalf("第一页应有 2 条且带 next: code=%d %+v", code, page)
+	}
+	if page.Data[0].PairID != "usde-susde" {
+		t.Fatalf("未指定 pair 时应返回第一个交易对: %s", page.Data[0].PairID)
+	}
+	if got := *page.Data[0].OfficialRate; got != "0.833333333333333333" {
+		t.Fatalf("小数应以字符串原样输出, 实际 %s", got)
+	}
+
+	var seen []time.Time
+	for _, s := range page.Data {
+		seen = append(seen, s.Bucket)
+	}
+	for page.Next != "" {
+		next := page.Next
+		page = samplePage{}
+		if code := get(t, h, next, &page); code != http.StatusOK {
+			t.Fatalf("翻页失败: %d", code)
+		}
+		for _, s := range page.Data {
+			seen = append(seen, s.Bucket)
+		}
+	}
+	if len(seen) != 5 {
+		t.Fatalf("翻页应不重不漏地覆盖 5 条, 实际 %v", seen)
+	}
+	for i := range seen {
+		if !seen[i].Equal(base.Add(time.Duration(i) * 5 * time.Minute)) {
+			t.Fatalf("第 %d 条顺序错误: %v", i, seen[i])
+		}
+	}
+
+	page = samplePage{}
+	get(t, h, "/api/samples?pair=dai-sdai&from=2025-09-22T10:00:00Z&to=2025-09-22T11:00:00Z", &page)
+	if len(page.Data) != 1 || page.Data[0].PairID != "dai-sdai" {
+		t.Fatalf("?pair= 应只返回该交易对的样本: %+v", page.Data)
+	}
+}
+
+func TestSamplesRejectBadQueries(t *testing.T) {
+	h := New(memory.New(), testPairs, zerolog.Nop())
+	for _, target := range []string{
+		"/api/samples?pair=unknown",
+		"/api/samples?from=yesterday",
+		"/api/samples?from=2025-09-22T11:00:00Z&to=2025-09-22T10:00:00Z",
+		"/api/samples?limit=0",
+		"/api/samples?resolution=5m",
+		"/api/alerts?offset=-1",
+	} {
+		var body errorJSON
+		if code := get(t, h, target, &body); code != http.StatusBadRequest || body.Error == "" {
+			t.Fatalf("%s 应返回 400 与错误信息, 实际 %d %+v", target, code, body)
+		}
+	}
+	// 内存存储没有汇总表。
+	if code := get(t, h, "/api/samples?resolution=1h", nil); code != http.StatusNotImplemented {
+		t.Fatalf("不支持汇总的存储应返回 501, 实际 %d", code)
+	}
+}
+
+func TestLatestSample(t *testing.T) {
+	store := memory.New()
+	h := New(store, testPairs, zerolog.Nop())
+	if code := get(t, h, "/api/samples/latest", nil); code != http.StatusNotFound {
+		t.Fatalf("无样本时应返回 404, 实际 %d", code)
+	}
+
+	base := time.Date(2025, 9, 22, 10, 0, 0, 0, time.UTC)
+	seedSamples(t, store, "usde-susde", base, 3)
+	var latest sampleJSON
+	if code := get(t, h, "/api/samples/latest", &latest); code != http.StatusOK {
+		t.Fatalf("应返回 200, 实际 %d", code)
+	}
+	if !latest.Bucket.Equal(base.Add(10 * time.Minute)) {
+		t.Fatalf("应返回最新 bucket, 实际 %v", latest.Bucket)
+	}
+}
+
+func TestAlertsPaginateByOffset(t *testing.T) {
+	store := memory.New()
+	base := time.Date(2025, 9, 22, 10, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		_, err := store.InsertAlert(context.Background(), storage.AlertRecord{
+			PairID:       "usde-susde",
+			SampleTS:     base.Add(time.Duration(i) * time.Hour),
+			DeviationPct: decimal.RequireFromString("0.4500000001"),
+			ThresholdPct: decimal.RequireFromString("0.4"),
+			Direction:    "up",
+			State:        "firing",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	h := New(store, testPairs, zerolog.Nop())
+
+	var page struct {
+		Data []alertJSON `json:"data"`
+		Next string      `json:"next"`
+	}
+	if code := get(t, h, "/api/alerts?limit=2", &page); code != http.StatusOK {
+		t.Fatalf("应返回 200, 实际 %d", code)
+	}
+	if len(page.Data) != 2 || page.Next != "/api/alerts?limit=2&offset=2" {
+		t.Fatalf("第一页应有 2 条并指向 offset=2: %+v", page)
+	}
+	if page.Data[0].DeviationPct != "0.4500000001" {
+		t.Fatalf("偏差应以字符串原样输出: %s", page.Data[0].DeviationPct)
+	}
+	first := page.Data[0].ID
+
+	page.Data, page.Next = nil, ""
+	get(t, h, "/api/alerts?limit=2&offset=2", &page)
+	if len(page.Data) != 1 || page.Next != "" || page.Data[0].ID == first {
+		t.Fatalf("最后一页应只剩 1 条且无 next: %+v", page)
+	}
+}
@@ -0,0 +1,139 @@
+package api
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"price-diff-alerts/internal/storage"
+)
+
+type pageJSON struct {
+	Data any `json:"data"`
+	// Next is the relative URL of the following page, empty on the last one.
+	Next string `json:"next,omitempty"`
+}
+
+type errorJSON struct {
+	Error string `json:"error"`
+}
+
+type sampleJSON struct {
+	PairID         string     `json:"pair_id"`
+	Bucket         time.Time  `json:"bucket"`
+	Status         string     `json:"status"`
+	OfficialRate   *string    `json:"official_rate"`
+	OfficialMethod *string    `json:"official_method,omitempty"`
+	MarketRate     *string    `json:"market_rate"`
+	DeviationPct   *string    `json:"deviation_pct"`
+	Notional       string     `json:"notional"`
+	CowQuality     string     `json:"cow_quality,omitempty"`
+	BlockNumber    *int64     `json:"block_number,omitempty"`
+	BlockHash      *string    `json:"block_hash,omitempty"`
+	BlockTime      *time.Time `json:"block_time,omitempty"`
+	Error          *string    `json:"error,omitempty"`
+	ErrorPhase     *string    `json:"error_phase,omitempty"`
+}
+
+func newSampleJSON(s storage.RateSample) sampleJSON {
+	return sampleJSON{
+		PairID:         s.PairID,
+		Bucket:         s.Bucket.UTC(),
+		Status:         s.Status,
+		OfficialRate:   nullString(s.OfficialRate),
+		OfficialMethod: s.OfficialMethod,
+		MarketRate:     nullString(s.MarketRate),
+		DeviationPct:   nullString(s.DeviationPct),
+		Notional:       s.Notional.String(),
+		CowQuality:     s.CowQuality,
+		BlockNumber:    s.BlockNumber,
+		BlockHash:      s.BlockHash,
+		BlockTime:      s.BlockTime,
+		Error:          s.Error,
+		ErrorPhase:     s.ErrorPhase,
+	}
+}
+
+type statsJSON struct {
+	Min  *string `json:"min"`
+	Max  *string `json:"max"`
+	Avg  *string `json:"avg"`
+	Last *string `json:"last"`
+}
+
+type rollupJSON struct {
+	PairID     string    `json:"pair_id"`
+	Bucket     time.Time `json:"bucket"`
+	Resolution string    `json:"resolution"`
+	Samples    int       `json:"samples"`
+	Complete   int       `json:"complete"`
+	Official   statsJSON `json:"official_rate"`
+	Market     statsJSON `json:"market_rate"`
+	Deviation  statsJSON `json:"deviation_pct"`
+}
+
+func newRollupJSON(r storage.SampleRollup) rollupJSON {
+	return rollupJSON{
+		PairID:     r.PairID,
+		Bucket:     r.Bucket.UTC(),
+		Resolution: r.Resolution,
+		Samples:    r.Samples,
+		Complete:   r.Complete,
+		Official:   newStatsJSON(r.Official),
+		Market:     newStatsJSON(r.Market),
+		Deviation:  newStatsJSON(r.Deviation),
+	}
+}
+
+func newStatsJSON(s storage.RollupStats) statsJSON {
+	return statsJSON{
+		Min:  nullString(s.Min),
+		Max:  nullString(s.Max),
+		Avg:  nullString(s.Avg),
+		Last: nullString(s.Last),
+	}
+}
+
+type alertJSON struct {
+	ID               int64      `json:"id"`
+	PairID           string     `json:"pair_id"`
+	SampleTS         time.Time  `json:"sample_ts"`
+	Direction        string     `json:"direction"`
+	State            string     `json:"state"`
+	DeviationPct     string     `json:"deviation_pct"`
+	ThresholdPct     string     `json:"threshold_pct"`
+	PeakDeviationPct *string    `json:"peak_deviation_pct"`
+	Channels         []string   `json:"channels"`
+	ConfirmedAt      *time.Time `json:"confirmed_at"`
+	ResolvedAt       *time.Time `json:"resolved_at"`
+	CreatedAt        time.Time  `json:"created_at"`
+}
+
+func newAlertJSON(a storage.AlertRecord) alertJSON {
+	channels := a.Channels
+	if channels == nil {
+		channels = []string{}
+	}
+	return alertJSON{
+		ID:               a.ID,
+		PairID:           a.PairID,
+		SampleTS:         a.SampleTS.UTC(),
+		Direction:        a.Direction,
+		State:            a.State,
+		DeviationPct:     a.DeviationPct.String(),
+		ThresholdPct:     a.ThresholdPct.String(),
+		PeakDeviationPct: nullString(a.PeakDeviationPct),
+		Channels:         channels,
+		ConfirmedAt:      a.ConfirmedAt,
+		ResolvedAt:       a.ResolvedAt,
+		CreatedAt:        a.CreatedAt.UTC(),
+	}
+}
+
+func nullString(d decimal.NullDecimal) *string {
+	if !d.Valid {
+		return nil
+	}
+	s := d.Decimal.String()
+	return &s
+}
@@ -3,6 +3,8 @@ package app
 import (
 	"context"
 	"errors"
+	"fmt"
+	"net/http"
 	"os/signal"
 	"syscall"
 	"time"
@@ -10,12 +12,14 @@ import (
 	"github.com/rs/zerolog"
 	"github.com/shopspring/decimal"
 
-	"price-diff-alerts/internal/alerting"
+	"price-diff-alerts/internal/api"
 	"price-diff-alerts/internal/config"
 	"price-diff-alerts/internal/fetcher"
+	"price-diff-alerts/internal/metrics"
 	"price-diff-alerts/internal/scheduler"
 	"price-diff-alerts/internal/service"
 	"price-diff-alerts/internal/storage"
+	"price-diff-alerts/internal/storage/sqlite"
 )
 
 // App aggregates configuration and shared dependencies for the CLI commands.
@@ -29,39 +33,84 @@ func NewApp(cfg *config.Config, logger zerolog.Logger) *App {
 	return &App{Config: cfg, Logger: logger.With().Str("component", "app").Logger()}
 }
 
-func (a *App) newFetchers() (fetcher.OfficialRateFetcher, fetcher.MarketRateFetcher) {
+// newPairs builds the fetchers of every configured pair.
+func (a *App) newPairs() []service.Pair {
+	pairs := make([]service.Pair, 0, len(a.Config.Pairs))
+	for _, pair := range a.Config.Pairs {
+		pairs = append(pairs, a.newPair(pair, 0))
+	}
+	return pairs
+}
+
+// newPair builds the fetchers of one pair with RPC calls capped at rpcRate
+// requests per second.
+func (a *App) newPair(pair config.PairConfig, rpcRate float64) service.Pair {
+	logger := a.Logger.With().Str("pair", pair.ID).Logger()
+
 	official := fetcher.NewOfficial(fetcher.OfficialOptions{
-		RPCURL:       a.Config.Ethereum.RPCURL,
-		SUSDEAddress: a.Config.Ethereum.SUSDEAddress,
-		Timeout:      a.Config.Ethereum.RequestTimeout,
-	}, a.Logger)
+		RPCURLs:           a.Config.Ethereum.Endpoints(),
+		Quorum:            a.Config.Ethereum.Quorum,
+		MulticallAddress:  a.Config.Ethereum.MulticallAddress,
+		VaultAddress:      pair.VaultAddress,
+		UnderlyingAddress: pair.UnderlyingAddress,
+		Method:            pair.OfficialMethod,
+		Timeout:           a.Config.Ethereum.RequestTimeout,
+		RateLimit:         rpcRate,
+	}, logger)
 
 	market := fetcher.NewMarket(fetcher.MarketOptions{
 		BaseURL:      a.Config.Cow.BaseURL,
 		PriceQuality: a.Config.Cow.PriceQuality,
-		NotionalUSDE: decimal.NewFromFloat(a.Config.Cow.NotionalUSDE),
+		Notional:     decimal.NewFromFloat(pair.Notional),
 		Timeout:      a.Config.Cow.RequestTimeout,
 		UserAgent:    a.Config.Cow.UserAgent,
-		SellToken:    a.Config.Ethereum.USDEAddress,
-		BuyToken:     a.Config.Ethereum.SUSDEAddress,
-	}, a.Logger)
+		SellToken:    pair.UnderlyingAddress,
+		BuyToken:     pair.VaultAddress,
+	}, logger)
 
-	return official, market
+	return service.Pair{Config: pair, Official: official, Market: market}
 }
 
-func (a *App) newNotifier() alerting.Notifier {
-	if a.Config.Alerting.Telegram.Enabled {
-		cfg := a.Config.Alerting.Telegram
-		return alerting.NewTelegramNotifier(cfg.BotToken, cfg.ChatID, cfg.APIBase, 10*time.Second, a.Logger)
+// resolvePair returns the pair selected by a --pair flag; empty selects the
+// first configured pair.
+func (a *App) resolvePair(id string) (config.PairConfig, error) {
+	if id == "" {
+		if len(a.Config.Pairs) == 0 {
+			return config.PairConfig{}, errors.New("no pairs configured")
+		}
+		return a.Config.Pairs[0], nil
 	}
-	return nil
+	pair, ok := a.Config.Pair(id)
+	if !ok {
+		return config.PairConfig{}, fmt.Errorf("pair %q not configured", id)
+	}
+	return pair, nil
+}
+
+// pairIDs lists the configured pair ids in order.
+func (a *App) pairIDs() []string {
+	ids := make([]string, 0, len(a.Config.Pairs))
+	for _, pair := range a.Config.Pairs {
+		ids = append(ids, pair.ID)
+	}
+	return ids
 }
 
-func (a *App) openStore(ctx context.Context) (*storage.Store, func(), error) {
+// openStore opens the backend selected by database.dsn: an embedded SQLite
+// file for sqlite: DSNs, PostgreSQL otherwise.
+func (a *App) openStore(ctx context.Context) (storage.Backend, func(), error) {
 	if a.Config.Database.DSN == "" {
 		return nil, nil, nil
 	}
 
+	if sqlite.IsDSN(a.Config.Database.DSN) {
+		store, err := sqlite.Open(ctx, a.Config.Database.DSN)
+		if err != nil {
+			return nil, nil, err
+		}
+		return store, store.Close, nil
+	}
+
 	pool, err := storage.NewPool(ctx, a.Config.Database)
 	if err != nil {
 		return nil, nil, err
@@ -89,6 +138,11 @@ func (a *App) Run(ctx context.Context) error {
 	if closeStore != nil {
 		defer closeStore()
 	}
+	if store != nil {
+		if err := a.checkSchema(ctx, store); err != nil {
+			return err
+		}
+	}
 
 	sched := scheduler.New(scheduler.Options{
 		Interval:     a.Config.Scheduler.Interval,
@@ -96,8 +150,10 @@ func (a *App) Run(ctx context.Context) error {
 		StartupDelay: a.Config.Scheduler.StartupDelay,
 	}, a.Logger)
 
-	official, market := a.newFetchers()
-	notifier := a.newNotifier()
+	notifier, err := a.newNotifier()
+	if err != nil {
+		return err
+	}
 
 	var sampleStore storage.RateSampleStore
 	var alertStore storage.AlertStore
@@ -106,7 +162,56 @@ func (a *App) Run(ctx context.Context) error {
 		alertStore = store
 	}
 
-	svc := service.New(a.Config, sched, official, market, sampleStore, alertStore, notifier, a.Logger)
+	svc := service.New(a.Config, sched, a.newPairs(), sampleStore, alertStore, notifier, a.Logger)
+
+	if a.Config.HTTP.Listen != "" {
+		m := metrics.New()
+		svc.SetMetrics(m)
+
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", m.Handler())
+		mux.Handle("/healthz", healthHandler(svc, false))
+		mux.Handle("/readyz", healthHandler(svc, true))
+		if store != nil && a.Config.HTTP.API {
+			mux.Handle("/api/", api.New(store, a.pairIDs(), a.Logger))
+		}
+		if err := a.listenHTTP(ctx, mux); err != nil {
+			return err
+		}
+	}
+
+	if digest := a.newDigestSender(); digest != nil {
+		if store == nil {
+			a.Logger.Warn().Msg("email digest requires database.dsn; digest disabled")
+		} else {
+			interval := a.Config.Alerting.Email.DigestInterval
+			go func() {
+				if err := svc.RunDigest(ctx, interval, digest); err != nil && !errors.Is(err, context.Canceled) {
+					a.Logger.Error().Err(err).Msg("digest loop stopped")
+				}
+			}()
+		}
+	}
+
+	if a.Config.Retention.Enabled {
+		if store == nil {
+			a.Logger.Warn().Msg("retention requires database.dsn; retention disabled")
+		} else {
+			go func() {
+				if err := svc.RunRetention(ctx); err != nil && !errors.Is(err, context.Canceled) {
+					a.Logger.Error().Err(err).Msg("retention loop stopped")
+				}
+			}()
+		}
+	}
+
+	if store != nil && notifier != nil && a.Config.Alerting.Enabled {
+		go func() {
+			if err := svc.RunOutbox(ctx); err != nil && !errors.Is(err, context.Canceled) {
+				a.Logger.Error().Err(err).Msg("outbox loop stopped")
+			}
+		}()
+	}
 
 	a.Logger.Info().Msg("starting monitoring service")
 	err = svc.Run(ctx)
@@ -121,6 +226,8 @@ func (a *App) Run(ctx context.Context) error {
 
 // ExportOptions hold parameters for exporting historical samples.
 type ExportOptions struct {
+	// Pair selects the exported pair; empty selects the first one.
+	Pair      string
 	From      *time.Time
 	To        *time.Time
 	PNGPath   string
@@ -130,13 +237,54 @@ type ExportOptions struct {
 
 // ShowOptions configure the show command.
 type ShowOptions struct {
+	// Pair selects the displayed pair; empty selects the first one.
+	Pair  string
 	Limit int
 }
 
+// MigrateOptions configure the migrate command.
+type MigrateOptions struct {
+	Action string
+	// Steps is the number of migrations rolled back by down.
+	Steps int
+	// Version is the target of goto and baseline.
+	Version int64
+}
+
+// OutboxOptions configure the outbox command.
+type OutboxOptions struct {
+	Action string
+	// Status filters list output; empty lists every status.
+	Status string
+	Limit  int
+	// IDs and AllDead select the messages replay requeues.
+	IDs     []int64
+	AllDead bool
+}
+
+// ServeOptions configure the serve command.
+type ServeOptions struct {
+	// Listen overrides http.listen.
+	Listen string
+}
+
+// HealthcheckOptions configure the healthcheck command.
+type HealthcheckOptions struct {
+	// URL overrides the endpoint derived from http.listen.
+	URL string
+	// Ready probes /readyz instead of /healthz.
+	Ready   bool
+	Timeout time.Duration
+}
+
 // BackfillOptions configure the backfill job.
 type BackfillOptions struct {
+	// Pair limits the backfill to one pair; empty backfills every pair.
+	Pair    string
 	From    time.Time
 	To      time.Time
 	DryRun  bool
 	Workers int
+	// Restart ignores any saved checkpoint and starts from From again.
+	Restart bool
 }
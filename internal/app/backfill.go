@@ -3,13 +3,34 @@ package app
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strings"
+	"sync"
 	"time"
 
+	"price-diff-alerts/internal/config"
 	"price-diff-alerts/internal/service"
 	"price-diff-alerts/internal/storage"
+	"price-diff-alerts/internal/storage/memory"
 )
 
-// Backfill processes historical intervals。
+// backfillJob is a single bucket handed to a worker; index preserves bucket order.
+type backfillJob struct {
+	index  int
+	bucket time.Time
+}
+
+type backfillResult struct {
+	index  int
+	sample storage.RateSample
+	err    error
+	skip   bool
+}
+
+// Backfill processes historical intervals。官方价按 bucket 时刻所在区块读取（需归档节点），
+// 已有 complete 记录的 bucket 会被跳过，避免覆盖实时采样。
+// 多个 worker 并发取数，但结果按 bucket 顺序写入，并按写入进度保存 checkpoint，中断后可续跑。
+// 未指定 --pair 时依次回填每个交易对，各自独立保存 checkpoint。
 func (a *App) Backfill(ctx context.Context, opts BackfillOptions) error {
 	interval := a.Config.Scheduler.Interval
 	if interval <= 0 {
@@ -22,17 +43,27 @@ func (a *App) Backfill(ctx context.Context, opts BackfillOptions) error {
 		return errors.New("回填范围为空，请检查 --from/--to")
 	}
 
-	if opts.Workers > 1 {
-		a.Logger.Warn().Int("workers", opts.Workers).Msg("当前回填暂不支持多线程，将顺序执行")
+	pairs := a.Config.Pairs
+	if opts.Pair != "" {
+		pair, err := a.resolvePair(opts.Pair)
+		if err != nil {
+			return err
+		}
+		pairs = []config.PairConfig{pair}
 	}
 
-	var store *storage.Store
+	var store storage.Backend
 	var closeStore func()
 	var err error
 	var rateStore storage.RateSampleStore
+	var checkpoints storage.BackfillCheckpointStore
 
 	if opts.DryRun {
+		// dry-run 写入内存存储，照常走跳过、checkpoint 与失败汇总逻辑。
 		a.Logger.Warn().Msg("回填 dry-run：不会写入数据库")
+		dryRun := memory.New()
+		rateStore = dryRun
+		checkpoints = dryRun
 	} else {
 		store, closeStore, err = a.openStore(ctx)
 		if err != nil {
@@ -45,36 +76,246 @@ func (a *App) Backfill(ctx context.Context, opts BackfillOptions) error {
 			defer closeStore()
 		}
 		rateStore = store
+		checkpoints = store
 	}
 
-	official, market := a.newFetchers()
+	var errs []error
+	for _, pair := range pairs {
+		rpcRate := a.Config.Backfill.RPCRateLimit
+		newWorker := func() service.Pair { return a.newPair(pair, rpcRate) }
+		err := a.backfillPair(ctx, pair, newWorker, start, end, opts, rateStore, checkpoints)
+		if err == nil {
+			continue
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", pair.ID, err))
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return errors.Join(errs...)
+}
 
-	svc := service.New(a.Config, nil, official, market, rateStore, nil, nil, a.Logger)
+// backfillPair 回填单个交易对的 [start, end)，每个 worker 使用 newWorker 构造的独立 fetcher。
+func (a *App) backfillPair(ctx context.Context, pair config.PairConfig, newWorker func() service.Pair, start, end time.Time, opts BackfillOptions, rateStore storage.RateSampleStore, checkpoints storage.BackfillCheckpointStore) error {
+	interval := a.Config.Scheduler.Interval
+	workers := a.Config.ResolveBackfillWorkers(opts.Workers)
+	logger := a.Logger.With().Str("pair", pair.ID).Logger()
 
-	processed := 0
-	failed := 0
-	for bucket := start; bucket.Before(end); bucket = bucket.Add(interval) {
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		default:
+	checkpoint := storage.BackfillCheckpoint{
+		JobKey:    backfillJobKey(pair.ID, start, end, interval),
+		From:      start,
+		To:        end,
+		Interval:  interval,
+		Watermark: start,
+	}
+	if checkpoints != nil && !opts.Restart {
+		saved, found, err := checkpoints.GetBackfillCheckpoint(ctx, checkpoint.JobKey)
+		if err != nil {
+			return err
 		}
+		if found {
+			checkpoint = saved
+			logger.Info().Str("job", saved.JobKey).
+				Time("watermark", saved.Watermark).
+				Int64("processed", saved.Processed).
+				Int64("failed", saved.Failed).
+				Msg("从 checkpoint 继续回填")
+		}
+	}
 
-		if err := svc.ProcessBucket(ctx, bucket); err != nil {
-			failed++
-			a.Logger.Error().Err(err).Time("bucket", bucket).Msg("回填失败")
-			continue
+	existing := make(map[time.Time]struct{})
+	if rateStore != nil {
+		samples, err := rateStore.ListSamplesBetween(ctx, pair.ID, checkpoint.Watermark, end)
+		if err != nil {
+			return err
+		}
+		for _, sample := range samples {
+			if sample.Status == storage.SampleStatusComplete {
+				existing[sample.Bucket.UTC()] = struct{}{}
+			}
 		}
-		processed++
 	}
 
-	a.Logger.Info().Int("processed", processed).Int("failed", failed).Msg("回填完成")
-	if failed > 0 {
-		return errors.New("部分 bucket 回填失败，请检查日志")
+	var buckets []time.Time
+	for bucket := checkpoint.Watermark.UTC(); bucket.Before(end); bucket = bucket.Add(interval) {
+		buckets = append(buckets, bucket)
+	}
+
+	logger.Info().Int("workers", workers).
+		Int("buckets", len(buckets)).
+		Float64("rpc_rate_limit", a.Config.Backfill.RPCRateLimit).
+		Msg("开始回填")
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	jobs := make(chan backfillJob)
+	results := make(chan backfillResult, workers)
+	// window bounds how far workers may run ahead of the in-order writer.
+	window := make(chan struct{}, workers*4)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		svc := service.New(a.Config, nil, []service.Pair{newWorker()}, nil, nil, nil, logger.With().Int("worker", i).Logger())
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				if _, ok := existing[job.bucket]; ok {
+					results <- backfillResult{index: job.index, skip: true}
+					continue
+				}
+				sample, err := svc.HistoricalSample(runCtx, pair.ID, job.bucket)
+				results <- backfillResult{index: job.index, sample: sample, err: err}
+			}
+		}()
+	}
+
+	go func() {
+		defer close(jobs)
+		for i, bucket := range buckets {
+			select {
+			case <-runCtx.Done():
+				return
+			case window <- struct{}{}:
+			}
+			select {
+			case <-runCtx.Done():
+				return
+			case jobs <- backfillJob{index: i, bucket: bucket}:
+			}
+		}
+	}()
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	saveCheckpoint := func() {
+		if checkpoints == nil {
+			return
+		}
+		saveCtx, cancelSave := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+		defer cancelSave()
+		if err := checkpoints.SaveBackfillCheckpoint(saveCtx, checkpoint); err != nil {
+			logger.Error().Err(err).Msg("保存回填 checkpoint 失败")
+		}
+	}
+
+	pending := make(map[int]backfillResult)
+	next := 0
+	skipped := 0
+	var runFailed []time.Time
+	var writeErr error
+
+	for res := range results {
+		pending[res.index] = res
+		for {
+			ready, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			bucket := buckets[next]
+
+			if writeErr == nil {
+				switch {
+				case ready.skip:
+					skipped++
+				case errors.Is(ready.err, context.Canceled) && runCtx.Err() != nil:
+					// Interrupted mid-fetch: leave the bucket for the next run.
+					writeErr = ready.err
+				default:
+					if rateStore != nil {
+						if err := rateStore.UpsertRateSample(runCtx, ready.sample); err != nil {
+							writeErr = fmt.Errorf("upsert sample %s: %w", bucket.Format(time.RFC3339), err)
+							cancel()
+						}
+					}
+					if writeErr == nil {
+						checkpoint.Processed++
+						if ready.err != nil {
+							checkpoint.Failed++
+							runFailed = append(runFailed, bucket)
+							logger.Error().Err(ready.err).Time("bucket", bucket).Msg("回填失败")
+						}
+					}
+				}
+				if writeErr == nil {
+					checkpoint.Watermark = bucket.Add(interval)
+					if (next+1)%a.Config.Backfill.CheckpointEvery == 0 {
+						saveCheckpoint()
+					}
+				}
+			}
+
+			next++
+			<-window
+		}
+	}
+
+	saveCheckpoint()
+
+	if writeErr == nil && ctx.Err() != nil {
+		writeErr = ctx.Err()
+	}
+	if writeErr != nil {
+		logger.Warn().Err(writeErr).Time("watermark", checkpoint.Watermark).Msg("回填中断，可重新执行同一命令继续")
+		return writeErr
+	}
+
+	failedBuckets := runFailed
+	if rateStore != nil {
+		// Report from storage so failures from earlier, resumed runs are included.
+		var err error
+		failedBuckets, err = listErroredBuckets(ctx, rateStore, pair.ID, start, end)
+		if err != nil {
+			return err
+		}
+	}
+
+	logger.Info().
+		Int64("processed", checkpoint.Processed).
+		Int("skipped", skipped).
+		Int64("failed", checkpoint.Failed).
+		Msg("回填完成")
+
+	if len(failedBuckets) > 0 {
+		formatted := make([]string, 0, len(failedBuckets))
+		for _, bucket := range failedBuckets {
+			formatted = append(formatted, bucket.UTC().Format(time.RFC3339))
+		}
+		logger.Error().Strs("failed_buckets", formatted).Msg("以下 bucket 回填失败")
+		return fmt.Errorf("%d 个 bucket 回填失败: %s", len(failedBuckets), strings.Join(formatted, ", "))
 	}
 	return nil
 }
 
+func listErroredBuckets(ctx context.Context, store storage.RateSampleStore, pairID string, from, to time.Time) ([]time.Time, error) {
+	samples, err := store.ListSamplesBetween(ctx, pairID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	var failed []time.Time
+	for _, sample := range samples {
+		if sample.Status == storage.SampleStatusErrored {
+			failed = append(failed, sample.Bucket)
+		}
+	}
+	return failed, nil
+}
+
+// backfillJobKey identifies a checkpoint. The legacy pair keeps the key used
+// before pairs existed so interrupted runs still resume.
+func backfillJobKey(pairID string, from, to time.Time, interval time.Duration) string {
+	key := fmt.Sprintf("%s/%s/%s", from.UTC().Format(time.RFC3339), to.UTC().Format(time.RFC3339), interval)
+	if pairID == config.LegacyPairID {
+		return key
+	}
+	return pairID + "/" + key
+}
+
 func alignForward(t time.Time, interval time.Duration) time.Time {
 	truncated := t.Truncate(interval)
 	if truncated.Before(t) {
@@ -0,0 +1,197 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/shopspring/decimal"
+
+	"price-diff-alerts/internal/config"
+	"price-diff-alerts/internal/fetcher"
+	"price-diff-alerts/internal/service"
+	"price-diff-alerts/internal/storage"
+	"price-diff-alerts/internal/storage/memory"
+)
+
+var backfillBase = time.Date(2025, 9, 22, 10, 0, 0, 0, time.UTC)
+
+// historicalOfficial 按 bucket 时间定位区块；越早的 bucket 取数越慢，使 worker 乱序完成。
+type historicalOfficial struct {
+	mu      sync.Mutex
+	fetched []time.Time
+	fail    map[time.Time]bool
+}
+
+func (h *historicalOfficial) FetchOfficial(ctx context.Context) (fetcher.OfficialQuote, error) {
+	return fetcher.OfficialQuote{}, errors.New("not used by backfill")
+}
+
+func (h *historicalOfficial) BlockAtTime(ctx context.Context, ts time.Time) (uint64, error) {
+	return uint64(ts.Unix()), nil
+}
+
+func (h *historicalOfficial) FetchOfficialAt(ctx context.Context, blockNumber uint64) (fetcher.OfficialQuote, error) {
+	bucket := time.Unix(int64(blockNumber), 0).UTC()
+	h.mu.Lock()
+	h.fetched = append(h.fetched, bucket)
+	h.mu.Unlock()
+
+	delay := time.Duration(5-bucket.Sub(backfillBase)/(5*time.Minute)%5) * time.Millisecond
+	select {
+	case <-ctx.Done():
+		return fetcher.OfficialQuote{}, ctx.Err()
+	case <-time.After(delay):
+	}
+	if h.fail[bucket] {
+		return fetcher.OfficialQuote{}, errors.New("archive node unavailable")
+	}
+	return fetcher.OfficialQuote{Rate: decimal.RequireFromString("0.83"), BlockNumber: blockNumber, Method: fetcher.MethodPreviewDeposit}, nil
+}
+
+func (h *historicalOfficial) fetchedBuckets() []time.Time {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]time.Time(nil), h.fetched...)
+}
+
+// orderedStore 记录样本写入顺序。
+type orderedStore struct {
+	*memory.Store
+	mu      sync.Mutex
+	written []time.Time
+}
+
+func (s *orderedStore) UpsertRateSample(ctx context.Context, sample storage.RateSample) error {
+	s.mu.Lock()
+	s.written = append(s.written, sample.Bucket)
+	s.mu.Unlock()
+	return s.Store.UpsertRateSample(ctx, sample)
+}
+
+func backfillApp() (*App, config.PairConfig) {
+	pair := config.PairConfig{ID: config.LegacyPairID, VaultSymbol: "sUSDe", UnderlyingSymbol: "USDe", Notional: 10000}
+	cfg := &config.Config{
+		Scheduler: config.SchedulerConfig{Interval: 5 * time.Minute},
+		Backfill:  config.BackfillConfig{Workers: 3, CheckpointEvery: 2},
+		Pairs:     []config.PairConfig{pair},
+	}
+	return NewApp(cfg, zerolog.Nop()), pair
+}
+
+func runBackfill(t *testing.T, a *App, pair config.PairConfig, official *historicalOfficial, store *orderedStore, opts BackfillOptions) error {
+	t.Helper()
+	newWorker := func() service.Pair { return service.Pair{Config: pair, Official: official} }
+	return a.backfillPair(context.Background(), pair, newWorker, opts.From, opts.To, opts, store, store)
+}
+
+func bucketAt(i int) time.Time {
+	return backfillBase.Add(time.Duration(i) * 5 * time.Minute)
+}
+
+func TestBackfillWritesInBucketOrder(t *testing.T) {
+	a, pair := backfillApp()
+	official := &historicalOfficial{}
+	store := &orderedStore{Store: memory.New()}
+	opts := BackfillOptions{From: backfillBase, To: bucketAt(12)}
+
+	if err := runBackfill(t, a, pair, official, store, opts); err != nil {
+		t.Fatal(err)
+	}
+	if len(store.written) != 12 {
+		t.Fatalf("应写入 12 个 bucket, 实际 %d", len(store.written))
+	}
+	for i, bucket := range store.written {
+		if !bucket.Equal(bucketAt(i)) {
+			t.Fatalf("worker 乱序完成时仍应按 bucket 顺序写入: %v", store.written)
+		}
+	}
+
+	checkpoint, found, err := store.GetBackfillCheckpoint(context.Background(), backfillJobKey(pair.ID, opts.From, opts.To, 5*time.Minute))
+	if err != nil || !found {
+		t.Fatalf("应保存 checkpoint: found=%v err=%v", found, err)
+	}
+	if !checkpoint.Watermark.Equal(opts.To) || checkpoint.Processed != 12 || checkpoint.Failed != 0 {
+		t.Fatalf("checkpoint 应推进到回填终点: %+v", checkpoint)
+	}
+}
+
+func TestBackfillResumesFromCheckpointAndSkipsExisting(t *testing.T) {
+	a, pair := backfillApp()
+	official := &historicalOfficial{}
+	store := &orderedStore{Store: memory.New()}
+	opts := BackfillOptions{From: backfillBase, To: bucketAt(8)}
+	ctx := context.Background()
+
+	// 上次运行停在第 4 个 bucket；其后已有一条实时采样的 complete 记录。
+	if err := store.SaveBackfillCheckpoint(ctx, storage.BackfillCheckpoint{
+		JobKey:    backfillJobKey(pair.ID, opts.From, opts.To, 5*time.Minute),
+		From:      opts.From,
+		To:        opts.To,
+		Interval:  5 * time.Minute,
+		Watermark: bucketAt(4),
+		Processed: 4,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	live := storage.RateSample{PairID: pair.ID, Bucket: bucketAt(5), Status: storage.SampleStatusComplete}
+	if err := store.Store.UpsertRateSample(ctx, live); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := runBackfill(t, a, pair, official, store, opts); err != nil {
+		t.Fatal(err)
+	}
+	fetched := official.fetchedBuckets()
+	if len(fetched) != 3 {
+		t.Fatalf("应只取 watermark 之后且尚无 complete 记录的 3 个 bucket, 实际 %v", fetched)
+	}
+	for _, bucket := range fetched {
+		if bucket.Before(bucketAt(4)) || bucket.Equal(bucketAt(5)) {
+			t.Fatalf("不应重取已完成或已有记录的 bucket: %v", fetched)
+		}
+	}
+	samples, err := store.ListSamplesBetween(ctx, pair.ID, bucketAt(5), bucketAt(6))
+	if err != nil || len(samples) != 1 || samples[0].Status != storage.SampleStatusComplete {
+		t.Fatalf("已有的 complete 记录不应被覆盖: %+v err=%v", samples, err)
+	}
+
+	// --restart 忽略 checkpoint，从头取数，但仍跳过已有记录。
+	official = &historicalOfficial{}
+	opts.Restart = true
+	if err := runBackfill(t, a, pair, official, store, opts); err != nil {
+		t.Fatal(err)
+	}
+	if fetched := official.fetchedBuckets(); len(fetched) != 7 {
+		t.Fatalf("restart 应从头回填除已有记录外的 7 个 bucket, 实际 %d", len(fetched))
+	}
+}
+
+func TestBackfillReportsFailedBuckets(t *testing.T) {
+	a, pair := backfillApp()
+	official := &historicalOfficial{fail: map[time.Time]bool{bucketAt(2): true}}
+	store := &orderedStore{Store: memory.New()}
+	opts := BackfillOptions{From: backfillBase, To: bucketAt(6)}
+
+	err := runBackfill(t, a, pair, official, store, opts)
+	if err == nil || !strings.Contains(err.Error(), bucketAt(2).Format(time.RFC3339)) {
+		t.Fatalf("应汇报失败的 bucket, 实际 %v", err)
+	}
+	if len(store.written) != 6 {
+		t.Fatalf("失败的 bucket 也应写入 errored 行且不阻塞后续 bucket, 实际写入 %d", len(store.written))
+	}
+	checkpoint, _, err := store.GetBackfillCheckpoint(context.Background(), backfillJobKey(pair.ID, opts.From, opts.To, 5*time.Minute))
+	if err != nil || checkpoint.Failed != 1 || !checkpoint.Watermark.Equal(opts.To) {
+		t.Fatalf("checkpoint 应记录 1 个失败并推进到终点: %+v err=%v", checkpoint, err)
+	}
+
+	// 续跑时失败的 bucket 仍会从存储中汇报出来。
+	err = runBackfill(t, a, pair, &historicalOfficial{}, store, opts)
+	if err == nil || !strings.Contains(err.Error(), "1 个 bucket 回填失败") {
+		t.Fatalf("续跑时应汇报之前失败的 bucket, 实际 %v", err)
+	}
+}
@@ -4,14 +4,17 @@ import (
 	"context"
 	"encoding/csv"
 	"errors"
+	"fmt"
 	"math"
 	"os"
 	"path/filepath"
+	"strconv"
 	"time"
 
 	"github.com/shopspring/decimal"
 	chart "github.com/wcharczuk/go-chart/v2"
 
+	"price-diff-alerts/internal/config"
 	"price-diff-alerts/internal/storage"
 )
 
@@ -22,6 +25,10 @@ func (a *App) Export(ctx context.Context, opts ExportOptions) error {
 	}
 
 	opts.MaxPoints = a.Config.ResolveMaxPoints(opts.MaxPoints)
+	pair, err := a.resolvePair(opts.Pair)
+	if err != nil {
+		return err
+	}
 
 	store, closeStore, err := a.openStore(ctx)
 	if err != nil {
@@ -48,7 +55,12 @@ func (a *App) Export(ctx context.Context, opts ExportOptions) error {
 		return errors.New("from must be before to")
 	}
 
-	samples, err := store.ListSamplesBetween(ctx, from, to)
+	resolution, step := chooseResolution(from, to, time.Now().UTC(), a.Config.Scheduler.Interval, opts.MaxPoints, a.Config.Retention)
+	if resolution != resolutionRaw {
+		return a.exportRollups(ctx, store, pair, resolution, step, from, to, opts)
+	}
+
+	samples, err := store.ListSamplesBetween(ctx, pair.ID, from, to)
 	if err != nil {
 		return err
 	}
@@ -57,8 +69,16 @@ func (a *App) Export(ctx context.Context, opts ExportOptions) error {
 		return nil
 	}
 
-	downsampled := downsampleSamples(samples, opts.MaxPoints)
-	a.Logger.Info().Int("total", len(samples)).Int("exported", len(downsampled)).Msg("exporting samples")
+	interval := a.Config.Scheduler.Interval
+	samples = fillMissingBuckets(samples, alignForward(from, interval), to, interval)
+	missing, errored := countUnhealthy(samples)
+
+	downsampled := downsample(samples, opts.MaxPoints)
+	a.Logger.Info().Int("total", len(samples)).
+		Int("missing", missing).
+		Int("errored", errored).
+		Int("exported", len(downsampled)).
+		Msg("exporting samples")
 
 	if opts.CSVPath != "" {
 		if err := writeSamplesCSV(opts.CSVPath, downsampled); err != nil {
@@ -67,7 +87,39 @@ func (a *App) Export(ctx context.Context, opts ExportOptions) error {
 	}
 
 	if opts.PNGPath != "" {
-		if err := writeSamplesPNG(opts.PNGPath, downsampled); err != nil {
+		if err := writeSamplesPNG(opts.PNGPath, pair, downsampled); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// exportRollups writes hourly or daily aggregates; the chart plots averages.
+func (a *App) exportRollups(ctx context.Context, store storage.RollupStore, pair config.PairConfig, resolution string, step time.Duration, from, to time.Time, opts ExportOptions) error {
+	rollups, err := store.ListRollupsBetween(ctx, pair.ID, resolution, from.Truncate(step), to)
+	if err != nil {
+		return err
+	}
+	if len(rollups) == 0 {
+		a.Logger.Info().Str("resolution", resolution).Msg("no rollups found for export window")
+		return nil
+	}
+
+	downsampled := downsample(rollups, opts.MaxPoints)
+	a.Logger.Info().Str("resolution", resolution).
+		Int("total", len(rollups)).
+		Int("exported", len(downsampled)).
+		Msg("exporting rollups")
+
+	if opts.CSVPath != "" {
+		if err := writeRollupsCSV(opts.CSVPath, downsampled); err != nil {
+			return err
+		}
+	}
+
+	if opts.PNGPath != "" {
+		if err := writeSamplesPNG(opts.PNGPath, pair, rollupAverages(downsampled)); err != nil {
 			return err
 		}
 	}
@@ -75,19 +127,111 @@ func (a *App) Export(ctx context.Context, opts ExportOptions) error {
 	return nil
 }
 
-func downsampleSamples(samples []storage.RateSample, max int) []storage.RateSample {
-	if max <= 0 || len(samples) <= max {
+// resolutionRaw selects rate_samples in chooseResolution.
+const resolutionRaw = "raw"
+
+// chooseResolution picks the finest resolution that still holds data for
+// from and needs at most maxPoints buckets, falling back to the coarsest
+// resolution retained for from. Without retention everything stays raw.
+func chooseResolution(from, to, now time.Time, interval time.Duration, maxPoints int, retention config.RetentionConfig) (string, time.Duration) {
+	if !retention.Enabled {
+		return resolutionRaw, interval
+	}
+
+	candidates := []struct {
+		name string
+		step time.Duration
+		keep time.Duration
+	}{
+		{resolutionRaw, interval, retention.Raw},
+		{storage.RollupHourly, time.Hour, retention.Hourly},
+		{storage.RollupDaily, 24 * time.Hour, retention.Daily},
+	}
+
+	// Candidates run finest to coarsest, so the last retained one is the fallback.
+	fallback := candidates[len(candidates)-1]
+	for _, c := range candidates {
+		if c.keep > 0 && from.Before(now.Add(-c.keep)) {
+			continue
+		}
+		fallback = c
+		if c.step > 0 && int(to.Sub(from)/c.step) <= maxPoints {
+			break
+		}
+	}
+	return fallback.name, fallback.step
+}
+
+// rollupAverages turns aggregates into chartable samples carrying the averages.
+func rollupAverages(rollups []storage.SampleRollup) []storage.RateSample {
+	samples := make([]storage.RateSample, 0, len(rollups))
+	for _, r := range rollups {
+		status := storage.SampleStatusComplete
+		switch {
+		case r.Complete > 0:
+		case r.Official.Count > 0 || r.Market.Count > 0:
+			status = storage.SampleStatusPartial
+		default:
+			status = storage.SampleStatusErrored
+		}
+		samples = append(samples, storage.RateSample{
+			Bucket:       r.Bucket,
+			OfficialRate: r.Official.Avg,
+			MarketRate:   r.Market.Avg,
+			DeviationPct: r.Deviation.Avg,
+			Status:       status,
+		})
+	}
+	return samples
+}
+
+// fillMissingBuckets inserts placeholder rows with status "missing" for every
+// bucket in [from, to) that has no stored sample. Input must be sorted ascending.
+func fillMissingBuckets(samples []storage.RateSample, from, to time.Time, interval time.Duration) []storage.RateSample {
+	if interval <= 0 || !from.Before(to) {
 		return samples
 	}
 
-	result := make([]storage.RateSample, 0, max)
-	step := float64(len(samples)-1) / float64(max-1)
+	result := make([]storage.RateSample, 0, len(samples))
+	idx := 0
+	for bucket := from; bucket.Before(to); bucket = bucket.Add(interval) {
+		for idx < len(samples) && samples[idx].Bucket.Before(bucket) {
+			result = append(result, samples[idx])
+			idx++
+		}
+		if idx < len(samples) && samples[idx].Bucket.Equal(bucket) {
+			continue
+		}
+		result = append(result, storage.RateSample{Bucket: bucket, Status: storage.SampleStatusMissing})
+	}
+	return append(result, samples[idx:]...)
+}
+
+func countUnhealthy(samples []storage.RateSample) (missing, errored int) {
+	for _, sample := range samples {
+		switch sample.Status {
+		case storage.SampleStatusMissing:
+			missing++
+		case storage.SampleStatusErrored:
+			errored++
+		}
+	}
+	return missing, errored
+}
+
+func downsample[T any](items []T, max int) []T {
+	if max <= 0 || len(items) <= max {
+		return items
+	}
+
+	result := make([]T, 0, max)
+	step := float64(len(items)-1) / float64(max-1)
 	for i := 0; i < max; i++ {
 		idx := int(math.Round(step * float64(i)))
-		if idx >= len(samples) {
-			idx = len(samples) - 1
+		if idx >= len(items) {
+			idx = len(items) - 1
 		}
-		result = append(result, samples[idx])
+		result = append(result, items[idx])
 	}
 	return result
 }
@@ -106,7 +250,7 @@ func writeSamplesCSV(path string, samples []storage.RateSample) error {
 	writer := csv.NewWriter(file)
 	defer writer.Flush()
 
-	header := []string{"bucket_ts", "official_susde_per_usde", "market_susde_per_usde", "deviation_pct", "notional_usde", "cow_quality", "status", "error"}
+	header := []string{"bucket_ts", "official_susde_per_usde", "market_susde_per_usde", "deviation_pct", "notional_usde", "cow_quality", "status", "error_phase", "error"}
 	if err := writer.Write(header); err != nil {
 		return err
 	}
@@ -116,14 +260,23 @@ func writeSamplesCSV(path string, samples []storage.RateSample) error {
 		if sample.Error != nil {
 			errMsg = *sample.Error
 		}
+		errPhase := ""
+		if sample.ErrorPhase != nil {
+			errPhase = *sample.ErrorPhase
+		}
+		notional := ""
+		if sample.Status != storage.SampleStatusMissing {
+			notional = sample.Notional.String()
+		}
 		record := []string{
 			sample.Bucket.Format(time.RFC3339),
-			sample.OfficialRate.String(),
-			sample.MarketRate.String(),
-			sample.DeviationPct.String(),
-			sample.NotionalUSDE.String(),
+			nullDecimalString(sample.OfficialRate),
+			nullDecimalString(sample.MarketRate),
+			nullDecimalString(sample.DeviationPct),
+			notional,
 			sample.CowQuality,
 			sample.Status,
+			errPhase,
 			errMsg,
 		}
 		if err := writer.Write(record); err != nil {
@@ -134,21 +287,65 @@ func writeSamplesCSV(path string, samples []storage.RateSample) error {
 	return writer.Error()
 }
 
-func writeSamplesPNG(path string, samples []storage.RateSample) error {
+func writeRollupsCSV(path string, rollups []storage.SampleRollup) error {
+	if err := ensureDir(path); err != nil {
+		return err
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	defer writer.Flush()
+
+	header := []string{"bucket_ts", "resolution", "samples", "complete"}
+	for _, series := range []string{"official", "market", "deviation"} {
+		header = append(header, series+"_min", series+"_max", series+"_avg", series+"_last")
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	for _, r := range rollups {
+		record := []string{
+			r.Bucket.Format(time.RFC3339),
+			r.Resolution,
+			strconv.Itoa(r.Samples),
+			strconv.Itoa(r.Complete),
+		}
+		for _, stats := range []storage.RollupStats{r.Official, r.Market, r.Deviation} {
+			record = append(record,
+				nullDecimalString(stats.Min),
+				nullDecimalString(stats.Max),
+				nullDecimalString(stats.Avg),
+				nullDecimalString(stats.Last),
+			)
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	return writer.Error()
+}
+
+func writeSamplesPNG(path string, pair config.PairConfig, samples []storage.RateSample) error {
 	if err := ensureDir(path); err != nil {
 		return err
 	}
 
-	x := make([]time.Time, len(samples))
-	official := make([]float64, len(samples))
-	market := make([]float64, len(samples))
-	deviation := make([]float64, len(samples))
+	official := chart.TimeSeries{Name: "Official"}
+	market := chart.TimeSeries{Name: "Market"}
+	deviation := chart.TimeSeries{Name: "Deviation %", YAxis: chart.YAxisSecondary}
 
-	for i, sample := range samples {
-		x[i] = sample.Bucket
-		official[i] = sample.OfficialRate.InexactFloat64()
-		market[i] = sample.MarketRate.InexactFloat64()
-		deviation[i] = sample.DeviationPct.InexactFloat64()
+	// Missing and failed legs are left out of their series instead of plotted as zero.
+	for _, sample := range samples {
+		appendPoint(&official, sample.Bucket, sample.OfficialRate)
+		appendPoint(&market, sample.Bucket, sample.MarketRate)
+		appendPoint(&deviation, sample.Bucket, sample.DeviationPct)
 	}
 
 	rateFormatter := func(v interface{}) string {
@@ -161,31 +358,14 @@ func writeSamplesPNG(path string, samples []storage.RateSample) error {
 			ValueFormatter: chart.TimeValueFormatter,
 		},
 		YAxis: chart.YAxis{
-			Name:           "Rate (sUSDe/USDe)",
+			Name:           fmt.Sprintf("Rate (%s/%s)", pair.VaultSymbol, pair.UnderlyingSymbol),
 			ValueFormatter: rateFormatter,
 		},
 		YAxisSecondary: chart.YAxis{
 			Name:           "Deviation (%)",
 			ValueFormatter: rateFormatter,
 		},
-		Series: []chart.Series{
-			chart.TimeSeries{
-				Name:    "Official",
-				XValues: x,
-				YValues: official,
-			},
-			chart.TimeSeries{
-				Name:    "Market",
-				XValues: x,
-				YValues: market,
-			},
-			chart.TimeSeries{
-				Name:    "Deviation %",
-				XValues: x,
-				YValues: deviation,
-				YAxis:   chart.YAxisSecondary,
-			},
-		},
+		Series: []chart.Series{official, market, deviation},
 	}
 	graph.Elements = []chart.Renderable{chart.Legend(&graph)}
 
@@ -198,6 +378,14 @@ func writeSamplesPNG(path string, samples []storage.RateSample) error {
 	return graph.Render(chart.PNG, file)
 }
 
+func appendPoint(series *chart.TimeSeries, ts time.Time, value decimal.NullDecimal) {
+	if !value.Valid {
+		return
+	}
+	series.XValues = append(series.XValues, ts)
+	series.YValues = append(series.YValues, value.Decimal.InexactFloat64())
+}
+
 func ensureDir(path string) error {
 	dir := filepath.Dir(path)
 	if dir == "." || dir == "" {
@@ -206,6 +394,16 @@ func ensureDir(path string) error {
 	return os.MkdirAll(dir, 0o755)
 }
 
-func formatDecimal(d decimal.Decimal, places int32) string {
-	return d.StringFixed(places)
+func formatDecimal(d decimal.NullDecimal, places int32) string {
+	if !d.Valid {
+		return "-"
+	}
+	return d.Decimal.StringFixed(places)
+}
+
+func nullDecimalString(d decimal.NullDecimal) string {
+	if !d.Valid {
+		return ""
+	}
+	return d.Decimal.String()
 }
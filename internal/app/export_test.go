@@ -0,0 +1,40 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"price-diff-alerts/internal/config"
+	"price-diff-alerts/internal/storage"
+)
+
+func TestChooseResolution(t *testing.T) {
+	now := time.Date(2025, 9, 22, 10, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	retention := config.RetentionConfig{Enabled: true, Raw: 30 * day, Hourly: 365 * day}
+
+	cases := []struct {
+		name      string
+		from, to  time.Time
+		maxPoints int
+		retention config.RetentionConfig
+		want      string
+		wantStep  time.Duration
+	}{
+		{"未启用保留策略时始终用原始样本", now.Add(-400 * day), now, 10, config.RetentionConfig{}, resolutionRaw, 5 * time.Minute},
+		{"点数足够时用原始样本", now.Add(-day), now, 1000, retention, resolutionRaw, 5 * time.Minute},
+		{"原始样本点数过多时用小时汇总", now.Add(-20 * day), now, 1000, retention, storage.RollupHourly, time.Hour},
+		{"小时汇总点数仍过多时用日汇总", now.Add(-300 * day), now, 1000, retention, storage.RollupDaily, day},
+		{"起点早于原始样本保留期时用小时汇总", now.Add(-40 * day), now.Add(-39 * day), 1000, retention, storage.RollupHourly, time.Hour},
+		{"起点早于小时汇总保留期时用日汇总", now.Add(-400 * day), now.Add(-399 * day), 1000, retention, storage.RollupDaily, day},
+		{"所有分辨率点数都过多时退回最粗的日汇总", now.Add(-20 * day), now, 5, retention, storage.RollupDaily, day},
+		{"起点早于所有保留期时退回日汇总", now.Add(-800 * day), now, 1000,
+			config.RetentionConfig{Enabled: true, Raw: 30 * day, Hourly: 365 * day, Daily: 730 * day}, storage.RollupDaily, day},
+	}
+	for _, tc := range cases {
+		got, step := chooseResolution(tc.from, tc.to, now, 5*time.Minute, tc.maxPoints, tc.retention)
+		if got != tc.want || step != tc.wantStep {
+			t.Fatalf("%s: 期望 %s/%s, 实际 %s/%s", tc.name, tc.want, tc.wantStep, got, step)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"price-diff-alerts/internal/service"
+)
+
+// healthHandler serves the service health report as JSON, answering 503 when
+// the probed condition fails so supervisors can act on the status alone.
+func healthHandler(svc *service.Service, readiness bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report := svc.Health(r.Context(), time.Now().UTC())
+		ok := report.Live
+		if readiness {
+			ok = report.Ready
+		}
+		status := http.StatusOK
+		if !ok {
+			status = http.StatusServiceUnavailable
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(report)
+	})
+}
+
+// Healthcheck probes a running watcher over HTTP and fails unless it answers
+// 200. It needs no database or RPC access, so Docker HEALTHCHECK and cron
+// watchdogs can call the binary directly.
+func (a *App) Healthcheck(ctx context.Context, opts HealthcheckOptions) error {
+	url := opts.URL
+	if url == "" {
+		base, err := localURL(a.Config.HTTP.Listen)
+		if err != nil {
+			return err
+		}
+		path := "/healthz"
+		if opts.Ready {
+			path = "/readyz"
+		}
+		url = base + path
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("probe %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	os.Stdout.Write(body)
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("probe %s: %s", url, resp.Status)
+	}
+	return nil
+}
+
+// localURL turns http.listen into a loopback URL; a wildcard host such as
+// ":9102" or "0.0.0.0:9102" is probed on 127.0.0.1.
+func localURL(listen string) (string, error) {
+	if listen == "" {
+		return "", errors.New("http.listen not configured; pass --url")
+	}
+	host, port, err := net.SplitHostPort(listen)
+	if err != nil {
+		return "", fmt.Errorf("parse http.listen %q: %w", listen, err)
+	}
+	if ip := net.ParseIP(host); host == "" || (ip != nil && ip.IsUnspecified()) {
+		host = "127.0.0.1"
+	}
+	return "http://" + net.JoinHostPort(host, port), nil
+}
@@ -0,0 +1,41 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+)
+
+// listenHTTP binds http.listen before the service starts, so a taken port
+// fails run instead of silently running without metrics. The server stops
+// when ctx is cancelled.
+func (a *App) listenHTTP(ctx context.Context, handler http.Handler) error {
+	listener, err := net.Listen("tcp", a.Config.HTTP.Listen)
+	if err != nil {
+		return fmt.Errorf("listen on http.listen %s: %w", a.Config.HTTP.Listen, err)
+	}
+
+	server := &http.Server{
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), a.Config.HTTP.ShutdownTimeout)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			a.Logger.Warn().Err(err).Msg("http server shutdown")
+		}
+	}()
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			a.Logger.Error().Err(err).Msg("http server stopped")
+		}
+	}()
+
+	a.Logger.Info().Str("addr", listener.Addr().String()).Msg("http server listening")
+	return nil
+}
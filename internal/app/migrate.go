@@ -0,0 +1,127 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"price-diff-alerts/internal/storage"
+)
+
+// Migrate actions.
+const (
+	MigrateUp       = "up"
+	MigrateDown     = "down"
+	MigrateGoto     = "goto"
+	MigrateStatus   = "status"
+	MigrateBaseline = "baseline"
+)
+
+// Migrate applies, rolls back or reports schema migrations.
+func (a *App) Migrate(ctx context.Context, opts MigrateOptions) error {
+	store, closeStore, err := a.openStore(ctx)
+	if err != nil {
+		return err
+	}
+	if store == nil {
+		return errors.New("database not configured; cannot migrate")
+	}
+	if closeStore != nil {
+		defer closeStore()
+	}
+
+	pg, ok := store.(*storage.Store)
+	if !ok {
+		return errors.New("migrate only applies to PostgreSQL; the SQLite schema is applied automatically on open")
+	}
+	migrator, err := a.newMigrator(pg)
+	if err != nil {
+		return err
+	}
+
+	onStep := func(m storage.Migration, direction string) {
+		a.Logger.Info().Int64("version", m.Version).Str("name", m.Name).Str("direction", direction).Msg("migration applied")
+		fmt.Fprintf(os.Stdout, "%s %06d_%s\n", direction, m.Version, m.Name)
+	}
+
+	switch opts.Action {
+	case MigrateUp:
+		err = migrator.Up(ctx, onStep)
+	case MigrateDown:
+		err = migrator.Down(ctx, opts.Steps, onStep)
+	case MigrateGoto:
+		err = migrator.Goto(ctx, opts.Version, onStep)
+	case MigrateBaseline:
+		err = migrator.Baseline(ctx, opts.Version)
+	case MigrateStatus:
+	default:
+		return fmt.Errorf("unknown migrate action %q", opts.Action)
+	}
+	if err != nil {
+		return err
+	}
+	if opts.Action == MigrateStatus || opts.Action == MigrateBaseline {
+		return printMigrationStatus(ctx, migrator)
+	}
+	return nil
+}
+
+func (a *App) newMigrator(store *storage.Store) (*storage.Migrator, error) {
+	source, err := storage.MigrationSource(a.Config.Database.MigrationsPath)
+	if err != nil {
+		return nil, err
+	}
+	migrations, err := storage.LoadMigrations(source)
+	if err != nil {
+		return nil, err
+	}
+	return store.Migrator(migrations), nil
+}
+
+// checkSchema warns about pending migrations, or fails when
+// database.require_current_schema is set. SQLite files bring their schema up
+// to date on open and are never behind.
+func (a *App) checkSchema(ctx context.Context, store storage.Backend) error {
+	pg, ok := store.(*storage.Store)
+	if !ok {
+		return nil
+	}
+	migrator, err := a.newMigrator(pg)
+	if err != nil {
+		return err
+	}
+	pending, err := migrator.Pending(ctx)
+	if err != nil {
+		return fmt.Errorf("check schema version: %w", err)
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+	if a.Config.Database.RequireCurrentSchema {
+		return fmt.Errorf("database schema is behind: %d pending migrations (first %06d_%s); run `usdewatcher migrate up`",
+			len(pending), pending[0].Version, pending[0].Name)
+	}
+	a.Logger.Warn().Int("pending", len(pending)).Int64("first", pending[0].Version).Msg("database schema has pending migrations")
+	return nil
+}
+
+func printMigrationStatus(ctx context.Context, migrator *storage.Migrator) error {
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(writer, "Version\tName\tApplied (UTC)")
+	for _, st := range statuses {
+		applied := "pending"
+		if st.AppliedAt != nil {
+			applied = st.AppliedAt.UTC().Format(time.RFC3339)
+		}
+		fmt.Fprintf(writer, "%06d\t%s\t%s\n", st.Version, st.Name, applied)
+	}
+	return writer.Flush()
+}
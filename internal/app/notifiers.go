@@ -0,0 +1,139 @@
+package app
+
+import (
+	"fmt"
+
+	"price-diff-alerts/internal/alerting"
+	"price-diff-alerts/internal/config"
+)
+
+// newNotifier fans alerts out to every channel listed in alerting.channels.
+// It returns nil when no listed channel is enabled.
+func (a *App) newNotifier() (alerting.Notifier, error) {
+	channels, err := a.notifierChannels()
+	if err != nil {
+		return nil, err
+	}
+	if len(channels) == 0 {
+		return nil, nil
+	}
+	return alerting.NewMultiNotifier(a.Logger, channels...), nil
+}
+
+func (a *App) notifierChannels() ([]alerting.Channel, error) {
+	cfg := a.Config.Alerting
+	channels := make([]alerting.Channel, 0, len(cfg.Channels))
+	seen := make(map[string]bool, len(cfg.Channels))
+
+	for _, name := range cfg.Channels {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		switch name {
+		case config.ChannelTelegram:
+			if !cfg.Telegram.Enabled {
+				a.Logger.Warn().Str("channel", name).Msg("alert channel listed but not enabled; skipping")
+				continue
+			}
+			tg := cfg.Telegram
+			notifier := alerting.NewTelegramNotifier(tg.BotToken, tg.ChatID, tg.APIBase, tg.Timeout, a.Logger)
+			channels = append(channels, newChannel(name, notifier, tg.DeliveryConfig))
+		case config.ChannelWebhook:
+			if !cfg.Webhook.Enabled {
+				a.Logger.Warn().Str("channel", name).Msg("alert channel listed but not enabled; skipping")
+				continue
+			}
+			wh := cfg.Webhook
+			notifier, err := alerting.NewWebhookNotifier(alerting.WebhookOptions{
+				URL:             wh.URL,
+				Template:        wh.Template,
+				Headers:         wh.Headers,
+				Secret:          wh.Secret,
+				SignatureHeader: wh.SignatureHeader,
+				Timeout:         wh.Timeout,
+			}, a.Logger)
+			if err != nil {
+				return nil, fmt.Errorf("alerting.webhook: %w", err)
+			}
+			channels = append(channels, newChannel(name, notifier, wh.DeliveryConfig))
+		case config.ChannelSlack:
+			if !cfg.Slack.Enabled {
+				a.Logger.Warn().Str("channel", name).Msg("alert channel listed but not enabled; skipping")
+				continue
+			}
+			sl := cfg.Slack
+			notifier := alerting.NewSlackNotifier(alerting.SlackOptions{
+				WebhookURL: sl.WebhookURL,
+				Channel:    sl.Channel,
+				Username:   sl.Username,
+				Timeout:    sl.Timeout,
+			}, a.Logger)
+			channels = append(channels, newChannel(name, notifier, sl.DeliveryConfig))
+		case config.ChannelDiscord:
+			if !cfg.Discord.Enabled {
+				a.Logger.Warn().Str("channel", name).Msg("alert channel listed but not enabled; skipping")
+				continue
+			}
+			dc := cfg.Discord
+			notifier := alerting.NewDiscordNotifier(alerting.DiscordOptions{
+				WebhookURL: dc.WebhookURL,
+				Username:   dc.Username,
+				Timeout:    dc.Timeout,
+			}, a.Logger)
+			channels = append(channels, newChannel(name, notifier, dc.DeliveryConfig))
+		case config.ChannelEmail:
+			if !cfg.Email.Enabled {
+				a.Logger.Warn().Str("channel", name).Msg("alert channel listed but not enabled; skipping")
+				continue
+			}
+			if cfg.Email.Mode == config.EmailModeDigest {
+				// Digest mail is sent on its own schedule, see newDigestSender.
+				continue
+			}
+			channels = append(channels, newChannel(name, a.newEmailNotifier(), cfg.Email.DeliveryConfig))
+		}
+	}
+	return channels, nil
+}
+
+// newDigestSender returns the email notifier when email is listed, enabled
+// and in digest mode; otherwise nil.
+func (a *App) newDigestSender() alerting.DigestSender {
+	cfg := a.Config.Alerting
+	if !cfg.Email.Enabled || cfg.Email.Mode != config.EmailModeDigest {
+		return nil
+	}
+	for _, name := range cfg.Channels {
+		if name == config.ChannelEmail {
+			return a.newEmailNotifier()
+		}
+	}
+	return nil
+}
+
+func (a *App) newEmailNotifier() *alerting.EmailNotifier {
+	em := a.Config.Alerting.Email
+	return alerting.NewEmailNotifier(alerting.EmailOptions{
+		Host:          em.Host,
+		Port:          em.Port,
+		Username:      em.Username,
+		Password:      em.Password,
+		From:          em.From,
+		To:            em.To,
+		StartTLS:      em.StartTLS,
+		SubjectPrefix: em.SubjectPrefix,
+		Timeout:       em.Timeout,
+	}, a.Logger)
+}
+
+func newChannel(name string, notifier alerting.Notifier, delivery config.DeliveryConfig) alerting.Channel {
+	return alerting.Channel{
+		Name:     name,
+		Notifier: notifier,
+		Retries:  delivery.Retries,
+		Timeout:  delivery.Timeout,
+		Backoff:  delivery.RetryBackoff,
+	}
+}
@@ -0,0 +1,90 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"price-diff-alerts/internal/storage"
+)
+
+// Outbox actions.
+const (
+	OutboxList   = "list"
+	OutboxReplay = "replay"
+)
+
+// Outbox lists queued notifications or requeues stuck ones for the worker.
+func (a *App) Outbox(ctx context.Context, opts OutboxOptions) error {
+	store, closeStore, err := a.openStore(ctx)
+	if err != nil {
+		return err
+	}
+	if store == nil {
+		return errors.New("database not configured; cannot inspect the outbox")
+	}
+	if closeStore != nil {
+		defer closeStore()
+	}
+
+	switch opts.Action {
+	case OutboxList:
+		msgs, err := store.ListOutbox(ctx, opts.Status, opts.Limit)
+		if err != nil {
+			return err
+		}
+		if len(msgs) == 0 {
+			fmt.Fprintln(os.Stdout, "no outbox messages found")
+			return nil
+		}
+		writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(writer, "ID\tCreated (UTC)\tKind\tStatus\tAttempts\tNext attempt\tChannels\tLast error")
+		for _, msg := range msgs {
+			next := ""
+			if msg.Status != storage.OutboxStatusDispatched {
+				next = msg.NextAttemptAt.UTC().Format(time.RFC3339)
+			}
+			lastErr := ""
+			if msg.LastError != nil {
+				lastErr = sanitizeInline(*msg.LastError)
+			}
+			fmt.Fprintf(writer, "%d\t%s\t%s\t%s\t%d\t%s\t%s\t%s\n",
+				msg.ID,
+				msg.CreatedAt.UTC().Format(time.RFC3339),
+				msg.Kind,
+				msg.Status,
+				msg.Attempts,
+				next,
+				strings.Join(msg.Channels, ","),
+				lastErr,
+			)
+		}
+		return writer.Flush()
+	case OutboxReplay:
+		if opts.AllDead {
+			n, err := store.ReplayDeadOutbox(ctx)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(os.Stdout, "requeued %d dead messages\n", n)
+		}
+		for _, id := range opts.IDs {
+			found, err := store.ReplayOutbox(ctx, id)
+			if err != nil {
+				return err
+			}
+			if !found {
+				return fmt.Errorf("outbox message %d not found or already dispatched", id)
+			}
+			fmt.Fprintf(os.Stdout, "requeued message %d\n", id)
+		}
+		fmt.Fprintln(os.Stdout, "the run command delivers requeued messages on its next outbox poll")
+		return nil
+	default:
+		return fmt.Errorf("unknown outbox action %q", opts.Action)
+	}
+}
@@ -0,0 +1,47 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"price-diff-alerts/internal/service"
+)
+
+// Retention runs one rollup and prune pass, regardless of retention.enabled.
+func (a *App) Retention(ctx context.Context) error {
+	store, closeStore, err := a.openStore(ctx)
+	if err != nil {
+		return err
+	}
+	if store == nil {
+		return errors.New("database not configured; cannot apply retention")
+	}
+	if closeStore != nil {
+		defer closeStore()
+	}
+
+	svc := service.New(a.Config, nil, nil, store, nil, nil, a.Logger)
+	result, err := svc.ApplyRetention(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stdout, "rolled up %d hourly and %d daily buckets before %s\n",
+		result.HourlyRolled, result.DailyRolled, result.RolledUpBefore.Format(time.RFC3339))
+	printPrune(os.Stdout, "raw samples", result.SamplesPruned, result.RawCutoff)
+	printPrune(os.Stdout, "hourly rollups", result.HourlyPruned, result.HourlyCutoff)
+	printPrune(os.Stdout, "daily rollups", result.DailyPruned, result.DailyCutoff)
+	return nil
+}
+
+func printPrune(w io.Writer, what string, pruned int64, cutoff time.Time) {
+	if cutoff.IsZero() {
+		fmt.Fprintf(w, "%s kept forever\n", what)
+		return
+	}
+	fmt.Fprintf(w, "pruned %d %s before %s\n", pruned, what, cutoff.Format(time.RFC3339))
+}
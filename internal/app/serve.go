@@ -0,0 +1,49 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os/signal"
+	"syscall"
+
+	"price-diff-alerts/internal/api"
+)
+
+// Serve exposes the read-only JSON API without sampling, so dashboards can
+// read from a replica or from a host that does not run the watcher.
+func (a *App) Serve(ctx context.Context, opts ServeOptions) error {
+	ctx, cancel := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	if opts.Listen != "" {
+		a.Config.HTTP.Listen = opts.Listen
+	}
+	if a.Config.HTTP.Listen == "" {
+		return errors.New("http.listen not configured; pass --listen")
+	}
+
+	store, closeStore, err := a.openStore(ctx)
+	if err != nil {
+		return err
+	}
+	if store == nil {
+		return errors.New("database not configured; nothing to serve")
+	}
+	if closeStore != nil {
+		defer closeStore()
+	}
+	if err := a.checkSchema(ctx, store); err != nil {
+		return err
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/api/", api.New(store, a.pairIDs(), a.Logger))
+	if err := a.listenHTTP(ctx, mux); err != nil {
+		return err
+	}
+
+	<-ctx.Done()
+	a.Logger.Info().Msg("api server stopped")
+	return nil
+}
@@ -8,10 +8,17 @@ import (
 	"strings"
 	"text/tabwriter"
 	"time"
+
+	"price-diff-alerts/internal/storage"
 )
 
-// Show prints recent samples.
+// Show prints recent samples of one pair.
 func (a *App) Show(ctx context.Context, opts ShowOptions) error {
+	pair, err := a.resolvePair(opts.Pair)
+	if err != nil {
+		return err
+	}
+
 	store, closeStore, err := a.openStore(ctx)
 	if err != nil {
 		return err
@@ -23,7 +30,7 @@ func (a *App) Show(ctx context.Context, opts ShowOptions) error {
 		defer closeStore()
 	}
 
-	samples, err := store.ListRecentSamples(ctx, opts.Limit)
+	samples, err := store.ListRecentSamples(ctx, pair.ID, opts.Limit)
 	if err != nil {
 		return err
 	}
@@ -32,23 +39,31 @@ func (a *App) Show(ctx context.Context, opts ShowOptions) error {
 		return nil
 	}
 
+	samples = fillMissingRecent(samples, a.Config.Scheduler.Interval, opts.Limit)
+
+	fmt.Fprintf(os.Stdout, "%s (%s/%s)\n", pair.Name(), pair.VaultSymbol, pair.UnderlyingSymbol)
 	writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
-	fmt.Fprintln(writer, "Time (UTC)\tOfficial\tMarket\tDeviation%\tQuality\tStatus\tError")
+	fmt.Fprintln(writer, "Time (UTC)\tOfficial\tMarket\tDeviation%\tQuality\tStatus\tPhase\tError")
 
 	for _, sample := range samples {
 		errMsg := ""
 		if sample.Error != nil {
 			errMsg = sanitizeInline(*sample.Error)
 		}
+		phase := ""
+		if sample.ErrorPhase != nil {
+			phase = *sample.ErrorPhase
+		}
 		fmt.Fprintf(
 			writer,
-			"%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			"%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
 			sample.Bucket.UTC().Format(time.RFC3339),
 			formatDecimal(sample.OfficialRate, 3),
 			formatDecimal(sample.MarketRate, 3),
 			formatDecimal(sample.DeviationPct, 3),
 			sample.CowQuality,
 			sample.Status,
+			phase,
 			errMsg,
 		)
 	}
@@ -57,6 +72,31 @@ func (a *App) Show(ctx context.Context, opts ShowOptions) error {
 	return nil
 }
 
+// fillMissingRecent marks gaps between descending samples as missing buckets,
+// keeping at most limit rows.
+func fillMissingRecent(samples []storage.RateSample, interval time.Duration, limit int) []storage.RateSample {
+	if interval <= 0 || len(samples) == 0 {
+		return samples
+	}
+
+	result := make([]storage.RateSample, 0, limit)
+	for i, sample := range samples {
+		if i > 0 {
+			for bucket := samples[i-1].Bucket.Add(-interval); bucket.After(sample.Bucket); bucket = bucket.Add(-interval) {
+				if len(result) >= limit {
+					return result
+				}
+				result = append(result, storage.RateSample{Bucket: bucket, Status: storage.SampleStatusMissing})
+			}
+		}
+		if len(result) >= limit {
+			return result
+		}
+		result = append(result, sample)
+	}
+	return result
+}
+
 func sanitizeInline(v string) string {
 	cleaned := strings.ReplaceAll(v, "\n", " ")
 	cleaned = strings.ReplaceAll(cleaned, "\r", " ")
@@ -8,17 +8,27 @@ import (
 
 	"github.com/shopspring/decimal"
 
+	"price-diff-alerts/internal/config"
 	"price-diff-alerts/internal/fetcher"
 	"price-diff-alerts/internal/service"
+	"price-diff-alerts/internal/storage/memory"
 )
 
-// SimulateAlert 通过给定的官方/市场价格模拟一次告警流程。
-func (a *App) SimulateAlert(ctx context.Context, official, market decimal.Decimal) error {
+// SimulateAlert 通过给定的官方/市场价格为指定交易对模拟一次告警流程，pairID 为空时取第一个交易对。
+func (a *App) SimulateAlert(ctx context.Context, pairID string, official, market decimal.Decimal) error {
 	if !a.Config.Alerting.Enabled {
 		return errors.New("alerting 未启用")
 	}
 
-	notifier := a.newNotifier()
+	pair, err := a.resolvePair(pairID)
+	if err != nil {
+		return err
+	}
+
+	notifier, err := a.newNotifier()
+	if err != nil {
+		return err
+	}
 	if notifier == nil {
 		return errors.New("未配置任何告警通道")
 	}
@@ -26,7 +36,13 @@ func (a *App) SimulateAlert(ctx context.Context, official, market decimal.Decima
 	off := &staticOfficialFetcher{rate: official}
 	mar := &staticMarketFetcher{rate: market}
 
-	svc := service.New(a.Config, nil, off, mar, nil, nil, notifier, a.Logger)
+	// 模拟只有一个 bucket，确认条件需要一次突破即可触发。
+	cfg := *a.Config
+	cfg.Alerting.Confirmation = config.ConfirmationConfig{Breaches: 1}
+
+	// 样本与告警写入内存存储，模拟流程与线上一致地经过状态机持久化，但不触及数据库。
+	store := memory.New()
+	svc := service.New(&cfg, nil, []service.Pair{{Config: pair, Official: off, Market: mar}}, store, store, notifier, a.Logger)
 
 	bucket := time.Now().UTC().Truncate(a.Config.Scheduler.Interval)
 	return svc.ProcessBucket(ctx, bucket)
@@ -36,8 +52,12 @@ type staticOfficialFetcher struct {
 	rate decimal.Decimal
 }
 
-func (s *staticOfficialFetcher) FetchOfficial(ctx context.Context) (decimal.Decimal, uint64, error) {
-	return s.rate, 0, nil
+func (s *staticOfficialFetcher) FetchOfficial(ctx context.Context) (fetcher.OfficialQuote, error) {
+	return fetcher.OfficialQuote{Rate: s.rate, Method: "simulated"}, nil
+}
+
+func (s *staticOfficialFetcher) FetchOfficialAt(ctx context.Context, blockNumber uint64) (fetcher.OfficialQuote, error) {
+	return fetcher.OfficialQuote{Rate: s.rate, BlockNumber: blockNumber, Method: "simulated"}, nil
 }
 
 type staticMarketFetcher struct {
@@ -10,10 +10,12 @@ import (
 )
 
 var (
+	backfillPair    string
 	backfillFrom    string
 	backfillTo      string
 	backfillDryRun  bool
 	backfillWorkers int
+	backfillRestart bool
 )
 
 var backfillCmd = &cobra.Command{
@@ -39,10 +41,12 @@ var backfillCmd = &cobra.Command{
 		}
 
 		opts := app.BackfillOptions{
+			Pair:    backfillPair,
 			From:    from,
 			To:      to,
 			DryRun:  backfillDryRun,
 			Workers: backfillWorkers,
+			Restart: backfillRestart,
 		}
 
 		return getApp().Backfill(cmd.Context(), opts)
@@ -50,8 +54,10 @@ var backfillCmd = &cobra.Command{
 }
 
 func init() {
+	backfillCmd.Flags().StringVar(&backfillPair, "pair", "", "Pair id to backfill (defaults to every configured pair)")
 	backfillCmd.Flags().StringVar(&backfillFrom, "from", "", "Start timestamp (RFC3339, inclusive)")
 	backfillCmd.Flags().StringVar(&backfillTo, "to", "", "End timestamp (RFC3339, exclusive)")
 	backfillCmd.Flags().BoolVar(&backfillDryRun, "dry-run", false, "Run without writing to storage")
-	backfillCmd.Flags().IntVar(&backfillWorkers, "workers", 2, "Number of concurrent workers")
+	backfillCmd.Flags().IntVar(&backfillWorkers, "workers", 0, "Number of concurrent workers (defaults to config)")
+	backfillCmd.Flags().BoolVar(&backfillRestart, "restart", false, "Ignore the saved checkpoint and start from --from again")
 }
@@ -10,6 +10,7 @@ import (
 )
 
 var (
+	exportPair      string
 	exportFrom      string
 	exportTo        string
 	exportPNGPath   string
@@ -22,6 +23,7 @@ var exportCmd = &cobra.Command{
 	Short: "Export sampled rates as CSV and/or PNG chart",
 	RunE: func(cmd *cobra.Command, args []string) error {
 		opts := app.ExportOptions{
+			Pair:      exportPair,
 			PNGPath:   exportPNGPath,
 			CSVPath:   exportCSVPath,
 			MaxPoints: exportMaxPoints,
@@ -48,6 +50,7 @@ var exportCmd = &cobra.Command{
 }
 
 func init() {
+	exportCmd.Flags().StringVar(&exportPair, "pair", "", "Pair id to export (defaults to the first configured pair)")
 	exportCmd.Flags().StringVar(&exportFrom, "from", "", "Start timestamp (RFC3339, inclusive)")
 	exportCmd.Flags().StringVar(&exportTo, "to", "", "End timestamp (RFC3339, exclusive)")
 	exportCmd.Flags().StringVar(&exportPNGPath, "png", "", "Path to write PNG chart")
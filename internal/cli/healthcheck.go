@@ -0,0 +1,38 @@
+package cli
+
+import (
+	"errors"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"price-diff-alerts/internal/app"
+)
+
+var (
+	healthcheckURL     string
+	healthcheckReady   bool
+	healthcheckTimeout time.Duration
+)
+
+var healthcheckCmd = &cobra.Command{
+	Use:   "healthcheck",
+	Short: "Probe a running watcher's /healthz (or /readyz) and exit non-zero when unhealthy",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if healthcheckTimeout <= 0 {
+			return errors.New("--timeout must be greater than zero")
+		}
+		return getApp().Healthcheck(cmd.Context(), app.HealthcheckOptions{
+			URL:     healthcheckURL,
+			Ready:   healthcheckReady,
+			Timeout: healthcheckTimeout,
+		})
+	},
+}
+
+func init() {
+	healthcheckCmd.Flags().StringVar(&healthcheckURL, "url", "", "Endpoint to probe (default: derived from http.listen)")
+	healthcheckCmd.Flags().BoolVar(&healthcheckReady, "ready", false, "Probe /readyz instead of /healthz")
+	healthcheckCmd.Flags().DurationVar(&healthcheckTimeout, "timeout", 5*time.Second, "Request timeout")
+}
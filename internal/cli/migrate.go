@@ -0,0 +1,94 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/spf13/cobra"
+
+	"price-diff-alerts/internal/app"
+)
+
+var (
+	migrateDownSteps int
+)
+
+var migrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Manage the database schema",
+}
+
+var migrateUpCmd = &cobra.Command{
+	Use:   "up",
+	Short: "Apply all pending migrations",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return getApp().Migrate(cmd.Context(), app.MigrateOptions{Action: app.MigrateUp})
+	},
+}
+
+var migrateDownCmd = &cobra.Command{
+	Use:   "down",
+	Short: "Roll back the most recent migrations",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if migrateDownSteps <= 0 {
+			return fmt.Errorf("--steps must be greater than zero")
+		}
+		return getApp().Migrate(cmd.Context(), app.MigrateOptions{Action: app.MigrateDown, Steps: migrateDownSteps})
+	},
+}
+
+var migrateGotoCmd = &cobra.Command{
+	Use:   "goto VERSION",
+	Short: "Migrate up or down to the given version (0 rolls back everything)",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		version, err := parseMigrationVersion(args[0])
+		if err != nil {
+			return err
+		}
+		return getApp().Migrate(cmd.Context(), app.MigrateOptions{Action: app.MigrateGoto, Version: version})
+	},
+}
+
+var migrateStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "List migrations and whether they are applied",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return getApp().Migrate(cmd.Context(), app.MigrateOptions{Action: app.MigrateStatus})
+	},
+}
+
+var migrateBaselineCmd = &cobra.Command{
+	Use:   "baseline VERSION",
+	Short: "Mark migrations up to VERSION as applied without running them",
+	Long:  "Records migrations up to VERSION in schema_migrations without executing them, for databases initialised with scripts/init_db.sh.",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		version, err := parseMigrationVersion(args[0])
+		if err != nil {
+			return err
+		}
+		return getApp().Migrate(cmd.Context(), app.MigrateOptions{Action: app.MigrateBaseline, Version: version})
+	},
+}
+
+func parseMigrationVersion(raw string) (int64, error) {
+	version, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || version < 0 {
+		return 0, fmt.Errorf("invalid migration version %q", raw)
+	}
+	return version, nil
+}
+
+func init() {
+	migrateDownCmd.Flags().IntVar(&migrateDownSteps, "steps", 1, "Number of migrations to roll back")
+
+	migrateCmd.AddCommand(migrateUpCmd)
+	migrateCmd.AddCommand(migrateDownCmd)
+	migrateCmd.AddCommand(migrateGotoCmd)
+	migrateCmd.AddCommand(migrateStatusCmd)
+	migrateCmd.AddCommand(migrateBaselineCmd)
+}
@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/spf13/cobra"
+
+	"price-diff-alerts/internal/app"
+)
+
+var (
+	outboxListStatus string
+	outboxListLimit  int
+	outboxReplayDead bool
+)
+
+var outboxCmd = &cobra.Command{
+	Use:   "outbox",
+	Short: "Inspect and replay queued alert notifications",
+}
+
+var outboxListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List outbox messages, newest first",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if outboxListLimit <= 0 {
+			return fmt.Errorf("--limit must be greater than zero")
+		}
+		status := outboxListStatus
+		switch status {
+		case "pending", "dispatched", "dead":
+		case "all":
+			status = ""
+		default:
+			return fmt.Errorf("--status must be pending, dispatched, dead or all")
+		}
+		return getApp().Outbox(cmd.Context(), app.OutboxOptions{Action: app.OutboxList, Status: status, Limit: outboxListLimit})
+	},
+}
+
+var outboxReplayCmd = &cobra.Command{
+	Use:   "replay [ID...]",
+	Short: "Requeue dead or stuck messages with a fresh retry budget",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 && !outboxReplayDead {
+			return fmt.Errorf("pass message IDs or --all-dead")
+		}
+		ids := make([]int64, 0, len(args))
+		for _, arg := range args {
+			id, err := strconv.ParseInt(arg, 10, 64)
+			if err != nil || id <= 0 {
+				return fmt.Errorf("invalid outbox message id %q", arg)
+			}
+			ids = append(ids, id)
+		}
+		return getApp().Outbox(cmd.Context(), app.OutboxOptions{Action: app.OutboxReplay, IDs: ids, AllDead: outboxReplayDead})
+	},
+}
+
+func init() {
+	outboxListCmd.Flags().StringVar(&outboxListStatus, "status", "all", "Filter by status: pending, dispatched, dead or all")
+	outboxListCmd.Flags().IntVar(&outboxListLimit, "limit", 50, "Number of messages to display")
+	outboxReplayCmd.Flags().BoolVar(&outboxReplayDead, "all-dead", false, "Requeue every dead message")
+
+	outboxCmd.AddCommand(outboxListCmd)
+	outboxCmd.AddCommand(outboxReplayCmd)
+}
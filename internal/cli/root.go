@@ -19,7 +19,7 @@ var (
 
 var rootCmd = &cobra.Command{
 	Use:   "usdewatcher",
-	Short: "Monitor USDe to sUSDe rate deviations",
+	Short: "Monitor ERC-4626 vault rate deviations against CoW quotes",
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 		if appHandle != nil {
 			return nil
@@ -58,6 +58,11 @@ func init() {
 	rootCmd.AddCommand(backfillCmd)
 	rootCmd.AddCommand(versionCmd)
 	rootCmd.AddCommand(simulateCmd)
+	rootCmd.AddCommand(migrateCmd)
+	rootCmd.AddCommand(retentionCmd)
+	rootCmd.AddCommand(outboxCmd)
+	rootCmd.AddCommand(healthcheckCmd)
+	rootCmd.AddCommand(serveCmd)
 }
 
 func getApp() *app.App {
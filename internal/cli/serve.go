@@ -0,0 +1,22 @@
+package cli
+
+import (
+	"github.com/spf13/cobra"
+
+	"price-diff-alerts/internal/app"
+)
+
+var serveListen string
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve the read-only JSON API over stored samples and alerts",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return getApp().Serve(cmd.Context(), app.ServeOptions{Listen: serveListen})
+	},
+}
+
+func init() {
+	serveCmd.Flags().StringVar(&serveListen, "listen", "", "Listen address (defaults to http.listen)")
+}
@@ -9,6 +9,7 @@ import (
 )
 
 var (
+	showPair  string
 	showLimit int
 )
 
@@ -21,6 +22,7 @@ var showCmd = &cobra.Command{
 		}
 
 		opts := app.ShowOptions{
+			Pair:  showPair,
 			Limit: showLimit,
 		}
 
@@ -29,5 +31,6 @@ var showCmd = &cobra.Command{
 }
 
 func init() {
+	showCmd.Flags().StringVar(&showPair, "pair", "", "Pair id to display (defaults to the first configured pair)")
 	showCmd.Flags().IntVar(&showLimit, "limit", 20, "Number of samples to display")
 }
@@ -8,6 +8,7 @@ import (
 )
 
 var (
+	simulatePair     string
 	simulateOfficial float64
 	simulateMarket   float64
 )
@@ -22,11 +23,12 @@ var simulateCmd = &cobra.Command{
 
 		official := decimal.NewFromFloat(simulateOfficial)
 		market := decimal.NewFromFloat(simulateMarket)
-		return getApp().SimulateAlert(cmd.Context(), official, market)
+		return getApp().SimulateAlert(cmd.Context(), simulatePair, official, market)
 	},
 }
 
 func init() {
-	simulateCmd.Flags().Float64Var(&simulateOfficial, "official", 0, "官方口径 sUSDe/USDe")
-	simulateCmd.Flags().Float64Var(&simulateMarket, "market", 0, "市场口径 sUSDe/USDe")
+	simulateCmd.Flags().StringVar(&simulatePair, "pair", "", "交易对 id（默认第一个交易对）")
+	simulateCmd.Flags().Float64Var(&simulateOfficial, "official", 0, "官方口径，每个底层代币可换得的金库份额")
+	simulateCmd.Flags().Float64Var(&simulateMarket, "market", 0, "市场口径，每个底层代币可换得的金库份额")
 }
@@ -2,9 +2,13 @@ package config
 
 import (
 	"fmt"
+	"net/url"
+	"regexp"
 	"strings"
+	"text/template"
 	"time"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/go-viper/mapstructure/v2"
 	"github.com/spf13/viper"
 
@@ -19,8 +23,12 @@ type Config struct {
 	Scheduler SchedulerConfig `mapstructure:"scheduler"`
 	Ethereum  EthereumConfig  `mapstructure:"ethereum"`
 	Cow       CowConfig       `mapstructure:"cow"`
+	Pairs     []PairConfig    `mapstructure:"pairs"`
 	Alerting  AlertingConfig  `mapstructure:"alerting"`
 	Export    ExportConfig    `mapstructure:"export"`
+	Backfill  BackfillConfig  `mapstructure:"backfill"`
+	Retention RetentionConfig `mapstructure:"retention"`
+	HTTP      HTTPConfig      `mapstructure:"http"`
 }
 
 // AppConfig general metadata.
@@ -29,13 +37,18 @@ type AppConfig struct {
 	Environment string `mapstructure:"environment"`
 }
 
-// DatabaseConfig encapsulates PostgreSQL connectivity.
+// DatabaseConfig encapsulates database connectivity. A sqlite: DSN selects
+// the embedded single-file backend; the pool and migration settings only
+// apply to PostgreSQL.
 type DatabaseConfig struct {
 	DSN             string        `mapstructure:"dsn"`
 	MaxOpenConns    int           `mapstructure:"max_open_conns"`
 	MaxIdleConns    int           `mapstructure:"max_idle_conns"`
 	ConnMaxLifetime time.Duration `mapstructure:"conn_max_lifetime"`
-	MigrationsPath  string        `mapstructure:"migrations_path"`
+	// MigrationsPath overrides the migrations embedded in the binary.
+	MigrationsPath string `mapstructure:"migrations_path"`
+	// RequireCurrentSchema makes run refuse to start with pending migrations.
+	RequireCurrentSchema bool `mapstructure:"require_current_schema"`
 }
 
 // SchedulerConfig governs sampling cadence.
@@ -46,15 +59,40 @@ type SchedulerConfig struct {
 	StartupDelay    time.Duration `mapstructure:"startup_delay"`
 }
 
-// EthereumConfig covers on-chain data access.
+// EthereumConfig covers on-chain data access. SUSDEAddress and USDEAddress
+// describe the single pair monitored when no pairs are configured.
 type EthereumConfig struct {
-	RPCURL         string        `mapstructure:"rpc_url"`
-	SUSDEAddress   string        `mapstructure:"susde_address"`
-	USDEAddress    string        `mapstructure:"usde_address"`
-	RequestTimeout time.Duration `mapstructure:"request_timeout"`
+	// RPCURLs lists the endpoints to fail over between; RPCURL is the
+	// single-endpoint form and is appended when set.
+	RPCURL  string   `mapstructure:"rpc_url"`
+	RPCURLs []string `mapstructure:"rpc_urls"`
+	// Quorum, when above one, accepts an official rate only once that many
+	// endpoints return it for the same block.
+	Quorum int `mapstructure:"quorum"`
+	// MulticallAddress overrides the Multicall3 contract on-chain reads are
+	// batched through; empty uses the canonical deployment.
+	MulticallAddress string        `mapstructure:"multicall_address"`
+	SUSDEAddress     string        `mapstructure:"susde_address"`
+	USDEAddress      string        `mapstructure:"usde_address"`
+	RequestTimeout   time.Duration `mapstructure:"request_timeout"`
+}
+
+// Endpoints returns rpc_urls followed by rpc_url, without duplicates.
+func (e EthereumConfig) Endpoints() []string {
+	var urls []string
+	seen := make(map[string]bool)
+	for _, u := range append(append([]string(nil), e.RPCURLs...), e.RPCURL) {
+		if u == "" || seen[u] {
+			continue
+		}
+		seen[u] = true
+		urls = append(urls, u)
+	}
+	return urls
 }
 
-// CowConfig captures CoW Protocol connectivity.
+// CowConfig captures CoW Protocol connectivity. NotionalUSDE only applies to
+// the pair derived from the ethereum addresses when no pairs are configured.
 type CowConfig struct {
 	BaseURL        string        `mapstructure:"base_url"`
 	PriceQuality   string        `mapstructure:"price_quality"`
@@ -63,21 +101,346 @@ type CowConfig struct {
 	UserAgent      string        `mapstructure:"user_agent"`
 }
 
+// LegacyPairID identifies the USDe/sUSDe pair built from ethereum.susde_address
+// and ethereum.usde_address when no pairs are configured. It matches the key
+// rows written before pairs existed are stored under.
+const LegacyPairID = "usde-susde"
+
+// PairConfig describes one monitored ERC-4626 vault: its official rate comes
+// from the vault contract and its market rate from quoting Notional underlying
+// tokens into vault shares on CoW. Zero thresholds and empty channels fall
+// back to the alerting section.
+type PairConfig struct {
+	// ID keys the pair's samples, alerts and alert state; changing it starts
+	// a new history.
+	ID                string  `mapstructure:"id"`
+	VaultAddress      string  `mapstructure:"vault_address"`
+	UnderlyingAddress string  `mapstructure:"underlying_address"`
+	VaultSymbol       string  `mapstructure:"vault_symbol"`
+	UnderlyingSymbol  string  `mapstructure:"underlying_symbol"`
+	Notional          float64 `mapstructure:"notional"`
+	// OfficialMethod is the ERC-4626 view the official rate is quoted from:
+	// previewDeposit or convertToShares on the deposit side, convertToAssets
+	// or previewRedeem on the redeem side.
+	OfficialMethod    string   `mapstructure:"official_method"`
+	ThresholdPct      float64  `mapstructure:"threshold_pct"`
+	ClearThresholdPct float64  `mapstructure:"clear_threshold_pct"`
+	Channels          []string `mapstructure:"channels"`
+}
+
+// Name returns the display name used in notifications, e.g. USDe-sUSDe.
+func (p PairConfig) Name() string {
+	return p.UnderlyingSymbol + "-" + p.VaultSymbol
+}
+
+// Pair returns the configured pair with the given id.
+func (c *Config) Pair(id string) (PairConfig, bool) {
+	for _, pair := range c.Pairs {
+		if pair.ID == id {
+			return pair, true
+		}
+	}
+	return PairConfig{}, false
+}
+
+var pairIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// resolvePairs derives the legacy pair when none is configured and fills
+// per-pair settings left to the alerting defaults.
+func (c *Config) resolvePairs() {
+	if len(c.Pairs) == 0 {
+		c.Pairs = []PairConfig{{
+			ID:                LegacyPairID,
+			VaultAddress:      c.Ethereum.SUSDEAddress,
+			UnderlyingAddress: c.Ethereum.USDEAddress,
+			VaultSymbol:       "sUSDe",
+			UnderlyingSymbol:  "USDe",
+			Notional:          c.Cow.NotionalUSDE,
+		}}
+	}
+	for i := range c.Pairs {
+		pair := &c.Pairs[i]
+		if pair.ThresholdPct == 0 {
+			pair.ThresholdPct = c.Alerting.ThresholdPct
+			if pair.ClearThresholdPct == 0 {
+				pair.ClearThresholdPct = c.Alerting.EffectiveClearThreshold()
+			}
+		}
+		if pair.ClearThresholdPct == 0 {
+			pair.ClearThresholdPct = pair.ThresholdPct
+		}
+		if len(pair.Channels) == 0 {
+			pair.Channels = append([]string(nil), c.Alerting.Channels...)
+		}
+		if pair.OfficialMethod == "" {
+			pair.OfficialMethod = "previewDeposit"
+		}
+	}
+}
+
+// officialMethods lists the ERC-4626 views the official rate can be quoted from.
+var officialMethods = map[string]bool{
+	"previewDeposit":  true,
+	"convertToShares": true,
+	"convertToAssets": true,
+	"previewRedeem":   true,
+}
+
+func (p PairConfig) validate(prefix string) error {
+	if !pairIDPattern.MatchString(p.ID) {
+		return fmt.Errorf("%s.id must be lowercase letters, digits, '-' or '_'", prefix)
+	}
+	if p.VaultSymbol == "" || p.UnderlyingSymbol == "" {
+		return fmt.Errorf("%s.vault_symbol and underlying_symbol are required", prefix)
+	}
+	if !common.IsHexAddress(p.VaultAddress) {
+		return fmt.Errorf("%s.vault_address must be a hex address", prefix)
+	}
+	if p.UnderlyingAddress != "" && !common.IsHexAddress(p.UnderlyingAddress) {
+		return fmt.Errorf("%s.underlying_address must be a hex address", prefix)
+	}
+	if p.Notional <= 0 {
+		return fmt.Errorf("%s.notional must be greater than zero", prefix)
+	}
+	if !officialMethods[p.OfficialMethod] {
+		return fmt.Errorf("%s.official_method: unsupported method %q", prefix, p.OfficialMethod)
+	}
+	if p.ThresholdPct < 0 {
+		return fmt.Errorf("%s.threshold_pct cannot be negative", prefix)
+	}
+	if p.ClearThresholdPct < 0 || p.ClearThresholdPct > p.ThresholdPct {
+		return fmt.Errorf("%s.clear_threshold_pct must be between 0 and threshold_pct", prefix)
+	}
+	for _, name := range p.Channels {
+		if !knownChannels[name] {
+			return fmt.Errorf("%s.channels: unknown channel %q", prefix, name)
+		}
+	}
+	return nil
+}
+
 // AlertingConfig defines alert thresholds and routing.
 type AlertingConfig struct {
-	Enabled      bool           `mapstructure:"enabled"`
-	ThresholdPct float64        `mapstructure:"threshold_pct"`
-	Cooldown     time.Duration  `mapstructure:"cooldown"`
-	Channels     []string       `mapstructure:"channels"`
-	Telegram     TelegramConfig `mapstructure:"telegram"`
+	Enabled           bool               `mapstructure:"enabled"`
+	ThresholdPct      float64            `mapstructure:"threshold_pct"`
+	ClearThresholdPct float64            `mapstructure:"clear_threshold_pct"`
+	Cooldown          time.Duration      `mapstructure:"cooldown"`
+	Confirmation      ConfirmationConfig `mapstructure:"confirmation"`
+	Channels          []string           `mapstructure:"channels"`
+	Telegram          TelegramConfig     `mapstructure:"telegram"`
+	Webhook           WebhookConfig      `mapstructure:"webhook"`
+	Slack             SlackConfig        `mapstructure:"slack"`
+	Discord           DiscordConfig      `mapstructure:"discord"`
+	Email             EmailConfig        `mapstructure:"email"`
+	Outbox            OutboxConfig       `mapstructure:"outbox"`
+}
+
+// OutboxConfig controls redelivery of queued notifications. A failed message
+// waits Backoff, doubling per attempt up to MaxBackoff, and is dead-lettered
+// after MaxAttempts.
+type OutboxConfig struct {
+	PollInterval time.Duration `mapstructure:"poll_interval"`
+	MaxAttempts  int           `mapstructure:"max_attempts"`
+	Backoff      time.Duration `mapstructure:"backoff"`
+	MaxBackoff   time.Duration `mapstructure:"max_backoff"`
+}
+
+func (o OutboxConfig) validate() error {
+	if o.PollInterval <= 0 {
+		return fmt.Errorf("alerting.outbox.poll_interval must be greater than zero")
+	}
+	if o.MaxAttempts < 1 {
+		return fmt.Errorf("alerting.outbox.max_attempts must be at least 1")
+	}
+	if o.Backoff <= 0 || o.MaxBackoff < o.Backoff {
+		return fmt.Errorf("alerting.outbox.backoff must be positive and not exceed max_backoff")
+	}
+	return nil
+}
+
+// ConfirmationConfig controls when a pending breach becomes firing: at least
+// Breaches of the last Window buckets must breach, and the episode must have
+// lasted MinDuration.
+type ConfirmationConfig struct {
+	Breaches    int           `mapstructure:"breaches"`
+	Window      int           `mapstructure:"window"`
+	MinDuration time.Duration `mapstructure:"min_duration"`
+}
+
+// MaxConfirmationWindow bounds alerting.confirmation.window.
+const MaxConfirmationWindow = 64
+
+// EffectiveClearThreshold returns the deviation at or below which a firing
+// alert resolves; zero falls back to ThresholdPct.
+func (a AlertingConfig) EffectiveClearThreshold() float64 {
+	if a.ClearThresholdPct <= 0 {
+		return a.ThresholdPct
+	}
+	return a.ClearThresholdPct
+}
+
+// EffectiveWindow returns the confirmation window, defaulting to Breaches.
+func (c ConfirmationConfig) EffectiveWindow() int {
+	if c.Window <= 0 {
+		return c.Breaches
+	}
+	return c.Window
+}
+
+// Alert channel names accepted in alerting.channels.
+const (
+	ChannelTelegram = "telegram"
+	ChannelWebhook  = "webhook"
+	ChannelSlack    = "slack"
+	ChannelDiscord  = "discord"
+	ChannelEmail    = "email"
+)
+
+// knownChannels lists every channel name a notifier can be built for.
+var knownChannels = map[string]bool{
+	ChannelTelegram: true,
+	ChannelWebhook:  true,
+	ChannelSlack:    true,
+	ChannelDiscord:  true,
+	ChannelEmail:    true,
+}
+
+// DeliveryConfig sets per-channel retry and timeout behaviour.
+type DeliveryConfig struct {
+	Retries      int           `mapstructure:"retries"`
+	Timeout      time.Duration `mapstructure:"timeout"`
+	RetryBackoff time.Duration `mapstructure:"retry_backoff"`
+}
+
+func (d DeliveryConfig) validate(prefix string) error {
+	if d.Retries < 0 {
+		return fmt.Errorf("%s.retries cannot be negative", prefix)
+	}
+	if d.Timeout < 0 || d.RetryBackoff < 0 {
+		return fmt.Errorf("%s timeouts cannot be negative", prefix)
+	}
+	return nil
 }
 
 // TelegramConfig 描述 Telegram 告警参数。
 type TelegramConfig struct {
-	Enabled  bool   `mapstructure:"enabled"`
-	BotToken string `mapstructure:"bot_token"`
-	ChatID   string `mapstructure:"chat_id"`
-	APIBase  string `mapstructure:"api_base"`
+	Enabled        bool   `mapstructure:"enabled"`
+	BotToken       string `mapstructure:"bot_token"`
+	ChatID         string `mapstructure:"chat_id"`
+	APIBase        string `mapstructure:"api_base"`
+	DeliveryConfig `mapstructure:",squash"`
+}
+
+// WebhookConfig configures the generic JSON webhook channel. Template is a
+// Go text/template rendered from alerting.Notification; empty uses the
+// built-in payload. Secret enables an HMAC-SHA256 signature header.
+type WebhookConfig struct {
+	Enabled         bool              `mapstructure:"enabled"`
+	URL             string            `mapstructure:"url"`
+	Template        string            `mapstructure:"template"`
+	Headers         map[string]string `mapstructure:"headers"`
+	Secret          string            `mapstructure:"secret"`
+	SignatureHeader string            `mapstructure:"signature_header"`
+	DeliveryConfig  `mapstructure:",squash"`
+}
+
+// SlackConfig configures the Slack incoming-webhook channel.
+type SlackConfig struct {
+	Enabled        bool   `mapstructure:"enabled"`
+	WebhookURL     string `mapstructure:"webhook_url"`
+	Channel        string `mapstructure:"channel"`
+	Username       string `mapstructure:"username"`
+	DeliveryConfig `mapstructure:",squash"`
+}
+
+// DiscordConfig configures the Discord webhook channel.
+type DiscordConfig struct {
+	Enabled        bool   `mapstructure:"enabled"`
+	WebhookURL     string `mapstructure:"webhook_url"`
+	Username       string `mapstructure:"username"`
+	DeliveryConfig `mapstructure:",squash"`
+}
+
+// Email delivery modes.
+const (
+	EmailModeImmediate = "immediate"
+	EmailModeDigest    = "digest"
+)
+
+// EmailConfig configures the SMTP channel. In digest mode alerts are not
+// mailed one by one; a summary of each DigestInterval is sent instead.
+type EmailConfig struct {
+	Enabled        bool          `mapstructure:"enabled"`
+	Mode           string        `mapstructure:"mode"`
+	Host           string        `mapstructure:"host"`
+	Port           int           `mapstructure:"port"`
+	Username       string        `mapstructure:"username"`
+	Password       string        `mapstructure:"password"`
+	From           string        `mapstructure:"from"`
+	To             []string      `mapstructure:"to"`
+	StartTLS       string        `mapstructure:"starttls"`
+	SubjectPrefix  string        `mapstructure:"subject_prefix"`
+	DigestInterval time.Duration `mapstructure:"digest_interval"`
+	DeliveryConfig `mapstructure:",squash"`
+}
+
+func (e EmailConfig) validate() error {
+	if err := e.DeliveryConfig.validate("alerting.email"); err != nil {
+		return err
+	}
+	if e.Host == "" || e.Port <= 0 {
+		return fmt.Errorf("alerting.email.host and port are required when the email channel is enabled")
+	}
+	if e.From == "" || len(e.To) == 0 {
+		return fmt.Errorf("alerting.email.from and alerting.email.to are required")
+	}
+	switch e.StartTLS {
+	case "auto", "always", "never":
+	default:
+		return fmt.Errorf("alerting.email.starttls must be auto, always or never")
+	}
+	switch e.Mode {
+	case EmailModeImmediate:
+	case EmailModeDigest:
+		if e.DigestInterval <= 0 {
+			return fmt.Errorf("alerting.email.digest_interval must be positive in digest mode")
+		}
+	default:
+		return fmt.Errorf("alerting.email.mode must be %s or %s", EmailModeImmediate, EmailModeDigest)
+	}
+	return nil
+}
+
+// WebhookTemplateFuncs names the functions the webhook notifier provides to
+// alerting.webhook.template.
+var WebhookTemplateFuncs = []string{"fixed", "json", "kind", "message", "rfc3339"}
+
+// validateWebhookTemplate parses a non-empty webhook template against stand-ins
+// for WebhookTemplateFuncs so syntax errors and unknown functions fail at load.
+func validateWebhookTemplate(text string) error {
+	if strings.TrimSpace(text) == "" {
+		return nil
+	}
+	funcs := template.FuncMap{}
+	for _, name := range WebhookTemplateFuncs {
+		funcs[name] = func(...any) string { return "" }
+	}
+	if _, err := template.New("webhook").Funcs(funcs).Parse(text); err != nil {
+		return fmt.Errorf("parse webhook template: %w", err)
+	}
+	return nil
+}
+
+// validateWebhookURL requires an absolute http(s) URL.
+func validateWebhookURL(key, raw string) error {
+	if raw == "" {
+		return fmt.Errorf("%s is required when the channel is enabled", key)
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" || (u.Scheme != "https" && u.Scheme != "http") {
+		return fmt.Errorf("%s must be an absolute http(s) URL", key)
+	}
+	return nil
 }
 
 // ExportConfig sets CLI export behaviour.
@@ -85,6 +448,35 @@ type ExportConfig struct {
 	MaxDataPoints int `mapstructure:"max_data_points"`
 }
 
+// BackfillConfig tunes historical backfill throughput.
+type BackfillConfig struct {
+	Workers         int     `mapstructure:"workers"`
+	RPCRateLimit    float64 `mapstructure:"rpc_rate_limit"`
+	CheckpointEvery int     `mapstructure:"checkpoint_every"`
+}
+
+// RetentionConfig controls rollups and pruning of historical data. A zero
+// retention keeps that resolution forever.
+type RetentionConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Interval is how often the rollup and prune pass runs.
+	Interval time.Duration `mapstructure:"interval"`
+	Raw      time.Duration `mapstructure:"raw"`
+	Hourly   time.Duration `mapstructure:"hourly"`
+	Daily    time.Duration `mapstructure:"daily"`
+}
+
+// HTTPConfig controls the listener run serves /metrics, /healthz and /readyz
+// on. An empty Listen disables it.
+type HTTPConfig struct {
+	Listen string `mapstructure:"listen"`
+	// API also mounts the read-only /api/ routes on run's listener; serve
+	// always mounts them.
+	API bool `mapstructure:"api"`
+	// ShutdownTimeout bounds how long in-flight requests may finish on exit.
+	ShutdownTimeout time.Duration `mapstructure:"shutdown_timeout"`
+}
+
 // Load builds configuration from file, environment, and defaults.
 func Load(path string) (*Config, error) {
 	v := viper.New()
@@ -110,6 +502,7 @@ func Load(path string) (*Config, error) {
 	if err := v.Unmarshal(&cfg, decodeHook()); err != nil {
 		return nil, fmt.Errorf("unmarshal config: %w", err)
 	}
+	cfg.resolvePairs()
 
 	if err := cfg.Validate(); err != nil {
 		return nil, err
@@ -151,16 +544,59 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("alerting.enabled", false)
 	v.SetDefault("alerting.threshold_pct", 0.4)
 	v.SetDefault("alerting.cooldown", "30m")
+	v.SetDefault("alerting.clear_threshold_pct", 0.0)
+	v.SetDefault("alerting.confirmation.breaches", 2)
+	v.SetDefault("alerting.confirmation.window", 0)
+	v.SetDefault("alerting.confirmation.min_duration", "0s")
 	v.SetDefault("alerting.channels", []string{"telegram"})
 	v.SetDefault("alerting.telegram.enabled", false)
 	v.SetDefault("alerting.telegram.api_base", "https://api.telegram.org")
+	v.SetDefault("alerting.telegram.retries", 2)
+	v.SetDefault("alerting.telegram.timeout", "10s")
+	v.SetDefault("alerting.telegram.retry_backoff", "2s")
+	v.SetDefault("alerting.webhook.enabled", false)
+	v.SetDefault("alerting.webhook.signature_header", "X-Signature-256")
+	v.SetDefault("alerting.webhook.retries", 2)
+	v.SetDefault("alerting.webhook.timeout", "10s")
+	v.SetDefault("alerting.webhook.retry_backoff", "2s")
+	v.SetDefault("alerting.email.enabled", false)
+	v.SetDefault("alerting.email.mode", EmailModeImmediate)
+	v.SetDefault("alerting.email.port", 587)
+	v.SetDefault("alerting.email.starttls", "auto")
+	v.SetDefault("alerting.email.subject_prefix", "[usdewatcher]")
+	v.SetDefault("alerting.email.digest_interval", "24h")
+	for _, channel := range []string{ChannelSlack, ChannelDiscord, ChannelEmail} {
+		v.SetDefault("alerting."+channel+".enabled", false)
+		v.SetDefault("alerting."+channel+".retries", 2)
+		v.SetDefault("alerting."+channel+".timeout", "10s")
+		v.SetDefault("alerting."+channel+".retry_backoff", "2s")
+	}
+	v.SetDefault("alerting.outbox.poll_interval", "30s")
+	v.SetDefault("alerting.outbox.max_attempts", 10)
+	v.SetDefault("alerting.outbox.backoff", "1m")
+	v.SetDefault("alerting.outbox.max_backoff", "1h")
 
 	v.SetDefault("export.max_data_points", 100000)
 
+	v.SetDefault("backfill.workers", 2)
+	v.SetDefault("backfill.rpc_rate_limit", 5.0)
+	v.SetDefault("backfill.checkpoint_every", 50)
+
+	v.SetDefault("retention.enabled", false)
+	v.SetDefault("retention.interval", "1h")
+	v.SetDefault("retention.raw", "720h")
+	v.SetDefault("retention.hourly", "8760h")
+	v.SetDefault("retention.daily", "0s")
+
+	v.SetDefault("http.listen", "")
+	v.SetDefault("http.shutdown_timeout", "5s")
+	v.SetDefault("http.api", true)
+
 	v.SetDefault("database.max_open_conns", 10)
 	v.SetDefault("database.max_idle_conns", 5)
 	v.SetDefault("database.conn_max_lifetime", "30m")
-	v.SetDefault("database.migrations_path", "migrations")
+	v.SetDefault("database.migrations_path", "")
+	v.SetDefault("database.require_current_schema", false)
 }
 
 func decodeHook() viper.DecoderConfigOption {
@@ -184,10 +620,107 @@ func (c *Config) Validate() error {
 	if c.Cow.NotionalUSDE <= 0 {
 		return fmt.Errorf("cow.notional_usde must be greater than zero")
 	}
+	if c.Ethereum.Quorum < 0 {
+		return fmt.Errorf("ethereum.quorum cannot be negative")
+	}
+	if n := len(c.Ethereum.Endpoints()); c.Ethereum.Quorum > 1 && c.Ethereum.Quorum > n {
+		return fmt.Errorf("ethereum.quorum %d exceeds the %d configured rpc endpoints", c.Ethereum.Quorum, n)
+	}
+	if c.Backfill.Workers <= 0 {
+		return fmt.Errorf("backfill.workers must be greater than zero")
+	}
+	if c.Backfill.RPCRateLimit < 0 {
+		return fmt.Errorf("backfill.rpc_rate_limit cannot be negative")
+	}
+	if c.Backfill.CheckpointEvery <= 0 {
+		return fmt.Errorf("backfill.checkpoint_every must be greater than zero")
+	}
+	if c.Retention.Enabled {
+		if err := c.Retention.validate(); err != nil {
+			return err
+		}
+	}
+	if c.HTTP.Listen != "" && c.HTTP.ShutdownTimeout <= 0 {
+		return fmt.Errorf("http.shutdown_timeout must be greater than zero")
+	}
 	if c.Alerting.ThresholdPct < 0 {
 		return fmt.Errorf("alerting.threshold_pct cannot be negative")
 	}
+	if c.Alerting.Cooldown < 0 {
+		return fmt.Errorf("alerting.cooldown cannot be negative")
+	}
+	if c.Alerting.ClearThresholdPct < 0 || c.Alerting.ClearThresholdPct > c.Alerting.ThresholdPct {
+		return fmt.Errorf("alerting.clear_threshold_pct must be between 0 and alerting.threshold_pct")
+	}
+	confirm := c.Alerting.Confirmation
+	if confirm.Breaches < 1 {
+		return fmt.Errorf("alerting.confirmation.breaches must be at least 1")
+	}
+	if confirm.Window != 0 && (confirm.Window < confirm.Breaches || confirm.Window > MaxConfirmationWindow) {
+		return fmt.Errorf("alerting.confirmation.window must be between breaches and %d", MaxConfirmationWindow)
+	}
+	if confirm.MinDuration < 0 {
+		return fmt.Errorf("alerting.confirmation.min_duration cannot be negative")
+	}
+	if c.Alerting.Enabled {
+		if err := c.Alerting.Outbox.validate(); err != nil {
+			return err
+		}
+	}
+	for _, name := range c.Alerting.Channels {
+		if !knownChannels[name] {
+			return fmt.Errorf("alerting.channels: unknown channel %q", name)
+		}
+	}
+	if len(c.Pairs) == 0 {
+		return fmt.Errorf("at least one pair must be configured")
+	}
+	seen := make(map[string]bool, len(c.Pairs))
+	for i, pair := range c.Pairs {
+		if err := pair.validate(fmt.Sprintf("pairs[%d]", i)); err != nil {
+			return err
+		}
+		if seen[pair.ID] {
+			return fmt.Errorf("pairs: duplicate id %q", pair.ID)
+		}
+		seen[pair.ID] = true
+	}
+	if c.Alerting.Webhook.Enabled {
+		if err := c.Alerting.Webhook.DeliveryConfig.validate("alerting.webhook"); err != nil {
+			return err
+		}
+		if err := validateWebhookURL("alerting.webhook.url", c.Alerting.Webhook.URL); err != nil {
+			return err
+		}
+		if err := validateWebhookTemplate(c.Alerting.Webhook.Template); err != nil {
+			return fmt.Errorf("alerting.webhook.template: %w", err)
+		}
+	}
+	if c.Alerting.Slack.Enabled {
+		if err := c.Alerting.Slack.DeliveryConfig.validate("alerting.slack"); err != nil {
+			return err
+		}
+		if err := validateWebhookURL("alerting.slack.webhook_url", c.Alerting.Slack.WebhookURL); err != nil {
+			return err
+		}
+	}
+	if c.Alerting.Discord.Enabled {
+		if err := c.Alerting.Discord.DeliveryConfig.validate("alerting.discord"); err != nil {
+			return err
+		}
+		if err := validateWebhookURL("alerting.discord.webhook_url", c.Alerting.Discord.WebhookURL); err != nil {
+			return err
+		}
+	}
+	if c.Alerting.Email.Enabled {
+		if err := c.Alerting.Email.validate(); err != nil {
+			return err
+		}
+	}
 	if c.Alerting.Telegram.Enabled {
+		if err := c.Alerting.Telegram.DeliveryConfig.validate("alerting.telegram"); err != nil {
+			return err
+		}
 		if c.Alerting.Telegram.BotToken == "" {
 			return fmt.Errorf("alerting.telegram.bot_token 必须配置")
 		}
@@ -198,6 +731,35 @@ func (c *Config) Validate() error {
 	return nil
 }
 
+func (r RetentionConfig) validate() error {
+	if r.Interval <= 0 {
+		return fmt.Errorf("retention.interval must be greater than zero")
+	}
+	if r.Raw < 0 || r.Hourly < 0 || r.Daily < 0 {
+		return fmt.Errorf("retention windows cannot be negative")
+	}
+	// Pruning happens on whole hours/days, so each kept window must cover at
+	// least one full bucket of the next resolution.
+	if r.Raw != 0 && r.Raw < 2*time.Hour {
+		return fmt.Errorf("retention.raw must be 0 or at least 2h")
+	}
+	if r.Hourly != 0 && r.Hourly < 48*time.Hour {
+		return fmt.Errorf("retention.hourly must be 0 or at least 48h")
+	}
+	if r.Daily != 0 && r.Daily < 48*time.Hour {
+		return fmt.Errorf("retention.daily must be 0 or at least 48h")
+	}
+	return nil
+}
+
+// ResolveBackfillWorkers returns either the CLI override or config default.
+func (c *Config) ResolveBackfillWorkers(override int) int {
+	if override > 0 {
+		return override
+	}
+	return c.Backfill.Workers
+}
+
 // ResolveMaxPoints returns either the CLI override or config default.
 func (c *Config) ResolveMaxPoints(override int) int {
 	if override > 0 {
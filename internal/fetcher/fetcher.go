@@ -3,13 +3,46 @@ package fetcher
 import (
 	"context"
 	"encoding/json"
+	"time"
 
 	"github.com/shopspring/decimal"
 )
 
-// OfficialRateFetcher retrieves the on-chain official sUSDe/USDe rate.
+// ERC-4626 view functions the official rate can be quoted from. The deposit
+// side quotes one underlying token into shares; the redeem side quotes one
+// share into underlying tokens and inverts the result.
+const (
+	MethodPreviewDeposit  = "previewDeposit"
+	MethodConvertToShares = "convertToShares"
+	MethodConvertToAssets = "convertToAssets"
+	MethodPreviewRedeem   = "previewRedeem"
+)
+
+// OfficialQuote is one reading of a vault's official rate.
+type OfficialQuote struct {
+	// Rate is vault shares per underlying token.
+	Rate decimal.Decimal
+	// BlockNumber, BlockHash and BlockTime identify the block the rate was
+	// read at; they are zero when unknown.
+	BlockNumber uint64
+	BlockHash   string
+	BlockTime   time.Time
+	// Method is the ERC-4626 view function the rate was derived from.
+	Method string
+}
+
+// OfficialRateFetcher retrieves a vault's on-chain official rate in shares
+// per underlying token.
 type OfficialRateFetcher interface {
-	FetchOfficial(ctx context.Context) (decimal.Decimal, uint64, error)
+	FetchOfficial(ctx context.Context) (OfficialQuote, error)
+	// FetchOfficialAt reads the rate as of a historical block.
+	FetchOfficialAt(ctx context.Context, blockNumber uint64) (OfficialQuote, error)
+}
+
+// BlockLocator maps wall-clock timestamps to Ethereum block numbers.
+type BlockLocator interface {
+	// BlockAtTime returns the latest block whose timestamp is not after ts.
+	BlockAtTime(ctx context.Context, ts time.Time) (uint64, error)
 }
 
 // MarketRateFetcher retrieves the secondary market rate from CoW Protocol.
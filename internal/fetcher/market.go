@@ -26,7 +26,7 @@ var dec1e18 = decimal.NewFromInt(1_000_000_000_000_000_000)
 type MarketOptions struct {
 	BaseURL      string
 	PriceQuality string
-	NotionalUSDE decimal.Decimal
+	Notional     decimal.Decimal
 	Timeout      time.Duration
 	UserAgent    string
 	SellToken    string
@@ -61,16 +61,17 @@ func NewMarket(opts MarketOptions, logger zerolog.Logger) *Market {
 	}
 }
 
-// FetchMarket retrieves a CoW Protocol quote and returns sUSDe/USDe.
+// FetchMarket retrieves a CoW Protocol quote selling the underlying token for
+// vault shares and returns shares per underlying token.
 func (m *Market) FetchMarket(ctx context.Context) (decimal.Decimal, json.RawMessage, string, error) {
-	if m.opts.NotionalUSDE.IsZero() {
+	if m.opts.Notional.IsZero() {
 		return decimal.Decimal{}, nil, "", errors.New("notional must be greater than zero")
 	}
 	if m.opts.SellToken == "" || m.opts.BuyToken == "" {
 		return decimal.Decimal{}, nil, "", errors.New("sellToken and buyToken addresses required")
 	}
 
-	sellAtoms := m.opts.NotionalUSDE.Mul(dec1e18)
+	sellAtoms := m.opts.Notional.Mul(dec1e18)
 	sellAtoms = sellAtoms.Round(0)
 	if sellAtoms.IsZero() {
 		return decimal.Decimal{}, nil, "", errors.New("sell amount rounded to zero")
@@ -175,23 +176,60 @@ type errorResponse struct {
 	Message     string `json:"message"`
 }
 
+// APIError is a non-2xx response from the CoW quote API. Type carries the
+// API's errorType (for example NoLiquidity) when the body had one.
+type APIError struct {
+	Status int
+	Type   string
+	Detail string
+}
+
+func (e *APIError) Error() string {
+	if e.Detail != "" {
+		return fmt.Sprintf("cow api error (%d): %s", e.Status, e.Detail)
+	}
+	return fmt.Sprintf("cow api error (%d)", e.Status)
+}
+
+// ErrorType classifies an error for metrics and health reports without its
+// text, which may carry URLs and credentials: the CoW errorType when the API
+// returned one, http_<status> for other API errors, timeout for deadline
+// errors and transport for everything else.
+func ErrorType(err error) string {
+	var apiErr *APIError
+	switch {
+	case err == nil:
+		return ""
+	case errors.As(err, &apiErr) && apiErr.Type != "":
+		return apiErr.Type
+	case errors.As(err, &apiErr):
+		return fmt.Sprintf("http_%d", apiErr.Status)
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	default:
+		return "transport"
+	}
+}
+
 func parseHTTPError(status int, payload []byte) error {
-	var apiErr errorResponse
-	if err := json.Unmarshal(payload, &apiErr); err == nil {
-		if apiErr.Description != "" {
-			return fmt.Errorf("cow api error (%d): %s", status, apiErr.Description)
+	apiErr := &APIError{Status: status}
+	var body errorResponse
+	if err := json.Unmarshal(payload, &body); err == nil {
+		apiErr.Type = body.ErrorType
+		switch {
+		case body.Description != "":
+			apiErr.Detail = body.Description
+		case body.Message != "":
+			apiErr.Detail = body.Message
+		default:
+			apiErr.Detail = body.ErrorType
 		}
-		if apiErr.Message != "" {
-			return fmt.Errorf("cow api error (%d): %s", status, apiErr.Message)
+		if apiErr.Detail != "" {
+			return apiErr
 		}
-		if apiErr.ErrorType != "" {
-			return fmt.Errorf("cow api error (%d): %s", status, apiErr.ErrorType)
-		}
-	}
-	if len(payload) > 0 {
-		return fmt.Errorf("cow api error (%d): %s", status, strings.TrimSpace(string(payload)))
 	}
-	return fmt.Errorf("cow api error (%d)", status)
+	apiErr.Detail = strings.TrimSpace(string(payload))
+	return apiErr
 }
 
 var _ MarketRateFetcher = (*Market)(nil)
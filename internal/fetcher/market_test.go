@@ -3,6 +3,8 @@ package fetcher
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -12,7 +14,7 @@ import (
 )
 
 func TestMarketFetchMissingTokens(t *testing.T) {
-	m := NewMarket(MarketOptions{NotionalUSDE: decimal.NewFromInt(1)}, noopLogger())
+	m := NewMarket(MarketOptions{Notional: decimal.NewFromInt(1)}, noopLogger())
 	if _, _, _, err := m.FetchMarket(context.Background()); err == nil {
 		t.Fatal("缺少 token 时应返回错误")
 	}
@@ -28,16 +30,32 @@ func TestMarketFetchHTTPError(t *testing.T) {
 	m := NewMarket(MarketOptions{
 		BaseURL:      srv.URL,
 		PriceQuality: "optimal",
-		NotionalUSDE: decimal.NewFromInt(1),
+		Notional:     decimal.NewFromInt(1),
 		Timeout:      time.Second,
 		UserAgent:    "test",
 		SellToken:    "0x1",
 		BuyToken:     "0x2",
 	}, noopLogger())
 
-	if _, _, _, err := m.FetchMarket(context.Background()); err == nil {
+	_, _, _, err := m.FetchMarket(context.Background())
+	if err == nil {
 		t.Fatal("HTTP 400 应返回错误")
 	}
+	if got := ErrorType(err); got != "bad" {
+		t.Fatalf("应识别 CoW errorType, 实际 %q", got)
+	}
+}
+
+func TestErrorTypeFallbacks(t *testing.T) {
+	if got := ErrorType(parseHTTPError(http.StatusBadGateway, []byte("upstream down"))); got != "http_502" {
+		t.Fatalf("无 errorType 时应按状态码分类, 实际 %q", got)
+	}
+	if got := ErrorType(fmt.Errorf("fetch: %w", context.DeadlineExceeded)); got != "timeout" {
+		t.Fatalf("超时应分类为 timeout, 实际 %q", got)
+	}
+	if got := ErrorType(errors.New("connection refused")); got != "transport" {
+		t.Fatalf("其他错误应分类为 transport, 实际 %q", got)
+	}
 }
 
 func TestMarketFetchSuccess(t *testing.T) {
@@ -57,7 +75,7 @@ func TestMarketFetchSuccess(t *testing.T) {
 	m := NewMarket(MarketOptions{
 		BaseURL:      srv.URL,
 		PriceQuality: "optimal",
-		NotionalUSDE: decimal.NewFromInt(1),
+		Notional:     decimal.NewFromInt(1),
 		Timeout:      time.Second,
 		UserAgent:    "test",
 		SellToken:    "0x1",
@@ -0,0 +1,204 @@
+package fetcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Multicall3Address is the canonical Multicall3 deployment, shared by
+// mainnet and most EVM chains.
+const Multicall3Address = "0xcA11bde05977b3631167028862bE2a173976CA11"
+
+const multicall3ABIJSON = `[{"inputs":[{"components":[{"internalType":"address","name":"target","type":"address"},{"internalType":"bool","name":"allowFailure","type":"bool"},{"internalType":"bytes","name":"callData","type":"bytes"}],"internalType":"struct Multicall3.Call3[]","name":"calls","type":"tuple[]"}],"name":"aggregate3","outputs":[{"components":[{"internalType":"bool","name":"success","type":"bool"},{"internalType":"bytes","name":"returnData","type":"bytes"}],"internalType":"struct Multicall3.Result[]","name":"returnData","type":"tuple[]"}],"stateMutability":"payable","type":"function"}]`
+
+var multicall3ABI = func() abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(multicall3ABIJSON))
+	if err != nil {
+		panic("failed to parse Multicall3 ABI: " + err.Error())
+	}
+	return parsed
+}()
+
+// multicallCall and multicallResult mirror Multicall3's Call3 and Result
+// tuples.
+type multicallCall struct {
+	Target       common.Address
+	AllowFailure bool
+	CallData     []byte
+}
+
+type multicallResult struct {
+	Success    bool
+	ReturnData []byte
+}
+
+// BlockCaller executes read-only calls at a block hash; *ethclient.Client
+// satisfies it.
+type BlockCaller interface {
+	CallContractAtHash(ctx context.Context, msg ethereum.CallMsg, blockHash common.Hash) ([]byte, error)
+}
+
+// errNotExecuted is returned by results read before their batch ran.
+var errNotExecuted = errors.New("batch not executed")
+
+// Batch collects view calls to run in a single Multicall3 aggregate3
+// eth_call at one block. Queue calls with Queue, run them with Execute, then
+// read each Result. Every call may fail on its own without failing the
+// others.
+type Batch struct {
+	multicall common.Address
+	calls     []batchCall
+	err       error
+}
+
+type batchCall struct {
+	target common.Address
+	data   []byte
+	method string
+	// resolve decodes the call's return data or records its failure.
+	resolve func(out []byte, err error)
+}
+
+// NewBatch starts an empty batch executed through the Multicall3 contract at
+// multicall.
+func NewBatch(multicall common.Address) *Batch {
+	return &Batch{multicall: multicall}
+}
+
+// Len reports how many calls are queued.
+func (b *Batch) Len() int {
+	return len(b.calls)
+}
+
+// Result is the decoded single output of one queued call.
+type Result[T any] struct {
+	value T
+	err   error
+}
+
+// Get returns the decoded value, or the error that call failed with.
+func (r *Result[T]) Get() (T, error) {
+	return r.value, r.err
+}
+
+// Queue adds a call of method on target, encoded with contract, to b. The
+// method must have exactly one output, which is decoded as T, e.g. *big.Int
+// for uint256, uint8 for uint8 and common.Address for address.
+func Queue[T any](b *Batch, target common.Address, contract *abi.ABI, method string, args ...any) *Result[T] {
+	r := &Result[T]{err: errNotExecuted}
+	data, err := contract.Pack(method, args...)
+	if err != nil {
+		r.err = contractErr(fmt.Errorf("pack %s: %w", method, err))
+		b.err = errors.Join(b.err, r.err)
+		return r
+	}
+
+	b.calls = append(b.calls, batchCall{
+		target: target,
+		data:   data,
+		method: method,
+		resolve: func(out []byte, err error) {
+			if err != nil {
+				r.err = err
+				return
+			}
+			outputs, err := contract.Unpack(method, out)
+			if err != nil {
+				r.err = contractErr(fmt.Errorf("decode %s: %w", method, err))
+				return
+			}
+			if len(outputs) != 1 {
+				r.err = contractErr(fmt.Errorf("unexpected %s response", method))
+				return
+			}
+			value, ok := outputs[0].(T)
+			if !ok {
+				r.err = contractErr(fmt.Errorf("%s returned %T, want %T", method, outputs[0], r.value))
+				return
+			}
+			r.value, r.err = value, nil
+		},
+	})
+	return r
+}
+
+// Execute runs every queued call at block. Several calls go through one
+// aggregate3 eth_call; a single call is sent directly. When no contract
+// exists at the Multicall3 address at block, e.g. before its deployment, the
+// calls are sent one by one instead. The returned error covers the
+// transport only; per-call failures surface through each Result.
+func (b *Batch) Execute(ctx context.Context, caller BlockCaller, block common.Hash) error {
+	if b.err != nil {
+		return b.err
+	}
+	switch len(b.calls) {
+	case 0:
+		return nil
+	case 1:
+		return b.executeEach(ctx, caller, block)
+	}
+
+	calls := make([]multicallCall, len(b.calls))
+	for i, c := range b.calls {
+		calls[i] = multicallCall{Target: c.target, AllowFailure: true, CallData: c.data}
+	}
+	payload, err := multicall3ABI.Pack("aggregate3", calls)
+	if err != nil {
+		return fmt.Errorf("pack aggregate3: %w", err)
+	}
+
+	res, err := caller.CallContractAtHash(ctx, ethereum.CallMsg{To: &b.multicall, Data: payload}, block)
+	if err != nil {
+		return fmt.Errorf("aggregate3: %w", err)
+	}
+	if len(res) == 0 {
+		return b.executeEach(ctx, caller, block)
+	}
+
+	outputs, err := multicall3ABI.Unpack("aggregate3", res)
+	if err != nil {
+		return fmt.Errorf("decode aggregate3: %w", err)
+	}
+	results := *abi.ConvertType(outputs[0], new([]multicallResult)).(*[]multicallResult)
+	if len(results) != len(b.calls) {
+		return fmt.Errorf("aggregate3 returned %d results for %d calls", len(results), len(b.calls))
+	}
+
+	for i, c := range b.calls {
+		if !results[i].Success {
+			c.resolve(nil, contractErr(fmt.Errorf("%s reverted", c.method)))
+			continue
+		}
+		c.resolve(results[i].ReturnData, nil)
+	}
+	return nil
+}
+
+// executeEach sends the queued calls as separate eth_calls. Reverts are
+// recorded on their results; any other error aborts the batch.
+func (b *Batch) executeEach(ctx context.Context, caller BlockCaller, block common.Hash) error {
+	for _, c := range b.calls {
+		out, err := caller.CallContractAtHash(ctx, ethereum.CallMsg{To: &c.target, Data: c.data}, block)
+		if err != nil && !isRevert(err) {
+			return fmt.Errorf("%s: %w", c.method, err)
+		}
+		c.resolve(out, err)
+	}
+	return nil
+}
+
+// isRevert reports whether err is an execution revert rather than a
+// transport or node failure.
+func isRevert(err error) bool {
+	var rpcErr interface{ ErrorCode() int }
+	if errors.As(err, &rpcErr) && rpcErr.ErrorCode() == 3 {
+		return true
+	}
+	return strings.Contains(err.Error(), "execution reverted")
+}
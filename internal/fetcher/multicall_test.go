@@ -0,0 +1,110 @@
+package fetcher
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// totalAssetsABI describes a view the fake node does not implement, so
+// calls to it revert.
+var totalAssetsABI = func() abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(`[{"inputs":[],"name":"totalAssets","outputs":[{"internalType":"uint256","name":"","type":"uint256"}],"stateMutability":"view","type":"function"}]`))
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}()
+
+func dialFakeNode(t *testing.T, node *fakeNode) *ethclient.Client {
+	t.Helper()
+	client, err := ethclient.Dial(node.start(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(client.Close)
+	return client
+}
+
+func TestBatchAggregatesCallsIntoOneEthCall(t *testing.T) {
+	node := newVaultNode(100, "800000000000000000")
+	client := dialFakeNode(t, node)
+	vault := common.HexToAddress("0x01")
+
+	batch := NewBatch(common.HexToAddress(Multicall3Address))
+	asset := Queue[common.Address](batch, vault, &erc4626ABI, "asset")
+	decimals := Queue[uint8](batch, vault, &erc4626ABI, "decimals")
+	shares := Queue[*big.Int](batch, vault, &erc4626ABI, MethodPreviewDeposit, big.NewInt(1e18))
+	total := Queue[*big.Int](batch, vault, &totalAssetsABI, "totalAssets")
+	mistyped := Queue[string](batch, vault, &erc4626ABI, "decimals")
+
+	if _, err := asset.Get(); err == nil {
+		t.Fatal("执行前读取结果应报错")
+	}
+	if err := batch.Execute(context.Background(), client, node.head.Hash); err != nil {
+		t.Fatalf("Execute 不应报错: %v", err)
+	}
+	if len(node.callBlocks) != 1 {
+		t.Fatalf("%d 个调用应合并为 1 次 eth_call, 实际 %d 次", batch.Len(), len(node.callBlocks))
+	}
+
+	if got, err := asset.Get(); err != nil || got != node.asset {
+		t.Fatalf("asset 结果不正确: %v %v", got, err)
+	}
+	if got, err := decimals.Get(); err != nil || got != 18 {
+		t.Fatalf("decimals 结果不正确: %v %v", got, err)
+	}
+	if got, err := shares.Get(); err != nil || got.Cmp(node.shares) != 0 {
+		t.Fatalf("previewDeposit 结果不正确: %v %v", got, err)
+	}
+	if _, err := total.Get(); err == nil {
+		t.Fatal("回滚的调用应单独报错")
+	}
+	if _, err := mistyped.Get(); err == nil {
+		t.Fatal("输出类型不匹配时应报错")
+	}
+}
+
+func TestBatchFallsBackWithoutMulticall(t *testing.T) {
+	node := newVaultNode(100, "800000000000000000")
+	node.noMulticall = true
+	client := dialFakeNode(t, node)
+	vault := common.HexToAddress("0x01")
+
+	batch := NewBatch(common.HexToAddress(Multicall3Address))
+	decimals := Queue[uint8](batch, vault, &erc4626ABI, "decimals")
+	shares := Queue[*big.Int](batch, vault, &erc4626ABI, MethodPreviewDeposit, big.NewInt(1e18))
+	total := Queue[*big.Int](batch, vault, &totalAssetsABI, "totalAssets")
+
+	if err := batch.Execute(context.Background(), client, node.head.Hash); err != nil {
+		t.Fatalf("没有 Multicall3 时应逐个调用而不是报错: %v", err)
+	}
+	if len(node.callBlocks) != 1+batch.Len() {
+		t.Fatalf("应先尝试 aggregate3 再逐个调用, 实际 %d 次 eth_call", len(node.callBlocks))
+	}
+	if got, err := decimals.Get(); err != nil || got != 18 {
+		t.Fatalf("decimals 结果不正确: %v %v", got, err)
+	}
+	if got, err := shares.Get(); err != nil || got.Cmp(node.shares) != 0 {
+		t.Fatalf("previewDeposit 结果不正确: %v %v", got, err)
+	}
+	if _, err := total.Get(); err == nil {
+		t.Fatal("回滚的调用应单独报错")
+	}
+}
+
+func TestBatchRejectsUnpackableCall(t *testing.T) {
+	batch := NewBatch(common.HexToAddress(Multicall3Address))
+	res := Queue[*big.Int](batch, common.HexToAddress("0x01"), &erc4626ABI, MethodPreviewDeposit, "not a number")
+	if _, err := res.Get(); err == nil {
+		t.Fatal("参数无法编码时结果应报错")
+	}
+	if err := batch.Execute(context.Background(), nil, common.Hash{}); err == nil {
+		t.Fatal("参数无法编码时 Execute 应报错")
+	}
+}
@@ -3,6 +3,7 @@ package fetcher
 import (
 	"context"
 	"errors"
+	"fmt"
 	"math/big"
 	"strings"
 	"sync"
@@ -11,14 +12,27 @@ import (
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/rs/zerolog"
 	"github.com/shopspring/decimal"
 )
 
-const (
-	erc4626ABIJSON = `[{"inputs":[{"internalType":"uint256","name":"assets","type":"uint256"}],"name":"previewDeposit","outputs":[{"internalType":"uint256","name":"","type":"uint256"}],"stateMutability":"view","type":"function"}]`
-)
+// erc4626ABIJSON covers the ERC-4626 views used for quoting plus the ERC-20
+// decimals() shared by the vault and its underlying token.
+const erc4626ABIJSON = `[
+{"inputs":[],"name":"asset","outputs":[{"internalType":"address","name":"","type":"address"}],"stateMutability":"view","type":"function"},
+{"inputs":[],"name":"decimals","outputs":[{"internalType":"uint8","name":"","type":"uint8"}],"stateMutability":"view","type":"function"},
+{"inputs":[{"internalType":"uint256","name":"assets","type":"uint256"}],"name":"previewDeposit","outputs":[{"internalType":"uint256","name":"","type":"uint256"}],"stateMutability":"view","type":"function"},
+{"inputs":[{"internalType":"uint256","name":"assets","type":"uint256"}],"name":"convertToShares","outputs":[{"internalType":"uint256","name":"","type":"uint256"}],"stateMutability":"view","type":"function"},
+{"inputs":[{"internalType":"uint256","name":"shares","type":"uint256"}],"name":"convertToAssets","outputs":[{"internalType":"uint256","name":"","type":"uint256"}],"stateMutability":"view","type":"function"},
+{"inputs":[{"internalType":"uint256","name":"shares","type":"uint256"}],"name":"previewRedeem","outputs":[{"internalType":"uint256","name":"","type":"uint256"}],"stateMutability":"view","type":"function"}
+]`
+
+// redeemQuotePrecision is the number of decimal places kept when inverting a
+// redeem-side quote into shares per underlying token.
+const redeemQuotePrecision = 18
 
 var (
 	erc4626ABI abi.ABI
@@ -34,98 +48,492 @@ func init() {
 
 // OfficialOptions parameterise the on-chain fetcher.
 type OfficialOptions struct {
-	RPCURL       string
-	SUSDEAddress string
-	Timeout      time.Duration
+	// RPCURLs lists the Ethereum endpoints to fail over between.
+	RPCURLs      []string
+	VaultAddress string
+	// UnderlyingAddress, when set, must match the vault's asset().
+	UnderlyingAddress string
+	// Method is the ERC-4626 view quoted for the rate; empty means
+	// previewDeposit.
+	Method string
+	// Quorum, when above one, accepts a rate only once that many endpoints
+	// return it for the same block.
+	Quorum int
+	// MulticallAddress overrides the Multicall3 contract reads are batched
+	// through; empty means the canonical deployment.
+	MulticallAddress string
+	Timeout          time.Duration
+	// RateLimit caps RPC requests per second; zero disables limiting.
+	RateLimit float64
 }
 
 // Official provides access to the official rate via Ethereum RPC.
 type Official struct {
-	opts      OfficialOptions
-	logger    zerolog.Logger
-	client    *ethclient.Client
-	clientMux sync.Mutex
+	opts    OfficialOptions
+	logger  zerolog.Logger
+	pool    *rpcPool
+	limiter *rateLimiter
+
+	info    *vaultInfo
+	infoMux sync.Mutex
+
+	blockTimes   map[uint64]uint64
+	blockTimeMux sync.Mutex
 }
 
+// vaultInfo holds the vault metadata needed to scale quotes. It is read once
+// and assumed immutable.
+type vaultInfo struct {
+	asset         common.Address
+	assetDecimals uint8
+	shareDecimals uint8
+}
+
+// maxCachedBlockTimes bounds the header timestamp cache used by BlockAtTime.
+const maxCachedBlockTimes = 4096
+
 // NewOfficial builds a new official rate fetcher.
 func NewOfficial(opts OfficialOptions, logger zerolog.Logger) *Official {
-	return &Official{opts: opts, logger: logger.With().Str("component", "official_fetcher").Logger()}
+	logger = logger.With().Str("component", "official_fetcher").Logger()
+	return &Official{
+		opts:       opts,
+		logger:     logger,
+		pool:       newRPCPool(opts.RPCURLs, logger),
+		limiter:    newRateLimiter(opts.RateLimit),
+		blockTimes: make(map[uint64]uint64),
+	}
+}
+
+// FetchOfficial retrieves the official vault shares per underlying token.
+// The head block is pinned first and every read is made at its hash, so the
+// rate, number, hash and timestamp all describe the same block.
+func (o *Official) FetchOfficial(ctx context.Context) (OfficialQuote, error) {
+	return o.fetchAt(ctx, nil)
+}
+
+// FetchOfficialAt retrieves the official rate as of blockNumber.
+// Blocks older than the node's pruning window need an archive RPC endpoint.
+func (o *Official) FetchOfficialAt(ctx context.Context, blockNumber uint64) (OfficialQuote, error) {
+	quote, err := o.fetchAt(ctx, new(big.Int).SetUint64(blockNumber))
+	if err != nil {
+		return OfficialQuote{}, fmt.Errorf("%s at block %d: %w", o.method(), blockNumber, err)
+	}
+	return quote, nil
 }
 
-// FetchOfficial retrieves the official sUSDe/USDe rate.
-func (o *Official) FetchOfficial(ctx context.Context) (decimal.Decimal, uint64, error) {
-	if o.opts.RPCURL == "" {
-		return decimal.Decimal{}, 0, errors.New("ethereum rpc url not configured")
+// fetchAt pins the block with the given number, or the head when nil, and
+// quotes the rate at its hash on the healthiest endpoint that answers.
+func (o *Official) fetchAt(ctx context.Context, number *big.Int) (OfficialQuote, error) {
+	if err := o.validate(); err != nil {
+		return OfficialQuote{}, err
 	}
-	if o.opts.SUSDEAddress == "" {
-		return decimal.Decimal{}, 0, errors.New("susde contract address not configured")
+	if o.opts.Quorum > 1 {
+		return o.fetchQuorum(ctx, number)
 	}
 
-	timeout := o.opts.Timeout
-	if timeout <= 0 {
-		timeout = 10 * time.Second
+	var quote OfficialQuote
+	err := o.pool.do(ctx, o.timeout(), func(ctx context.Context, client *ethclient.Client) error {
+		var err error
+		quote, err = o.readAt(ctx, client, number)
+		return err
+	})
+	if err != nil {
+		return OfficialQuote{}, err
 	}
+	return quote, nil
+}
 
-	var cancel context.CancelFunc
-	ctx, cancel = context.WithTimeout(ctx, timeout)
-	defer cancel()
+// fetchQuorum reads the rate at one block height from every endpoint and
+// accepts it once Quorum endpoints agree on both the block hash and the rate.
+// The head is pinned on the healthiest endpoint; endpoints that have not seen
+// that block yet simply do not count towards the quorum.
+func (o *Official) fetchQuorum(ctx context.Context, number *big.Int) (OfficialQuote, error) {
+	if number == nil {
+		err := o.pool.do(ctx, o.timeout(), func(ctx context.Context, client *ethclient.Client) error {
+			head, err := o.pinBlock(ctx, client, nil)
+			number = new(big.Int).SetUint64(uint64(head.Number))
+			return err
+		})
+		if err != nil {
+			return OfficialQuote{}, err
+		}
+	}
 
-	client, err := o.getClient(ctx)
-	if err != nil {
-		return decimal.Decimal{}, 0, err
+	quotes := make([]OfficialQuote, o.pool.size())
+	errs := o.pool.all(ctx, o.timeout(), func(ctx context.Context, i int, client *ethclient.Client) error {
+		var err error
+		quotes[i], err = o.readAt(ctx, client, number)
+		return err
+	})
+	if err := ctx.Err(); err != nil {
+		return OfficialQuote{}, err
 	}
 
-	addr := common.HexToAddress(o.opts.SUSDEAddress)
-	assets := new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil)
+	quote, agreeing := agreeingQuote(quotes, errs)
+	if agreeing < o.opts.Quorum {
+		return OfficialQuote{}, fmt.Errorf("rpc quorum not reached at block %s: %d of %d endpoints agree, need %d: %w",
+			number, agreeing, o.pool.size(), o.opts.Quorum, errors.Join(errs...))
+	}
+	return quote, nil
+}
 
-	payload, err := erc4626ABI.Pack("previewDeposit", assets)
+// agreeingQuote returns the quote most endpoints returned, matching on block
+// hash and rate, and how many endpoints returned it.
+func agreeingQuote(quotes []OfficialQuote, errs []error) (OfficialQuote, int) {
+	var (
+		best      OfficialQuote
+		bestCount int
+	)
+	for i, q := range quotes {
+		if errs[i] != nil {
+			continue
+		}
+		count := 0
+		for j, other := range quotes {
+			if errs[j] == nil && other.BlockHash == q.BlockHash && other.Rate.Equal(q.Rate) {
+				count++
+			}
+		}
+		if count > bestCount {
+			best, bestCount = q, count
+		}
+	}
+	return best, bestCount
+}
+
+// readAt pins the block with the given number, or the head when nil, and
+// quotes the rate at its hash through client.
+func (o *Official) readAt(ctx context.Context, client *ethclient.Client, number *big.Int) (OfficialQuote, error) {
+	block, err := o.pinBlock(ctx, client, number)
 	if err != nil {
-		return decimal.Decimal{}, 0, err
+		return OfficialQuote{}, err
 	}
 
-	res, err := client.CallContract(ctx, ethereum.CallMsg{To: &addr, Data: payload}, nil)
+	rate, err := o.quote(ctx, client, block.Hash)
 	if err != nil {
-		return decimal.Decimal{}, 0, err
+		return OfficialQuote{}, err
 	}
 
-	outputs, err := erc4626ABI.Unpack("previewDeposit", res)
+	return OfficialQuote{
+		Rate:        rate,
+		BlockNumber: uint64(block.Number),
+		BlockHash:   block.Hash.Hex(),
+		BlockTime:   time.Unix(int64(block.Time), 0).UTC(),
+		Method:      o.method(),
+	}, nil
+}
+
+// pinnedBlock is the subset of an eth_getBlockByNumber result that
+// identifies the block a quote is read at. The hash is taken from the node
+// rather than recomputed from the header fields.
+type pinnedBlock struct {
+	Number hexutil.Uint64 `json:"number"`
+	Hash   common.Hash    `json:"hash"`
+	Time   hexutil.Uint64 `json:"timestamp"`
+}
+
+func (o *Official) pinBlock(ctx context.Context, client *ethclient.Client, number *big.Int) (pinnedBlock, error) {
+	tag := "latest"
+	if number != nil {
+		tag = hexutil.EncodeBig(number)
+	}
+
+	if err := o.limiter.Wait(ctx); err != nil {
+		return pinnedBlock{}, err
+	}
+	var block *pinnedBlock
+	if err := client.Client().CallContext(ctx, &block, "eth_getBlockByNumber", tag, false); err != nil {
+		return pinnedBlock{}, fmt.Errorf("fetch %s block: %w", tag, err)
+	}
+	if block == nil {
+		return pinnedBlock{}, fmt.Errorf("fetch %s block: %w", tag, ethereum.NotFound)
+	}
+	o.rememberBlockTime(uint64(block.Number), uint64(block.Time))
+	return *block, nil
+}
+
+// BlockAtTime finds the latest block whose timestamp is not after ts.
+// It interpolates on block timestamps and falls back to bisection, caching
+// probed headers so consecutive buckets resolve in a handful of calls.
+func (o *Official) BlockAtTime(ctx context.Context, ts time.Time) (uint64, error) {
+	var number uint64
+	err := o.pool.do(ctx, 0, func(ctx context.Context, client *ethclient.Client) error {
+		var err error
+		number, err = o.blockAtTime(ctx, client, ts)
+		return err
+	})
+	return number, err
+}
+
+func (o *Official) blockAtTime(ctx context.Context, client *ethclient.Client, ts time.Time) (uint64, error) {
+	target := uint64(ts.Unix())
+
+	latest, err := o.headerByNumber(ctx, client, nil)
 	if err != nil {
-		return decimal.Decimal{}, 0, err
+		return 0, fmt.Errorf("fetch latest header: %w", err)
+	}
+	hi, hiTime := latest.Number.Uint64(), latest.Time
+	o.rememberBlockTime(hi, hiTime)
+	if hiTime <= target {
+		return hi, nil
+	}
+
+	lo, loTime := o.closestCachedBelow(target)
+	if lo == 0 {
+		loTime, err = o.blockTime(ctx, client, 0)
+		if err != nil {
+			return 0, err
+		}
+		if loTime > target {
+			return 0, fmt.Errorf("timestamp %s precedes genesis block", ts.UTC().Format(time.RFC3339))
+		}
 	}
 
-	if len(outputs) != 1 {
-		return decimal.Decimal{}, 0, errors.New("unexpected previewDeposit response")
+	return searchBlock(target, lo, loTime, hi, hiTime, func(number uint64) (uint64, error) {
+		return o.blockTime(ctx, client, number)
+	})
+}
+
+// searchBlock narrows [lo, hi] down to the last block whose timestamp is not
+// after target. Callers must guarantee time(lo) <= target < time(hi).
+func searchBlock(target, lo, loTime, hi, hiTime uint64, timeOf func(uint64) (uint64, error)) (uint64, error) {
+	bisect := false
+	for hi-lo > 1 {
+		var mid uint64
+		if bisect || hiTime <= loTime {
+			mid = lo + (hi-lo)/2
+		} else {
+			mid = lo + (target-loTime)*(hi-lo)/(hiTime-loTime)
+		}
+		if mid <= lo {
+			mid = lo + 1
+		}
+		if mid >= hi {
+			mid = hi - 1
+		}
+
+		midTime, err := timeOf(mid)
+		if err != nil {
+			return 0, err
+		}
+
+		width := hi - lo
+		if midTime <= target {
+			lo, loTime = mid, midTime
+		} else {
+			hi, hiTime = mid, midTime
+		}
+		// Fall back to bisection whenever interpolation fails to halve the range.
+		bisect = !bisect && hi-lo > width/2
 	}
 
-	shares, ok := outputs[0].(*big.Int)
-	if !ok {
-		return decimal.Decimal{}, 0, errors.New("failed to decode previewDeposit output")
+	return lo, nil
+}
+
+func (o *Official) validate() error {
+	if len(o.opts.RPCURLs) == 0 {
+		return errors.New("ethereum rpc url not configured")
+	}
+	if o.opts.Quorum > len(o.opts.RPCURLs) {
+		return fmt.Errorf("rpc quorum %d exceeds the %d configured endpoints", o.opts.Quorum, len(o.opts.RPCURLs))
+	}
+	if o.opts.VaultAddress == "" {
+		return errors.New("vault contract address not configured")
+	}
+	switch o.method() {
+	case MethodPreviewDeposit, MethodConvertToShares, MethodConvertToAssets, MethodPreviewRedeem:
+	default:
+		return fmt.Errorf("unsupported official rate method %q", o.opts.Method)
+	}
+	return nil
+}
+
+func (o *Official) method() string {
+	if o.opts.Method == "" {
+		return MethodPreviewDeposit
 	}
+	return o.opts.Method
+}
 
-	official := decimal.NewFromBigInt(shares, -18)
+func (o *Official) timeout() time.Duration {
+	if o.opts.Timeout <= 0 {
+		return 10 * time.Second
+	}
+	return o.opts.Timeout
+}
 
-	blockNumber, err := client.BlockNumber(ctx)
+// quote reads one unit through the configured method and scales the result
+// by the discovered decimals. Reads needed for every sample share one batch,
+// so adding another on-chain input does not add a round trip.
+func (o *Official) quote(ctx context.Context, client *ethclient.Client, block common.Hash) (decimal.Decimal, error) {
+	caller := limitedCaller{client: client, limiter: o.limiter}
+	info, err := o.vaultInfo(ctx, caller, block)
 	if err != nil {
-		return decimal.Decimal{}, 0, err
+		return decimal.Decimal{}, err
 	}
 
-	return official, blockNumber, nil
+	method := o.method()
+	batch := NewBatch(o.multicall())
+	shares := Queue[*big.Int](batch, common.HexToAddress(o.opts.VaultAddress), &erc4626ABI, method, quoteInput(method, info))
+	if err := batch.Execute(ctx, caller, block); err != nil {
+		return decimal.Decimal{}, err
+	}
+
+	amount, err := shares.Get()
+	if err != nil {
+		return decimal.Decimal{}, err
+	}
+	return quoteRate(method, amount, info)
 }
 
-func (o *Official) getClient(ctx context.Context) (*ethclient.Client, error) {
-	o.clientMux.Lock()
-	defer o.clientMux.Unlock()
+// vaultInfo discovers the vault's asset and the decimals of both tokens on
+// first use, reading them at block.
+func (o *Official) vaultInfo(ctx context.Context, caller BlockCaller, block common.Hash) (vaultInfo, error) {
+	o.infoMux.Lock()
+	defer o.infoMux.Unlock()
 
-	if o.client != nil {
-		return o.client, nil
+	if o.info != nil {
+		return *o.info, nil
 	}
 
-	client, err := ethclient.DialContext(ctx, o.opts.RPCURL)
+	vault := common.HexToAddress(o.opts.VaultAddress)
+	batch := NewBatch(o.multicall())
+	assetResult := Queue[common.Address](batch, vault, &erc4626ABI, "asset")
+	shareResult := Queue[uint8](batch, vault, &erc4626ABI, "decimals")
+	if err := batch.Execute(ctx, caller, block); err != nil {
+		return vaultInfo{}, fmt.Errorf("read vault metadata: %w", err)
+	}
+	asset, err := assetResult.Get()
+	if err != nil {
+		return vaultInfo{}, fmt.Errorf("read vault asset: %w", err)
+	}
+	if o.opts.UnderlyingAddress != "" && asset != common.HexToAddress(o.opts.UnderlyingAddress) {
+		return vaultInfo{}, contractErr(fmt.Errorf("vault asset %s does not match underlying address %s", asset.Hex(), o.opts.UnderlyingAddress))
+	}
+	shareDecimals, err := shareResult.Get()
 	if err != nil {
+		return vaultInfo{}, fmt.Errorf("read vault decimals: %w", err)
+	}
+
+	// The asset's decimals need its address, so they take a second call.
+	batch = NewBatch(o.multicall())
+	assetDecimalsResult := Queue[uint8](batch, asset, &erc4626ABI, "decimals")
+	if err := batch.Execute(ctx, caller, block); err != nil {
+		return vaultInfo{}, fmt.Errorf("read asset decimals: %w", err)
+	}
+	assetDecimals, err := assetDecimalsResult.Get()
+	if err != nil {
+		return vaultInfo{}, fmt.Errorf("read asset decimals: %w", err)
+	}
+
+	info := vaultInfo{asset: asset, assetDecimals: assetDecimals, shareDecimals: shareDecimals}
+	o.info = &info
+	o.logger.Info().
+		Str("asset", asset.Hex()).
+		Uint8("asset_decimals", assetDecimals).
+		Uint8("share_decimals", shareDecimals).
+		Str("method", o.method()).
+		Msg("vault metadata discovered")
+	return info, nil
+}
+
+func (o *Official) multicall() common.Address {
+	if o.opts.MulticallAddress == "" {
+		return common.HexToAddress(Multicall3Address)
+	}
+	return common.HexToAddress(o.opts.MulticallAddress)
+}
+
+// limitedCaller spends one rate-limit slot per eth_call.
+type limitedCaller struct {
+	client  *ethclient.Client
+	limiter *rateLimiter
+}
+
+func (c limitedCaller) CallContractAtHash(ctx context.Context, msg ethereum.CallMsg, blockHash common.Hash) ([]byte, error) {
+	if err := c.limiter.Wait(ctx); err != nil {
 		return nil, err
 	}
-	o.client = client
-	return client, nil
+	return c.client.CallContractAtHash(ctx, msg, blockHash)
 }
 
-var _ OfficialRateFetcher = (*Official)(nil)
+// redeemSide reports whether method quotes shares into underlying tokens.
+func redeemSide(method string) bool {
+	return method == MethodConvertToAssets || method == MethodPreviewRedeem
+}
+
+// quoteInput is one whole token of the side method takes as input.
+func quoteInput(method string, info vaultInfo) *big.Int {
+	decimals := info.assetDecimals
+	if redeemSide(method) {
+		decimals = info.shareDecimals
+	}
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)
+}
+
+// quoteRate turns the raw output for quoteInput into shares per underlying
+// token.
+func quoteRate(method string, amount *big.Int, info vaultInfo) (decimal.Decimal, error) {
+	if !redeemSide(method) {
+		return decimal.NewFromBigInt(amount, -int32(info.shareDecimals)), nil
+	}
+	if amount.Sign() == 0 {
+		return decimal.Decimal{}, contractErr(fmt.Errorf("%s returned zero assets", method))
+	}
+	assets := decimal.NewFromBigInt(amount, -int32(info.assetDecimals))
+	return decimal.NewFromInt(1).DivRound(assets, redeemQuotePrecision), nil
+}
+
+func (o *Official) headerByNumber(ctx context.Context, client *ethclient.Client, number *big.Int) (*types.Header, error) {
+	if err := o.limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, o.timeout())
+	defer cancel()
+	return client.HeaderByNumber(ctx, number)
+}
+
+func (o *Official) blockTime(ctx context.Context, client *ethclient.Client, number uint64) (uint64, error) {
+	o.blockTimeMux.Lock()
+	cached, ok := o.blockTimes[number]
+	o.blockTimeMux.Unlock()
+	if ok {
+		return cached, nil
+	}
+
+	header, err := o.headerByNumber(ctx, client, new(big.Int).SetUint64(number))
+	if err != nil {
+		return 0, fmt.Errorf("fetch header %d: %w", number, err)
+	}
+	o.rememberBlockTime(number, header.Time)
+	return header.Time, nil
+}
+
+func (o *Official) rememberBlockTime(number, ts uint64) {
+	o.blockTimeMux.Lock()
+	defer o.blockTimeMux.Unlock()
+	if len(o.blockTimes) >= maxCachedBlockTimes {
+		o.blockTimes = make(map[uint64]uint64)
+	}
+	o.blockTimes[number] = ts
+}
+
+// closestCachedBelow returns the highest cached block whose timestamp is not
+// after target, or zero when nothing useful is cached.
+func (o *Official) closestCachedBelow(target uint64) (uint64, uint64) {
+	o.blockTimeMux.Lock()
+	defer o.blockTimeMux.Unlock()
+
+	var best, bestTime uint64
+	for number, ts := range o.blockTimes {
+		if ts <= target && number > best {
+			best, bestTime = number, ts
+		}
+	}
+	return best, bestTime
+}
+
+var (
+	_ OfficialRateFetcher = (*Official)(nil)
+	_ BlockLocator        = (*Official)(nil)
+)
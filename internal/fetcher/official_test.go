@@ -2,17 +2,356 @@ package fetcher
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/shopspring/decimal"
 )
 
+// fakeNode serves the JSON-RPC calls the official fetcher makes against a
+// vault with 18-decimal shares and asset.
+type fakeNode struct {
+	mu     sync.Mutex
+	head   pinnedBlock
+	asset  common.Address
+	shares *big.Int
+	// down makes every request fail with HTTP 503.
+	down bool
+	// noMulticall leaves the Multicall3 address without code.
+	noMulticall bool
+	// callBlocks records the block selector of every eth_call.
+	callBlocks []string
+	requests   int
+}
+
+func (n *fakeNode) start(t *testing.T) string {
+	t.Helper()
+	srv := httptest.NewServer(n)
+	t.Cleanup(srv.Close)
+	return srv.URL
+}
+
+func (n *fakeNode) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID     json.RawMessage   `json:"id"`
+		Method string            `json:"method"`
+		Params []json.RawMessage `json:"params"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.requests++
+	if n.down {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		return
+	}
+
+	resp := map[string]any{"jsonrpc": "2.0", "id": req.ID}
+	switch req.Method {
+	case "eth_getBlockByNumber":
+		resp["result"] = n.head
+	case "eth_call":
+		var call struct {
+			To    common.Address `json:"to"`
+			Input hexutil.Bytes  `json:"input"`
+		}
+		_ = json.Unmarshal(req.Params[0], &call)
+		n.callBlocks = append(n.callBlocks, string(req.Params[1]))
+		var (
+			out []byte
+			err error
+		)
+		if call.To == common.HexToAddress(Multicall3Address) {
+			out, err = n.aggregate(call.Input)
+		} else {
+			out, err = n.answer(call.Input)
+		}
+		if err != nil {
+			resp["error"] = map[string]any{"code": 3, "message": err.Error()}
+		} else {
+			resp["result"] = hexutil.Bytes(out)
+		}
+	default:
+		resp["error"] = map[string]any{"code": -32601, "message": "method not found"}
+	}
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// aggregate answers a Multicall3 aggregate3 call, reporting calls the node
+// cannot answer as failed.
+func (n *fakeNode) aggregate(input []byte) ([]byte, error) {
+	if n.noMulticall {
+		return nil, nil
+	}
+	method := multicall3ABI.Methods["aggregate3"]
+	args, err := method.Inputs.Unpack(input[4:])
+	if err != nil {
+		return nil, err
+	}
+	calls := *abi.ConvertType(args[0], new([]multicallCall)).(*[]multicallCall)
+	results := make([]multicallResult, len(calls))
+	for i, c := range calls {
+		out, err := n.answer(c.CallData)
+		results[i] = multicallResult{Success: err == nil, ReturnData: out}
+	}
+	return method.Outputs.Pack(results)
+}
+
+func (n *fakeNode) answer(input []byte) ([]byte, error) {
+	method, err := erc4626ABI.MethodById(input)
+	if err != nil {
+		return nil, errors.New("execution reverted")
+	}
+	switch method.Name {
+	case "asset":
+		return method.Outputs.Pack(n.asset)
+	case "decimals":
+		return method.Outputs.Pack(uint8(18))
+	case MethodPreviewDeposit, MethodConvertToShares, MethodConvertToAssets, MethodPreviewRedeem:
+		return method.Outputs.Pack(n.shares)
+	default:
+		return nil, errors.New("execution reverted")
+	}
+}
+
 func TestOfficialMissingConfig(t *testing.T) {
 	off := NewOfficial(OfficialOptions{}, noopLogger())
-	if _, _, err := off.FetchOfficial(context.Background()); err == nil {
+	if _, err := off.FetchOfficial(context.Background()); err == nil {
 		t.Fatal("未配置 RPC 时应报错")
 	}
 
-	off = NewOfficial(OfficialOptions{RPCURL: "http://localhost"}, noopLogger())
-	if _, _, err := off.FetchOfficial(context.Background()); err == nil {
+	off = NewOfficial(OfficialOptions{RPCURLs: []string{"http://localhost"}}, noopLogger())
+	if _, err := off.FetchOfficial(context.Background()); err == nil {
 		t.Fatal("缺少合约地址应报错")
 	}
+
+	off = NewOfficial(OfficialOptions{RPCURLs: []string{"http://localhost"}, VaultAddress: "0x01", Method: "totalAssets"}, noopLogger())
+	if _, err := off.FetchOfficial(context.Background()); err == nil {
+		t.Fatal("不支持的报价方法应报错")
+	}
+}
+
+func TestFetchOfficialReadsAtPinnedBlock(t *testing.T) {
+	shares, _ := new(big.Int).SetString("800000000000000000", 10)
+	node := &fakeNode{
+		head: pinnedBlock{
+			Number: 21_000_000,
+			Hash:   common.HexToHash("0xfeed"),
+			Time:   1_700_000_000,
+		},
+		asset:  common.HexToAddress("0x02"),
+		shares: shares,
+	}
+	off := NewOfficial(OfficialOptions{
+		RPCURLs:           []string{node.start(t)},
+		VaultAddress:      "0x01",
+		UnderlyingAddress: "0x02",
+	}, noopLogger())
+
+	quote, err := off.FetchOfficial(context.Background())
+	if err != nil {
+		t.Fatalf("FetchOfficial 不应报错: %v", err)
+	}
+	if !quote.Rate.Equal(decimal.RequireFromString("0.8")) || quote.Method != MethodPreviewDeposit {
+		t.Fatalf("汇率或报价方法不正确: %+v", quote)
+	}
+	if quote.BlockNumber != 21_000_000 || quote.BlockHash != node.head.Hash.Hex() || !quote.BlockTime.Equal(time.Unix(1_700_000_000, 0)) {
+		t.Fatalf("区块信息应来自固定的区块头: %+v", quote)
+	}
+
+	want := `{"blockHash":"` + node.head.Hash.Hex() + `"}`
+	if len(node.callBlocks) == 0 {
+		t.Fatal("应至少发起一次 eth_call")
+	}
+	for _, got := range node.callBlocks {
+		if got != want {
+			t.Fatalf("所有 eth_call 应固定在同一区块哈希: %s", got)
+		}
+	}
+}
+
+func TestFetchOfficialRejectsMismatchedAsset(t *testing.T) {
+	node := &fakeNode{
+		head:   pinnedBlock{Number: 1, Hash: common.HexToHash("0x01")},
+		asset:  common.HexToAddress("0x03"),
+		shares: big.NewInt(1),
+	}
+	second := &fakeNode{head: node.head, asset: node.asset, shares: node.shares}
+	off := NewOfficial(OfficialOptions{
+		RPCURLs:           []string{node.start(t), second.start(t)},
+		VaultAddress:      "0x01",
+		UnderlyingAddress: "0x02",
+	}, noopLogger())
+
+	if _, err := off.FetchOfficial(context.Background()); err == nil {
+		t.Fatal("金库 asset() 与配置的底层代币不一致时应报错")
+	}
+	// 换哪个节点结果都一样：不应切换节点，也不应扣分或断开连接。
+	second.mu.Lock()
+	defer second.mu.Unlock()
+	if second.requests != 0 {
+		t.Fatalf("合约层面的错误不应切换到其他节点, 实际请求 %d 次", second.requests)
+	}
+	if ep := off.pool.endpoints[0]; ep.score != 1 || ep.client == nil {
+		t.Fatalf("合约层面的错误不应降低节点健康分或断开连接: score=%v client=%v", ep.score, ep.client)
+	}
+}
+
+// newVaultNode returns a healthy node at head with the given
+// previewDeposit output for 1e18 assets.
+func newVaultNode(head uint64, shares string) *fakeNode {
+	out, _ := new(big.Int).SetString(shares, 10)
+	return &fakeNode{
+		head:   pinnedBlock{Number: hexutil.Uint64(head), Hash: common.BigToHash(new(big.Int).SetUint64(head)), Time: 1_700_000_000},
+		asset:  common.HexToAddress("0x02"),
+		shares: out,
+	}
+}
+
+func TestFetchOfficialFailsOverToHealthyEndpoint(t *testing.T) {
+	flaky := newVaultNode(100, "800000000000000000")
+	flaky.down = true
+	healthy := newVaultNode(100, "800000000000000000")
+
+	off := NewOfficial(OfficialOptions{
+		RPCURLs:      []string{flaky.start(t), healthy.start(t)},
+		VaultAddress: "0x01",
+	}, noopLogger())
+
+	for i := 0; i < 3; i++ {
+		quote, err := off.FetchOfficial(context.Background())
+		if err != nil {
+			t.Fatalf("第 %d 次读取应切换到健康节点: %v", i+1, err)
+		}
+		if !quote.Rate.Equal(decimal.RequireFromString("0.8")) {
+			t.Fatalf("汇率不正确: %s", quote.Rate)
+		}
+	}
+	flaky.mu.Lock()
+	if flaky.requests != 1 {
+		t.Fatalf("失败过的节点健康分应降低、不再被优先尝试, 实际请求 %d 次", flaky.requests)
+	}
+	flaky.down = false
+	flaky.mu.Unlock()
+	healthy.mu.Lock()
+	healthy.down = true
+	healthy.mu.Unlock()
+	if _, err := off.FetchOfficial(context.Background()); err != nil {
+		t.Fatalf("原先失败的节点恢复后应重新连接并可用: %v", err)
+	}
+}
+
+func TestFetchOfficialQuorum(t *testing.T) {
+	a := newVaultNode(100, "800000000000000000")
+	b := newVaultNode(100, "800000000000000000")
+	c := newVaultNode(100, "810000000000000000")
+	urls := []string{a.start(t), b.start(t), c.start(t)}
+
+	off := NewOfficial(OfficialOptions{RPCURLs: urls, VaultAddress: "0x01", Quorum: 2}, noopLogger())
+	quote, err := off.FetchOfficial(context.Background())
+	if err != nil {
+		t.Fatalf("两个节点一致时应满足 quorum=2: %v", err)
+	}
+	if !quote.Rate.Equal(decimal.RequireFromString("0.8")) || quote.BlockNumber != 100 {
+		t.Fatalf("应采用多数节点的结果: %+v", quote)
+	}
+
+	off = NewOfficial(OfficialOptions{RPCURLs: urls, VaultAddress: "0x01", Quorum: 3}, noopLogger())
+	if _, err := off.FetchOfficial(context.Background()); err == nil {
+		t.Fatal("只有两个节点一致时 quorum=3 应报错")
+	}
+
+	off = NewOfficial(OfficialOptions{RPCURLs: urls[:1], VaultAddress: "0x01", Quorum: 2}, noopLogger())
+	if _, err := off.FetchOfficial(context.Background()); err == nil {
+		t.Fatal("quorum 超过节点数时应报错")
+	}
+}
+
+func TestQuoteRateScalesByDiscoveredDecimals(t *testing.T) {
+	// 6 位小数的底层代币、18 位小数的金库份额，1 份额约值 1.05 底层代币。
+	info := vaultInfo{assetDecimals: 6, shareDecimals: 18}
+
+	if got := quoteInput(MethodConvertToShares, info); got.String() != "1000000" {
+		t.Fatalf("存入方向应输入 1 个底层代币, 实际 %s", got)
+	}
+	if got := quoteInput(MethodPreviewRedeem, info); got.String() != "1000000000000000000" {
+		t.Fatalf("赎回方向应输入 1 个份额, 实际 %s", got)
+	}
+
+	shares, _ := new(big.Int).SetString("952380952380952380", 10)
+	rate, err := quoteRate(MethodConvertToShares, shares, info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rate.String() != "0.95238095238095238" {
+		t.Fatalf("convertToShares 汇率不正确: %s", rate)
+	}
+
+	rate, err = quoteRate(MethodConvertToAssets, big.NewInt(1_050_000), info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := decimal.RequireFromString("0.952380952380952381"); !rate.Equal(want) {
+		t.Fatalf("convertToAssets 汇率应取倒数: 期望 %s, 实际 %s", want, rate)
+	}
+
+	if _, err := quoteRate(MethodPreviewRedeem, big.NewInt(0), info); err == nil {
+		t.Fatal("赎回得到 0 资产时应报错")
+	}
+}
+
+func TestSearchBlockFindsLastBlockNotAfterTarget(t *testing.T) {
+	// 区块间隔 12 秒，但 100-199 之间缺失了若干 slot。
+	timeOf := func(n uint64) (uint64, error) {
+		ts := 1_000 + n*12
+		if n >= 150 {
+			ts += 120
+		}
+		return ts, nil
+	}
+
+	cases := []struct {
+		target uint64
+		want   uint64
+	}{
+		{target: 1_000, want: 0},
+		{target: 1_011, want: 0},
+		{target: 1_012, want: 1},
+		{target: 1_000 + 149*12 + 60, want: 149},
+		{target: 1_000 + 150*12 + 120, want: 150},
+		{target: 1_000 + 998*12 + 120 + 5, want: 998},
+	}
+
+	hiTime, _ := timeOf(999)
+	for _, tc := range cases {
+		calls := 0
+		counting := func(n uint64) (uint64, error) {
+			calls++
+			return timeOf(n)
+		}
+		got, err := searchBlock(tc.target, 0, 1_000, 999, hiTime, counting)
+		if err != nil {
+			t.Fatalf("searchBlock 不应报错: %v", err)
+		}
+		if got != tc.want {
+			t.Fatalf("target %d: 期望区块 %d, 实际 %d", tc.target, tc.want, got)
+		}
+		if calls > 24 {
+			t.Fatalf("target %d: 查询次数过多 %d", tc.target, calls)
+		}
+	}
 }
@@ -0,0 +1,30 @@
+package fetcher
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterSpacesCalls(t *testing.T) {
+	limiter := newRateLimiter(50)
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		if err := limiter.Wait(context.Background()); err != nil {
+			t.Fatalf("Wait 不应报错: %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 55*time.Millisecond {
+		t.Fatalf("4 次调用在 50 rps 下至少需要 60ms, 实际 %s", elapsed)
+	}
+}
+
+func TestRateLimiterNilNeverBlocks(t *testing.T) {
+	var limiter *rateLimiter
+	if newRateLimiter(0) != nil {
+		t.Fatal("rps<=0 应返回 nil")
+	}
+	if err := limiter.Wait(context.Background()); err != nil {
+		t.Fatalf("nil limiter 不应报错: %v", err)
+	}
+}
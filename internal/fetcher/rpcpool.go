@@ -0,0 +1,199 @@
+package fetcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/rs/zerolog"
+)
+
+// rpcScoreWeight is the weight of the newest outcome in an endpoint's health
+// score; the score is an exponentially weighted success rate in [0, 1].
+const rpcScoreWeight = 0.3
+
+// rpcPool fails over between Ethereum RPC endpoints. Attempts go to the
+// healthiest endpoints first, and a failed endpoint's client is closed and
+// redialled on next use so a connection that went bad is never reused.
+type rpcPool struct {
+	logger    zerolog.Logger
+	endpoints []*rpcEndpoint
+	mu        sync.Mutex
+}
+
+type rpcEndpoint struct {
+	url string
+	// label is the endpoint without path or credentials, safe to log.
+	label  string
+	client *ethclient.Client
+	score  float64
+}
+
+func newRPCPool(urls []string, logger zerolog.Logger) *rpcPool {
+	pool := &rpcPool{logger: logger}
+	for _, raw := range urls {
+		pool.endpoints = append(pool.endpoints, &rpcEndpoint{url: raw, label: endpointLabel(raw), score: 1})
+	}
+	return pool
+}
+
+// endpointLabel strips the path, query and user info that often carry API
+// keys.
+func endpointLabel(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return "rpc"
+	}
+	return u.Scheme + "://" + u.Host
+}
+
+func (p *rpcPool) size() int {
+	return len(p.endpoints)
+}
+
+// ranked orders the endpoints by descending health, keeping the configured
+// order between equally healthy ones.
+func (p *rpcPool) ranked() []*rpcEndpoint {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	ranked := append([]*rpcEndpoint(nil), p.endpoints...)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].score > ranked[j].score
+	})
+	return ranked
+}
+
+// client returns the endpoint's client, dialling it on first use or after a
+// failure dropped the previous one.
+func (p *rpcPool) client(ctx context.Context, ep *rpcEndpoint) (*ethclient.Client, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if ep.client != nil {
+		return ep.client, nil
+	}
+	client, err := ethclient.DialContext(ctx, ep.url)
+	if err != nil {
+		return nil, err
+	}
+	ep.client = client
+	return client, nil
+}
+
+// report folds an attempt's outcome into the endpoint's score. Failures drop
+// the client so the next attempt reconnects.
+func (p *rpcPool) report(ep *rpcEndpoint, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	outcome := 1.0
+	if err != nil {
+		outcome = 0
+		if ep.client != nil {
+			ep.client.Close()
+			ep.client = nil
+		}
+	}
+	ep.score = (1-rpcScoreWeight)*ep.score + rpcScoreWeight*outcome
+}
+
+// attempt runs fn against one endpoint, bounding it by timeout when positive.
+func (p *rpcPool) attempt(ctx context.Context, ep *rpcEndpoint, timeout time.Duration, fn func(context.Context, *ethclient.Client) error) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	client, err := p.client(ctx, ep)
+	if err != nil {
+		return err
+	}
+	return fn(ctx, client)
+}
+
+// contractError marks a failure of the contract call itself, such as a
+// revert, undecodable output or a vault that does not match the
+// configuration. Every endpoint would answer the same, so it says nothing
+// about the endpoint's health.
+type contractError struct {
+	err error
+}
+
+func (e *contractError) Error() string { return e.err.Error() }
+
+func (e *contractError) Unwrap() error { return e.err }
+
+func contractErr(err error) error {
+	return &contractError{err: err}
+}
+
+// nodeFailure reports whether err points at the endpoint rather than at the
+// contract call.
+func nodeFailure(err error) bool {
+	var ce *contractError
+	return err != nil && !errors.As(err, &ce) && !isRevert(err)
+}
+
+// do runs fn against the endpoints in health order until one succeeds. An
+// attempt cut short by ctx itself is not held against the endpoint, and a
+// contract failure is returned at once without failing over.
+func (p *rpcPool) do(ctx context.Context, timeout time.Duration, fn func(context.Context, *ethclient.Client) error) error {
+	if p.size() == 0 {
+		return errors.New("ethereum rpc url not configured")
+	}
+
+	var errs []error
+	for _, ep := range p.ranked() {
+		err := p.attempt(ctx, ep, timeout, fn)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil && !nodeFailure(err) {
+			return err
+		}
+		p.report(ep, err)
+		if err == nil {
+			return nil
+		}
+		p.logger.Warn().Err(err).Str("endpoint", ep.label).Msg("rpc endpoint failed")
+		errs = append(errs, fmt.Errorf("%s: %w", ep.label, err))
+	}
+	return errors.Join(errs...)
+}
+
+// all runs fn against every endpoint concurrently and returns each
+// endpoint's error in configured order. Contract failures are returned but
+// not held against the endpoint.
+func (p *rpcPool) all(ctx context.Context, timeout time.Duration, fn func(ctx context.Context, i int, client *ethclient.Client) error) []error {
+	errs := make([]error, len(p.endpoints))
+	var wg sync.WaitGroup
+	for i, ep := range p.endpoints {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = p.attempt(ctx, ep, timeout, func(ctx context.Context, client *ethclient.Client) error {
+				return fn(ctx, i, client)
+			})
+		}()
+	}
+	wg.Wait()
+
+	if ctx.Err() == nil {
+		for i, ep := range p.endpoints {
+			if errs[i] == nil || nodeFailure(errs[i]) {
+				p.report(ep, errs[i])
+			}
+			if errs[i] != nil {
+				p.logger.Warn().Err(errs[i]).Str("endpoint", ep.label).Msg("rpc endpoint failed")
+				errs[i] = fmt.Errorf("%s: %w", ep.label, errs[i])
+			}
+		}
+	}
+	return errs
+}
@@ -0,0 +1,120 @@
+package fetcher
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// HTTP 客户端在首次请求前不会建立连接，因此这些地址无需真实节点。
+var poolURLs = []string{"http://127.0.0.1:1/a", "http://127.0.0.1:2/b", "http://127.0.0.1:3/c"}
+
+func rankedLabels(p *rpcPool) []string {
+	var labels []string
+	for _, ep := range p.ranked() {
+		labels = append(labels, ep.label)
+	}
+	return labels
+}
+
+func TestRPCPoolRanksByHealthScore(t *testing.T) {
+	pool := newRPCPool(poolURLs, noopLogger())
+	a, b, c := pool.endpoints[0], pool.endpoints[1], pool.endpoints[2]
+	if a.label != "http://127.0.0.1:1" {
+		t.Fatalf("日志标签不应包含路径: %s", a.label)
+	}
+
+	got := rankedLabels(pool)
+	if got[0] != a.label || got[1] != b.label || got[2] != c.label {
+		t.Fatalf("健康分相同时应保持配置顺序: %v", got)
+	}
+
+	pool.report(a, errors.New("connection refused"))
+	pool.report(b, errors.New("connection refused"))
+	pool.report(b, errors.New("connection refused"))
+	got = rankedLabels(pool)
+	if got[0] != c.label || got[1] != a.label || got[2] != b.label {
+		t.Fatalf("应按健康分从高到低排序: %v", got)
+	}
+
+	// 成功会逐步恢复健康分。
+	for i := 0; i < 20; i++ {
+		pool.report(b, nil)
+	}
+	if got = rankedLabels(pool); got[1] != b.label || got[2] != a.label {
+		t.Fatalf("连续成功后健康分应回升并排到失败过的节点之前: %v", got)
+	}
+}
+
+func TestRPCPoolRedialsAfterFailure(t *testing.T) {
+	pool := newRPCPool(poolURLs[:1], noopLogger())
+	ep := pool.endpoints[0]
+	ctx := context.Background()
+
+	first, err := pool.client(ctx, ep)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := pool.client(ctx, ep); again != first {
+		t.Fatal("未失败时应复用同一连接")
+	}
+
+	pool.report(ep, nil)
+	if ep.client != first {
+		t.Fatal("成功不应断开连接")
+	}
+
+	pool.report(ep, errors.New("connection reset"))
+	if ep.client != nil {
+		t.Fatal("失败后应关闭并丢弃连接")
+	}
+	redialled, err := pool.client(ctx, ep)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if redialled == first {
+		t.Fatal("失败后下次使用应重新拨号")
+	}
+}
+
+func TestRPCPoolFailsOverOnlyOnNodeFailures(t *testing.T) {
+	pool := newRPCPool(poolURLs, noopLogger())
+	ctx := context.Background()
+
+	var tried []*ethclient.Client
+	err := pool.do(ctx, 0, func(ctx context.Context, client *ethclient.Client) error {
+		tried = append(tried, client)
+		if len(tried) == 1 {
+			return errors.New("503 service unavailable")
+		}
+		return nil
+	})
+	if err != nil || len(tried) != 2 {
+		t.Fatalf("节点故障应切换到下一个节点: tried=%d err=%v", len(tried), err)
+	}
+	if pool.endpoints[0].score >= 1 || pool.endpoints[1].score != 1 {
+		t.Fatalf("只有失败的节点应被扣分: %v %v", pool.endpoints[0].score, pool.endpoints[1].score)
+	}
+
+	tried = nil
+	reverted := errors.New("execution reverted")
+	err = pool.do(ctx, 0, func(ctx context.Context, client *ethclient.Client) error {
+		tried = append(tried, client)
+		return reverted
+	})
+	if !errors.Is(err, reverted) || len(tried) != 1 {
+		t.Fatalf("合约 revert 应直接返回而不切换节点: tried=%d err=%v", len(tried), err)
+	}
+
+	errs := pool.all(ctx, 0, func(ctx context.Context, i int, client *ethclient.Client) error {
+		if i == 2 {
+			return contractErr(errors.New("decode previewDeposit"))
+		}
+		return nil
+	})
+	if errs[2] == nil || pool.endpoints[2].score != 1 || pool.endpoints[2].client == nil {
+		t.Fatalf("合约错误应返回但不扣分、不断开: err=%v score=%v", errs[2], pool.endpoints[2].score)
+	}
+}
@@ -0,0 +1,122 @@
+// Package metrics exposes the watcher's own health as Prometheus metrics:
+// the latest rates and deviation, fetch latency and errors, fired alerts and
+// the last bucket that was recorded successfully. Every series carries the
+// pair id as the pair label.
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/shopspring/decimal"
+
+	"price-diff-alerts/internal/fetcher"
+	"price-diff-alerts/internal/storage"
+)
+
+const namespace = "usdewatcher"
+
+// Metrics owns a private registry so tests and multiple instances never
+// collide on the global default registry.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	officialRate  *prometheus.GaugeVec
+	marketRate    *prometheus.GaugeVec
+	deviationPct  *prometheus.GaugeVec
+	lastSuccess   *prometheus.GaugeVec
+	fetchDuration *prometheus.HistogramVec
+	fetchErrors   *prometheus.CounterVec
+	alertsFired   *prometheus.CounterVec
+}
+
+// New registers every collector, plus the Go runtime and process collectors.
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		officialRate: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "official_rate",
+			Help:      "Latest on-chain vault shares per underlying token.",
+		}, []string{"pair"}),
+		marketRate: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "market_rate",
+			Help:      "Latest CoW quote of vault shares per underlying token.",
+		}, []string{"pair"}),
+		deviationPct: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "deviation_pct",
+			Help:      "Latest market deviation from the official rate, in percent.",
+		}, []string{"pair"}),
+		lastSuccess: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "last_success_bucket_timestamp_seconds",
+			Help:      "Start of the last bucket recorded with both rates, as a unix timestamp.",
+		}, []string{"pair"}),
+		fetchDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "fetch_duration_seconds",
+			Help:      "Latency of rate fetches by fetcher.",
+			Buckets:   []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+		}, []string{"pair", "fetcher"}),
+		fetchErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "fetch_errors_total",
+			Help:      "Failed fetches by phase; cow_error_type classifies market failures.",
+		}, []string{"pair", "phase", "cow_error_type"}),
+		alertsFired: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "alerts_fired_total",
+			Help:      "Firing notifications committed, by deviation direction.",
+		}, []string{"pair", "direction"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.officialRate,
+		m.marketRate,
+		m.deviationPct,
+		m.lastSuccess,
+		m.fetchDuration,
+		m.fetchErrors,
+		m.alertsFired,
+	)
+	return m
+}
+
+// Handler serves the registry in the Prometheus exposition format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// ObserveFetch records how long one fetch of phase (official or market) took.
+func (m *Metrics) ObserveFetch(pairID, phase string, elapsed time.Duration) {
+	m.fetchDuration.WithLabelValues(pairID, phase).Observe(elapsed.Seconds())
+}
+
+// FetchFailed counts a failed leg. Official failures carry no CoW error type.
+func (m *Metrics) FetchFailed(pairID, phase string, err error) {
+	errType := ""
+	if phase == storage.SamplePhaseMarket {
+		errType = fetcher.ErrorType(err)
+	}
+	m.fetchErrors.WithLabelValues(pairID, phase, errType).Inc()
+}
+
+// SampleRecorded publishes the rates of a committed complete bucket.
+func (m *Metrics) SampleRecorded(pairID string, bucket time.Time, official, market, deviation decimal.Decimal) {
+	m.officialRate.WithLabelValues(pairID).Set(official.InexactFloat64())
+	m.marketRate.WithLabelValues(pairID).Set(market.InexactFloat64())
+	m.deviationPct.WithLabelValues(pairID).Set(deviation.InexactFloat64())
+	m.lastSuccess.WithLabelValues(pairID).Set(float64(bucket.Unix()))
+}
+
+// AlertFired counts one committed firing notification.
+func (m *Metrics) AlertFired(pairID, direction string) {
+	m.alertsFired.WithLabelValues(pairID, direction).Inc()
+}
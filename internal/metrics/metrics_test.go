@@ -0,0 +1,43 @@
+package metrics
+
+import (
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"price-diff-alerts/internal/fetcher"
+	"price-diff-alerts/internal/storage"
+)
+
+func TestHandlerExposesServiceMetrics(t *testing.T) {
+	m := New()
+	bucket := time.Date(2025, 9, 22, 10, 0, 0, 0, time.UTC)
+	m.ObserveFetch("usde-susde", storage.SamplePhaseOfficial, 120*time.Millisecond)
+	m.FetchFailed("usde-susde", storage.SamplePhaseMarket, &fetcher.APIError{Status: 400, Type: "NoLiquidity"})
+	m.FetchFailed("usde-susde", storage.SamplePhaseOfficial, errors.New("rpc down"))
+	m.SampleRecorded("usde-susde", bucket, decimal.RequireFromString("1.2"), decimal.RequireFromString("1.212"), decimal.RequireFromString("1"))
+	m.AlertFired("usde-susde", "up")
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body, _ := io.ReadAll(rec.Body)
+
+	for _, want := range []string{
+		`usdewatcher_fetch_duration_seconds_count{fetcher="official",pair="usde-susde"} 1`,
+		`usdewatcher_fetch_errors_total{cow_error_type="NoLiquidity",pair="usde-susde",phase="market"} 1`,
+		`usdewatcher_fetch_errors_total{cow_error_type="",pair="usde-susde",phase="official"} 1`,
+		`usdewatcher_deviation_pct{pair="usde-susde"} 1`,
+		`usdewatcher_last_success_bucket_timestamp_seconds{pair="usde-susde"} 1.7585352e+09`,
+		`usdewatcher_alerts_fired_total{direction="up",pair="usde-susde"} 1`,
+		`go_goroutines`,
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Fatalf("/metrics 缺少 %s:\n%s", want, body)
+		}
+	}
+}
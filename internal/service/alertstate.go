@@ -0,0 +1,259 @@
+package service
+
+import (
+	"math/bits"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"price-diff-alerts/internal/storage"
+)
+
+// alertPolicy holds the knobs that drive state transitions.
+type alertPolicy struct {
+	// breaches of the last window buckets must breach before firing.
+	breaches    int
+	window      int
+	minDuration time.Duration
+	cooldown    time.Duration
+	interval    time.Duration
+}
+
+// alertObservation is one evaluated bucket.
+type alertObservation struct {
+	bucket   time.Time
+	breached bool
+	// cleared is set once the deviation is back inside the clear threshold.
+	cleared   bool
+	direction string
+	deviation decimal.Decimal
+}
+
+// alertDecision tells the caller what the transition requires.
+type alertDecision struct {
+	from   string
+	to     string
+	notify bool
+	// suppressed is set when a confirmed breach is held back by the cooldown.
+	suppressed bool
+	// resolved is set when a notified firing episode ended with this
+	// observation; previous then describes that episode.
+	resolved bool
+	// previous is the state before the observation, used to close its episode.
+	previous storage.AlertState
+}
+
+// windowSize is the confirmation window, never smaller than breaches.
+func (p alertPolicy) windowSize() int {
+	if p.window < p.breaches {
+		return p.breaches
+	}
+	return p.window
+}
+
+func (d alertDecision) changed() bool {
+	return d.from != d.to
+}
+
+// advanceAlertState applies one observation to the state machine
+// ok → pending → firing → resolved → ok. A pending episode fires once
+// policy.breaches of the last policy.window buckets breached in the same
+// direction and it has lasted policy.minDuration. A firing episode resolves
+// only when the deviation clears (hysteresis) or flips direction, and a
+// direction is notified at most once per cooldown window.
+func advanceAlertState(st storage.AlertState, obs alertObservation, policy alertPolicy) (storage.AlertState, alertDecision) {
+	if st.State == "" {
+		st.State = storage.AlertStateOK
+	}
+	decision := alertDecision{from: st.State, previous: st}
+
+	st.BreachWindow = shiftWindow(st.BreachWindow, st.LastBucket, obs.bucket, policy)
+	bucket := obs.bucket
+	st.LastBucket = &bucket
+
+	switch st.State {
+	case storage.AlertStateFiring:
+		switch {
+		case obs.breached && obs.direction == st.Direction:
+			st.BreachWindow |= 1
+		case obs.breached:
+			// The deviation flipped sides: close this episode and start another.
+			startEpisode(&st, obs)
+		case obs.cleared:
+			st.State = storage.AlertStateResolved
+			st.BreachWindow = 0
+		}
+	case storage.AlertStatePending:
+		switch {
+		case st.BreachWindow == 0:
+			// No breach within the window (gap, restart): start over.
+			resetAlertState(&st)
+			if obs.breached {
+				startEpisode(&st, obs)
+			}
+		case obs.breached && obs.direction == st.Direction:
+			st.BreachWindow |= 1
+		case obs.breached:
+			startEpisode(&st, obs)
+		case !confirmationFeasible(st.BreachWindow, policy):
+			resetAlertState(&st)
+		}
+	default:
+		resetAlertState(&st)
+		if obs.breached {
+			startEpisode(&st, obs)
+		}
+	}
+
+	if st.State == storage.AlertStatePending || st.State == storage.AlertStateFiring {
+		trackPeak(&st, obs)
+	}
+	decision.resolved = decision.from == storage.AlertStateFiring && decision.previous.Notified &&
+		(st.State != storage.AlertStateFiring || !samePendingSince(st, decision.previous))
+
+	st.BreachWindow &= windowMask(policy.windowSize())
+	st.BreachCount = bits.OnesCount64(st.BreachWindow)
+
+	if st.State == storage.AlertStatePending && confirmed(st, bucket, policy) {
+		st.State = storage.AlertStateFiring
+		st.FiringSince = &bucket
+		st.Notified = false
+	}
+
+	if st.State == storage.AlertStateFiring && !st.Notified && obs.breached {
+		if cooldownElapsed(st, obs.direction, bucket, policy.cooldown) {
+			st.Notified = true
+			setLastAlert(&st, obs.direction, bucket)
+			decision.notify = true
+		} else {
+			decision.suppressed = true
+		}
+	}
+
+	decision.to = st.State
+	return st, decision
+}
+
+func startEpisode(st *storage.AlertState, obs alertObservation) {
+	resetAlertState(st)
+	bucket := obs.bucket
+	st.State = storage.AlertStatePending
+	st.Direction = obs.direction
+	st.PendingSince = &bucket
+	st.BreachWindow = 1
+	st.BreachCount = 1
+	st.PeakDeviationPct = decimal.NewNullDecimal(obs.deviation)
+}
+
+// trackPeak keeps the largest same-direction deviation of the open episode.
+func trackPeak(st *storage.AlertState, obs alertObservation) {
+	if obs.direction != st.Direction {
+		return
+	}
+	if !st.PeakDeviationPct.Valid || obs.deviation.Abs().GreaterThan(st.PeakDeviationPct.Decimal.Abs()) {
+		st.PeakDeviationPct = decimal.NewNullDecimal(obs.deviation)
+	}
+}
+
+func samePendingSince(a, b storage.AlertState) bool {
+	if a.PendingSince == nil || b.PendingSince == nil {
+		return a.PendingSince == b.PendingSince
+	}
+	return a.PendingSince.Equal(*b.PendingSince)
+}
+
+func resetAlertState(st *storage.AlertState) {
+	st.State = storage.AlertStateOK
+	st.Direction = ""
+	st.BreachCount = 0
+	st.BreachWindow = 0
+	st.PendingSince = nil
+	st.FiringSince = nil
+	st.Notified = false
+	st.PeakDeviationPct = decimal.NullDecimal{}
+}
+
+func confirmed(st storage.AlertState, bucket time.Time, policy alertPolicy) bool {
+	if st.BreachCount < policy.breaches {
+		return false
+	}
+	if policy.minDuration <= 0 || st.PendingSince == nil {
+		return true
+	}
+	return bucket.Sub(*st.PendingSince) >= policy.minDuration
+}
+
+// confirmationFeasible reports whether some run of future breaches can still
+// reach policy.breaches before the episode's existing breaches age out.
+func confirmationFeasible(window uint64, policy alertPolicy) bool {
+	size := policy.windowSize()
+	for k := 1; k < size; k++ {
+		surviving := bits.OnesCount64(window & windowMask(size-k))
+		if surviving > 0 && surviving+k >= policy.breaches {
+			return true
+		}
+	}
+	return false
+}
+
+// shiftWindow ages the breach window by the number of buckets since last. It
+// keeps one bucket beyond the confirmation window so that, even with a
+// one-bucket window, a breach right after the previous one continues the
+// episode; the caller trims the window once the observation is applied.
+func shiftWindow(window uint64, last *time.Time, bucket time.Time, policy alertPolicy) uint64 {
+	if last == nil {
+		return 0
+	}
+	steps := 1
+	if policy.interval > 0 {
+		steps = int(bucket.Sub(*last) / policy.interval)
+	}
+	switch {
+	case steps <= 0:
+		// Same bucket re-evaluated: replace its bit.
+		window &^= 1
+	case steps >= 64:
+		window = 0
+	default:
+		window <<= uint(steps)
+	}
+	return window & windowMask(policy.windowSize()+1)
+}
+
+func windowMask(size int) uint64 {
+	if size <= 0 {
+		return 0
+	}
+	if size >= 64 {
+		return ^uint64(0)
+	}
+	return uint64(1)<<uint(size) - 1
+}
+
+func cooldownElapsed(st storage.AlertState, direction string, bucket time.Time, cooldown time.Duration) bool {
+	last := lastAlert(st, direction)
+	if last == nil || cooldown <= 0 {
+		return true
+	}
+	return !bucket.Before(last.Add(cooldown))
+}
+
+func lastAlert(st storage.AlertState, direction string) *time.Time {
+	switch direction {
+	case "up":
+		return st.LastAlertUpAt
+	case "down":
+		return st.LastAlertDownAt
+	default:
+		return nil
+	}
+}
+
+func setLastAlert(st *storage.AlertState, direction string, bucket time.Time) {
+	switch direction {
+	case "up":
+		st.LastAlertUpAt = &bucket
+	case "down":
+		st.LastAlertDownAt = &bucket
+	}
+}
@@ -0,0 +1,160 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"price-diff-alerts/internal/storage"
+)
+
+func TestAdvanceAlertStateLifecycle(t *testing.T) {
+	policy := alertPolicy{breaches: 2, cooldown: 30 * time.Minute, interval: 5 * time.Minute}
+	base := time.Date(2025, 9, 22, 10, 0, 0, 0, time.UTC)
+	st := storage.AlertState{Key: testPairID}
+
+	steps := []struct {
+		breached bool
+		want     string
+		notify   bool
+	}{
+		{breached: true, want: storage.AlertStatePending},
+		{breached: true, want: storage.AlertStateFiring, notify: true},
+		{breached: true, want: storage.AlertStateFiring},
+		{breached: false, want: storage.AlertStateResolved},
+		{breached: false, want: storage.AlertStateOK},
+	}
+
+	for i, step := range steps {
+		var decision alertDecision
+		obs := alertObservation{bucket: base.Add(time.Duration(i) * 5 * time.Minute), breached: step.breached, cleared: !step.breached, direction: "up"}
+		st, decision = advanceAlertState(st, obs, policy)
+		if st.State != step.want {
+			t.Fatalf("第 %d 步: 期望状态 %s, 实际 %s", i, step.want, st.State)
+		}
+		if decision.notify != step.notify {
+			t.Fatalf("第 %d 步: notify 期望 %v", i, step.notify)
+		}
+	}
+}
+
+func TestAdvanceAlertStateCooldownPerDirection(t *testing.T) {
+	policy := alertPolicy{breaches: 1, cooldown: 30 * time.Minute, interval: 5 * time.Minute}
+	base := time.Date(2025, 9, 22, 10, 0, 0, 0, time.UTC)
+	st := storage.AlertState{Key: testPairID}
+
+	observe := func(offset time.Duration, breached bool, direction string) alertDecision {
+		var decision alertDecision
+		st, decision = advanceAlertState(st, alertObservation{bucket: base.Add(offset), breached: breached, cleared: !breached, direction: direction}, policy)
+		return decision
+	}
+
+	if d := observe(0, true, "up"); !d.notify {
+		t.Fatal("首次确认应通知")
+	}
+	observe(5*time.Minute, false, "flat")
+	observe(10*time.Minute, false, "flat")
+
+	if d := observe(15*time.Minute, true, "up"); d.notify || !d.suppressed {
+		t.Fatal("冷却期内同方向应被抑制")
+	}
+	if d := observe(20*time.Minute, true, "down"); !d.notify {
+		t.Fatal("反方向不受冷却影响")
+	}
+	if d := observe(25*time.Minute, true, "up"); d.notify {
+		t.Fatal("冷却未结束不应通知")
+	}
+	if d := observe(30*time.Minute, true, "up"); !d.notify {
+		t.Fatal("冷却结束且仍在告警中应补发通知")
+	}
+}
+
+func TestAdvanceAlertStatePendingRequiresAdjacentBuckets(t *testing.T) {
+	policy := alertPolicy{breaches: 2, interval: 5 * time.Minute}
+	base := time.Date(2025, 9, 22, 10, 0, 0, 0, time.UTC)
+	st := storage.AlertState{Key: testPairID}
+
+	st, _ = advanceAlertState(st, alertObservation{bucket: base, breached: true, direction: "up"}, policy)
+	st, decision := advanceAlertState(st, alertObservation{bucket: base.Add(time.Hour), breached: true, direction: "up"}, policy)
+	if st.State != storage.AlertStatePending || decision.notify {
+		t.Fatalf("间隔过长的两次突破不应确认告警, 状态 %s", st.State)
+	}
+}
+
+func TestAdvanceAlertStateWindowConfirmation(t *testing.T) {
+	policy := alertPolicy{breaches: 2, window: 3, interval: 5 * time.Minute}
+	base := time.Date(2025, 9, 22, 10, 0, 0, 0, time.UTC)
+	st := storage.AlertState{Key: testPairID}
+
+	observe := func(i int, breached bool) alertDecision {
+		var decision alertDecision
+		st, decision = advanceAlertState(st, alertObservation{bucket: base.Add(time.Duration(i) * 5 * time.Minute), breached: breached, cleared: !breached, direction: "up"}, policy)
+		return decision
+	}
+
+	observe(0, true)
+	observe(1, false)
+	if st.State != storage.AlertStatePending {
+		t.Fatalf("2/3 窗口内单次回落应保持 pending, 实际 %s", st.State)
+	}
+	if d := observe(2, true); !d.notify || st.State != storage.AlertStateFiring {
+		t.Fatalf("窗口内第二次突破应确认告警, 状态 %s", st.State)
+	}
+
+	st = storage.AlertState{Key: testPairID}
+	observe(10, true)
+	observe(11, false)
+	observe(12, false)
+	if st.State != storage.AlertStateOK {
+		t.Fatalf("已无法在窗口内确认时应回到 ok, 实际 %s", st.State)
+	}
+}
+
+func TestAdvanceAlertStateMinDuration(t *testing.T) {
+	policy := alertPolicy{breaches: 1, minDuration: 10 * time.Minute, interval: 5 * time.Minute}
+	base := time.Date(2025, 9, 22, 10, 0, 0, 0, time.UTC)
+	st := storage.AlertState{Key: testPairID}
+
+	for i, want := range []string{storage.AlertStatePending, storage.AlertStatePending, storage.AlertStateFiring} {
+		st, _ = advanceAlertState(st, alertObservation{bucket: base.Add(time.Duration(i) * 5 * time.Minute), breached: true, direction: "down"}, policy)
+		if st.State != want {
+			t.Fatalf("第 %d 步: 期望状态 %s, 实际 %s", i, want, st.State)
+		}
+	}
+}
+
+func TestAdvanceAlertStateHysteresis(t *testing.T) {
+	policy := alertPolicy{breaches: 1, interval: 5 * time.Minute}
+	base := time.Date(2025, 9, 22, 10, 0, 0, 0, time.UTC)
+	st := storage.AlertState{Key: testPairID}
+
+	st, _ = advanceAlertState(st, alertObservation{bucket: base, breached: true, direction: "up"}, policy)
+	st, _ = advanceAlertState(st, alertObservation{bucket: base.Add(5 * time.Minute), direction: "up"}, policy)
+	if st.State != storage.AlertStateFiring {
+		t.Fatalf("偏离仍高于解除阈值时应保持 firing, 实际 %s", st.State)
+	}
+	st, _ = advanceAlertState(st, alertObservation{bucket: base.Add(10 * time.Minute), cleared: true, direction: "up"}, policy)
+	if st.State != storage.AlertStateResolved {
+		t.Fatalf("回落到解除阈值以内应 resolved, 实际 %s", st.State)
+	}
+}
+
+func TestAdvanceAlertStateTracksPeakAndResolution(t *testing.T) {
+	policy := alertPolicy{breaches: 1, interval: 5 * time.Minute}
+	base := time.Date(2025, 9, 22, 10, 0, 0, 0, time.UTC)
+	st := storage.AlertState{Key: testPairID}
+
+	var decision alertDecision
+	for i, dev := range []string{"-0.5", "-0.9", "-0.6"} {
+		st, _ = advanceAlertState(st, alertObservation{bucket: base.Add(time.Duration(i) * 5 * time.Minute), breached: true, direction: "down", deviation: decimal.RequireFromString(dev)}, policy)
+	}
+	st, decision = advanceAlertState(st, alertObservation{bucket: base.Add(15 * time.Minute), cleared: true, direction: "down", deviation: decimal.RequireFromString("-0.1")}, policy)
+
+	if !decision.resolved {
+		t.Fatal("已通知的 firing 回落后应触发恢复")
+	}
+	if got := decision.previous.PeakDeviationPct.Decimal.String(); got != "-0.9" {
+		t.Fatalf("峰值应为 -0.9, 实际 %s", got)
+	}
+}
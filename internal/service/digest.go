@@ -0,0 +1,156 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"price-diff-alerts/internal/alerting"
+	"price-diff-alerts/internal/storage"
+)
+
+// digestAlertLimit bounds how many recent alert rows a digest scans.
+const digestAlertLimit = 1000
+
+// digestLockOffset keeps the digest lock distinct from the sampling lock so
+// only one instance mails each period without blocking bucket processing.
+const digestLockOffset = 1
+
+// BuildDigest summarises the alerts and deviation range of one pair over
+// [from, to).
+func (s *Service) BuildDigest(ctx context.Context, pairID string, from, to time.Time) (alerting.Digest, error) {
+	if s.store == nil || s.alertStore == nil {
+		return alerting.Digest{}, errors.New("digest requires a database store")
+	}
+	p, err := s.pair(pairID)
+	if err != nil {
+		return alerting.Digest{}, err
+	}
+
+	digest := alerting.Digest{Pair: p.cfg.Name(), From: from, To: to}
+
+	samples, err := s.store.ListSamplesBetween(ctx, pairID, from, to)
+	if err != nil {
+		return alerting.Digest{}, fmt.Errorf("list samples: %w", err)
+	}
+	digest.Samples = len(samples)
+	for _, sample := range samples {
+		if sample.Status != storage.SampleStatusComplete || !sample.DeviationPct.Valid {
+			digest.Unhealthy++
+			continue
+		}
+		dev := sample.DeviationPct.Decimal
+		if !digest.MinDeviationPct.Valid || dev.LessThan(digest.MinDeviationPct.Decimal) {
+			digest.MinDeviationPct = decimal.NewNullDecimal(dev)
+		}
+		if !digest.MaxDeviationPct.Valid || dev.GreaterThan(digest.MaxDeviationPct.Decimal) {
+			digest.MaxDeviationPct = decimal.NewNullDecimal(dev)
+		}
+	}
+	if interval := s.policy.interval; interval > 0 {
+		if expected := int(to.Sub(from) / interval); expected > len(samples) {
+			digest.Unhealthy += expected - len(samples)
+		}
+	}
+
+	alerts, err := s.alertStore.ListRecentAlerts(ctx, digestAlertLimit)
+	if err != nil {
+		return alerting.Digest{}, fmt.Errorf("list alerts: %w", err)
+	}
+	// ListRecentAlerts is newest first; the digest reads oldest first.
+	for i := len(alerts) - 1; i >= 0; i-- {
+		rec := alerts[i]
+		if rec.PairID != pairID || !alertInPeriod(rec, from, to) {
+			continue
+		}
+		digest.Alerts = append(digest.Alerts, alerting.DigestAlert{
+			StartedAt:        rec.SampleTS,
+			Direction:        rec.Direction,
+			State:            rec.State,
+			DeviationPct:     rec.DeviationPct,
+			PeakDeviationPct: rec.PeakDeviationPct,
+			ResolvedAt:       rec.ResolvedAt,
+		})
+	}
+	return digest, nil
+}
+
+// alertInPeriod keeps confirmed episodes that overlap [from, to).
+func alertInPeriod(rec storage.AlertRecord, from, to time.Time) bool {
+	if rec.State == storage.AlertStatePending || rec.State == storage.AlertStateCancelled {
+		return false
+	}
+	if !rec.SampleTS.Before(to) {
+		return false
+	}
+	return rec.ResolvedAt == nil || !rec.ResolvedAt.Before(from)
+}
+
+// RunDigest mails a digest per pair of the previous period at every interval
+// boundary until ctx is cancelled.
+func (s *Service) RunDigest(ctx context.Context, interval time.Duration, sender alerting.DigestSender) error {
+	if interval <= 0 {
+		return fmt.Errorf("digest interval must be positive")
+	}
+	for {
+		next := time.Now().UTC().Truncate(interval).Add(interval)
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+		s.sendDigest(ctx, next.Add(-interval), next, sender)
+	}
+}
+
+// sendDigest mails the digests of [from, to) under the digest lock; with no
+// advisory lock key configured every instance sends them. Pairs whose digest
+// for a period ending at or after to was already recorded are skipped, so an
+// instance whose timer fires after another released the lock sends nothing.
+func (s *Service) sendDigest(ctx context.Context, from, to time.Time, sender alerting.DigestSender) {
+	if s.lockKey != 0 && s.locker != nil {
+		unlock, acquired, err := s.locker.TryAdvisoryLock(ctx, s.lockKey+digestLockOffset)
+		if err != nil {
+			s.logger.Error().Err(err).Msg("failed to acquire digest lock")
+			return
+		}
+		if !acquired {
+			s.logger.Debug().Time("from", from).Msg("skip digest because lock held elsewhere")
+			return
+		}
+		defer unlock()
+	}
+
+	for _, p := range s.pairs {
+		if s.digests != nil {
+			last, found, err := s.digests.LastDigestEnd(ctx, p.cfg.ID)
+			if err != nil {
+				p.logger.Error().Err(err).Time("to", to).Msg("failed to load last digest period")
+				continue
+			}
+			if found && !last.Before(to) {
+				p.logger.Debug().Time("to", to).Msg("skip digest already sent for period")
+				continue
+			}
+		}
+		digest, err := s.BuildDigest(ctx, p.cfg.ID, from, to)
+		if err != nil {
+			p.logger.Error().Err(err).Time("from", from).Time("to", to).Msg("failed to build digest")
+			continue
+		}
+		if err := sender.SendDigest(ctx, digest); err != nil {
+			p.logger.Error().Err(err).Time("from", from).Time("to", to).Msg("failed to send digest")
+			continue
+		}
+		if s.digests != nil {
+			if err := s.digests.MarkDigestSent(ctx, p.cfg.ID, to); err != nil {
+				p.logger.Error().Err(err).Time("to", to).Msg("failed to record digest period")
+			}
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/shopspring/decimal"
+
+	"price-diff-alerts/internal/alerting"
+	"price-diff-alerts/internal/storage"
+	"price-diff-alerts/internal/storage/memory"
+)
+
+func TestBuildDigestSummarisesPeriod(t *testing.T) {
+	from := time.Date(2025, 9, 22, 0, 0, 0, 0, time.UTC)
+	to := from.Add(30 * time.Minute)
+	dev := func(v string) decimal.NullDecimal { return decimal.NewNullDecimal(decimal.RequireFromString(v)) }
+
+	store := &recordingStore{samples: []storage.RateSample{
+		{Bucket: from, Status: storage.SampleStatusComplete, DeviationPct: dev("-0.2")},
+		{Bucket: from.Add(5 * time.Minute), Status: storage.SampleStatusComplete, DeviationPct: dev("0.7")},
+		{Bucket: from.Add(10 * time.Minute), Status: storage.SampleStatusErrored},
+		{Bucket: from.Add(15 * time.Minute), Status: storage.SampleStatusComplete, DeviationPct: dev("0.1")},
+	}}
+	resolvedAt := from.Add(15 * time.Minute)
+	alerts := &memoryAlertStore{alerts: []storage.AlertRecord{
+		{PairID: testPairID, SampleTS: from.Add(-time.Hour), State: storage.AlertStateResolved, ResolvedAt: &resolvedAt, Direction: "down"},
+		{PairID: testPairID, SampleTS: from.Add(5 * time.Minute), State: storage.AlertStateFiring, Direction: "up"},
+		{PairID: testPairID, SampleTS: from.Add(20 * time.Minute), State: storage.AlertStateCancelled, Direction: "up"},
+	}}
+
+	svc := New(testConfig(), nil, testPairs(&stubOfficial{}, &stubMarket{}), store, alerts, nil, zerolog.Nop())
+	digest, err := svc.BuildDigest(context.Background(), testPairID, from, to)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if digest.Samples != 4 || digest.Unhealthy != 3 {
+		t.Fatalf("样本统计不正确: samples=%d unhealthy=%d", digest.Samples, digest.Unhealthy)
+	}
+	if digest.MinDeviationPct.Decimal.String() != "-0.2" || digest.MaxDeviationPct.Decimal.String() != "0.7" {
+		t.Fatalf("偏离区间不正确: %s .. %s", digest.MinDeviationPct.Decimal, digest.MaxDeviationPct.Decimal)
+	}
+	if len(digest.Alerts) != 2 {
+		t.Fatalf("应包含与周期重叠且已确认的 2 个告警, 实际 %d", len(digest.Alerts))
+	}
+}
+
+// windowStore 记录 ListSamplesBetween 收到的时间窗口。
+type windowStore struct {
+	busyLocker
+	from, to time.Time
+}
+
+func (w *windowStore) ListSamplesBetween(ctx context.Context, pairID string, from, to time.Time) ([]storage.RateSample, error) {
+	w.from, w.to = from, to
+	return nil, nil
+}
+
+type countingDigestSender struct {
+	digests []alerting.Digest
+}
+
+func (c *countingDigestSender) SendDigest(ctx context.Context, digest alerting.Digest) error {
+	c.digests = append(c.digests, digest)
+	return nil
+}
+
+func TestSendDigestLocksOnlyWithLockKey(t *testing.T) {
+	from := time.Date(2025, 9, 22, 0, 0, 0, 0, time.UTC)
+	to := from.Add(24 * time.Hour)
+
+	// 未配置 advisory_lock_key 时不加锁，即使锁被其他实例持有也照常发送。
+	store := &windowStore{}
+	sender := &countingDigestSender{}
+	svc := New(testConfig(), nil, testPairs(&stubOfficial{}, &stubMarket{}), store, &memoryAlertStore{}, nil, zerolog.Nop())
+	svc.sendDigest(context.Background(), from, to, sender)
+	if len(sender.digests) != 1 {
+		t.Fatalf("未配置锁时应发送摘要, 实际 %d 份", len(sender.digests))
+	}
+	if !store.from.Equal(from) || !store.to.Equal(to) {
+		t.Fatalf("样本窗口应为半开区间 [from, to): %s .. %s", store.from, store.to)
+	}
+
+	cfg := testConfig()
+	cfg.Scheduler.AdvisoryLockKey = 42
+	sender = &countingDigestSender{}
+	svc = New(cfg, nil, testPairs(&stubOfficial{}, &stubMarket{}), store, &memoryAlertStore{}, nil, zerolog.Nop())
+	svc.sendDigest(context.Background(), from, to, sender)
+	if len(sender.digests) != 0 {
+		t.Fatalf("摘要锁被其他实例持有时不应发送, 实际 %d 份", len(sender.digests))
+	}
+}
+
+func TestSendDigestSkipsPeriodAlreadySent(t *testing.T) {
+	from := time.Date(2025, 9, 22, 0, 0, 0, 0, time.UTC)
+	to := from.Add(24 * time.Hour)
+
+	// 两个实例共用同一个存储；后触发的实例不应重复发送同一周期。
+	store := memory.New()
+	sender := &countingDigestSender{}
+	for i := 0; i < 2; i++ {
+		svc := New(testConfig(), nil, testPairs(&stubOfficial{}, &stubMarket{}), store, &memoryAlertStore{}, nil, zerolog.Nop())
+		svc.sendDigest(context.Background(), from, to, sender)
+	}
+	if len(sender.digests) != 1 {
+		t.Fatalf("同一周期只应发送一次摘要, 实际 %d 份", len(sender.digests))
+	}
+
+	svc := New(testConfig(), nil, testPairs(&stubOfficial{}, &stubMarket{}), store, &memoryAlertStore{}, nil, zerolog.Nop())
+	svc.sendDigest(context.Background(), to, to.Add(24*time.Hour), sender)
+	if len(sender.digests) != 2 {
+		t.Fatalf("下一个周期应照常发送, 实际 %d 份", len(sender.digests))
+	}
+}
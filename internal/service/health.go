@@ -0,0 +1,199 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"price-diff-alerts/internal/fetcher"
+)
+
+// pingTimeout bounds the database check of a health probe.
+const pingTimeout = 2 * time.Second
+
+// FetcherHealth is the latest outcome of one fetcher. Errors are reported by
+// type only (see fetcher.ErrorType): their text can carry endpoint URLs and
+// API keys, and is logged instead.
+type FetcherHealth struct {
+	LastSuccessAt *time.Time `json:"last_success_at,omitempty"`
+	LastErrorAt   *time.Time `json:"last_error_at,omitempty"`
+	LastErrorType string     `json:"last_error_type,omitempty"`
+}
+
+// DatabaseHealth reports the result of pinging the store. Like fetcher
+// errors, a ping failure is reported by type so the DSN never leaks.
+type DatabaseHealth struct {
+	Configured bool   `json:"configured"`
+	Reachable  bool   `json:"reachable"`
+	ErrorType  string `json:"error_type,omitempty"`
+}
+
+// PairHealth is the progress of one monitored pair.
+type PairHealth struct {
+	LastBucket      *time.Time               `json:"last_bucket,omitempty"`
+	LastCompletedAt *time.Time               `json:"last_completed_at,omitempty"`
+	Fetchers        map[string]FetcherHealth `json:"fetchers"`
+}
+
+// HealthReport is the pipeline state served by /healthz and /readyz.
+//
+// Live is false when the sampling loop has not run a bucket for 2×interval,
+// which means it is stuck and should be restarted. Ready additionally needs a
+// reachable database and, on the instance holding the advisory lock, a bucket
+// completed within 2×interval for every pair. An instance on standby behind
+// another lock holder stays ready.
+type HealthReport struct {
+	Live          bool                  `json:"live"`
+	Ready         bool                  `json:"ready"`
+	Reasons       []string              `json:"reasons,omitempty"`
+	Database      DatabaseHealth        `json:"database"`
+	StartedAt     time.Time             `json:"started_at"`
+	LastAttemptAt *time.Time            `json:"last_attempt_at,omitempty"`
+	MaxAge        string                `json:"max_age"`
+	LockHeld      *bool                 `json:"lock_held,omitempty"`
+	Pairs         map[string]PairHealth `json:"pairs"`
+}
+
+// healthTracker records pipeline progress for health reports.
+type healthTracker struct {
+	mu          sync.Mutex
+	startedAt   time.Time
+	grace       time.Duration
+	lastAttempt *time.Time
+	lockHeld    *bool
+	// pairIDs lists every configured pair so one that never completes is
+	// still reported.
+	pairIDs []string
+	pairs   map[string]*PairHealth
+}
+
+func newHealthTracker(startupDelay time.Duration, pairIDs []string) *healthTracker {
+	h := &healthTracker{
+		startedAt: time.Now().UTC(),
+		grace:     startupDelay,
+		pairIDs:   pairIDs,
+		pairs:     make(map[string]*PairHealth, len(pairIDs)),
+	}
+	for _, id := range pairIDs {
+		h.pairs[id] = &PairHealth{Fetchers: make(map[string]FetcherHealth)}
+	}
+	return h
+}
+
+// pair returns the progress of id; callers hold mu.
+func (h *healthTracker) pair(id string) *PairHealth {
+	ph, ok := h.pairs[id]
+	if !ok {
+		ph = &PairHealth{Fetchers: make(map[string]FetcherHealth)}
+		h.pairs[id] = ph
+	}
+	return ph
+}
+
+// attempted records that the loop reached a bucket.
+func (h *healthTracker) attempted(at time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.lastAttempt = &at
+}
+
+// locked records the outcome of the advisory lock; held is nil when the
+// service runs without one.
+func (h *healthTracker) locked(held *bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.lockHeld = held
+}
+
+func (h *healthTracker) fetched(pairID, phase string, at time.Time, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	ph := h.pair(pairID)
+	fh := ph.Fetchers[phase]
+	if err != nil {
+		fh.LastErrorAt = &at
+		fh.LastErrorType = fetcher.ErrorType(err)
+	} else {
+		fh.LastSuccessAt = &at
+	}
+	ph.Fetchers[phase] = fh
+}
+
+func (h *healthTracker) completed(pairID string, bucket, at time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	ph := h.pair(pairID)
+	ph.LastBucket = &bucket
+	ph.LastCompletedAt = &at
+}
+
+// Health evaluates the pipeline at now. The database is pinged when the store
+// supports it.
+func (s *Service) Health(ctx context.Context, now time.Time) HealthReport {
+	h := s.health
+	maxAge := 2 * s.policy.interval
+
+	h.mu.Lock()
+	report := HealthReport{
+		StartedAt:     h.startedAt,
+		LastAttemptAt: h.lastAttempt,
+		MaxAge:        maxAge.String(),
+		LockHeld:      h.lockHeld,
+		Pairs:         make(map[string]PairHealth, len(h.pairs)),
+	}
+	for id, ph := range h.pairs {
+		snapshot := PairHealth{
+			LastBucket:      ph.LastBucket,
+			LastCompletedAt: ph.LastCompletedAt,
+			Fetchers:        make(map[string]FetcherHealth, len(ph.Fetchers)),
+		}
+		for phase, fh := range ph.Fetchers {
+			snapshot.Fetchers[phase] = fh
+		}
+		report.Pairs[id] = snapshot
+	}
+	pairIDs := h.pairIDs
+	// Until the first bucket is due, age is measured from startup.
+	since := h.startedAt.Add(h.grace)
+	h.mu.Unlock()
+
+	fresh := func(t *time.Time) bool {
+		from := since
+		if t != nil && t.After(from) {
+			from = *t
+		}
+		return now.Sub(from) <= maxAge
+	}
+
+	report.Live = fresh(report.LastAttemptAt)
+	if !report.Live {
+		report.Reasons = append(report.Reasons, "no bucket attempted within "+maxAge.String())
+	}
+
+	ready := report.Live
+	if s.pinger != nil {
+		report.Database.Configured = true
+		pingCtx, cancel := context.WithTimeout(ctx, pingTimeout)
+		err := s.pinger.Ping(pingCtx)
+		cancel()
+		if err != nil {
+			report.Database.ErrorType = fetcher.ErrorType(err)
+			report.Reasons = append(report.Reasons, "database unreachable")
+			ready = false
+		} else {
+			report.Database.Reachable = true
+		}
+	}
+
+	standby := report.LockHeld != nil && !*report.LockHeld
+	if !standby {
+		for _, id := range pairIDs {
+			if !fresh(report.Pairs[id].LastCompletedAt) {
+				report.Reasons = append(report.Reasons, "pair "+id+": no bucket completed within "+maxAge.String())
+				ready = false
+			}
+		}
+	}
+	report.Ready = ready
+	return report
+}
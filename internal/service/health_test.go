@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/shopspring/decimal"
+
+	"price-diff-alerts/internal/storage"
+)
+
+type pingingStore struct {
+	recordingStore
+	pingErr error
+}
+
+func (p *pingingStore) Ping(ctx context.Context) error {
+	return p.pingErr
+}
+
+type busyLocker struct {
+	pingingStore
+}
+
+func (b *busyLocker) TryAdvisoryLock(ctx context.Context, key int64) (func(), bool, error) {
+	return nil, false, nil
+}
+
+func TestHealthTracksBucketsAndFetchers(t *testing.T) {
+	store := &pingingStore{}
+	market := &stubMarket{rate: decimal.RequireFromString("1.01")}
+	svc := New(testConfig(), nil, testPairs(&stubOfficial{rate: decimal.NewFromInt(1)}, market), store, nil, nil, zerolog.Nop())
+
+	if err := svc.ProcessBucket(context.Background(), time.Now().UTC()); err != nil {
+		t.Fatal(err)
+	}
+	report := svc.Health(context.Background(), time.Now().UTC())
+	if !report.Live || !report.Ready || !report.Database.Reachable {
+		t.Fatalf("刚完成 bucket 应存活且就绪: %+v", report)
+	}
+	if report.LockHeld != nil {
+		t.Fatal("未配置 advisory lock 时不应报告锁状态")
+	}
+	if report.Pairs[testPairID].Fetchers[storage.SamplePhaseMarket].LastSuccessAt == nil {
+		t.Fatalf("应记录市场价成功时间: %+v", report.Pairs)
+	}
+
+	market.err = errors.New("cow down")
+	_ = svc.ProcessBucket(context.Background(), time.Now().UTC())
+	report = svc.Health(context.Background(), time.Now().UTC().Add(11*time.Minute))
+	if report.Pairs[testPairID].Fetchers[storage.SamplePhaseMarket].LastErrorType != "transport" {
+		t.Fatalf("应记录市场价最后一次错误: %+v", report.Pairs)
+	}
+	if report.Live || report.Ready {
+		t.Fatalf("超过 2×interval 无 bucket 时应判定为卡住: %+v", report)
+	}
+
+	store.pingErr = errors.New("dial postgres://app:secret@db:5432/app: connection refused")
+	report = svc.Health(context.Background(), time.Now().UTC())
+	if !report.Live || report.Ready || report.Database.ErrorType != "transport" {
+		t.Fatalf("数据库不可达时应存活但未就绪: %+v", report)
+	}
+}
+
+func TestHealthStandbyStaysReady(t *testing.T) {
+	cfg := testConfig()
+	cfg.Scheduler.AdvisoryLockKey = 7
+	store := &busyLocker{}
+	svc := New(cfg, nil, testPairs(&stubOfficial{rate: decimal.NewFromInt(1)}, &stubMarket{rate: decimal.NewFromInt(1)}), store, nil, nil, zerolog.Nop())
+
+	// 已运行一小时、一直在等待锁的备用实例。
+	svc.health.startedAt = svc.health.startedAt.Add(-time.Hour)
+	if err := svc.ProcessBucket(context.Background(), time.Now().UTC()); err != nil {
+		t.Fatal(err)
+	}
+	report := svc.Health(context.Background(), time.Now().UTC())
+	if report.LockHeld == nil || *report.LockHeld {
+		t.Fatalf("应报告未持有锁: %+v", report.LockHeld)
+	}
+	if !report.Live || !report.Ready {
+		t.Fatalf("备用实例不完成 bucket 也应就绪: %+v", report)
+	}
+}
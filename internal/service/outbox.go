@@ -0,0 +1,292 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"price-diff-alerts/internal/alerting"
+	"price-diff-alerts/internal/config"
+	"price-diff-alerts/internal/storage"
+)
+
+// outboxBatch bounds how many queued notifications one drain sends.
+const outboxBatch = 100
+
+// outboxLockOffset keeps outbox draining on its own advisory lock.
+const outboxLockOffset = 3
+
+// bucketTx holds the stores one bucket writes through. Fields stay nil for
+// stores the service was built without.
+type bucketTx struct {
+	samples storage.RateSampleStore
+	alerts  storage.AlertStore
+	states  storage.AlertStateStore
+	outbox  storage.OutboxStore
+}
+
+// pendingNote is a notification decided inside the bucket transaction.
+// outboxID is set once the note has been queued.
+type pendingNote struct {
+	note     alerting.Notification
+	episode  *time.Time
+	outboxID int64
+}
+
+// inUnitOfWork runs fn in one store transaction when the store supports it,
+// otherwise directly against the service's stores.
+func (s *Service) inUnitOfWork(ctx context.Context, fn func(tx bucketTx) error) error {
+	if s.uow == nil {
+		tx := bucketTx{alerts: s.alertStore}
+		if s.store != nil {
+			tx.samples = s.store
+		}
+		if s.stateStore != nil {
+			tx.states = s.stateStore
+		}
+		return fn(tx)
+	}
+	return s.uow.InTx(ctx, func(stx storage.Tx) error {
+		tx := bucketTx{samples: stx}
+		if s.alertStore != nil {
+			tx.alerts = stx
+		}
+		if s.stateStore != nil {
+			tx.states = stx
+		}
+		if s.outbox != nil {
+			tx.outbox = stx
+		}
+		return fn(tx)
+	})
+}
+
+// enqueueNotes queues notes in the bucket transaction so they are only sent
+// once the alert decision that produced them has committed. A queued note is
+// first due one backoff later: deliverAfterCommit makes the first attempt, and
+// the outbox worker only takes over if that attempt was never recorded.
+func (s *Service) enqueueNotes(ctx context.Context, tx bucketTx, notes []pendingNote) error {
+	if s.notifier == nil || tx.outbox == nil {
+		return nil
+	}
+	due := time.Now().UTC().Add(s.outboxBackoff(1))
+	for i, p := range notes {
+		payload, err := json.Marshal(p.note)
+		if err != nil {
+			return fmt.Errorf("encode %s notification: %w", p.note.Kind, err)
+		}
+		id, err := tx.outbox.EnqueueOutbox(ctx, storage.OutboxMessage{
+			EpisodeTS:     p.episode,
+			Kind:          p.note.Kind,
+			Payload:       payload,
+			NextAttemptAt: due,
+			Channels:      p.note.Channels,
+		})
+		if err != nil {
+			return err
+		}
+		notes[i].outboxID = id
+	}
+	return nil
+}
+
+// deliverAfterCommit sends the notifications of a committed bucket. Queued
+// notes get one attempt each and any retries are left to RunOutbox; without
+// an outbox they are sent straight from notes.
+func (s *Service) deliverAfterCommit(ctx context.Context, notes []pendingNote) {
+	if s.notifier == nil {
+		return
+	}
+	for _, p := range notes {
+		if p.outboxID == 0 {
+			_ = s.dispatch(ctx, p.note, p.episode)
+			continue
+		}
+		msg := storage.OutboxMessage{ID: p.outboxID, EpisodeTS: p.episode, Kind: p.note.Kind, Channels: p.note.Channels}
+		failed, sendErr := s.deliver(ctx, p.note, p.episode, p.note.Channels)
+		if _, err := s.recordOutboxAttempt(ctx, msg, failed, sendErr, time.Now().UTC()); err != nil {
+			s.logger.Error().Err(err).Int64("outbox_id", p.outboxID).Msg("failed to record outbox attempt")
+		}
+	}
+}
+
+// OutboxResult reports what one outbox drain did.
+type OutboxResult struct {
+	Dispatched int
+	Retrying   int
+	Dead       int
+}
+
+// DeliverOutbox sends every outbox message due at now. A failed message is
+// rescheduled with exponential backoff, limited to the channels that failed,
+// and becomes dead once it has used alerting.outbox.max_attempts.
+func (s *Service) DeliverOutbox(ctx context.Context, now time.Time) (OutboxResult, error) {
+	if s.outbox == nil {
+		return OutboxResult{}, errors.New("outbox requires a database store")
+	}
+	if s.notifier == nil {
+		return OutboxResult{}, errors.New("outbox requires a notifier")
+	}
+	msgs, err := s.outbox.ListDueOutbox(ctx, now, outboxBatch)
+	if err != nil {
+		return OutboxResult{}, err
+	}
+
+	var result OutboxResult
+	for _, msg := range msgs {
+		failed, sendErr := s.dispatchOutbox(ctx, msg)
+		status, err := s.recordOutboxAttempt(ctx, msg, failed, sendErr, now)
+		if err != nil {
+			return result, err
+		}
+		switch status {
+		case storage.OutboxStatusDispatched:
+			result.Dispatched++
+		case storage.OutboxStatusDead:
+			result.Dead++
+		default:
+			result.Retrying++
+		}
+	}
+	return result, nil
+}
+
+// recordOutboxAttempt stores the outcome of one delivery attempt of msg and
+// returns the status it left the message in.
+func (s *Service) recordOutboxAttempt(ctx context.Context, msg storage.OutboxMessage, failed []string, sendErr error, now time.Time) (string, error) {
+	attempt := storage.OutboxAttempt{Status: storage.OutboxStatusDispatched, NextAttemptAt: now}
+	if sendErr != nil {
+		text := sendErr.Error()
+		attempt.Error = &text
+		attempt.Channels = msg.Channels
+		if len(failed) > 0 {
+			attempt.Channels = failed
+		}
+		attempts := msg.Attempts + 1
+		if attempts >= s.outboxMaxAttempts() {
+			attempt.Status = storage.OutboxStatusDead
+			s.logger.Error().Int64("outbox_id", msg.ID).
+				Str("kind", msg.Kind).
+				Int("attempts", attempts).
+				Strs("channels", attempt.Channels).
+				Msg("notification dead-lettered after exhausting retries")
+		} else {
+			attempt.Status = storage.OutboxStatusPending
+			attempt.NextAttemptAt = now.Add(s.outboxBackoff(attempts))
+			s.logger.Warn().Int64("outbox_id", msg.ID).
+				Int("attempts", attempts).
+				Time("next_attempt_at", attempt.NextAttemptAt).
+				Msg("notification delivery failed; will retry")
+		}
+	}
+	return attempt.Status, s.outbox.MarkOutboxAttempt(ctx, msg.ID, attempt)
+}
+
+// RunOutbox redelivers due outbox messages every alerting.outbox.poll_interval
+// until ctx is cancelled.
+func (s *Service) RunOutbox(ctx context.Context) error {
+	interval := s.outboxPolicy.PollInterval
+	if interval <= 0 {
+		return fmt.Errorf("outbox poll interval must be positive")
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		s.outboxPass(ctx)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// outboxPass drains the outbox under the outbox lock so only one instance
+// sends each message.
+func (s *Service) outboxPass(ctx context.Context) {
+	s.outboxMu.Lock()
+	defer s.outboxMu.Unlock()
+
+	if s.lockKey != 0 && s.locker != nil {
+		unlock, acquired, err := s.locker.TryAdvisoryLock(ctx, s.lockKey+outboxLockOffset)
+		if err != nil {
+			s.logger.Error().Err(err).Msg("failed to acquire outbox lock")
+			return
+		}
+		if !acquired {
+			s.logger.Debug().Msg("skip outbox drain because lock held elsewhere")
+			return
+		}
+		defer unlock()
+	}
+
+	result, err := s.DeliverOutbox(ctx, time.Now().UTC())
+	if err != nil {
+		s.logger.Error().Err(err).Msg("outbox drain failed")
+		return
+	}
+	if result != (OutboxResult{}) {
+		s.logger.Info().Int("dispatched", result.Dispatched).
+			Int("retrying", result.Retrying).
+			Int("dead", result.Dead).
+			Msg("outbox drained")
+	}
+}
+
+func (s *Service) dispatchOutbox(ctx context.Context, msg storage.OutboxMessage) ([]string, error) {
+	note, err := decodeOutboxPayload(msg.Payload)
+	if err != nil {
+		s.logger.Error().Err(err).Int64("outbox_id", msg.ID).Msg("failed to decode outbox payload")
+		return nil, fmt.Errorf("decode payload: %w", err)
+	}
+	return s.deliver(ctx, note, msg.EpisodeTS, msg.Channels)
+}
+
+// legacyPayload is a notification queued before pairs existed: it has no
+// pair fields and carries the notional as NotionalUSDE.
+type legacyPayload struct {
+	alerting.Notification
+	NotionalUSDE decimal.Decimal
+}
+
+// decodeOutboxPayload decodes a queued notification, attributing payloads
+// written before pairs existed to the USDe/sUSDe pair.
+func decodeOutboxPayload(payload []byte) (alerting.Notification, error) {
+	var decoded legacyPayload
+	if err := json.Unmarshal(payload, &decoded); err != nil {
+		return alerting.Notification{}, err
+	}
+	note := decoded.Notification
+	if note.PairID == "" {
+		note.PairID = config.LegacyPairID
+		note.Pair = "USDe-sUSDe"
+		note.VaultSymbol = "sUSDe"
+		note.UnderlyingSymbol = "USDe"
+		note.Notional = decoded.NotionalUSDE
+	}
+	return note, nil
+}
+
+// outboxBackoff returns the wait before the attempt following the given
+// number of failed attempts: Backoff doubled per attempt, capped at MaxBackoff.
+func (s *Service) outboxBackoff(attempts int) time.Duration {
+	wait := s.outboxPolicy.Backoff
+	if wait <= 0 {
+		return 0
+	}
+	for i := 1; i < attempts; i++ {
+		if wait >= s.outboxPolicy.MaxBackoff/2 {
+			return s.outboxPolicy.MaxBackoff
+		}
+		wait *= 2
+	}
+	return min(wait, s.outboxPolicy.MaxBackoff)
+}
+
+func (s *Service) outboxMaxAttempts() int {
+	return max(s.outboxPolicy.MaxAttempts, 1)
+}
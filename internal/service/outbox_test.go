@@ -0,0 +1,269 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/shopspring/decimal"
+
+	"price-diff-alerts/internal/alerting"
+	"price-diff-alerts/internal/config"
+	"price-diff-alerts/internal/storage"
+)
+
+// memoryUnitOfWork 在内存中模拟事务：fn 或提交失败时回滚样本、告警、状态与 outbox。
+type memoryUnitOfWork struct {
+	*recordingStore
+	*memoryAlertStore
+	outbox     []storage.OutboxMessage
+	failCommit bool
+}
+
+func newMemoryUnitOfWork() *memoryUnitOfWork {
+	return &memoryUnitOfWork{recordingStore: &recordingStore{}, memoryAlertStore: &memoryAlertStore{}}
+}
+
+func (m *memoryUnitOfWork) InTx(ctx context.Context, fn func(tx storage.Tx) error) error {
+	samples := append([]storage.RateSample(nil), m.samples...)
+	alerts := append([]storage.AlertRecord(nil), m.alerts...)
+	state := m.state
+	outbox := append([]storage.OutboxMessage(nil), m.outbox...)
+
+	err := fn(m)
+	if err == nil && m.failCommit {
+		err = errors.New("commit failed")
+	}
+	if err != nil {
+		m.samples, m.alerts, m.state, m.outbox = samples, alerts, state, outbox
+	}
+	return err
+}
+
+func (m *memoryUnitOfWork) EnqueueOutbox(ctx context.Context, msg storage.OutboxMessage) (int64, error) {
+	msg.ID = int64(len(m.outbox) + 1)
+	msg.Status = storage.OutboxStatusPending
+	m.outbox = append(m.outbox, msg)
+	return msg.ID, nil
+}
+
+func (m *memoryUnitOfWork) ListDueOutbox(ctx context.Context, now time.Time, limit int) ([]storage.OutboxMessage, error) {
+	var due []storage.OutboxMessage
+	for _, msg := range m.outbox {
+		if msg.Status == storage.OutboxStatusPending && !msg.NextAttemptAt.After(now) && len(due) < limit {
+			due = append(due, msg)
+		}
+	}
+	return due, nil
+}
+
+func (m *memoryUnitOfWork) MarkOutboxAttempt(ctx context.Context, id int64, attempt storage.OutboxAttempt) error {
+	for i := range m.outbox {
+		if m.outbox[i].ID == id {
+			m.outbox[i].Status = attempt.Status
+			m.outbox[i].Attempts++
+			m.outbox[i].LastError = attempt.Error
+			m.outbox[i].Channels = attempt.Channels
+			m.outbox[i].NextAttemptAt = attempt.NextAttemptAt
+		}
+	}
+	return nil
+}
+
+func TestNotificationWaitsForCommit(t *testing.T) {
+	uow := newMemoryUnitOfWork()
+	notifier := &countingNotifier{}
+	official := &stubOfficial{rate: decimal.NewFromInt(1)}
+	market := &stubMarket{rate: decimal.RequireFromString("1.01")}
+	base := time.Date(2025, 9, 22, 10, 0, 0, 0, time.UTC)
+	svc := New(testConfig(), nil, testPairs(official, market), uow, uow, notifier, zerolog.Nop())
+
+	if err := svc.ProcessBucket(context.Background(), base); err != nil {
+		t.Fatal(err)
+	}
+
+	// 确认告警的那个 bucket 提交失败：样本、告警和 outbox 都应回滚，且不发通知。
+	uow.failCommit = true
+	if err := svc.ProcessBucket(context.Background(), base.Add(5*time.Minute)); err == nil {
+		t.Fatal("提交失败时 ProcessBucket 应返回错误")
+	}
+	if len(notifier.notes) != 0 || len(uow.outbox) != 0 {
+		t.Fatalf("提交失败不应发送或保留通知, notes=%d outbox=%d", len(notifier.notes), len(uow.outbox))
+	}
+	if len(uow.samples) != 1 || uow.state == nil || uow.state.State != storage.AlertStatePending {
+		t.Fatalf("提交失败应回滚到上一个 bucket 的结果: samples=%d state=%+v", len(uow.samples), uow.state)
+	}
+
+	uow.failCommit = false
+	if err := svc.ProcessBucket(context.Background(), base.Add(5*time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if len(notifier.notes) != 1 || len(uow.alerts) != 1 || uow.alerts[0].State != storage.AlertStateFiring {
+		t.Fatalf("提交成功后应发送一次告警: notes=%d alerts=%+v", len(notifier.notes), uow.alerts)
+	}
+	if len(uow.outbox) != 1 || uow.outbox[0].Status != storage.OutboxStatusDispatched || !uow.outbox[0].EpisodeTS.Equal(base) {
+		t.Fatalf("outbox 消息应标记为已发送并关联首次突破: %+v", uow.outbox)
+	}
+}
+
+func TestOutboxRetriesFailedChannelsThenDeadLetters(t *testing.T) {
+	uow := newMemoryUnitOfWork()
+	healthy := &countingNotifier{}
+	broken := &toggleNotifier{fail: true}
+	multi := alerting.NewMultiNotifier(zerolog.Nop(),
+		alerting.Channel{Name: "telegram", Notifier: healthy},
+		alerting.Channel{Name: "webhook", Notifier: broken},
+	)
+	cfg := testConfig()
+	cfg.Alerting.Outbox = config.OutboxConfig{PollInterval: time.Minute, MaxAttempts: 3, Backoff: time.Minute, MaxBackoff: 90 * time.Second}
+	svc := New(cfg, nil, testPairs(&stubOfficial{rate: decimal.NewFromInt(1)}, &stubMarket{rate: decimal.RequireFromString("1.01")}), uow, uow, multi, zerolog.Nop())
+
+	base := time.Date(2025, 9, 22, 10, 0, 0, 0, time.UTC)
+	uow.outbox = []storage.OutboxMessage{{ID: 1, EpisodeTS: &base, Kind: alerting.KindFiring, Payload: []byte(`{"Kind":"firing"}`), Status: storage.OutboxStatusPending, NextAttemptAt: base}}
+
+	result, err := svc.DeliverOutbox(context.Background(), base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := uow.outbox[0]
+	if result.Retrying != 1 || msg.Status != storage.OutboxStatusPending || !msg.NextAttemptAt.Equal(base.Add(time.Minute)) {
+		t.Fatalf("首次失败应在 1 分钟后重试: result=%+v msg=%+v", result, msg)
+	}
+	if len(msg.Channels) != 1 || msg.Channels[0] != "webhook" || len(healthy.notes) != 1 {
+		t.Fatalf("重试应只针对失败渠道: %+v", msg.Channels)
+	}
+
+	// 未到期时不应重投。
+	if result, _ := svc.DeliverOutbox(context.Background(), base.Add(30*time.Second)); result != (OutboxResult{}) {
+		t.Fatalf("未到重试时间不应投递: %+v", result)
+	}
+
+	if _, err := svc.DeliverOutbox(context.Background(), base.Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	msg = uow.outbox[0]
+	if !msg.NextAttemptAt.Equal(base.Add(150*time.Second)) || len(healthy.notes) != 1 {
+		t.Fatalf("第二次退避应翻倍并受上限约束, 且不重复发送健康渠道: next=%s healthy=%d", msg.NextAttemptAt, len(healthy.notes))
+	}
+
+	result, err = svc.DeliverOutbox(context.Background(), msg.NextAttemptAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Dead != 1 || uow.outbox[0].Status != storage.OutboxStatusDead || uow.outbox[0].Attempts != 3 {
+		t.Fatalf("用尽重试次数后应进入 dead 状态: result=%+v msg=%+v", result, uow.outbox[0])
+	}
+}
+
+func TestOutboxDispatchesOnRecovery(t *testing.T) {
+	uow := newMemoryUnitOfWork()
+	notifier := &toggleNotifier{fail: true}
+	cfg := testConfig()
+	cfg.Alerting.Outbox = config.OutboxConfig{PollInterval: time.Minute, MaxAttempts: 5, Backoff: time.Minute, MaxBackoff: time.Hour}
+	svc := New(cfg, nil, testPairs(&stubOfficial{rate: decimal.NewFromInt(1)}, &stubMarket{rate: decimal.RequireFromString("1.01")}), uow, uow, notifier, zerolog.Nop())
+
+	base := time.Date(2025, 9, 22, 10, 0, 0, 0, time.UTC)
+	uow.outbox = []storage.OutboxMessage{{ID: 1, Kind: alerting.KindFiring, Payload: []byte(`{"Kind":"firing"}`), Status: storage.OutboxStatusPending, NextAttemptAt: base}}
+	if _, err := svc.DeliverOutbox(context.Background(), base); err != nil {
+		t.Fatal(err)
+	}
+
+	notifier.fail = false
+	result, err := svc.DeliverOutbox(context.Background(), base.Add(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Dispatched != 1 || uow.outbox[0].Status != storage.OutboxStatusDispatched || notifier.sent != 1 {
+		t.Fatalf("渠道恢复后应投递成功: result=%+v msg=%+v", result, uow.outbox[0])
+	}
+}
+
+func TestBucketDeliversOnlyItsOwnNotesOnce(t *testing.T) {
+	uow := newMemoryUnitOfWork()
+	notifier := &toggleNotifier{fail: true}
+	cfg := testConfig()
+	cfg.Alerting.Outbox = config.OutboxConfig{PollInterval: time.Minute, MaxAttempts: 5, Backoff: time.Minute, MaxBackoff: time.Hour}
+	svc := New(cfg, nil, testPairs(&stubOfficial{rate: decimal.NewFromInt(1)}, &stubMarket{rate: decimal.RequireFromString("1.01")}), uow, uow, notifier, zerolog.Nop())
+
+	// 之前积压的到期消息留给 outbox worker，采样流程不应顺带投递。
+	base := time.Date(2025, 9, 22, 10, 0, 0, 0, time.UTC)
+	uow.outbox = []storage.OutboxMessage{{ID: 1, Kind: alerting.KindFiring, Payload: []byte(`{"Kind":"firing"}`), Status: storage.OutboxStatusPending, NextAttemptAt: base}}
+	for i := 0; i < 2; i++ {
+		if err := svc.ProcessBucket(context.Background(), base.Add(time.Duration(i)*5*time.Minute)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(uow.outbox) != 2 || uow.outbox[0].Attempts != 0 {
+		t.Fatalf("积压的消息不应在采样时投递: %+v", uow.outbox)
+	}
+	queued := uow.outbox[1]
+	if queued.Attempts != 1 || queued.Status != storage.OutboxStatusPending || !queued.NextAttemptAt.After(time.Now()) {
+		t.Fatalf("本 bucket 的通知只应尝试一次, 重试留给 outbox worker: %+v", queued)
+	}
+}
+
+func TestPairChannelsLimitDelivery(t *testing.T) {
+	base := time.Date(2025, 9, 22, 10, 0, 0, 0, time.UTC)
+	pairs := func() []Pair {
+		pairs := testPairs(&stubOfficial{rate: decimal.NewFromInt(1)}, &stubMarket{rate: decimal.RequireFromString("1.01")})
+		pairs[0].Config.Channels = []string{"telegram"}
+		return pairs
+	}
+
+	// 直接投递与经 outbox 投递都只应到达交易对配置的渠道。
+	uow := newMemoryUnitOfWork()
+	for _, withOutbox := range []bool{false, true} {
+		telegram, webhook := &countingNotifier{}, &countingNotifier{}
+		multi := alerting.NewMultiNotifier(zerolog.Nop(),
+			alerting.Channel{Name: "telegram", Notifier: telegram},
+			alerting.Channel{Name: "webhook", Notifier: webhook},
+		)
+		var svc *Service
+		if withOutbox {
+			svc = New(testConfig(), nil, pairs(), uow, uow, multi, zerolog.Nop())
+		} else {
+			svc = New(testConfig(), nil, pairs(), nil, &memoryAlertStore{}, multi, zerolog.Nop())
+		}
+
+		for i := 0; i < 2; i++ {
+			if err := svc.ProcessBucket(context.Background(), base.Add(time.Duration(i)*5*time.Minute)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if len(telegram.notes) != 1 || len(webhook.notes) != 0 {
+			t.Fatalf("outbox=%v: 交易对限定的渠道之外不应收到通知, telegram=%d webhook=%d", withOutbox, len(telegram.notes), len(webhook.notes))
+		}
+	}
+	if len(uow.outbox) != 1 || uow.outbox[0].Status != storage.OutboxStatusDispatched {
+		t.Fatalf("告警应经 outbox 发送: %+v", uow.outbox)
+	}
+}
+
+type toggleNotifier struct {
+	fail bool
+	sent int
+}
+
+func (n *toggleNotifier) Notify(ctx context.Context, note alerting.Notification) error {
+	if n.fail {
+		return errors.New("channel down")
+	}
+	n.sent++
+	return nil
+}
+
+func TestLegacyOutboxPayloadDecodesAsUSDePair(t *testing.T) {
+	payload := []byte(`{"Kind":"firing","Direction":"up","NotionalUSDE":"10000","DeviationPct":"0.5"}`)
+	note, err := decodeOutboxPayload(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if note.PairID != config.LegacyPairID || note.Pair != "USDe-sUSDe" || note.UnderlyingSymbol != "USDe" {
+		t.Fatalf("旧 payload 应归属 USDe/sUSDe 交易对: %+v", note)
+	}
+	if !note.Notional.Equal(decimal.NewFromInt(10000)) || note.Direction != "up" {
+		t.Fatalf("旧 payload 字段应保留: %+v", note)
+	}
+}
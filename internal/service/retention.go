@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"price-diff-alerts/internal/storage"
+)
+
+// retentionLockOffset keeps the retention pass on its own advisory lock.
+const retentionLockOffset = 2
+
+// RetentionResult reports what one retention pass did.
+type RetentionResult struct {
+	HourlyRolled   int64
+	DailyRolled    int64
+	SamplesPruned  int64
+	HourlyPruned   int64
+	DailyPruned    int64
+	RawCutoff      time.Time
+	HourlyCutoff   time.Time
+	DailyCutoff    time.Time
+	RolledUpBefore time.Time
+}
+
+// ApplyRetention rolls completed hours and days up and then prunes rows older
+// than their retention window. Cutoffs are aligned to whole hours (raw) and
+// days (rollups), so every pruned row is already covered by the next
+// resolution.
+func (s *Service) ApplyRetention(ctx context.Context, now time.Time) (RetentionResult, error) {
+	if s.rollupStore == nil {
+		return RetentionResult{}, errors.New("retention requires a database store")
+	}
+
+	now = now.UTC()
+	result := RetentionResult{RolledUpBefore: now.Truncate(time.Hour)}
+
+	var err error
+	if result.HourlyRolled, err = s.rollupStore.RollupSamples(ctx, storage.RollupHourly, result.RolledUpBefore); err != nil {
+		return result, err
+	}
+	if result.DailyRolled, err = s.rollupStore.RollupSamples(ctx, storage.RollupDaily, truncateDay(now)); err != nil {
+		return result, err
+	}
+
+	if s.retention.Raw > 0 {
+		result.RawCutoff = now.Add(-s.retention.Raw).Truncate(time.Hour)
+		if result.SamplesPruned, err = s.rollupStore.DeleteSamplesBefore(ctx, result.RawCutoff); err != nil {
+			return result, err
+		}
+	}
+	if s.retention.Hourly > 0 {
+		result.HourlyCutoff = truncateDay(now.Add(-s.retention.Hourly))
+		if result.HourlyPruned, err = s.rollupStore.DeleteRollupsBefore(ctx, storage.RollupHourly, result.HourlyCutoff); err != nil {
+			return result, err
+		}
+	}
+	if s.retention.Daily > 0 {
+		result.DailyCutoff = truncateDay(now.Add(-s.retention.Daily))
+		if result.DailyPruned, err = s.rollupStore.DeleteRollupsBefore(ctx, storage.RollupDaily, result.DailyCutoff); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// RunRetention applies retention immediately and then every interval until
+// ctx is cancelled.
+func (s *Service) RunRetention(ctx context.Context) error {
+	interval := s.retention.Interval
+	if interval <= 0 {
+		return fmt.Errorf("retention interval must be positive")
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		s.retentionPass(ctx)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Service) retentionPass(ctx context.Context) {
+	if s.lockKey != 0 && s.locker != nil {
+		unlock, acquired, err := s.locker.TryAdvisoryLock(ctx, s.lockKey+retentionLockOffset)
+		if err != nil {
+			s.logger.Error().Err(err).Msg("failed to acquire retention lock")
+			return
+		}
+		if !acquired {
+			s.logger.Debug().Msg("skip retention because lock held elsewhere")
+			return
+		}
+		defer unlock()
+	}
+
+	result, err := s.ApplyRetention(ctx, time.Now())
+	if err != nil {
+		s.logger.Error().Err(err).Msg("retention pass failed")
+		return
+	}
+	s.logger.Info().Int64("hourly_rolled", result.HourlyRolled).
+		Int64("daily_rolled", result.DailyRolled).
+		Int64("samples_pruned", result.SamplesPruned).
+		Int64("hourly_pruned", result.HourlyPruned).
+		Int64("daily_pruned", result.DailyPruned).
+		Msg("retention pass completed")
+}
+
+func truncateDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"price-diff-alerts/internal/config"
+	"price-diff-alerts/internal/storage"
+)
+
+type rollupRecordingStore struct {
+	recordingStore
+	calls []string
+}
+
+func (r *rollupRecordingStore) RollupSamples(ctx context.Context, resolution string, before time.Time) (int64, error) {
+	r.calls = append(r.calls, fmt.Sprintf("rollup %s < %s", resolution, before.Format(time.RFC3339)))
+	return 1, nil
+}
+
+func (r *rollupRecordingStore) ListRollupsBetween(ctx context.Context, pairID, resolution string, from, to time.Time) ([]storage.SampleRollup, error) {
+	return nil, nil
+}
+
+func (r *rollupRecordingStore) ListRollupsPage(ctx context.Context, pairID, resolution string, from, to time.Time, limit int) ([]storage.SampleRollup, error) {
+	return nil, nil
+}
+
+func (r *rollupRecordingStore) DeleteRollupsBefore(ctx context.Context, resolution string, before time.Time) (int64, error) {
+	r.calls = append(r.calls, fmt.Sprintf("prune %s < %s", resolution, before.Format(time.RFC3339)))
+	return 2, nil
+}
+
+func (r *rollupRecordingStore) DeleteSamplesBefore(ctx context.Context, before time.Time) (int64, error) {
+	r.calls = append(r.calls, fmt.Sprintf("prune raw < %s", before.Format(time.RFC3339)))
+	return 3, nil
+}
+
+func TestApplyRetentionRollsUpBeforePruning(t *testing.T) {
+	cfg := testConfig()
+	cfg.Retention = config.RetentionConfig{Enabled: true, Interval: time.Hour, Raw: 72 * time.Hour, Hourly: 30 * 24 * time.Hour}
+	store := &rollupRecordingStore{}
+	svc := New(cfg, nil, testPairs(&stubOfficial{}, &stubMarket{}), store, nil, nil, zerolog.Nop())
+
+	now := time.Date(2025, 9, 22, 10, 42, 0, 0, time.UTC)
+	result, err := svc.ApplyRetention(context.Background(), now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"rollup 1h < 2025-09-22T10:00:00Z",
+		"rollup 1d < 2025-09-22T00:00:00Z",
+		"prune raw < 2025-09-19T10:00:00Z",
+		"prune 1h < 2025-08-23T00:00:00Z",
+	}
+	if len(store.calls) != len(want) {
+		t.Fatalf("调用序列不正确: %v", store.calls)
+	}
+	for i := range want {
+		if store.calls[i] != want[i] {
+			t.Fatalf("第 %d 步应为 %q，实际 %q", i, want[i], store.calls[i])
+		}
+	}
+	if result.SamplesPruned != 3 || result.HourlyPruned != 2 || !result.DailyCutoff.IsZero() {
+		t.Fatalf("结果不正确: %+v", result)
+	}
+}
+
+func TestApplyRetentionRequiresRollupStore(t *testing.T) {
+	svc := New(testConfig(), nil, testPairs(&stubOfficial{}, &stubMarket{}), &recordingStore{}, nil, nil, zerolog.Nop())
+	if _, err := svc.ApplyRetention(context.Background(), time.Now()); err == nil {
+		t.Fatal("没有 RollupStore 时应报错")
+	}
+}
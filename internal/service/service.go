@@ -2,7 +2,10 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/rs/zerolog"
@@ -18,52 +21,216 @@ import (
 // Service orchestrates fetching, persistence, and alerting.
 type Service struct {
 	scheduler  *scheduler.Scheduler
-	official   fetcher.OfficialRateFetcher
-	market     fetcher.MarketRateFetcher
+	pairs      []*pairRuntime
 	store      storage.RateSampleStore
 	alertStore storage.AlertStore
 	notifier   alerting.Notifier
 	logger     zerolog.Logger
 
+	alertsOn bool
+	locker   storage.AdvisoryLocker
+	lockKey  int64
+
+	policy        alertPolicy
+	stateStore    storage.AlertStateStore
+	deliveryStore storage.AlertDeliveryStore
+	rollupStore   storage.RollupStore
+	retention     config.RetentionConfig
+	uow           storage.UnitOfWork
+	outbox        storage.OutboxStore
+	outboxPolicy  config.OutboxConfig
+	digests       storage.DigestStore
+	// outboxMu keeps overlapping drains in this process from double-sending.
+	outboxMu sync.Mutex
+	metrics  Metrics
+	health   *healthTracker
+	pinger   storage.Pinger
+}
+
+// Pair is one monitored pair together with the fetchers that sample it.
+type Pair struct {
+	Config   config.PairConfig
+	Official fetcher.OfficialRateFetcher
+	Market   fetcher.MarketRateFetcher
+}
+
+// pairRuntime is the per-pair state of the sampling loop.
+type pairRuntime struct {
+	cfg      config.PairConfig
+	official fetcher.OfficialRateFetcher
+	market   fetcher.MarketRateFetcher
+	locator  fetcher.BlockLocator
+	logger   zerolog.Logger
+
 	threshold decimal.Decimal
-	notional  decimal.Decimal
-	channels  []string
-	alertsOn  bool
-	locker    storage.AdvisoryLocker
-	lockKey   int64
+	// clear is the hysteresis level a firing alert must fall back to.
+	clear    decimal.Decimal
+	notional decimal.Decimal
+	channels []string
+	// alertState caches the last known state; the store copy wins when available.
+	alertState storage.AlertState
+}
 
-	consecutiveBreaches int
+// notification fills the pair's identity and routing into note.
+func (p *pairRuntime) notification(note alerting.Notification) alerting.Notification {
+	note.PairID = p.cfg.ID
+	note.Pair = p.cfg.Name()
+	note.VaultSymbol = p.cfg.VaultSymbol
+	note.UnderlyingSymbol = p.cfg.UnderlyingSymbol
+	note.ThresholdPct = p.threshold
+	note.Channels = p.channels
+	note.Notional = p.notional
+	return note
 }
 
-// New constructs the monitoring service.
-func New(cfg *config.Config, sched *scheduler.Scheduler, official fetcher.OfficialRateFetcher, market fetcher.MarketRateFetcher, store storage.RateSampleStore, alertStore storage.AlertStore, notifier alerting.Notifier, logger zerolog.Logger) *Service {
-	threshold := decimal.Zero
-	if cfg.Alerting.Enabled && cfg.Alerting.ThresholdPct > 0 {
-		threshold = decimal.NewFromFloat(cfg.Alerting.ThresholdPct)
-	}
+// Metrics receives the service's operational signals. It is satisfied by
+// *metrics.Metrics; the default discards everything. Every signal carries the
+// pair id; fetches and failures are also labelled with the sample phase
+// (official or market).
+type Metrics interface {
+	ObserveFetch(pairID, phase string, elapsed time.Duration)
+	FetchFailed(pairID, phase string, err error)
+	SampleRecorded(pairID string, bucket time.Time, official, market, deviation decimal.Decimal)
+	AlertFired(pairID, direction string)
+}
+
+type nopMetrics struct{}
+
+func (nopMetrics) ObserveFetch(string, string, time.Duration) {}
+func (nopMetrics) FetchFailed(string, string, error)          {}
+func (nopMetrics) SampleRecorded(string, time.Time, decimal.Decimal, decimal.Decimal, decimal.Decimal) {
+}
+func (nopMetrics) AlertFired(string, string) {}
+
+// New constructs the monitoring service for pairs, which are sampled in
+// order every bucket.
+func New(cfg *config.Config, sched *scheduler.Scheduler, pairs []Pair, store storage.RateSampleStore, alertStore storage.AlertStore, notifier alerting.Notifier, logger zerolog.Logger) *Service {
+	logger = logger.With().Str("component", "service").Logger()
 
-	notional := decimal.NewFromFloat(cfg.Cow.NotionalUSDE)
+	runtimes := make([]*pairRuntime, 0, len(pairs))
+	ids := make([]string, 0, len(pairs))
+	for _, pair := range pairs {
+		runtimes = append(runtimes, newPairRuntime(cfg, pair, logger))
+		ids = append(ids, pair.Config.ID)
+	}
 
 	var locker storage.AdvisoryLocker
 	if l, ok := store.(storage.AdvisoryLocker); ok {
 		locker = l
 	}
 
+	var stateStore storage.AlertStateStore
+	if st, ok := alertStore.(storage.AlertStateStore); ok {
+		stateStore = st
+	}
+
+	var deliveryStore storage.AlertDeliveryStore
+	if st, ok := alertStore.(storage.AlertDeliveryStore); ok {
+		deliveryStore = st
+	}
+
+	var rollupStore storage.RollupStore
+	if st, ok := store.(storage.RollupStore); ok {
+		rollupStore = st
+	}
+
+	var uow storage.UnitOfWork
+	if u, ok := store.(storage.UnitOfWork); ok {
+		uow = u
+	}
+
+	var outbox storage.OutboxStore
+	if o, ok := store.(storage.OutboxStore); ok {
+		outbox = o
+	}
+
+	var digests storage.DigestStore
+	if d, ok := store.(storage.DigestStore); ok {
+		digests = d
+	}
+
+	var pinger storage.Pinger
+	if p, ok := store.(storage.Pinger); ok {
+		pinger = p
+	}
+
 	return &Service{
 		scheduler:  sched,
-		official:   official,
-		market:     market,
+		pairs:      runtimes,
 		store:      store,
 		alertStore: alertStore,
 		notifier:   notifier,
-		logger:     logger.With().Str("component", "service").Logger(),
-		threshold:  threshold,
-		notional:   notional,
-		channels:   cfg.Alerting.Channels,
+		logger:     logger,
 		alertsOn:   cfg.Alerting.Enabled,
 		locker:     locker,
 		lockKey:    cfg.Scheduler.AdvisoryLockKey,
+		policy: alertPolicy{
+			breaches:    cfg.Alerting.Confirmation.Breaches,
+			window:      cfg.Alerting.Confirmation.EffectiveWindow(),
+			minDuration: cfg.Alerting.Confirmation.MinDuration,
+			cooldown:    cfg.Alerting.Cooldown,
+			interval:    cfg.Scheduler.Interval,
+		},
+		stateStore:    stateStore,
+		deliveryStore: deliveryStore,
+		rollupStore:   rollupStore,
+		retention:     cfg.Retention,
+		uow:           uow,
+		outbox:        outbox,
+		outboxPolicy:  cfg.Alerting.Outbox,
+		digests:       digests,
+		metrics:       nopMetrics{},
+		health:        newHealthTracker(cfg.Scheduler.StartupDelay, ids),
+		pinger:        pinger,
+	}
+}
+
+func newPairRuntime(cfg *config.Config, pair Pair, logger zerolog.Logger) *pairRuntime {
+	threshold := decimal.Zero
+	clearThreshold := decimal.Zero
+	if cfg.Alerting.Enabled && pair.Config.ThresholdPct > 0 {
+		threshold = decimal.NewFromFloat(pair.Config.ThresholdPct)
+		clearThreshold = threshold
+		if pair.Config.ClearThresholdPct > 0 {
+			clearThreshold = decimal.NewFromFloat(pair.Config.ClearThresholdPct)
+		}
+	}
+
+	var locator fetcher.BlockLocator
+	if l, ok := pair.Official.(fetcher.BlockLocator); ok {
+		locator = l
+	}
+
+	return &pairRuntime{
+		cfg:        pair.Config,
+		official:   pair.Official,
+		market:     pair.Market,
+		locator:    locator,
+		logger:     logger.With().Str("pair", pair.Config.ID).Logger(),
+		threshold:  threshold,
+		clear:      clearThreshold,
+		notional:   decimal.NewFromFloat(pair.Config.Notional),
+		channels:   pair.Config.Channels,
+		alertState: storage.AlertState{Key: pair.Config.ID, State: storage.AlertStateOK},
+	}
+}
+
+// pair returns the runtime of the configured pair with the given id.
+func (s *Service) pair(id string) (*pairRuntime, error) {
+	for _, p := range s.pairs {
+		if p.cfg.ID == id {
+			return p, nil
+		}
+	}
+	return nil, fmt.Errorf("pair %q not configured", id)
+}
+
+// SetMetrics routes fetch, sample and alert signals to m.
+func (s *Service) SetMetrics(m Metrics) {
+	if m == nil {
+		m = nopMetrics{}
 	}
+	s.metrics = m
 }
 
 // Run begins the aligned sampling loop.
@@ -76,10 +243,14 @@ func (s *Service) Run(ctx context.Context) error {
 
 // ProcessBucket 执行单个时间桶的采样逻辑。
 func (s *Service) ProcessBucket(ctx context.Context, bucket time.Time) error {
+	// A failed lock query still counts as an attempt: the loop is alive and
+	// the database outage is reported by readiness instead.
+	s.health.attempted(time.Now().UTC())
 	unlock, proceed, err := s.acquireLock(ctx)
 	if err != nil {
 		return err
 	}
+	s.health.locked(s.lockState(proceed))
 	if !proceed {
 		s.logger.Debug().Time("bucket", bucket).Msg("skip bucket because advisory lock held elsewhere")
 		return nil
@@ -88,99 +259,469 @@ func (s *Service) ProcessBucket(ctx context.Context, bucket time.Time) error {
 		defer unlock()
 	}
 
-	return s.executeBucket(ctx, bucket)
+	// A failing pair must not hold back the others; every failure is reported.
+	var errs []error
+	for _, p := range s.pairs {
+		if err := s.executeBucket(ctx, p, bucket); err != nil {
+			errs = append(errs, fmt.Errorf("pair %s: %w", p.cfg.ID, err))
+		}
+	}
+	return errors.Join(errs...)
 }
 
-func (s *Service) executeBucket(ctx context.Context, bucket time.Time) error {
-	officialRate, blockNumber, err := s.official.FetchOfficial(ctx)
-	if err != nil {
-		return fmt.Errorf("fetch official rate: %w", err)
+func (s *Service) executeBucket(ctx context.Context, p *pairRuntime, bucket time.Time) error {
+	sample := storage.RateSample{
+		PairID:    p.cfg.ID,
+		Bucket:    bucket,
+		Notional:  p.notional,
+		Status:    storage.SampleStatusComplete,
+		CreatedAt: time.Now().UTC(),
 	}
 
-	if officialRate.IsZero() {
-		return fmt.Errorf("official rate returned zero")
+	var failures []legFailure
+
+	started := time.Now()
+	official, err := p.official.FetchOfficial(ctx)
+	officialRate := official.Rate
+	s.metrics.ObserveFetch(p.cfg.ID, storage.SamplePhaseOfficial, time.Since(started))
+	if err == nil && officialRate.IsZero() {
+		err = errors.New("official rate returned zero")
+	}
+	s.health.fetched(p.cfg.ID, storage.SamplePhaseOfficial, time.Now().UTC(), err)
+	if err != nil {
+		failures = append(failures, legFailure{phase: storage.SamplePhaseOfficial, err: fmt.Errorf("fetch official rate: %w", err)})
+	} else {
+		recordOfficial(&sample, official)
 	}
 
-	marketRate, quote, quality, err := s.market.FetchMarket(ctx)
+	started = time.Now()
+	marketRate, quote, quality, err := p.market.FetchMarket(ctx)
+	s.metrics.ObserveFetch(p.cfg.ID, storage.SamplePhaseMarket, time.Since(started))
+	s.health.fetched(p.cfg.ID, storage.SamplePhaseMarket, time.Now().UTC(), err)
 	if err != nil {
-		return fmt.Errorf("fetch market rate: %w", err)
+		failures = append(failures, legFailure{phase: storage.SamplePhaseMarket, err: fmt.Errorf("fetch market rate: %w", err)})
+	} else {
+		sample.MarketRate = decimal.NewNullDecimal(marketRate)
+		sample.CowQuote = quote
+		sample.CowQuality = quality
+	}
+
+	if len(failures) > 0 {
+		for _, f := range failures {
+			s.metrics.FetchFailed(p.cfg.ID, f.phase, f.err)
+		}
+		return s.recordFailedBucket(ctx, p, sample, failures)
 	}
 
 	deviation := marketRate.Div(officialRate).Sub(decimal.NewFromInt(1)).Mul(decimal.NewFromInt(100))
+	sample.DeviationPct = decimal.NewNullDecimal(deviation)
 
-	sample := storage.RateSample{
-		Bucket:       bucket,
-		OfficialRate: officialRate,
-		MarketRate:   marketRate,
-		DeviationPct: deviation,
-		NotionalUSDE: s.notional,
-		CowQuality:   quality,
-		CowQuote:     quote,
-		Status:       "complete",
-		CreatedAt:    time.Now().UTC(),
-	}
-	if blockNumber != 0 {
-		block := int64(blockNumber)
-		sample.BlockNumber = &block
+	// The sample, the alert rows, the alert state and any queued notification
+	// commit together; nothing is sent unless that commit succeeds.
+	var (
+		notes []pendingNote
+		next  *storage.AlertState
+	)
+	err = s.inUnitOfWork(ctx, func(tx bucketTx) error {
+		notes, next = nil, nil
+		if tx.samples != nil {
+			if err := tx.samples.UpsertRateSample(ctx, sample); err != nil {
+				return fmt.Errorf("upsert sample: %w", err)
+			}
+		}
+		// Alert state is tracked even without a notifier so digests can report it.
+		if s.alertsOn && (s.notifier != nil || s.alertStore != nil) && !p.threshold.IsZero() {
+			st, queued, err := s.evaluateAlert(ctx, tx, p, bucket, officialRate, marketRate, deviation)
+			if err != nil {
+				return err
+			}
+			notes, next = queued, &st
+		}
+		return nil
+	})
+	if err != nil {
+		p.logger.Error().Err(err).Time("bucket", bucket).Msg("failed to persist bucket")
+		return err
+	}
+	if next != nil {
+		p.alertState = *next
 	}
 
+	p.logger.Info().Time("bucket", bucket).
+		Str("quality", quality).
+		Str("deviation_pct", deviation.String()).
+		Msg("sample recorded")
+
+	s.health.completed(p.cfg.ID, bucket, time.Now().UTC())
+	s.metrics.SampleRecorded(p.cfg.ID, bucket, officialRate, marketRate, deviation)
+	for _, pending := range notes {
+		if pending.note.Kind == alerting.KindFiring {
+			s.metrics.AlertFired(p.cfg.ID, pending.note.Direction)
+		}
+	}
+
+	s.deliverAfterCommit(ctx, notes)
+	return nil
+}
+
+// ProcessHistoricalBucket 回填指定交易对的单个历史时间桶并写入存储，失败时同样落一行 errored 记录。
+func (s *Service) ProcessHistoricalBucket(ctx context.Context, pairID string, bucket time.Time) error {
+	sample, buildErr := s.HistoricalSample(ctx, pairID, bucket)
+	if sample.PairID == "" {
+		return buildErr
+	}
 	if s.store != nil {
 		if err := s.store.UpsertRateSample(ctx, sample); err != nil {
-			s.logger.Error().Err(err).Time("bucket", bucket).Msg("failed to upsert sample")
+			return fmt.Errorf("upsert historical sample: %w", err)
 		}
 	}
+	return buildErr
+}
 
-	s.logger.Info().Time("bucket", bucket).
-		Str("quality", quality).
+// HistoricalSample 构造单个历史时间桶的样本但不落库：官方价读取 bucket 时刻对应区块的链上状态，
+// 市场价无法还原，因此该腿记为缺失而不是用当前报价冒充。历史 bucket 不触发告警。
+// 返回错误时样本状态为 errored，调用方仍应持久化它；交易对未配置时返回空样本。
+func (s *Service) HistoricalSample(ctx context.Context, pairID string, bucket time.Time) (storage.RateSample, error) {
+	p, err := s.pair(pairID)
+	if err != nil {
+		return storage.RateSample{}, err
+	}
+	sample := storage.RateSample{
+		PairID:    p.cfg.ID,
+		Bucket:    bucket,
+		Notional:  p.notional,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	if p.locator == nil {
+		return s.failSample(p, sample, []legFailure{{phase: storage.SamplePhaseOfficial, err: errors.New("official fetcher cannot locate historical blocks")}})
+	}
+
+	blockNumber, err := p.locator.BlockAtTime(ctx, bucket)
+	if err != nil {
+		return s.failSample(p, sample, []legFailure{{phase: storage.SamplePhaseOfficial, err: fmt.Errorf("locate block: %w", err)}})
+	}
+	block := int64(blockNumber)
+	sample.BlockNumber = &block
+
+	official, err := p.official.FetchOfficialAt(ctx, blockNumber)
+	officialRate := official.Rate
+	if err == nil && officialRate.IsZero() {
+		err = errors.New("official rate returned zero")
+	}
+	if err != nil {
+		return s.failSample(p, sample, []legFailure{{phase: storage.SamplePhaseOfficial, err: fmt.Errorf("fetch official rate: %w", err)}})
+	}
+	recordOfficial(&sample, official)
+
+	phase := storage.SamplePhaseMarket
+	msg := "market quote cannot be reconstructed for historical buckets"
+	sample.Status = storage.SampleStatusPartial
+	sample.ErrorPhase = &phase
+	sample.Error = &msg
+
+	p.logger.Debug().Time("bucket", bucket).
+		Uint64("block", blockNumber).
+		Str("official", officialRate.String()).
+		Msg("historical sample built")
+	return sample, nil
+}
+
+// recordOfficial copies an official quote and the block it was read at into
+// sample; details the fetcher did not report stay NULL.
+func recordOfficial(sample *storage.RateSample, quote fetcher.OfficialQuote) {
+	sample.OfficialRate = decimal.NewNullDecimal(quote.Rate)
+	if quote.Method != "" {
+		method := quote.Method
+		sample.OfficialMethod = &method
+	}
+	if quote.BlockNumber != 0 {
+		block := int64(quote.BlockNumber)
+		sample.BlockNumber = &block
+	}
+	if quote.BlockHash != "" {
+		hash := quote.BlockHash
+		sample.BlockHash = &hash
+	}
+	if !quote.BlockTime.IsZero() {
+		ts := quote.BlockTime.UTC()
+		sample.BlockTime = &ts
+	}
+}
+
+// evaluateAlert advances the persisted alert state machine for one complete
+// bucket inside tx and returns the new state together with the notifications
+// to send once tx commits.
+func (s *Service) evaluateAlert(ctx context.Context, tx bucketTx, p *pairRuntime, bucket time.Time, officialRate, marketRate, deviation decimal.Decimal) (storage.AlertState, []pendingNote, error) {
+	direction := classifyDeviation(deviation)
+	obs := alertObservation{
+		bucket:    bucket,
+		breached:  deviation.Abs().GreaterThan(p.threshold),
+		cleared:   deviation.Abs().LessThanOrEqual(p.clear),
+		direction: direction,
+		deviation: deviation,
+	}
+
+	current, err := s.loadAlertState(ctx, tx, p)
+	if err != nil {
+		return storage.AlertState{}, nil, err
+	}
+	next, decision := advanceAlertState(current, obs, s.policy)
+
+	logEvent := p.logger.Debug()
+	if decision.changed() {
+		logEvent = p.logger.Info()
+	}
+	logEvent.Time("bucket", bucket).
+		Str("from", decision.from).
+		Str("to", decision.to).
+		Str("direction", next.Direction).
+		Int("breaches", next.BreachCount).
+		Int("window", s.policy.windowSize()).
 		Str("deviation_pct", deviation.String()).
-		Msg("sample recorded")
+		Msg("alert state evaluated")
 
-	if s.alertsOn && s.notifier != nil && !s.threshold.IsZero() {
-		if deviation.Abs().GreaterThan(s.threshold) {
-			s.consecutiveBreaches++
-			if s.consecutiveBreaches < 2 {
-				s.logger.Debug().Time("bucket", bucket).
-					Str("deviation_pct", deviation.String()).
-					Msg("threshold breached once; waiting for confirmation")
-				return nil
-			}
+	if err := s.syncAlertRecord(ctx, tx, p, decision.previous, next, bucket, deviation); err != nil {
+		return storage.AlertState{}, nil, err
+	}
 
-			direction := classifyDeviation(deviation)
-			note := alerting.Notification{
-				Bucket:       bucket,
-				OfficialRate: officialRate,
-				MarketRate:   marketRate,
-				DeviationPct: deviation,
-				ThresholdPct: s.threshold,
-				Direction:    direction,
-				Channels:     s.channels,
-				NotionalUSDE: s.notional,
-			}
-			if s.alertStore != nil {
-				record := storage.AlertRecord{
-					SampleTS:     bucket,
-					DeviationPct: deviation,
-					ThresholdPct: s.threshold,
-					Direction:    direction,
-					Channels:     s.channels,
-				}
-				if _, err := s.alertStore.InsertAlert(ctx, record); err != nil {
-					s.logger.Error().Err(err).Time("bucket", bucket).Msg("failed to persist alert record")
-				}
-			}
-			if err := s.notifier.Notify(ctx, note); err != nil {
-				s.logger.Error().Err(err).Time("bucket", bucket).Msg("failed to dispatch alert")
-			}
-		} else if s.consecutiveBreaches > 0 {
-			s.consecutiveBreaches = 0
+	if decision.suppressed {
+		p.logger.Info().Time("bucket", bucket).
+			Str("direction", direction).
+			Dur("cooldown", s.policy.cooldown).
+			Msg("alert confirmed but suppressed by cooldown")
+	}
+
+	var notes []pendingNote
+	if decision.resolved {
+		note := p.notification(resolvedNotification(decision.previous, bucket, officialRate, marketRate, deviation))
+		p.logger.Info().Time("bucket", bucket).
+			Str("direction", note.Direction).
+			Dur("duration", note.Duration).
+			Str("peak_deviation_pct", note.PeakDeviationPct.String()).
+			Msg("alert resolved")
+		notes = append(notes, pendingNote{note: note, episode: decision.previous.PendingSince})
+	}
+
+	if decision.notify {
+		notes = append(notes, pendingNote{note: p.notification(alerting.Notification{
+			Kind:         alerting.KindFiring,
+			Bucket:       bucket,
+			OfficialRate: officialRate,
+			MarketRate:   marketRate,
+			DeviationPct: deviation,
+			Direction:    direction,
+		}), episode: next.PendingSince})
+	}
+
+	if err := s.enqueueNotes(ctx, tx, notes); err != nil {
+		return storage.AlertState{}, nil, err
+	}
+	if tx.states != nil {
+		if err := tx.states.SaveAlertState(ctx, next); err != nil {
+			return storage.AlertState{}, nil, fmt.Errorf("save alert state: %w", err)
 		}
-	} else if s.consecutiveBreaches > 0 {
-		s.consecutiveBreaches = 0
 	}
+	return next, notes, nil
+}
+
+// syncAlertRecord mirrors the episode lifecycle onto the alerts table: one row
+// per episode keyed by its first breaching bucket, moving pending → firing →
+// resolved, or pending → cancelled when confirmation never happened.
+func (s *Service) syncAlertRecord(ctx context.Context, tx bucketTx, p *pairRuntime, prev, next storage.AlertState, bucket time.Time, deviation decimal.Decimal) error {
+	if tx.alerts == nil {
+		return nil
+	}
+	prevKey := episodeKey(prev)
+	nextKey := episodeKey(next)
 
+	if prevKey != nil && (nextKey == nil || !nextKey.Equal(*prevKey)) {
+		var err error
+		closed := storage.AlertStateCancelled
+		if prev.State == storage.AlertStateFiring {
+			closed = storage.AlertStateResolved
+			err = tx.alerts.ResolveAlert(ctx, p.cfg.ID, *prevKey, bucket, prev.PeakDeviationPct.Decimal)
+		} else {
+			err = tx.alerts.UpdateAlertState(ctx, p.cfg.ID, *prevKey, closed)
+		}
+		if err != nil {
+			return fmt.Errorf("close alert record %s as %s: %w", prevKey.Format(time.RFC3339), closed, err)
+		}
+	}
+
+	if nextKey == nil {
+		return nil
+	}
+	if prevKey != nil && nextKey.Equal(*prevKey) && prev.State == next.State {
+		return nil
+	}
+	record := storage.AlertRecord{
+		PairID:       p.cfg.ID,
+		SampleTS:     *nextKey,
+		DeviationPct: deviation,
+		ThresholdPct: p.threshold,
+		Direction:    next.Direction,
+		Channels:     p.channels,
+		State:        next.State,
+		ConfirmedAt:  next.FiringSince,
+		// The peak is final once ResolveAlert closes the row.
+		PeakDeviationPct: next.PeakDeviationPct,
+	}
+	if _, err := tx.alerts.InsertAlert(ctx, record); err != nil {
+		return fmt.Errorf("persist alert record %s: %w", nextKey.Format(time.RFC3339), err)
+	}
 	return nil
 }
 
+// dispatch sends note to its pair's channels; see deliver.
+func (s *Service) dispatch(ctx context.Context, note alerting.Notification, episode *time.Time) error {
+	_, err := s.deliver(ctx, note, episode, note.Channels)
+	return err
+}
+
+// deliver sends note to channels, or to every channel when channels is empty
+// or the notifier cannot target individual channels. When the notifier
+// reports per-channel results they are recorded against the alert row of the
+// note's pair whose episode starts at episode, and the failed channel names
+// are returned. The returned error joins every channel that failed.
+func (s *Service) deliver(ctx context.Context, note alerting.Notification, episode *time.Time, channels []string) ([]string, error) {
+	if s.notifier == nil {
+		return nil, nil
+	}
+	reporter, ok := s.notifier.(alerting.DeliveryReporter)
+	if !ok {
+		err := s.notifier.Notify(ctx, note)
+		if err != nil {
+			s.logger.Error().Err(err).Str("pair", note.PairID).Time("bucket", note.Bucket).Str("kind", note.Kind).Msg("failed to dispatch alert")
+		}
+		return nil, err
+	}
+
+	var results []alerting.DeliveryResult
+	if targeted, ok := s.notifier.(alerting.ChannelDeliverer); ok && len(channels) > 0 {
+		results = targeted.DeliverTo(ctx, note, channels)
+	} else {
+		results = reporter.Deliver(ctx, note)
+	}
+	deliveries := make([]storage.AlertDelivery, 0, len(results))
+	var (
+		errs   []error
+		failed []string
+	)
+	for _, res := range results {
+		d := storage.AlertDelivery{
+			Kind:     note.Kind,
+			Channel:  res.Channel,
+			Status:   storage.DeliveryStatusDelivered,
+			Attempts: res.Attempts,
+		}
+		if res.Err != nil {
+			msg := res.Err.Error()
+			d.Status = storage.DeliveryStatusFailed
+			d.Error = &msg
+			errs = append(errs, fmt.Errorf("%s: %w", res.Channel, res.Err))
+			failed = append(failed, res.Channel)
+			s.logger.Error().Err(res.Err).Str("pair", note.PairID).Time("bucket", note.Bucket).
+				Str("kind", note.Kind).
+				Str("channel", res.Channel).
+				Int("attempts", res.Attempts).
+				Msg("failed to dispatch alert")
+		}
+		deliveries = append(deliveries, d)
+	}
+
+	if s.deliveryStore != nil && episode != nil {
+		if err := s.deliveryStore.RecordAlertDeliveries(ctx, note.PairID, *episode, deliveries); err != nil {
+			s.logger.Error().Err(err).Str("pair", note.PairID).Time("episode", *episode).Msg("failed to record alert deliveries")
+		}
+	}
+	return failed, errors.Join(errs...)
+}
+
+// resolvedNotification describes the episode in prev, which ended at bucket.
+func resolvedNotification(prev storage.AlertState, bucket time.Time, officialRate, marketRate, deviation decimal.Decimal) alerting.Notification {
+	note := alerting.Notification{
+		Kind:             alerting.KindResolved,
+		Bucket:           bucket,
+		OfficialRate:     officialRate,
+		MarketRate:       marketRate,
+		DeviationPct:     deviation,
+		Direction:        prev.Direction,
+		PeakDeviationPct: prev.PeakDeviationPct.Decimal,
+	}
+	if prev.PendingSince != nil {
+		note.StartedAt = *prev.PendingSince
+		note.Duration = bucket.Sub(*prev.PendingSince)
+	}
+	return note
+}
+
+// episodeKey returns the first breaching bucket of an open episode.
+func episodeKey(st storage.AlertState) *time.Time {
+	if st.State != storage.AlertStatePending && st.State != storage.AlertStateFiring {
+		return nil
+	}
+	return st.PendingSince
+}
+
+// loadAlertState prefers the persisted state so another leader's progress is honoured.
+func (s *Service) loadAlertState(ctx context.Context, tx bucketTx, p *pairRuntime) (storage.AlertState, error) {
+	if tx.states == nil {
+		return p.alertState, nil
+	}
+	st, found, err := tx.states.GetAlertState(ctx, p.alertState.Key)
+	if err != nil {
+		return storage.AlertState{}, fmt.Errorf("load alert state: %w", err)
+	}
+	if !found {
+		return storage.AlertState{Key: p.alertState.Key, State: storage.AlertStateOK}, nil
+	}
+	return st, nil
+}
+
+// legFailure records which fetch leg failed for a bucket.
+type legFailure struct {
+	phase string
+	err   error
+}
+
+// recordFailedBucket persists an errored row keeping whichever leg succeeded,
+// so the bucket shows up as failed rather than missing.
+func (s *Service) recordFailedBucket(ctx context.Context, p *pairRuntime, sample storage.RateSample, failures []legFailure) error {
+	sample, failErr := s.failSample(p, sample, failures)
+
+	if s.store != nil {
+		if err := s.store.UpsertRateSample(ctx, sample); err != nil {
+			p.logger.Error().Err(err).Time("bucket", sample.Bucket).Msg("failed to upsert errored sample")
+		}
+	}
+	return failErr
+}
+
+// failSample marks sample as errored with the failing phases and returns the joined error.
+func (s *Service) failSample(p *pairRuntime, sample storage.RateSample, failures []legFailure) (storage.RateSample, error) {
+	phases := make([]string, 0, len(failures))
+	errs := make([]error, 0, len(failures))
+	for _, f := range failures {
+		phases = append(phases, f.phase)
+		errs = append(errs, f.err)
+	}
+	failErr := errors.Join(errs...)
+
+	phase := strings.Join(phases, ",")
+	msg := failErr.Error()
+	sample.Status = storage.SampleStatusErrored
+	sample.ErrorPhase = &phase
+	sample.Error = &msg
+
+	p.logger.Warn().Time("bucket", sample.Bucket).
+		Str("phase", phase).
+		Err(failErr).
+		Msg("sample recorded as errored")
+
+	return sample, failErr
+}
+
 func classifyDeviation(d decimal.Decimal) string {
 	switch d.Sign() {
 	case 1:
@@ -192,6 +733,15 @@ func classifyDeviation(d decimal.Decimal) string {
 	}
 }
 
+// lockState reports lock ownership for health checks; nil means the service
+// runs without an advisory lock.
+func (s *Service) lockState(acquired bool) *bool {
+	if s.lockKey == 0 || s.locker == nil {
+		return nil
+	}
+	return &acquired
+}
+
 func (s *Service) acquireLock(ctx context.Context) (func(), bool, error) {
 	if s.lockKey == 0 || s.locker == nil {
 		return nil, true, nil
@@ -0,0 +1,500 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/shopspring/decimal"
+
+	"price-diff-alerts/internal/alerting"
+	"price-diff-alerts/internal/config"
+	"price-diff-alerts/internal/fetcher"
+	"price-diff-alerts/internal/storage"
+)
+
+type stubOfficial struct {
+	rate decimal.Decimal
+	err  error
+}
+
+func (s *stubOfficial) FetchOfficial(ctx context.Context) (fetcher.OfficialQuote, error) {
+	return fetcher.OfficialQuote{
+		Rate:        s.rate,
+		BlockNumber: 42,
+		BlockHash:   "0x2a",
+		BlockTime:   time.Unix(1_700_000_000, 0),
+		Method:      fetcher.MethodPreviewDeposit,
+	}, s.err
+}
+
+func (s *stubOfficial) FetchOfficialAt(ctx context.Context, blockNumber uint64) (fetcher.OfficialQuote, error) {
+	return fetcher.OfficialQuote{Rate: s.rate, BlockNumber: blockNumber, Method: fetcher.MethodPreviewDeposit}, s.err
+}
+
+func (s *stubOfficial) BlockAtTime(ctx context.Context, ts time.Time) (uint64, error) {
+	return uint64(ts.Unix() / 12), nil
+}
+
+type stubMarket struct {
+	rate decimal.Decimal
+	err  error
+}
+
+func (s *stubMarket) FetchMarket(ctx context.Context) (decimal.Decimal, json.RawMessage, string, error) {
+	if s.err != nil {
+		return decimal.Decimal{}, nil, "", s.err
+	}
+	return s.rate, json.RawMessage(`{}`), "optimal", nil
+}
+
+type recordingStore struct {
+	samples []storage.RateSample
+}
+
+func (r *recordingStore) UpsertRateSample(ctx context.Context, sample storage.RateSample) error {
+	r.samples = append(r.samples, sample)
+	return nil
+}
+
+func (r *recordingStore) ListSamplesBetween(ctx context.Context, pairID string, from, to time.Time) ([]storage.RateSample, error) {
+	return r.samples, nil
+}
+
+func (r *recordingStore) ListRecentSamples(ctx context.Context, pairID string, limit int) ([]storage.RateSample, error) {
+	return r.samples, nil
+}
+
+func (r *recordingStore) MarkSampleErrored(ctx context.Context, pairID string, bucket time.Time, phase, errMsg string) error {
+	return nil
+}
+
+func (r *recordingStore) CountSamples(ctx context.Context) (int64, error) {
+	return int64(len(r.samples)), nil
+}
+
+type memoryAlertStore struct {
+	alerts     []storage.AlertRecord
+	state      *storage.AlertState
+	deliveries map[time.Time][]storage.AlertDelivery
+}
+
+func (m *memoryAlertStore) InsertAlert(ctx context.Context, alert storage.AlertRecord) (storage.AlertRecord, error) {
+	for i := range m.alerts {
+		if m.alerts[i].SampleTS.Equal(alert.SampleTS) {
+			alert.ID = m.alerts[i].ID
+			m.alerts[i] = alert
+			return alert, nil
+		}
+	}
+	alert.ID = int64(len(m.alerts) + 1)
+	m.alerts = append(m.alerts, alert)
+	return alert, nil
+}
+
+func (m *memoryAlertStore) UpdateAlertState(ctx context.Context, pairID string, sampleTS time.Time, state string) error {
+	for i := range m.alerts {
+		if m.alerts[i].SampleTS.Equal(sampleTS) {
+			m.alerts[i].State = state
+		}
+	}
+	return nil
+}
+
+func (m *memoryAlertStore) ResolveAlert(ctx context.Context, pairID string, sampleTS, resolvedAt time.Time, peak decimal.Decimal) error {
+	for i := range m.alerts {
+		if m.alerts[i].SampleTS.Equal(sampleTS) {
+			m.alerts[i].State = storage.AlertStateResolved
+			m.alerts[i].ResolvedAt = &resolvedAt
+			m.alerts[i].PeakDeviationPct = decimal.NewNullDecimal(peak)
+		}
+	}
+	return nil
+}
+
+func (m *memoryAlertStore) ListRecentAlerts(ctx context.Context, limit int) ([]storage.AlertRecord, error) {
+	return m.alerts, nil
+}
+
+func (m *memoryAlertStore) DeleteAlertsBefore(ctx context.Context, olderThan time.Time) error {
+	return nil
+}
+
+func (m *memoryAlertStore) RecordAlertDeliveries(ctx context.Context, pairID string, sampleTS time.Time, deliveries []storage.AlertDelivery) error {
+	if m.deliveries == nil {
+		m.deliveries = make(map[time.Time][]storage.AlertDelivery)
+	}
+	m.deliveries[sampleTS] = append(m.deliveries[sampleTS], deliveries...)
+	return nil
+}
+
+func (m *memoryAlertStore) ListAlertDeliveries(ctx context.Context, alertID int64) ([]storage.AlertDelivery, error) {
+	return nil, nil
+}
+
+func (m *memoryAlertStore) GetAlertState(ctx context.Context, key string) (storage.AlertState, bool, error) {
+	if m.state == nil {
+		return storage.AlertState{}, false, nil
+	}
+	return *m.state, true, nil
+}
+
+func (m *memoryAlertStore) SaveAlertState(ctx context.Context, st storage.AlertState) error {
+	m.state = &st
+	return nil
+}
+
+type countingNotifier struct {
+	notes []alerting.Notification
+}
+
+func (c *countingNotifier) Notify(ctx context.Context, note alerting.Notification) error {
+	c.notes = append(c.notes, note)
+	return nil
+}
+
+const testPairID = "usde-susde"
+
+func testConfig() *config.Config {
+	return &config.Config{
+		Scheduler: config.SchedulerConfig{Interval: 5 * time.Minute},
+		Alerting: config.AlertingConfig{
+			Enabled:      true,
+			ThresholdPct: 0.4,
+			Cooldown:     30 * time.Minute,
+			Confirmation: config.ConfirmationConfig{Breaches: 2},
+		},
+	}
+}
+
+// testPairs monitors a single USDe/sUSDe pair alerting above 0.4%.
+func testPairs(official fetcher.OfficialRateFetcher, market fetcher.MarketRateFetcher) []Pair {
+	return []Pair{{
+		Config: config.PairConfig{
+			ID:                testPairID,
+			VaultSymbol:       "sUSDe",
+			UnderlyingSymbol:  "USDe",
+			Notional:          10000,
+			ThresholdPct:      0.4,
+			ClearThresholdPct: 0.4,
+		},
+		Official: official,
+		Market:   market,
+	}}
+}
+
+func TestExecuteBucketRecordsMarketFailure(t *testing.T) {
+	store := &recordingStore{}
+	official := &stubOfficial{rate: decimal.RequireFromString("0.83")}
+	market := &stubMarket{err: errors.New("cow api error (500)")}
+	svc := New(testConfig(), nil, testPairs(official, market), store, nil, nil, zerolog.Nop())
+
+	bucket := time.Date(2025, 9, 22, 10, 0, 0, 0, time.UTC)
+	if err := svc.ProcessBucket(context.Background(), bucket); err == nil {
+		t.Fatal("市场价失败时应返回错误")
+	}
+
+	if len(store.samples) != 1 {
+		t.Fatalf("失败的 bucket 也应写入一行, 实际 %d", len(store.samples))
+	}
+	sample := store.samples[0]
+	if sample.Status != storage.SampleStatusErrored {
+		t.Fatalf("status 应为 errored, 实际 %s", sample.Status)
+	}
+	if sample.ErrorPhase == nil || *sample.ErrorPhase != storage.SamplePhaseMarket {
+		t.Fatalf("error_phase 应为 market: %v", sample.ErrorPhase)
+	}
+	if !sample.OfficialRate.Valid || sample.MarketRate.Valid || sample.DeviationPct.Valid {
+		t.Fatalf("应只保留成功的官方价: %+v", sample)
+	}
+	if sample.BlockNumber == nil || *sample.BlockNumber != 42 {
+		t.Fatal("应保留官方价的区块号")
+	}
+	if sample.BlockHash == nil || *sample.BlockHash != "0x2a" || sample.BlockTime == nil || sample.BlockTime.Unix() != 1_700_000_000 {
+		t.Fatalf("应保留官方价所在区块的哈希与时间: %v %v", sample.BlockHash, sample.BlockTime)
+	}
+	if sample.OfficialMethod == nil || *sample.OfficialMethod != fetcher.MethodPreviewDeposit {
+		t.Fatalf("应记录官方价的报价方法: %v", sample.OfficialMethod)
+	}
+}
+
+func TestExecuteBucketRecordsBothLegsFailing(t *testing.T) {
+	store := &recordingStore{}
+	official := &stubOfficial{err: errors.New("rpc down")}
+	market := &stubMarket{err: errors.New("cow down")}
+	svc := New(testConfig(), nil, testPairs(official, market), store, nil, nil, zerolog.Nop())
+
+	if err := svc.ProcessBucket(context.Background(), time.Now().UTC()); err == nil {
+		t.Fatal("两侧失败应返回错误")
+	}
+	if len(store.samples) != 1 {
+		t.Fatalf("应写入一行, 实际 %d", len(store.samples))
+	}
+	if phase := store.samples[0].ErrorPhase; phase == nil || *phase != "official,market" {
+		t.Fatalf("error_phase 不正确: %v", phase)
+	}
+}
+
+func TestExecuteBucketRecordsCompleteSample(t *testing.T) {
+	store := &recordingStore{}
+	official := &stubOfficial{rate: decimal.NewFromInt(1)}
+	market := &stubMarket{rate: decimal.RequireFromString("1.01")}
+	svc := New(testConfig(), nil, testPairs(official, market), store, nil, nil, zerolog.Nop())
+
+	if err := svc.ProcessBucket(context.Background(), time.Now().UTC()); err != nil {
+		t.Fatalf("正常采样不应报错: %v", err)
+	}
+	sample := store.samples[0]
+	if sample.Status != storage.SampleStatusComplete || sample.ErrorPhase != nil {
+		t.Fatalf("应为 complete: %+v", sample)
+	}
+	if !sample.DeviationPct.Valid || !sample.DeviationPct.Decimal.Equal(decimal.NewFromInt(1)) {
+		t.Fatalf("偏差应为 1%%, 实际 %v", sample.DeviationPct)
+	}
+}
+
+func TestProcessBucketSamplesEveryPair(t *testing.T) {
+	store := &recordingStore{}
+	pairs := testPairs(&stubOfficial{rate: decimal.NewFromInt(1)}, &stubMarket{rate: decimal.RequireFromString("1.001")})
+	pairs = append(pairs, Pair{
+		Config:   config.PairConfig{ID: "dai-sdai", VaultSymbol: "sDAI", UnderlyingSymbol: "DAI", Notional: 5000, ThresholdPct: 0.4},
+		Official: &stubOfficial{err: errors.New("rpc down")},
+		Market:   &stubMarket{rate: decimal.NewFromInt(1)},
+	})
+	svc := New(testConfig(), nil, pairs, store, nil, nil, zerolog.Nop())
+	svc.health.startedAt = svc.health.startedAt.Add(-time.Hour)
+
+	bucket := time.Date(2025, 9, 22, 10, 0, 0, 0, time.UTC)
+	err := svc.ProcessBucket(context.Background(), bucket)
+	if err == nil || !strings.Contains(err.Error(), "pair dai-sdai") {
+		t.Fatalf("应报告失败交易对: %v", err)
+	}
+	if len(store.samples) != 2 {
+		t.Fatalf("每个交易对都应写入一行, 实际 %d", len(store.samples))
+	}
+	if got := store.samples[0]; got.PairID != testPairID || got.Status != storage.SampleStatusComplete || !got.Notional.Equal(decimal.NewFromInt(10000)) {
+		t.Fatalf("一个交易对失败不应影响其他交易对: %+v", got)
+	}
+	if got := store.samples[1]; got.PairID != "dai-sdai" || got.Status != storage.SampleStatusErrored {
+		t.Fatalf("失败交易对应以自身 pair_id 记为 errored: %+v", got)
+	}
+
+	report := svc.Health(context.Background(), time.Now().UTC())
+	if report.Ready || report.Pairs[testPairID].LastCompletedAt == nil {
+		t.Fatalf("任一交易对未完成 bucket 时不应就绪: %+v", report)
+	}
+}
+
+func TestProcessHistoricalBucketMarksMarketMissing(t *testing.T) {
+	store := &recordingStore{}
+	official := &stubOfficial{rate: decimal.RequireFromString("0.83")}
+	market := &stubMarket{rate: decimal.NewFromInt(1)}
+	svc := New(testConfig(), nil, testPairs(official, market), store, nil, nil, zerolog.Nop())
+
+	bucket := time.Date(2025, 9, 22, 10, 0, 0, 0, time.UTC)
+	if err := svc.ProcessHistoricalBucket(context.Background(), testPairID, bucket); err != nil {
+		t.Fatalf("历史回填不应报错: %v", err)
+	}
+
+	sample := store.samples[0]
+	if sample.Status != storage.SampleStatusPartial {
+		t.Fatalf("status 应为 partial, 实际 %s", sample.Status)
+	}
+	if sample.MarketRate.Valid {
+		t.Fatal("历史市场价不应被伪造")
+	}
+	if sample.BlockNumber == nil || *sample.BlockNumber != bucket.Unix()/12 {
+		t.Fatalf("区块号应来自 BlockAtTime: %v", sample.BlockNumber)
+	}
+}
+
+func TestAlertStateSurvivesRestart(t *testing.T) {
+	alerts := &memoryAlertStore{}
+	notifier := &countingNotifier{}
+	official := &stubOfficial{rate: decimal.NewFromInt(1)}
+	market := &stubMarket{rate: decimal.RequireFromString("1.01")}
+	base := time.Date(2025, 9, 22, 10, 0, 0, 0, time.UTC)
+
+	first := New(testConfig(), nil, testPairs(official, market), nil, alerts, notifier, zerolog.Nop())
+	if err := first.ProcessBucket(context.Background(), base); err != nil {
+		t.Fatal(err)
+	}
+	if alerts.state == nil || alerts.state.State != storage.AlertStatePending {
+		t.Fatalf("首次突破后应持久化 pending 状态: %+v", alerts.state)
+	}
+
+	// 模拟重启：新实例从存储中继续确认，而不是从零开始。
+	second := New(testConfig(), nil, testPairs(official, market), nil, alerts, notifier, zerolog.Nop())
+	if err := second.ProcessBucket(context.Background(), base.Add(5*time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if len(notifier.notes) != 1 || len(alerts.alerts) != 1 {
+		t.Fatalf("重启后第二次突破应触发一次告警, notes=%d alerts=%d", len(notifier.notes), len(alerts.alerts))
+	}
+	if rec := alerts.alerts[0]; rec.State != storage.AlertStateFiring || !rec.SampleTS.Equal(base) || rec.ConfirmedAt == nil {
+		t.Fatalf("告警记录应以首次突破为键并标记 firing: %+v", rec)
+	}
+
+	// 再次重启后仍处于 firing，不应重复告警。
+	third := New(testConfig(), nil, testPairs(official, market), nil, alerts, notifier, zerolog.Nop())
+	if err := third.ProcessBucket(context.Background(), base.Add(10*time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if len(notifier.notes) != 1 {
+		t.Fatalf("持续 firing 不应重复通知, 实际 %d", len(notifier.notes))
+	}
+}
+
+func TestUnconfirmedEpisodeIsCancelled(t *testing.T) {
+	alerts := &memoryAlertStore{}
+	notifier := &countingNotifier{}
+	official := &stubOfficial{rate: decimal.NewFromInt(1)}
+	market := &stubMarket{rate: decimal.RequireFromString("1.01")}
+	base := time.Date(2025, 9, 22, 10, 0, 0, 0, time.UTC)
+
+	svc := New(testConfig(), nil, testPairs(official, market), nil, alerts, notifier, zerolog.Nop())
+	if err := svc.ProcessBucket(context.Background(), base); err != nil {
+		t.Fatal(err)
+	}
+	if len(alerts.alerts) != 1 || alerts.alerts[0].State != storage.AlertStatePending {
+		t.Fatalf("首次突破应写入 pending 记录: %+v", alerts.alerts)
+	}
+
+	market.rate = decimal.RequireFromString("1.001")
+	if err := svc.ProcessBucket(context.Background(), base.Add(5*time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if alerts.alerts[0].State != storage.AlertStateCancelled || len(notifier.notes) != 0 {
+		t.Fatalf("未确认的突破应标记为 cancelled: %+v", alerts.alerts[0])
+	}
+}
+
+func TestResolvedNotificationCarriesEpisodeSummary(t *testing.T) {
+	alerts := &memoryAlertStore{}
+	notifier := &countingNotifier{}
+	official := &stubOfficial{rate: decimal.NewFromInt(1)}
+	market := &stubMarket{}
+	base := time.Date(2025, 9, 22, 10, 0, 0, 0, time.UTC)
+	svc := New(testConfig(), nil, testPairs(official, market), nil, alerts, notifier, zerolog.Nop())
+
+	for i, rate := range []string{"1.01", "1.02", "1.008", "1.001"} {
+		market.rate = decimal.RequireFromString(rate)
+		if err := svc.ProcessBucket(context.Background(), base.Add(time.Duration(i)*5*time.Minute)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(notifier.notes) != 2 {
+		t.Fatalf("应发送一次告警和一次恢复通知, 实际 %d", len(notifier.notes))
+	}
+	resolved := notifier.notes[1]
+	if !resolved.Resolved() || resolved.Direction != "up" {
+		t.Fatalf("第二条应为 up 方向的恢复通知: %+v", resolved)
+	}
+	if resolved.Duration != 15*time.Minute || !resolved.StartedAt.Equal(base) {
+		t.Fatalf("持续时间应从首次突破算起: %s", resolved.Duration)
+	}
+	if resolved.PeakDeviationPct.StringFixed(0) != "2" {
+		t.Fatalf("峰值偏离应为约 2%%, 实际 %s", resolved.PeakDeviationPct)
+	}
+
+	rec := alerts.alerts[0]
+	if rec.State != storage.AlertStateResolved || rec.ResolvedAt == nil || !rec.ResolvedAt.Equal(base.Add(15*time.Minute)) {
+		t.Fatalf("告警记录应标记 resolved 并记录时间: %+v", rec)
+	}
+	if !rec.PeakDeviationPct.Valid || !rec.PeakDeviationPct.Decimal.Equal(resolved.PeakDeviationPct) {
+		t.Fatalf("告警记录应保存峰值: %+v", rec.PeakDeviationPct)
+	}
+}
+
+type failingNotifier struct{}
+
+func (failingNotifier) Notify(ctx context.Context, note alerting.Notification) error {
+	return errors.New("channel down")
+}
+
+func TestDeliveryResultsRecordedPerChannel(t *testing.T) {
+	alerts := &memoryAlertStore{}
+	healthy := &countingNotifier{}
+	multi := alerting.NewMultiNotifier(zerolog.Nop(),
+		alerting.Channel{Name: "telegram", Notifier: healthy},
+		alerting.Channel{Name: "webhook", Notifier: failingNotifier{}, Retries: 1},
+	)
+	official := &stubOfficial{rate: decimal.NewFromInt(1)}
+	market := &stubMarket{rate: decimal.RequireFromString("1.01")}
+	base := time.Date(2025, 9, 22, 10, 0, 0, 0, time.UTC)
+	svc := New(testConfig(), nil, testPairs(official, market), nil, alerts, multi, zerolog.Nop())
+
+	for i := 0; i < 2; i++ {
+		if err := svc.ProcessBucket(context.Background(), base.Add(time.Duration(i)*5*time.Minute)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(healthy.notes) != 1 {
+		t.Fatalf("健康渠道不应受失败渠道影响, 实际 %d", len(healthy.notes))
+	}
+	got := alerts.deliveries[base]
+	if len(got) != 2 {
+		t.Fatalf("应按渠道记录投递结果: %+v", got)
+	}
+	if got[0].Status != storage.DeliveryStatusDelivered || got[1].Status != storage.DeliveryStatusFailed || got[1].Attempts != 2 {
+		t.Fatalf("投递结果不正确: %+v", got)
+	}
+}
+
+type recordingMetrics struct {
+	fetches  map[string]int
+	failures []string
+	samples  int
+	fired    []string
+}
+
+func (m *recordingMetrics) ObserveFetch(pairID, phase string, elapsed time.Duration) {
+	if m.fetches == nil {
+		m.fetches = map[string]int{}
+	}
+	m.fetches[phase]++
+}
+
+func (m *recordingMetrics) FetchFailed(pairID, phase string, err error) {
+	m.failures = append(m.failures, phase)
+}
+
+func (m *recordingMetrics) SampleRecorded(pairID string, bucket time.Time, official, market, deviation decimal.Decimal) {
+	m.samples++
+}
+
+func (m *recordingMetrics) AlertFired(pairID, direction string) {
+	m.fired = append(m.fired, direction)
+}
+
+func TestMetricsFollowBucketOutcome(t *testing.T) {
+	market := &stubMarket{rate: decimal.RequireFromString("1.01")}
+	svc := New(testConfig(), nil, testPairs(&stubOfficial{rate: decimal.NewFromInt(1)}, market), nil, nil, &countingNotifier{}, zerolog.Nop())
+	m := &recordingMetrics{}
+	svc.SetMetrics(m)
+
+	base := time.Date(2025, 9, 22, 10, 0, 0, 0, time.UTC)
+	for i := 0; i < 2; i++ {
+		if err := svc.ProcessBucket(context.Background(), base.Add(time.Duration(i)*5*time.Minute)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	market.err = errors.New("cow down")
+	_ = svc.ProcessBucket(context.Background(), base.Add(10*time.Minute))
+
+	if m.fetches[storage.SamplePhaseOfficial] != 3 || m.fetches[storage.SamplePhaseMarket] != 3 {
+		t.Fatalf("每个 bucket 都应记录两条腿的耗时: %+v", m.fetches)
+	}
+	if m.samples != 2 || len(m.failures) != 1 || m.failures[0] != storage.SamplePhaseMarket {
+		t.Fatalf("成功与失败的 bucket 计数不正确: samples=%d failures=%v", m.samples, m.failures)
+	}
+	if len(m.fired) != 1 || m.fired[0] != "up" {
+		t.Fatalf("确认告警后应计一次触发: %v", m.fired)
+	}
+}
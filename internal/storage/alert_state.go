@@ -0,0 +1,77 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"price-diff-alerts/internal/storage/sqlc"
+)
+
+// AlertStateStore persists the alert state machine.
+type AlertStateStore interface {
+	GetAlertState(ctx context.Context, key string) (AlertState, bool, error)
+	SaveAlertState(ctx context.Context, state AlertState) error
+}
+
+// GetAlertState loads the state for key; found is false before the first save.
+func (s *Store) GetAlertState(ctx context.Context, key string) (AlertState, bool, error) {
+	q, err := s.queries()
+	if err != nil {
+		return AlertState{}, false, err
+	}
+
+	row, queryErr := q.GetAlertState(ctx, key)
+	if errors.Is(queryErr, pgx.ErrNoRows) {
+		return AlertState{}, false, nil
+	}
+	if queryErr != nil {
+		return AlertState{}, false, fmt.Errorf("get alert state: %w", queryErr)
+	}
+	return AlertState{
+		Key:              row.StateKey,
+		State:            row.State,
+		Direction:        row.Direction.String,
+		BreachCount:      int(row.BreachCount),
+		BreachWindow:     uint64(row.BreachWindow),
+		PeakDeviationPct: row.PeakDeviationPct,
+		PendingSince:     timeFrom(row.PendingSince),
+		FiringSince:      timeFrom(row.FiringSince),
+		Notified:         row.Notified,
+		LastBucket:       timeFrom(row.LastBucket),
+		LastAlertUpAt:    timeFrom(row.LastAlertUpAt),
+		LastAlertDownAt:  timeFrom(row.LastAlertDownAt),
+		UpdatedAt:        row.UpdatedAt.Time,
+	}, true, nil
+}
+
+// SaveAlertState upserts the state for st.Key.
+func (s *Store) SaveAlertState(ctx context.Context, st AlertState) error {
+	q, err := s.queries()
+	if err != nil {
+		return err
+	}
+
+	if execErr := q.SaveAlertState(ctx, sqlc.SaveAlertStateParams{
+		StateKey:         st.Key,
+		State:            st.State,
+		Direction:        pgtype.Text{String: st.Direction, Valid: st.Direction != ""},
+		BreachCount:      int32(st.BreachCount),
+		BreachWindow:     int64(st.BreachWindow),
+		PendingSince:     timestamptzPtr(st.PendingSince),
+		FiringSince:      timestamptzPtr(st.FiringSince),
+		Notified:         st.Notified,
+		LastBucket:       timestamptzPtr(st.LastBucket),
+		LastAlertUpAt:    timestamptzPtr(st.LastAlertUpAt),
+		LastAlertDownAt:  timestamptzPtr(st.LastAlertDownAt),
+		PeakDeviationPct: st.PeakDeviationPct,
+	}); execErr != nil {
+		return fmt.Errorf("save alert state: %w", execErr)
+	}
+	return nil
+}
+
+var _ AlertStateStore = (*Store)(nil)
@@ -0,0 +1,21 @@
+package storage
+
+// Backend is a complete persistence implementation. database.dsn selects
+// PostgreSQL (*Store) or the embedded SQLite file (package sqlite).
+type Backend interface {
+	RateSampleStore
+	AlertStore
+	HistoryStore
+	AlertStateStore
+	AlertDeliveryStore
+	RollupStore
+	BackfillCheckpointStore
+	OutboxStore
+	OutboxAdmin
+	UnitOfWork
+	AdvisoryLocker
+	Pinger
+	Close()
+}
+
+var _ Backend = (*Store)(nil)
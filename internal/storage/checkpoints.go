@@ -0,0 +1,66 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"price-diff-alerts/internal/storage/sqlc"
+)
+
+// BackfillCheckpointStore persists resumable backfill progress.
+type BackfillCheckpointStore interface {
+	GetBackfillCheckpoint(ctx context.Context, jobKey string) (BackfillCheckpoint, bool, error)
+	SaveBackfillCheckpoint(ctx context.Context, cp BackfillCheckpoint) error
+}
+
+// GetBackfillCheckpoint loads a checkpoint; found is false when the job never ran.
+func (s *Store) GetBackfillCheckpoint(ctx context.Context, jobKey string) (BackfillCheckpoint, bool, error) {
+	q, err := s.queries()
+	if err != nil {
+		return BackfillCheckpoint{}, false, err
+	}
+
+	row, queryErr := q.GetBackfillCheckpoint(ctx, jobKey)
+	if errors.Is(queryErr, pgx.ErrNoRows) {
+		return BackfillCheckpoint{}, false, nil
+	}
+	if queryErr != nil {
+		return BackfillCheckpoint{}, false, fmt.Errorf("get backfill checkpoint: %w", queryErr)
+	}
+	return BackfillCheckpoint{
+		JobKey:    row.JobKey,
+		From:      row.RangeFrom.Time,
+		To:        row.RangeTo.Time,
+		Interval:  time.Duration(row.IntervalSec) * time.Second,
+		Watermark: row.Watermark.Time,
+		Processed: row.Processed,
+		Failed:    row.Failed,
+		UpdatedAt: row.UpdatedAt.Time,
+	}, true, nil
+}
+
+// SaveBackfillCheckpoint upserts a job's watermark and counters.
+func (s *Store) SaveBackfillCheckpoint(ctx context.Context, cp BackfillCheckpoint) error {
+	q, err := s.queries()
+	if err != nil {
+		return err
+	}
+	if execErr := q.SaveBackfillCheckpoint(ctx, sqlc.SaveBackfillCheckpointParams{
+		JobKey:      cp.JobKey,
+		RangeFrom:   timestamptz(cp.From),
+		RangeTo:     timestamptz(cp.To),
+		IntervalSec: int64(cp.Interval / time.Second),
+		Watermark:   timestamptz(cp.Watermark),
+		Processed:   cp.Processed,
+		Failed:      cp.Failed,
+	}); execErr != nil {
+		return fmt.Errorf("save backfill checkpoint: %w", execErr)
+	}
+	return nil
+}
+
+var _ BackfillCheckpointStore = (*Store)(nil)
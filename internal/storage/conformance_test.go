@@ -0,0 +1,48 @@
+package storage_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"price-diff-alerts/internal/config"
+	"price-diff-alerts/internal/storage"
+	"price-diff-alerts/internal/storage/storagetest"
+)
+
+// TestPostgresConformance runs the shared suite against a scratch database
+// named by USDE_TEST_DATABASE_DSN; its tables are truncated between cases.
+func TestPostgresConformance(t *testing.T) {
+	dsn := os.Getenv("USDE_TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("USDE_TEST_DATABASE_DSN not set")
+	}
+	ctx := context.Background()
+
+	pool, err := storage.NewPool(ctx, config.DatabaseConfig{DSN: dsn})
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := storage.NewStore(pool)
+	t.Cleanup(store.Close)
+
+	source, err := storage.MigrationSource("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	migrations, err := storage.LoadMigrations(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Migrator(migrations).Up(ctx, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	storagetest.Run(t, func(t *testing.T) storagetest.Store {
+		if _, err := pool.Exec(ctx, `TRUNCATE rate_samples, alerts, alert_deliveries, alert_state,
+    alert_outbox, rate_sample_rollups, backfill_checkpoints, digest_periods RESTART IDENTITY CASCADE`); err != nil {
+			t.Fatal(err)
+		}
+		return store
+	})
+}
@@ -0,0 +1,131 @@
+package storage
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/shopspring/decimal"
+
+	"price-diff-alerts/internal/storage/sqlc"
+)
+
+// Conversions between the domain models and the sqlc row types.
+
+func timestamptz(t time.Time) pgtype.Timestamptz {
+	return pgtype.Timestamptz{Time: t, Valid: true}
+}
+
+func timestamptzPtr(t *time.Time) pgtype.Timestamptz {
+	if t == nil {
+		return pgtype.Timestamptz{}
+	}
+	return timestamptz(*t)
+}
+
+func timeFrom(ts pgtype.Timestamptz) *time.Time {
+	if !ts.Valid {
+		return nil
+	}
+	t := ts.Time
+	return &t
+}
+
+func textPtr(v *string) pgtype.Text {
+	if v == nil {
+		return pgtype.Text{}
+	}
+	return pgtype.Text{String: *v, Valid: true}
+}
+
+func stringFrom(t pgtype.Text) *string {
+	if !t.Valid {
+		return nil
+	}
+	s := t.String
+	return &s
+}
+
+func int8Ptr(v *int64) pgtype.Int8 {
+	if v == nil {
+		return pgtype.Int8{}
+	}
+	return pgtype.Int8{Int64: *v, Valid: true}
+}
+
+func int64From(v pgtype.Int8) *int64 {
+	if !v.Valid {
+		return nil
+	}
+	n := v.Int64
+	return &n
+}
+
+// nullJSON maps an empty document to SQL NULL.
+func nullJSON(raw json.RawMessage) []byte {
+	if len(raw) == 0 {
+		return nil
+	}
+	return []byte(raw)
+}
+
+func numeric(d decimal.Decimal) pgtype.Numeric {
+	return pgtype.Numeric{Int: d.Coefficient(), Exp: d.Exponent(), Valid: true}
+}
+
+func rateSampleFromRow(row sqlc.RateSample) RateSample {
+	sample := RateSample{
+		PairID:         row.PairID,
+		Bucket:         row.BucketTs.Time,
+		OfficialRate:   row.OfficialSusdePerUsde,
+		MarketRate:     row.MarketSusdePerUsde,
+		DeviationPct:   row.DeviationPct,
+		Notional:       row.NotionalUsde,
+		CowQuality:     row.CowQuality,
+		BlockNumber:    int64From(row.BlockNumber),
+		OfficialMethod: stringFrom(row.OfficialMethod),
+		BlockHash:      stringFrom(row.BlockHash),
+		BlockTime:      timeFrom(row.BlockTime),
+		Status:         row.Status,
+		Error:          stringFrom(row.Error),
+		ErrorPhase:     stringFrom(row.ErrorPhase),
+		CreatedAt:      row.CreatedAt.Time,
+	}
+	if len(row.CowQuote) > 0 {
+		sample.CowQuote = json.RawMessage(row.CowQuote)
+	}
+	return sample
+}
+
+func rateSamplesFromRows(rows []sqlc.RateSample) []RateSample {
+	samples := make([]RateSample, 0, len(rows))
+	for _, row := range rows {
+		samples = append(samples, rateSampleFromRow(row))
+	}
+	return samples
+}
+
+func alertFromRow(row sqlc.Alert) AlertRecord {
+	return AlertRecord{
+		ID:               row.ID,
+		PairID:           row.PairID,
+		SampleTS:         row.SampleTs.Time,
+		DeviationPct:     row.DeviationPct,
+		ThresholdPct:     row.ThresholdPct,
+		Direction:        row.Direction,
+		Channels:         row.Channels,
+		State:            row.State,
+		ConfirmedAt:      timeFrom(row.ConfirmedAt),
+		ResolvedAt:       timeFrom(row.ResolvedAt),
+		PeakDeviationPct: row.PeakDeviationPct,
+		CreatedAt:        row.CreatedAt.Time,
+	}
+}
+
+func alertsFromRows(rows []sqlc.Alert) []AlertRecord {
+	alerts := make([]AlertRecord, 0, len(rows))
+	for _, row := range rows {
+		alerts = append(alerts, alertFromRow(row))
+	}
+	return alerts
+}
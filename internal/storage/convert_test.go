@@ -0,0 +1,49 @@
+package storage
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/shopspring/decimal"
+
+	"price-diff-alerts/internal/storage/sqlc"
+)
+
+func TestRateSampleRoundTripsNullableColumns(t *testing.T) {
+	bucket := time.Date(2025, 9, 22, 10, 0, 0, 0, time.UTC)
+	phase := SamplePhaseMarket
+	row := sqlc.RateSample{
+		BucketTs:             timestamptz(bucket),
+		OfficialSusdePerUsde: decimal.NewNullDecimal(decimal.RequireFromString("1.1")),
+		NotionalUsde:         decimal.NewFromInt(10000),
+		Status:               SampleStatusErrored,
+		ErrorPhase:           textPtr(&phase),
+	}
+
+	sample := rateSampleFromRow(row)
+	if !sample.Bucket.Equal(bucket) || sample.MarketRate.Valid || sample.CowQuote != nil || sample.BlockNumber != nil || sample.Error != nil {
+		t.Fatalf("空列应保持为空: %+v", sample)
+	}
+	if sample.ErrorPhase == nil || *sample.ErrorPhase != SamplePhaseMarket {
+		t.Fatalf("error_phase 转换不正确: %+v", sample.ErrorPhase)
+	}
+
+	if nullJSON(json.RawMessage(nil)) != nil || nullJSON(json.RawMessage(`{}`)) == nil {
+		t.Fatal("空报价应写为 NULL，非空报价应保留")
+	}
+}
+
+func TestNumericPreservesScale(t *testing.T) {
+	n := numeric(decimal.RequireFromString("0.45"))
+	if !n.Valid || n.Int.Int64() != 45 || n.Exp != -2 {
+		t.Fatalf("numeric 转换不正确: %+v", n)
+	}
+	if ts := timestamptzPtr(nil); ts.Valid {
+		t.Fatal("nil 时间应为 NULL")
+	}
+	if got := timeFrom(pgtype.Timestamptz{}); got != nil {
+		t.Fatal("NULL 时间应转换为 nil")
+	}
+}
@@ -0,0 +1,67 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"price-diff-alerts/internal/storage/sqlc"
+)
+
+// AlertDeliveryStore records per-channel delivery results against alert rows.
+type AlertDeliveryStore interface {
+	RecordAlertDeliveries(ctx context.Context, pairID string, sampleTS time.Time, deliveries []AlertDelivery) error
+	ListAlertDeliveries(ctx context.Context, alertID int64) ([]AlertDelivery, error)
+}
+
+// RecordAlertDeliveries stores deliveries for the pair's alert keyed by
+// sampleTS in one transaction. Rows are silently skipped when no such alert
+// exists.
+func (s *Store) RecordAlertDeliveries(ctx context.Context, pairID string, sampleTS time.Time, deliveries []AlertDelivery) error {
+	return s.WithTx(ctx, func(tx *Store) error {
+		for _, d := range deliveries {
+			if execErr := tx.q.InsertAlertDelivery(ctx, sqlc.InsertAlertDeliveryParams{
+				PairID:   pairID,
+				SampleTs: timestamptz(sampleTS),
+				Kind:     d.Kind,
+				Channel:  d.Channel,
+				Status:   d.Status,
+				Attempts: int32(d.Attempts),
+				Error:    textPtr(d.Error),
+			}); execErr != nil {
+				return fmt.Errorf("record alert delivery %s: %w", d.Channel, execErr)
+			}
+		}
+		return nil
+	})
+}
+
+// ListAlertDeliveries returns the delivery history of one alert, oldest first.
+func (s *Store) ListAlertDeliveries(ctx context.Context, alertID int64) ([]AlertDelivery, error) {
+	q, err := s.queries()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, queryErr := q.ListAlertDeliveries(ctx, alertID)
+	if queryErr != nil {
+		return nil, fmt.Errorf("list alert deliveries: %w", queryErr)
+	}
+
+	deliveries := make([]AlertDelivery, 0, len(rows))
+	for _, row := range rows {
+		deliveries = append(deliveries, AlertDelivery{
+			ID:        row.ID,
+			AlertID:   row.AlertID,
+			Kind:      row.Kind,
+			Channel:   row.Channel,
+			Status:    row.Status,
+			Attempts:  int(row.Attempts),
+			Error:     stringFrom(row.Error),
+			CreatedAt: row.CreatedAt.Time,
+		})
+	}
+	return deliveries, nil
+}
+
+var _ AlertDeliveryStore = (*Store)(nil)
@@ -0,0 +1,57 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"price-diff-alerts/internal/storage/sqlc"
+)
+
+// DigestStore records which digest periods have been mailed so instances
+// whose digest timers fire at different times send each period once.
+type DigestStore interface {
+	// LastDigestEnd returns the end of the latest period mailed for pairID;
+	// found is false when none has been sent.
+	LastDigestEnd(ctx context.Context, pairID string) (time.Time, bool, error)
+	// MarkDigestSent records that the period ending at periodEnd was mailed.
+	// An earlier periodEnd never moves the recorded end back.
+	MarkDigestSent(ctx context.Context, pairID string, periodEnd time.Time) error
+}
+
+// LastDigestEnd returns the end of the latest period mailed for pairID.
+func (s *Store) LastDigestEnd(ctx context.Context, pairID string) (time.Time, bool, error) {
+	q, err := s.queries()
+	if err != nil {
+		return time.Time{}, false, err
+	}
+
+	row, queryErr := q.GetDigestPeriod(ctx, pairID)
+	if errors.Is(queryErr, pgx.ErrNoRows) {
+		return time.Time{}, false, nil
+	}
+	if queryErr != nil {
+		return time.Time{}, false, fmt.Errorf("get digest period: %w", queryErr)
+	}
+	return row.PeriodEnd.Time, true, nil
+}
+
+// MarkDigestSent records that the period ending at periodEnd was mailed.
+func (s *Store) MarkDigestSent(ctx context.Context, pairID string, periodEnd time.Time) error {
+	q, err := s.queries()
+	if err != nil {
+		return err
+	}
+	if execErr := q.MarkDigestSent(ctx, sqlc.MarkDigestSentParams{
+		PairID:    pairID,
+		PeriodEnd: timestamptz(periodEnd),
+	}); execErr != nil {
+		return fmt.Errorf("mark digest sent: %w", execErr)
+	}
+	return nil
+}
+
+var _ DigestStore = (*Store)(nil)
@@ -0,0 +1,173 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"price-diff-alerts/internal/storage"
+)
+
+// InsertAlert persists an alert episode; a second insert for the same pair
+// and SampleTS updates that row and keeps its id and creation time.
+func (s *Store) InsertAlert(ctx context.Context, alert storage.AlertRecord) (storage.AlertRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := keyOf(alert.PairID, alert.SampleTS)
+	if prev, ok := s.alerts[key]; ok {
+		alert.ID = prev.ID
+		alert.CreatedAt = prev.CreatedAt
+		alert.ResolvedAt = prev.ResolvedAt
+	} else {
+		s.nextAlertID++
+		alert.ID = s.nextAlertID
+		alert.CreatedAt = s.now().UTC()
+		alert.ResolvedAt = nil
+	}
+	alert.SampleTS = key.ts
+	if alert.Channels == nil {
+		alert.Channels = []string{}
+	}
+	s.alerts[key] = cloneAlert(alert)
+	return cloneAlert(alert), nil
+}
+
+// ListRecentAlerts lists the most recently created alerts.
+func (s *Store) ListRecentAlerts(ctx context.Context, limit int) ([]storage.AlertRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := make([]storage.AlertRecord, 0, len(s.alerts))
+	for _, alert := range s.alerts {
+		out = append(out, cloneAlert(alert))
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].CreatedAt.Equal(out[j].CreatedAt) {
+			return out[i].CreatedAt.After(out[j].CreatedAt)
+		}
+		return out[i].ID > out[j].ID
+	})
+	return truncate(out, limit), nil
+}
+
+// ListAlertsBetween lists alert episodes that started within [from, to).
+func (s *Store) ListAlertsBetween(ctx context.Context, from, to time.Time) ([]storage.AlertRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := []storage.AlertRecord{}
+	for key, alert := range s.alerts {
+		if !key.ts.Before(from) && key.ts.Before(to) {
+			out = append(out, cloneAlert(alert))
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].SampleTS.Before(out[j].SampleTS) })
+	return out, nil
+}
+
+// UpdateAlertState moves an existing alert episode to a new state.
+func (s *Store) UpdateAlertState(ctx context.Context, pairID string, sampleTS time.Time, state string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := keyOf(pairID, sampleTS)
+	if alert, ok := s.alerts[key]; ok {
+		alert.State = state
+		s.alerts[key] = alert
+	}
+	return nil
+}
+
+// ResolveAlert closes an episode with its resolution time and peak deviation.
+func (s *Store) ResolveAlert(ctx context.Context, pairID string, sampleTS, resolvedAt time.Time, peak decimal.Decimal) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := keyOf(pairID, sampleTS)
+	if alert, ok := s.alerts[key]; ok {
+		resolved := resolvedAt.UTC()
+		alert.State = storage.AlertStateResolved
+		alert.ResolvedAt = &resolved
+		alert.PeakDeviationPct = decimal.NewNullDecimal(peak)
+		s.alerts[key] = alert
+	}
+	return nil
+}
+
+// DeleteAlertsBefore removes alerts created before olderThan along with their
+// deliveries.
+func (s *Store) DeleteAlertsBefore(ctx context.Context, olderThan time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, alert := range s.alerts {
+		if alert.CreatedAt.Before(olderThan) {
+			delete(s.alerts, key)
+			delete(s.deliveries, alert.ID)
+		}
+	}
+	return nil
+}
+
+// GetAlertState loads the state for key; found is false before the first save.
+func (s *Store) GetAlertState(ctx context.Context, key string) (storage.AlertState, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st, ok := s.states[key]
+	return st, ok, nil
+}
+
+// SaveAlertState upserts the state for st.Key.
+func (s *Store) SaveAlertState(ctx context.Context, st storage.AlertState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st.UpdatedAt = s.now().UTC()
+	s.states[st.Key] = st
+	return nil
+}
+
+// RecordAlertDeliveries stores deliveries for the pair's alert keyed by
+// sampleTS. They are dropped when no such alert exists, as in PostgreSQL.
+func (s *Store) RecordAlertDeliveries(ctx context.Context, pairID string, sampleTS time.Time, deliveries []storage.AlertDelivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	alert, ok := s.alerts[keyOf(pairID, sampleTS)]
+	if !ok {
+		return nil
+	}
+	now := s.now().UTC()
+	for _, d := range deliveries {
+		s.nextDeliv++
+		d.ID = s.nextDeliv
+		d.AlertID = alert.ID
+		d.Error = clonePtr(d.Error)
+		d.CreatedAt = now
+		s.deliveries[alert.ID] = append(s.deliveries[alert.ID], d)
+	}
+	return nil
+}
+
+// ListAlertDeliveries returns the delivery history of one alert, oldest first.
+func (s *Store) ListAlertDeliveries(ctx context.Context, alertID int64) ([]storage.AlertDelivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]storage.AlertDelivery{}, s.deliveries[alertID]...), nil
+}
+
+func cloneAlert(alert storage.AlertRecord) storage.AlertRecord {
+	alert.Channels = append([]string{}, alert.Channels...)
+	alert.ConfirmedAt = clonePtr(alert.ConfirmedAt)
+	alert.ResolvedAt = clonePtr(alert.ResolvedAt)
+	return alert
+}
+
+var (
+	_ storage.AlertStore         = (*Store)(nil)
+	_ storage.HistoryStore       = (*Store)(nil)
+	_ storage.AlertStateStore    = (*Store)(nil)
+	_ storage.AlertDeliveryStore = (*Store)(nil)
+)
@@ -0,0 +1,272 @@
+// Package memory is a concurrency-safe in-memory storage backend. It mirrors
+// the PostgreSQL semantics (samples keyed by pair and bucket, one alert per
+// pair and sample_ts,
+// exclusive advisory locks) for tests and dry runs that must not touch a
+// database.
+package memory
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"price-diff-alerts/internal/storage"
+)
+
+// ErrNotFound is returned when an update targets a row that does not exist.
+var ErrNotFound = errors.New("memory: no rows")
+
+// Store keeps every row in maps guarded by a single mutex. Returned values are
+// copies, so callers never share state with the store.
+type Store struct {
+	mu          sync.Mutex
+	samples     map[seriesKey]storage.RateSample
+	alerts      map[seriesKey]storage.AlertRecord
+	nextAlertID int64
+	states      map[string]storage.AlertState
+	deliveries  map[int64][]storage.AlertDelivery
+	nextDeliv   int64
+	checkpoints map[string]storage.BackfillCheckpoint
+	digests     map[string]time.Time
+	locks       map[int64]struct{}
+
+	// now stamps the created_at/updated_at columns the database would fill.
+	now func() time.Time
+}
+
+// seriesKey addresses a row of one pair by its bucket or episode start.
+type seriesKey struct {
+	pairID string
+	ts     time.Time
+}
+
+func keyOf(pairID string, ts time.Time) seriesKey {
+	return seriesKey{pairID: pairID, ts: ts.UTC()}
+}
+
+// New returns an empty store.
+func New() *Store {
+	return &Store{
+		samples:     make(map[seriesKey]storage.RateSample),
+		alerts:      make(map[seriesKey]storage.AlertRecord),
+		states:      make(map[string]storage.AlertState),
+		deliveries:  make(map[int64][]storage.AlertDelivery),
+		checkpoints: make(map[string]storage.BackfillCheckpoint),
+		digests:     make(map[string]time.Time),
+		locks:       make(map[int64]struct{}),
+		now:         time.Now,
+	}
+}
+
+// Close is a no-op; it lets the store stand in wherever a closable backend is
+// expected.
+func (s *Store) Close() {}
+
+// Ping always succeeds.
+func (s *Store) Ping(ctx context.Context) error {
+	return nil
+}
+
+// TryAdvisoryLock grants key to one holder at a time until it is released.
+func (s *Store) TryAdvisoryLock(ctx context.Context, key int64) (func(), bool, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, false, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, held := s.locks[key]; held {
+		return nil, false, nil
+	}
+	s.locks[key] = struct{}{}
+
+	var once sync.Once
+	unlock := func() {
+		once.Do(func() {
+			s.mu.Lock()
+			delete(s.locks, key)
+			s.mu.Unlock()
+		})
+	}
+	return unlock, true, nil
+}
+
+// UpsertRateSample persists or replaces the sample for sample.PairID and
+// sample.Bucket.
+func (s *Store) UpsertRateSample(ctx context.Context, sample storage.RateSample) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := keyOf(sample.PairID, sample.Bucket)
+	createdAt := s.now().UTC()
+	if prev, ok := s.samples[key]; ok {
+		createdAt = prev.CreatedAt
+	}
+	sample.Bucket = key.ts
+	sample.CreatedAt = createdAt
+	s.samples[key] = cloneSample(sample)
+	return nil
+}
+
+// ListSamplesBetween lists samples of the pair within [from, to) in bucket
+// order.
+func (s *Store) ListSamplesBetween(ctx context.Context, pairID string, from, to time.Time) ([]storage.RateSample, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := []storage.RateSample{}
+	for key, sample := range s.samples {
+		if key.pairID == pairID && !key.ts.Before(from) && key.ts.Before(to) {
+			out = append(out, cloneSample(sample))
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Bucket.Before(out[j].Bucket) })
+	return out, nil
+}
+
+// ListSamplesPage lists at most limit of the pair's samples in [from, to) in
+// bucket order.
+func (s *Store) ListSamplesPage(ctx context.Context, pairID string, from, to time.Time, limit int) ([]storage.RateSample, error) {
+	out, err := s.ListSamplesBetween(ctx, pairID, from, to)
+	return truncate(out, limit), err
+}
+
+// ListRecentSamples lists the pair's most recent samples ordered by
+// descending bucket.
+func (s *Store) ListRecentSamples(ctx context.Context, pairID string, limit int) ([]storage.RateSample, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := []storage.RateSample{}
+	for key, sample := range s.samples {
+		if key.pairID == pairID {
+			out = append(out, cloneSample(sample))
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Bucket.After(out[j].Bucket) })
+	return truncate(out, limit), nil
+}
+
+// ListSamplesByDeviation lists the pair's largest deviations in [from, to).
+func (s *Store) ListSamplesByDeviation(ctx context.Context, pairID string, from, to time.Time, minAbsPct decimal.Decimal, limit int) ([]storage.RateSample, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	threshold := minAbsPct.Abs()
+	out := []storage.RateSample{}
+	for key, sample := range s.samples {
+		if key.pairID != pairID || key.ts.Before(from) || !key.ts.Before(to) || !sample.DeviationPct.Valid {
+			continue
+		}
+		if sample.DeviationPct.Decimal.Abs().GreaterThanOrEqual(threshold) {
+			out = append(out, cloneSample(sample))
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		a, b := out[i].DeviationPct.Decimal.Abs(), out[j].DeviationPct.Decimal.Abs()
+		if !a.Equal(b) {
+			return a.GreaterThan(b)
+		}
+		return out[i].Bucket.Before(out[j].Bucket)
+	})
+	return truncate(out, limit), nil
+}
+
+// MarkSampleErrored marks an existing sample as errored in the given phase.
+func (s *Store) MarkSampleErrored(ctx context.Context, pairID string, bucket time.Time, phase, errMsg string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := keyOf(pairID, bucket)
+	sample, ok := s.samples[key]
+	if !ok {
+		return ErrNotFound
+	}
+	sample.Status = storage.SampleStatusErrored
+	sample.Error = &errMsg
+	sample.ErrorPhase = &phase
+	s.samples[key] = sample
+	return nil
+}
+
+// CountSamples counts the stored samples of every pair.
+func (s *Store) CountSamples(ctx context.Context) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return int64(len(s.samples)), nil
+}
+
+// GetBackfillCheckpoint loads a checkpoint; found is false when the job never ran.
+func (s *Store) GetBackfillCheckpoint(ctx context.Context, jobKey string) (storage.BackfillCheckpoint, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cp, ok := s.checkpoints[jobKey]
+	return cp, ok, nil
+}
+
+// SaveBackfillCheckpoint upserts a job's watermark and counters.
+func (s *Store) SaveBackfillCheckpoint(ctx context.Context, cp storage.BackfillCheckpoint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cp.UpdatedAt = s.now().UTC()
+	s.checkpoints[cp.JobKey] = cp
+	return nil
+}
+
+// LastDigestEnd returns the end of the latest period mailed for pairID.
+func (s *Store) LastDigestEnd(ctx context.Context, pairID string) (time.Time, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	end, ok := s.digests[pairID]
+	return end, ok, nil
+}
+
+// MarkDigestSent records that the period ending at periodEnd was mailed.
+func (s *Store) MarkDigestSent(ctx context.Context, pairID string, periodEnd time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if end, ok := s.digests[pairID]; !ok || periodEnd.After(end) {
+		s.digests[pairID] = periodEnd.UTC()
+	}
+	return nil
+}
+
+func cloneSample(sample storage.RateSample) storage.RateSample {
+	sample.CowQuote = append([]byte(nil), sample.CowQuote...)
+	if len(sample.CowQuote) == 0 {
+		sample.CowQuote = nil
+	}
+	sample.BlockNumber = clonePtr(sample.BlockNumber)
+	sample.OfficialMethod = clonePtr(sample.OfficialMethod)
+	sample.BlockHash = clonePtr(sample.BlockHash)
+	sample.BlockTime = clonePtr(sample.BlockTime)
+	sample.Error = clonePtr(sample.Error)
+	sample.ErrorPhase = clonePtr(sample.ErrorPhase)
+	return sample
+}
+
+func clonePtr[T any](v *T) *T {
+	if v == nil {
+		return nil
+	}
+	c := *v
+	return &c
+}
+
+func truncate[T any](rows []T, limit int) []T {
+	if limit >= 0 && len(rows) > limit {
+		return rows[:limit]
+	}
+	return rows
+}
+
+var (
+	_ storage.RateSampleStore         = (*Store)(nil)
+	_ storage.AdvisoryLocker          = (*Store)(nil)
+	_ storage.Pinger                  = (*Store)(nil)
+	_ storage.BackfillCheckpointStore = (*Store)(nil)
+	_ storage.DigestStore             = (*Store)(nil)
+)
@@ -0,0 +1,42 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"price-diff-alerts/internal/storage"
+	"price-diff-alerts/internal/storage/storagetest"
+)
+
+func TestConformance(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storagetest.Store { return New() })
+}
+
+func TestConcurrentUpserts(t *testing.T) {
+	store := New()
+	base := time.Date(2025, 9, 22, 10, 0, 0, 0, time.UTC)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				_ = store.UpsertRateSample(context.Background(), storage.RateSample{
+					Bucket:       base.Add(time.Duration(j) * 5 * time.Minute),
+					DeviationPct: decimal.NewNullDecimal(decimal.NewFromInt(int64(i))),
+					Status:       storage.SampleStatusComplete,
+				})
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if count, _ := store.CountSamples(context.Background()); count != 50 {
+		t.Fatalf("并发 upsert 后每个 bucket 应只有一行, 实际 %d", count)
+	}
+}
@@ -0,0 +1,370 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"price-diff-alerts/db"
+)
+
+// migrationLockKey serialises concurrent migrate runs ("usdemigr").
+const migrationLockKey int64 = 0x7573646d69677200
+
+const (
+	createSchemaMigrationsSQL = `CREATE TABLE IF NOT EXISTS schema_migrations (
+        version    BIGINT       PRIMARY KEY,
+        name       TEXT         NOT NULL,
+        applied_at timestamptz  NOT NULL DEFAULT now()
+    );`
+
+	listSchemaMigrationsSQL    = `SELECT version, applied_at FROM schema_migrations ORDER BY version;`
+	insertSchemaMigrationSQL   = `INSERT INTO schema_migrations (version, name) VALUES ($1, $2);`
+	baselineSchemaMigrationSQL = `INSERT INTO schema_migrations (version, name) VALUES ($1, $2)
+        ON CONFLICT (version) DO NOTHING;`
+	deleteSchemaMigrationSQL = `DELETE FROM schema_migrations WHERE version = $1;`
+)
+
+var migrationFilePattern = regexp.MustCompile(`^(\d+)_([A-Za-z0-9_]+)\.(up|down)\.sql$`)
+
+// Migration is one numbered schema change with its up and down scripts.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus reports whether a migration has been applied.
+type MigrationStatus struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// Applied reports whether the migration is recorded in schema_migrations.
+func (s MigrationStatus) Applied() bool {
+	return s.AppliedAt != nil
+}
+
+// MigrationSource returns the migrations directory at path, or the copy
+// embedded in the binary when path is empty.
+func MigrationSource(dir string) (fs.FS, error) {
+	if dir == "" {
+		return fs.Sub(db.Migrations, "migrations")
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("database.migrations_path: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("database.migrations_path %s is not a directory", dir)
+	}
+	return os.DirFS(dir), nil
+}
+
+// LoadMigrations parses NNNNNN_name.up.sql / .down.sql pairs, sorted by version.
+func LoadMigrations(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("read migrations: %w", err)
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		m := migrationFilePattern.FindStringSubmatch(entry.Name())
+		if m == nil {
+			continue
+		}
+		version, err := strconv.ParseInt(m[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("parse migration version %s: %w", entry.Name(), err)
+		}
+		body, err := fs.ReadFile(fsys, path.Clean(entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("read migration %s: %w", entry.Name(), err)
+		}
+
+		mig, ok := byVersion[version]
+		if !ok {
+			mig = &Migration{Version: version, Name: m[2]}
+			byVersion[version] = mig
+		} else if mig.Name != m[2] {
+			return nil, fmt.Errorf("migration %d has conflicting names %s and %s", version, mig.Name, m[2])
+		}
+		if m[3] == "up" {
+			mig.Up = string(body)
+		} else {
+			mig.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, mig := range byVersion {
+		if mig.Up == "" {
+			return nil, fmt.Errorf("migration %d (%s) has no up script", mig.Version, mig.Name)
+		}
+		migrations = append(migrations, *mig)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// planMigrations returns the steps that move the applied set to target:
+// pending migrations up to target in ascending order, then applied ones
+// above target in descending order.
+func planMigrations(migrations []Migration, applied map[int64]bool, target int64) (up, down []Migration) {
+	for _, mig := range migrations {
+		if mig.Version <= target && !applied[mig.Version] {
+			up = append(up, mig)
+		}
+	}
+	for i := len(migrations) - 1; i >= 0; i-- {
+		mig := migrations[i]
+		if mig.Version > target && applied[mig.Version] {
+			down = append(down, mig)
+		}
+	}
+	return up, down
+}
+
+// Migrator applies migrations to PostgreSQL, one transaction per migration,
+// recording versions in schema_migrations.
+type Migrator struct {
+	pool       *pgxpool.Pool
+	migrations []Migration
+}
+
+// NewMigrator builds a migrator over the given migrations.
+func NewMigrator(pool *pgxpool.Pool, migrations []Migration) *Migrator {
+	return &Migrator{pool: pool, migrations: migrations}
+}
+
+// Latest returns the highest known migration version, or 0.
+func (m *Migrator) Latest() int64 {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Status lists every known migration with its applied time.
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	applied, err := m.applied(ctx, m.pool)
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]MigrationStatus, 0, len(m.migrations))
+	for _, mig := range m.migrations {
+		st := MigrationStatus{Migration: mig}
+		if at, ok := applied[mig.Version]; ok {
+			at := at
+			st.AppliedAt = &at
+		}
+		statuses = append(statuses, st)
+	}
+	return statuses, nil
+}
+
+// Pending returns migrations not yet applied.
+func (m *Migrator) Pending(ctx context.Context) ([]Migration, error) {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var pending []Migration
+	for _, st := range statuses {
+		if !st.Applied() {
+			pending = append(pending, st.Migration)
+		}
+	}
+	return pending, nil
+}
+
+// Up applies every pending migration.
+func (m *Migrator) Up(ctx context.Context, onStep func(Migration, string)) error {
+	return m.Goto(ctx, m.Latest(), onStep)
+}
+
+// Down rolls back the most recent steps applied migrations.
+func (m *Migrator) Down(ctx context.Context, steps int, onStep func(Migration, string)) error {
+	if steps <= 0 {
+		return fmt.Errorf("down steps must be positive")
+	}
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return err
+	}
+	var applied []int64
+	for _, st := range statuses {
+		if st.Applied() {
+			applied = append(applied, st.Version)
+		}
+	}
+	target := int64(0)
+	if steps < len(applied) {
+		target = applied[len(applied)-steps-1]
+	}
+	return m.Goto(ctx, target, onStep)
+}
+
+// Goto migrates up or down until exactly the migrations <= target are applied.
+func (m *Migrator) Goto(ctx context.Context, target int64, onStep func(Migration, string)) error {
+	if target != 0 && !m.known(target) {
+		return fmt.Errorf("unknown migration version %d", target)
+	}
+
+	conn, err := m.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("acquire migration connection: %w", err)
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, `SELECT pg_advisory_lock($1)`, migrationLockKey); err != nil {
+		return fmt.Errorf("acquire migration lock: %w", err)
+	}
+	defer func() {
+		_, _ = conn.Exec(context.Background(), `SELECT pg_advisory_unlock($1)`, migrationLockKey)
+	}()
+
+	if _, err := conn.Exec(ctx, createSchemaMigrationsSQL); err != nil {
+		return fmt.Errorf("create schema_migrations: %w", err)
+	}
+	appliedAt, err := m.applied(ctx, conn)
+	if err != nil {
+		return err
+	}
+	applied := make(map[int64]bool, len(appliedAt))
+	for v := range appliedAt {
+		applied[v] = true
+	}
+
+	up, down := planMigrations(m.migrations, applied, target)
+	for _, mig := range down {
+		if mig.Down == "" {
+			return fmt.Errorf("migration %d (%s) has no down script", mig.Version, mig.Name)
+		}
+		if err := m.apply(ctx, conn, mig, mig.Down, deleteSchemaMigrationSQL, mig.Version); err != nil {
+			return err
+		}
+		if onStep != nil {
+			onStep(mig, "down")
+		}
+	}
+	for _, mig := range up {
+		if err := m.apply(ctx, conn, mig, mig.Up, insertSchemaMigrationSQL, mig.Version, mig.Name); err != nil {
+			return err
+		}
+		if onStep != nil {
+			onStep(mig, "up")
+		}
+	}
+	return nil
+}
+
+// Baseline records migrations <= version as applied without running them,
+// for databases created before schema_migrations existed.
+func (m *Migrator) Baseline(ctx context.Context, version int64) error {
+	if !m.known(version) {
+		return fmt.Errorf("unknown migration version %d", version)
+	}
+	if _, err := m.pool.Exec(ctx, createSchemaMigrationsSQL); err != nil {
+		return fmt.Errorf("create schema_migrations: %w", err)
+	}
+	for _, mig := range m.migrations {
+		if mig.Version > version {
+			break
+		}
+		if _, err := m.pool.Exec(ctx, baselineSchemaMigrationSQL, mig.Version, mig.Name); err != nil {
+			return fmt.Errorf("baseline migration %d: %w", mig.Version, err)
+		}
+	}
+	return nil
+}
+
+func (m *Migrator) apply(ctx context.Context, conn *pgxpool.Conn, mig Migration, script, recordSQL string, args ...interface{}) error {
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin migration %d: %w", mig.Version, err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if _, err := tx.Exec(ctx, script); err != nil {
+		return fmt.Errorf("migration %d (%s): %w", mig.Version, mig.Name, err)
+	}
+	if _, err := tx.Exec(ctx, recordSQL, args...); err != nil {
+		return fmt.Errorf("record migration %d: %w", mig.Version, err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit migration %d: %w", mig.Version, err)
+	}
+	return nil
+}
+
+type migrationQuerier interface {
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+}
+
+// applied returns recorded versions; a missing schema_migrations table means
+// nothing has been applied yet.
+func (m *Migrator) applied(ctx context.Context, q migrationQuerier) (map[int64]time.Time, error) {
+	rows, err := q.Query(ctx, listSchemaMigrationsSQL)
+	if err != nil {
+		if isUndefinedTable(err) {
+			return map[int64]time.Time{}, nil
+		}
+		return nil, fmt.Errorf("list schema migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int64]time.Time)
+	for rows.Next() {
+		var (
+			version int64
+			at      time.Time
+		)
+		if err := rows.Scan(&version, &at); err != nil {
+			return nil, err
+		}
+		applied[version] = at
+	}
+	if err := rows.Err(); err != nil {
+		if isUndefinedTable(err) {
+			return map[int64]time.Time{}, nil
+		}
+		return nil, err
+	}
+	return applied, nil
+}
+
+func (m *Migrator) known(version int64) bool {
+	for _, mig := range m.migrations {
+		if mig.Version == version {
+			return true
+		}
+	}
+	return false
+}
+
+func isUndefinedTable(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "42P01"
+}
+
+// Migrator returns a migrator sharing the store's pool.
+func (s *Store) Migrator(migrations []Migration) *Migrator {
+	return NewMigrator(s.pool, migrations)
+}
@@ -0,0 +1,70 @@
+package storage
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadMigrationsPairsAndSorts(t *testing.T) {
+	fsys := fstest.MapFS{
+		"000002_two.up.sql":   {Data: []byte("CREATE TABLE two ();")},
+		"000002_two.down.sql": {Data: []byte("DROP TABLE two;")},
+		"000001_one.up.sql":   {Data: []byte("CREATE TABLE one ();")},
+		"README.md":           {Data: []byte("ignored")},
+	}
+	migrations, err := LoadMigrations(fsys)
+	if err != nil {
+		t.Fatalf("加载迁移失败: %v", err)
+	}
+	if len(migrations) != 2 || migrations[0].Version != 1 || migrations[1].Version != 2 {
+		t.Fatalf("迁移顺序不正确: %+v", migrations)
+	}
+	if migrations[0].Down != "" || migrations[1].Down != "DROP TABLE two;" || migrations[1].Name != "two" {
+		t.Fatalf("up/down 配对不正确: %+v", migrations)
+	}
+}
+
+func TestLoadMigrationsRejectsMissingUp(t *testing.T) {
+	fsys := fstest.MapFS{"000001_one.down.sql": {Data: []byte("DROP TABLE one;")}}
+	if _, err := LoadMigrations(fsys); err == nil {
+		t.Fatal("缺少 up 脚本时应报错")
+	}
+}
+
+func TestEmbeddedMigrationsAreComplete(t *testing.T) {
+	source, err := MigrationSource("")
+	if err != nil {
+		t.Fatalf("读取内嵌迁移失败: %v", err)
+	}
+	migrations, err := LoadMigrations(source)
+	if err != nil {
+		t.Fatalf("加载内嵌迁移失败: %v", err)
+	}
+	for i, m := range migrations {
+		if m.Version != int64(i+1) {
+			t.Fatalf("迁移编号不连续: %d 位置出现 %d", i+1, m.Version)
+		}
+		if m.Down == "" {
+			t.Fatalf("迁移 %d 缺少 down 脚本", m.Version)
+		}
+	}
+}
+
+func TestPlanMigrations(t *testing.T) {
+	migrations := []Migration{{Version: 1}, {Version: 2}, {Version: 3}, {Version: 4}}
+
+	up, down := planMigrations(migrations, map[int64]bool{1: true}, 3)
+	if len(down) != 0 || len(up) != 2 || up[0].Version != 2 || up[1].Version != 3 {
+		t.Fatalf("向上规划不正确: up=%+v down=%+v", up, down)
+	}
+
+	up, down = planMigrations(migrations, map[int64]bool{1: true, 2: true, 3: true, 4: true}, 2)
+	if len(up) != 0 || len(down) != 2 || down[0].Version != 4 || down[1].Version != 3 {
+		t.Fatalf("向下规划应按倒序回滚: up=%+v down=%+v", up, down)
+	}
+
+	up, down = planMigrations(migrations, map[int64]bool{1: true, 3: true}, 2)
+	if len(up) != 1 || up[0].Version != 2 || len(down) != 1 || down[0].Version != 3 {
+		t.Fatalf("缺口应被补齐且多余版本回滚: up=%+v down=%+v", up, down)
+	}
+}
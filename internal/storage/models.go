@@ -7,28 +7,192 @@ import (
 	"github.com/shopspring/decimal"
 )
 
-// RateSample represents a persisted 5-minute observation window.
+// Sample statuses stored in rate_samples.status.
+const (
+	SampleStatusComplete = "complete"
+	SampleStatusErrored  = "errored"
+	// SampleStatusPartial marks a backfilled bucket whose market leg could not be reconstructed.
+	SampleStatusPartial = "partial"
+	// SampleStatusMissing marks a bucket without any stored row; it is never persisted.
+	SampleStatusMissing = "missing"
+)
+
+// Phases recorded in rate_samples.error_phase for errored buckets.
+const (
+	SamplePhaseOfficial = "official"
+	SamplePhaseMarket   = "market"
+)
+
+// RateSample represents a persisted 5-minute observation window of one pair.
+// Rates are vault shares per underlying token and are null when the
+// corresponding leg failed for an errored bucket. Notional is in underlying
+// tokens. BlockNumber, BlockHash and BlockTime identify the block the
+// official rate was read at, and OfficialMethod the ERC-4626 view it was
+// quoted from.
 type RateSample struct {
-	Bucket       time.Time
-	OfficialRate decimal.Decimal
-	MarketRate   decimal.Decimal
-	DeviationPct decimal.Decimal
-	NotionalUSDE decimal.Decimal
-	CowQuality   string
-	CowQuote     json.RawMessage
-	BlockNumber  *int64
-	Status       string
-	Error        *string
-	CreatedAt    time.Time
+	PairID         string
+	Bucket         time.Time
+	OfficialRate   decimal.NullDecimal
+	MarketRate     decimal.NullDecimal
+	DeviationPct   decimal.NullDecimal
+	Notional       decimal.Decimal
+	CowQuality     string
+	CowQuote       json.RawMessage
+	BlockNumber    *int64
+	BlockHash      *string
+	BlockTime      *time.Time
+	OfficialMethod *string
+	Status         string
+	Error          *string
+	ErrorPhase     *string
+	CreatedAt      time.Time
+}
+
+// Rollup resolutions stored in rate_sample_rollups.resolution.
+const (
+	RollupHourly = "1h"
+	RollupDaily  = "1d"
+)
+
+// RollupStats aggregates one series over the samples of a bucket that carry
+// it; Count is how many there were.
+type RollupStats struct {
+	Min   decimal.NullDecimal
+	Max   decimal.NullDecimal
+	Avg   decimal.NullDecimal
+	Last  decimal.NullDecimal
+	Count int
+}
+
+// SampleRollup is an hourly or daily aggregate of one pair's rate samples.
+// Samples counts every stored bucket and Complete the complete ones. Official
+// and market stats cover every sample with that leg, deviation stats only
+// complete samples.
+type SampleRollup struct {
+	PairID     string
+	Resolution string
+	Bucket     time.Time
+	Samples    int
+	Complete   int
+	Official   RollupStats
+	Market     RollupStats
+	Deviation  RollupStats
+	UpdatedAt  time.Time
 }
 
-// AlertRecord captures an emitted alert for de-duplication/auditing.
+// AlertRecord captures an alert episode for de-duplication/auditing. SampleTS
+// is the first breaching bucket of the pair; State follows the episode
+// (pending, firing, resolved or cancelled).
 type AlertRecord struct {
 	ID           int64
+	PairID       string
 	SampleTS     time.Time
 	DeviationPct decimal.Decimal
 	ThresholdPct decimal.Decimal
 	Direction    string
 	Channels     []string
-	CreatedAt    time.Time
+	State        string
+	ConfirmedAt  *time.Time
+	ResolvedAt   *time.Time
+	// PeakDeviationPct is the largest deviation of the episode.
+	PeakDeviationPct decimal.NullDecimal
+	CreatedAt        time.Time
+}
+
+// Delivery statuses recorded in alert_deliveries.status.
+const (
+	DeliveryStatusDelivered = "delivered"
+	DeliveryStatusFailed    = "failed"
+)
+
+// AlertDelivery is one channel's delivery outcome for an alert notification.
+// Kind is the notification kind (firing or resolved).
+type AlertDelivery struct {
+	ID        int64
+	AlertID   int64
+	Kind      string
+	Channel   string
+	Status    string
+	Attempts  int
+	Error     *string
+	CreatedAt time.Time
+}
+
+// Outbox statuses stored in alert_outbox.status. Pending messages are retried
+// until they are dispatched or run out of attempts and become dead.
+const (
+	OutboxStatusPending    = "pending"
+	OutboxStatusDispatched = "dispatched"
+	OutboxStatusDead       = "dead"
+)
+
+// OutboxMessage is a notification committed together with the alert decision
+// that produced it and dispatched after the commit. EpisodeTS, together with
+// the pair named in the payload, keys the alert row deliveries are recorded
+// against. Channels, when set, limits delivery to those channels: the pair's
+// channels when queued, narrowed to the ones that failed after each attempt.
+type OutboxMessage struct {
+	ID            int64
+	EpisodeTS     *time.Time
+	Kind          string
+	Payload       json.RawMessage
+	Status        string
+	Attempts      int
+	LastError     *string
+	CreatedAt     time.Time
+	LastAttemptAt *time.Time
+	NextAttemptAt time.Time
+	Channels      []string
+}
+
+// OutboxAttempt is the outcome of one dispatch attempt.
+type OutboxAttempt struct {
+	Status        string
+	Error         *string
+	Channels      []string
+	NextAttemptAt time.Time
+}
+
+// Alert states persisted in alert_state.state.
+const (
+	AlertStateOK       = "ok"
+	AlertStatePending  = "pending"
+	AlertStateFiring   = "firing"
+	AlertStateResolved = "resolved"
+	// AlertStateCancelled only appears on alert rows whose pending episode never confirmed.
+	AlertStateCancelled = "cancelled"
+)
+
+// AlertState is the persisted alert state machine for one monitored series.
+// It is re-read under the advisory lock every bucket so restarts and leader
+// changes continue the same episode.
+type AlertState struct {
+	Key         string
+	State       string
+	Direction   string
+	BreachCount int
+	// BreachWindow holds one bit per recent bucket, bit 0 being the latest.
+	BreachWindow uint64
+	// PeakDeviationPct is the largest deviation seen in the open episode.
+	PeakDeviationPct decimal.NullDecimal
+	PendingSince     *time.Time
+	FiringSince      *time.Time
+	Notified         bool
+	LastBucket       *time.Time
+	LastAlertUpAt    *time.Time
+	LastAlertDownAt  *time.Time
+	UpdatedAt        time.Time
+}
+
+// BackfillCheckpoint tracks resumable progress of a backfill job. Every bucket
+// before Watermark has been written (complete, partial or errored).
+type BackfillCheckpoint struct {
+	JobKey    string
+	From      time.Time
+	To        time.Time
+	Interval  time.Duration
+	Watermark time.Time
+	Processed int64
+	Failed    int64
+	UpdatedAt time.Time
 }
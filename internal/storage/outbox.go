@@ -0,0 +1,178 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"price-diff-alerts/internal/storage/sqlc"
+)
+
+// OutboxStore queues notifications inside the alert transaction.
+type OutboxStore interface {
+	EnqueueOutbox(ctx context.Context, msg OutboxMessage) (int64, error)
+	// ListDueOutbox returns pending messages whose next attempt is due at now.
+	ListDueOutbox(ctx context.Context, now time.Time, limit int) ([]OutboxMessage, error)
+	// MarkOutboxAttempt records one dispatch attempt and its resulting status.
+	MarkOutboxAttempt(ctx context.Context, id int64, attempt OutboxAttempt) error
+}
+
+// OutboxAdmin lists and replays outbox messages for operators.
+type OutboxAdmin interface {
+	// ListOutbox returns the newest messages, optionally filtered by status.
+	ListOutbox(ctx context.Context, status string, limit int) ([]OutboxMessage, error)
+	// ReplayOutbox requeues one undispatched message with a fresh attempt
+	// budget and reports whether it was found.
+	ReplayOutbox(ctx context.Context, id int64) (bool, error)
+	// ReplayDeadOutbox requeues every dead message.
+	ReplayDeadOutbox(ctx context.Context) (int64, error)
+}
+
+// Tx is the transactional view a unit of work runs against.
+type Tx interface {
+	RateSampleStore
+	AlertStore
+	AlertStateStore
+	OutboxStore
+}
+
+// UnitOfWork commits everything written through the Tx passed to fn, or
+// nothing when fn returns an error.
+type UnitOfWork interface {
+	InTx(ctx context.Context, fn func(tx Tx) error) error
+}
+
+// InTx implements UnitOfWork on top of WithTx.
+func (s *Store) InTx(ctx context.Context, fn func(tx Tx) error) error {
+	return s.WithTx(ctx, func(tx *Store) error {
+		return fn(tx)
+	})
+}
+
+// EnqueueOutbox stores a pending notification, first due at
+// msg.NextAttemptAt, and returns its id.
+func (s *Store) EnqueueOutbox(ctx context.Context, msg OutboxMessage) (int64, error) {
+	q, err := s.queries()
+	if err != nil {
+		return 0, err
+	}
+	id, queryErr := q.EnqueueOutbox(ctx, sqlc.EnqueueOutboxParams{
+		EpisodeTs:     timestamptzPtr(msg.EpisodeTS),
+		Kind:          msg.Kind,
+		Payload:       []byte(msg.Payload),
+		NextAttemptAt: timestamptz(msg.NextAttemptAt),
+		Channels:      msg.Channels,
+	})
+	if queryErr != nil {
+		return 0, fmt.Errorf("enqueue outbox: %w", queryErr)
+	}
+	return id, nil
+}
+
+// ListDueOutbox returns pending messages due at now, earliest first.
+func (s *Store) ListDueOutbox(ctx context.Context, now time.Time, limit int) ([]OutboxMessage, error) {
+	q, err := s.queries()
+	if err != nil {
+		return nil, err
+	}
+	rows, queryErr := q.ListDueOutbox(ctx, sqlc.ListDueOutboxParams{
+		NextAttemptAt: timestamptz(now),
+		Limit:         int32(limit),
+	})
+	if queryErr != nil {
+		return nil, fmt.Errorf("list due outbox: %w", queryErr)
+	}
+	return outboxFromRows(rows), nil
+}
+
+// MarkOutboxAttempt updates status, attempts, last error and the next attempt
+// time of a message.
+func (s *Store) MarkOutboxAttempt(ctx context.Context, id int64, attempt OutboxAttempt) error {
+	q, err := s.queries()
+	if err != nil {
+		return err
+	}
+	if execErr := q.MarkOutboxAttempt(ctx, sqlc.MarkOutboxAttemptParams{
+		ID:            id,
+		Status:        attempt.Status,
+		LastError:     textPtr(attempt.Error),
+		Channels:      attempt.Channels,
+		NextAttemptAt: timestamptz(attempt.NextAttemptAt),
+	}); execErr != nil {
+		return fmt.Errorf("mark outbox attempt: %w", execErr)
+	}
+	return nil
+}
+
+// ListOutbox returns the newest messages; an empty status matches all.
+func (s *Store) ListOutbox(ctx context.Context, status string, limit int) ([]OutboxMessage, error) {
+	q, err := s.queries()
+	if err != nil {
+		return nil, err
+	}
+	rows, queryErr := q.ListOutbox(ctx, sqlc.ListOutboxParams{
+		Status:   status,
+		RowLimit: int32(limit),
+	})
+	if queryErr != nil {
+		return nil, fmt.Errorf("list outbox: %w", queryErr)
+	}
+	return outboxFromRows(rows), nil
+}
+
+// ReplayOutbox requeues message id unless it was already dispatched.
+func (s *Store) ReplayOutbox(ctx context.Context, id int64) (bool, error) {
+	q, err := s.queries()
+	if err != nil {
+		return false, err
+	}
+	n, execErr := q.ReplayOutbox(ctx, id)
+	if execErr != nil {
+		return false, fmt.Errorf("replay outbox %d: %w", id, execErr)
+	}
+	return n > 0, nil
+}
+
+// ReplayDeadOutbox requeues every dead message.
+func (s *Store) ReplayDeadOutbox(ctx context.Context) (int64, error) {
+	q, err := s.queries()
+	if err != nil {
+		return 0, err
+	}
+	n, execErr := q.ReplayDeadOutbox(ctx)
+	if execErr != nil {
+		return 0, fmt.Errorf("replay dead outbox: %w", execErr)
+	}
+	return n, nil
+}
+
+func outboxFromRow(row sqlc.AlertOutbox) OutboxMessage {
+	return OutboxMessage{
+		ID:            row.ID,
+		EpisodeTS:     timeFrom(row.EpisodeTs),
+		Kind:          row.Kind,
+		Payload:       row.Payload,
+		Status:        row.Status,
+		Attempts:      int(row.Attempts),
+		LastError:     stringFrom(row.LastError),
+		CreatedAt:     row.CreatedAt.Time,
+		LastAttemptAt: timeFrom(row.LastAttemptAt),
+		NextAttemptAt: row.NextAttemptAt.Time,
+		Channels:      row.Channels,
+	}
+}
+
+func outboxFromRows(rows []sqlc.AlertOutbox) []OutboxMessage {
+	msgs := make([]OutboxMessage, 0, len(rows))
+	for _, row := range rows {
+		msgs = append(msgs, outboxFromRow(row))
+	}
+	return msgs
+}
+
+var (
+	_ OutboxStore = (*Store)(nil)
+	_ OutboxAdmin = (*Store)(nil)
+	_ UnitOfWork  = (*Store)(nil)
+	_ Tx          = (*Store)(nil)
+)
@@ -2,8 +2,6 @@ package storage
 
 import (
 	"context"
-	"database/sql"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"time"
@@ -11,6 +9,8 @@ import (
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/shopspring/decimal"
+
+	"price-diff-alerts/internal/storage/sqlc"
 )
 
 var (
@@ -19,101 +19,6 @@ var (
 )
 
 const (
-	upsertRateSampleSQL = `INSERT INTO rate_samples (
-        bucket_ts,
-        official_susde_per_usde,
-        market_susde_per_usde,
-        deviation_pct,
-        notional_usde,
-        cow_quality,
-        cow_quote,
-        block_number,
-        status,
-        error
-    ) VALUES (
-        $1,$2,$3,$4,$5,$6,$7,$8,$9,$10
-    )
-    ON CONFLICT (bucket_ts) DO UPDATE
-    SET
-        official_susde_per_usde = EXCLUDED.official_susde_per_usde,
-        market_susde_per_usde   = EXCLUDED.market_susde_per_usde,
-        deviation_pct           = EXCLUDED.deviation_pct,
-        notional_usde           = EXCLUDED.notional_usde,
-        cow_quality             = EXCLUDED.cow_quality,
-        cow_quote               = EXCLUDED.cow_quote,
-        block_number            = EXCLUDED.block_number,
-        status                  = EXCLUDED.status,
-        error                   = EXCLUDED.error;`
-
-	listSamplesBetweenSQL = `SELECT
-        bucket_ts,
-        official_susde_per_usde,
-        market_susde_per_usde,
-        deviation_pct,
-        notional_usde,
-        cow_quality,
-        cow_quote,
-        block_number,
-        status,
-        error,
-        created_at
-    FROM rate_samples
-    WHERE bucket_ts >= $1
-      AND bucket_ts < $2
-    ORDER BY bucket_ts;`
-
-	listRecentSamplesSQL = `SELECT
-        bucket_ts,
-        official_susde_per_usde,
-        market_susde_per_usde,
-        deviation_pct,
-        notional_usde,
-        cow_quality,
-        cow_quote,
-        block_number,
-        status,
-        error,
-        created_at
-    FROM rate_samples
-    ORDER BY bucket_ts DESC
-    LIMIT $1;`
-
-	markSampleErroredSQL = `UPDATE rate_samples
-    SET status = 'errored', error = $2
-    WHERE bucket_ts = $1;`
-
-	countSamplesSQL = `SELECT COUNT(*) FROM rate_samples;`
-
-	insertAlertSQL = `INSERT INTO alerts (
-        sample_ts,
-        deviation_pct,
-        threshold_pct,
-        direction,
-        channels
-    ) VALUES (
-        $1,$2,$3,$4,$5
-    )
-    ON CONFLICT (sample_ts) DO UPDATE
-    SET deviation_pct = EXCLUDED.deviation_pct,
-        threshold_pct = EXCLUDED.threshold_pct,
-        direction     = EXCLUDED.direction,
-        channels      = EXCLUDED.channels
-    RETURNING id, sample_ts, deviation_pct, threshold_pct, direction, channels, created_at;`
-
-	listRecentAlertsSQL = `SELECT
-        id,
-        sample_ts,
-        deviation_pct,
-        threshold_pct,
-        direction,
-        channels,
-        created_at
-    FROM alerts
-    ORDER BY created_at DESC
-    LIMIT $1;`
-
-	deleteAlertsBeforeSQL = `DELETE FROM alerts WHERE created_at < $1;`
-
 	tryAdvisoryLockSQL = `SELECT pg_try_advisory_lock($1);`
 	advisoryUnlockSQL  = `SELECT pg_advisory_unlock($1);`
 )
@@ -121,42 +26,103 @@ const (
 // RateSampleStore defines operations for rate sample persistence.
 type RateSampleStore interface {
 	UpsertRateSample(ctx context.Context, sample RateSample) error
-	ListSamplesBetween(ctx context.Context, from, to time.Time) ([]RateSample, error)
-	ListRecentSamples(ctx context.Context, limit int) ([]RateSample, error)
-	MarkSampleErrored(ctx context.Context, bucket time.Time, errMsg string) error
+	ListSamplesBetween(ctx context.Context, pairID string, from, to time.Time) ([]RateSample, error)
+	ListRecentSamples(ctx context.Context, pairID string, limit int) ([]RateSample, error)
+	MarkSampleErrored(ctx context.Context, pairID string, bucket time.Time, phase, errMsg string) error
+	// CountSamples counts the samples of every pair.
 	CountSamples(ctx context.Context) (int64, error)
 }
 
-// AlertStore defines operations for alert auditing.
+// AlertStore defines operations for alert auditing. Episodes are keyed by
+// (pair, SampleTS); listings cover every pair.
 type AlertStore interface {
 	InsertAlert(ctx context.Context, alert AlertRecord) (AlertRecord, error)
 	ListRecentAlerts(ctx context.Context, limit int) ([]AlertRecord, error)
+	UpdateAlertState(ctx context.Context, pairID string, sampleTS time.Time, state string) error
+	ResolveAlert(ctx context.Context, pairID string, sampleTS, resolvedAt time.Time, peak decimal.Decimal) error
 	DeleteAlertsBefore(ctx context.Context, olderThan time.Time) error
 }
 
+// HistoryStore serves range reads over samples and alerts.
+type HistoryStore interface {
+	// ListAlertsBetween lists episodes whose first breach falls in [from, to).
+	ListAlertsBetween(ctx context.Context, from, to time.Time) ([]AlertRecord, error)
+	// ListSamplesByDeviation lists samples of the pair in [from, to) whose
+	// absolute deviation is at least minAbsPct, largest first.
+	ListSamplesByDeviation(ctx context.Context, pairID string, from, to time.Time, minAbsPct decimal.Decimal, limit int) ([]RateSample, error)
+	// ListSamplesPage lists at most limit samples of the pair in [from, to),
+	// oldest first; the next page starts from the bucket after the last one
+	// returned.
+	ListSamplesPage(ctx context.Context, pairID string, from, to time.Time, limit int) ([]RateSample, error)
+}
+
+// Pinger reports whether the database is reachable.
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
 // AdvisoryLocker exposes advisory lock helpers.
 type AdvisoryLocker interface {
 	TryAdvisoryLock(ctx context.Context, key int64) (unlock func(), acquired bool, err error)
 }
 
-// Store aggregates access to rate samples and alerts.
+// Store aggregates access to rate samples and alerts on top of the sqlc
+// queries. A Store handed out by WithTx runs every query in that transaction.
 type Store struct {
 	pool *pgxpool.Pool
+	q    *sqlc.Queries
+	tx   pgx.Tx
 }
 
 // NewStore wires a pgx pool into a Store.
 func NewStore(pool *pgxpool.Pool) *Store {
-	return &Store{pool: pool}
+	return &Store{pool: pool, q: sqlc.New(pool)}
 }
 
 // Close releases the underlying pool resources.
 func (s *Store) Close() {
-	if s == nil || s.pool == nil {
+	if s == nil || s.pool == nil || s.tx != nil {
 		return
 	}
 	s.pool.Close()
 }
 
+// Ping checks that the pool can reach PostgreSQL.
+func (s *Store) Ping(ctx context.Context) error {
+	pool, err := s.getPool()
+	if err != nil {
+		return err
+	}
+	return pool.Ping(ctx)
+}
+
+// WithTx runs fn with a Store bound to a single transaction, committing when
+// fn returns nil and rolling back otherwise. Calls on a Store that is already
+// transactional reuse the outer transaction.
+func (s *Store) WithTx(ctx context.Context, fn func(tx *Store) error) error {
+	if s != nil && s.tx != nil {
+		return fn(s)
+	}
+	pool, err := s.getPool()
+	if err != nil {
+		return err
+	}
+
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if err := fn(&Store{pool: pool, q: s.q.WithTx(tx), tx: tx}); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
+
 // TryAdvisoryLock attempts to acquire a postgres advisory lock and returns a release func.
 func (s *Store) TryAdvisoryLock(ctx context.Context, key int64) (func(), bool, error) {
 	pool, err := s.getPool()
@@ -197,111 +163,133 @@ func (s *Store) getPool() (*pgxpool.Pool, error) {
 	return s.pool, nil
 }
 
+func (s *Store) queries() (*sqlc.Queries, error) {
+	if s == nil || s.q == nil {
+		return nil, ErrNotConfigured
+	}
+	return s.q, nil
+}
+
 // UpsertRateSample persists or updates a rate sample.
 func (s *Store) UpsertRateSample(ctx context.Context, sample RateSample) error {
-	pool, err := s.getPool()
+	q, err := s.queries()
 	if err != nil {
 		return err
 	}
 
-	official := sample.OfficialRate.String()
-	market := sample.MarketRate.String()
-	deviation := sample.DeviationPct.String()
-	notional := sample.NotionalUSDE.String()
-
-	var block interface{}
-	if sample.BlockNumber != nil {
-		block = *sample.BlockNumber
-	}
-
-	var errMsg interface{}
-	if sample.Error != nil {
-		errMsg = *sample.Error
-	}
-
-	_, execErr := pool.Exec(ctx, upsertRateSampleSQL,
-		sample.Bucket,
-		official,
-		market,
-		deviation,
-		notional,
-		sample.CowQuality,
-		[]byte(sample.CowQuote),
-		block,
-		sample.Status,
-		errMsg,
-	)
-	if execErr != nil {
+	if execErr := q.UpsertRateSample(ctx, sqlc.UpsertRateSampleParams{
+		PairID:               sample.PairID,
+		BucketTs:             timestamptz(sample.Bucket),
+		OfficialSusdePerUsde: sample.OfficialRate,
+		MarketSusdePerUsde:   sample.MarketRate,
+		DeviationPct:         sample.DeviationPct,
+		NotionalUsde:         sample.Notional,
+		CowQuality:           sample.CowQuality,
+		CowQuote:             nullJSON(sample.CowQuote),
+		BlockNumber:          int8Ptr(sample.BlockNumber),
+		Status:               sample.Status,
+		Error:                textPtr(sample.Error),
+		ErrorPhase:           textPtr(sample.ErrorPhase),
+		OfficialMethod:       textPtr(sample.OfficialMethod),
+		BlockHash:            textPtr(sample.BlockHash),
+		BlockTime:            timestamptzPtr(sample.BlockTime),
+	}); execErr != nil {
 		return fmt.Errorf("upsert rate sample: %w", execErr)
 	}
 	return nil
 }
 
-// ListSamplesBetween lists samples within a time window.
-func (s *Store) ListSamplesBetween(ctx context.Context, from, to time.Time) ([]RateSample, error) {
-	pool, err := s.getPool()
+// ListSamplesBetween lists samples of the pair within a time window.
+func (s *Store) ListSamplesBetween(ctx context.Context, pairID string, from, to time.Time) ([]RateSample, error) {
+	q, err := s.queries()
 	if err != nil {
 		return nil, err
 	}
 
-	rows, queryErr := pool.Query(ctx, listSamplesBetweenSQL, from, to)
+	rows, queryErr := q.ListSamplesBetween(ctx, sqlc.ListSamplesBetweenParams{
+		PairID:     pairID,
+		BucketTs:   timestamptz(from),
+		BucketTs_2: timestamptz(to),
+	})
 	if queryErr != nil {
 		return nil, fmt.Errorf("list samples between: %w", queryErr)
 	}
-	defer rows.Close()
+	return rateSamplesFromRows(rows), nil
+}
 
-	samples := make([]RateSample, 0)
-	for rows.Next() {
-		sample, scanErr := scanRateSample(rows)
-		if scanErr != nil {
-			return nil, scanErr
-		}
-		samples = append(samples, sample)
+// ListRecentSamples lists the pair's most recent samples ordered by
+// descending bucket.
+func (s *Store) ListRecentSamples(ctx context.Context, pairID string, limit int) ([]RateSample, error) {
+	q, err := s.queries()
+	if err != nil {
+		return nil, err
 	}
-	if rows.Err() != nil {
-		return nil, rows.Err()
+
+	rows, queryErr := q.ListRecentSamples(ctx, sqlc.ListRecentSamplesParams{
+		PairID: pairID,
+		Limit:  int32(limit),
+	})
+	if queryErr != nil {
+		return nil, fmt.Errorf("list recent samples: %w", queryErr)
 	}
-	return samples, nil
+	return rateSamplesFromRows(rows), nil
 }
 
-// ListRecentSamples lists the most recent samples ordered by descending bucket.
-func (s *Store) ListRecentSamples(ctx context.Context, limit int) ([]RateSample, error) {
-	pool, err := s.getPool()
+// ListSamplesByDeviation lists the pair's largest deviations in [from, to).
+func (s *Store) ListSamplesByDeviation(ctx context.Context, pairID string, from, to time.Time, minAbsPct decimal.Decimal, limit int) ([]RateSample, error) {
+	q, err := s.queries()
 	if err != nil {
 		return nil, err
 	}
 
-	rows, queryErr := pool.Query(ctx, listRecentSamplesSQL, limit)
+	rows, queryErr := q.ListSamplesByDeviation(ctx, sqlc.ListSamplesByDeviationParams{
+		PairID:             pairID,
+		FromTs:             timestamptz(from),
+		ToTs:               timestamptz(to),
+		MinAbsDeviationPct: numeric(minAbsPct.Abs()),
+		RowLimit:           int32(limit),
+	})
 	if queryErr != nil {
-		return nil, fmt.Errorf("list recent samples: %w", queryErr)
+		return nil, fmt.Errorf("list samples by deviation: %w", queryErr)
 	}
-	defer rows.Close()
+	return rateSamplesFromRows(rows), nil
+}
 
-	samples := make([]RateSample, 0, limit)
-	for rows.Next() {
-		sample, scanErr := scanRateSample(rows)
-		if scanErr != nil {
-			return nil, scanErr
-		}
-		samples = append(samples, sample)
+// ListSamplesPage lists at most limit of the pair's samples in [from, to).
+func (s *Store) ListSamplesPage(ctx context.Context, pairID string, from, to time.Time, limit int) ([]RateSample, error) {
+	q, err := s.queries()
+	if err != nil {
+		return nil, err
 	}
-	if rows.Err() != nil {
-		return nil, rows.Err()
+
+	rows, queryErr := q.ListSamplesPage(ctx, sqlc.ListSamplesPageParams{
+		PairID:   pairID,
+		FromTs:   timestamptz(from),
+		ToTs:     timestamptz(to),
+		RowLimit: int32(limit),
+	})
+	if queryErr != nil {
+		return nil, fmt.Errorf("list samples page: %w", queryErr)
 	}
-	return samples, nil
+	return rateSamplesFromRows(rows), nil
 }
 
-// MarkSampleErrored marks a sample as errored.
-func (s *Store) MarkSampleErrored(ctx context.Context, bucket time.Time, errMsg string) error {
-	pool, err := s.getPool()
+// MarkSampleErrored marks an existing sample as errored in the given phase.
+func (s *Store) MarkSampleErrored(ctx context.Context, pairID string, bucket time.Time, phase, errMsg string) error {
+	q, err := s.queries()
 	if err != nil {
 		return err
 	}
-	cmdTag, execErr := pool.Exec(ctx, markSampleErroredSQL, bucket, errMsg)
+	affected, execErr := q.MarkSampleErrored(ctx, sqlc.MarkSampleErroredParams{
+		PairID:     pairID,
+		BucketTs:   timestamptz(bucket),
+		Error:      textPtr(&errMsg),
+		ErrorPhase: textPtr(&phase),
+	})
 	if execErr != nil {
 		return fmt.Errorf("mark sample errored: %w", execErr)
 	}
-	if cmdTag.RowsAffected() == 0 {
+	if affected == 0 {
 		return pgx.ErrNoRows
 	}
 	return nil
@@ -309,189 +297,122 @@ func (s *Store) MarkSampleErrored(ctx context.Context, bucket time.Time, errMsg
 
 // CountSamples counts stored samples.
 func (s *Store) CountSamples(ctx context.Context) (int64, error) {
-	pool, err := s.getPool()
+	q, err := s.queries()
 	if err != nil {
 		return 0, err
 	}
-	var count int64
-	if scanErr := pool.QueryRow(ctx, countSamplesSQL).Scan(&count); scanErr != nil {
-		return 0, fmt.Errorf("count samples: %w", scanErr)
+	count, queryErr := q.CountSamples(ctx)
+	if queryErr != nil {
+		return 0, fmt.Errorf("count samples: %w", queryErr)
 	}
 	return count, nil
 }
 
-// InsertAlert persists an alert emission.
+// InsertAlert persists an alert episode, updating the row keyed by
+// (PairID, SampleTS) as the episode moves through its states.
 func (s *Store) InsertAlert(ctx context.Context, alert AlertRecord) (AlertRecord, error) {
-	pool, err := s.getPool()
+	q, err := s.queries()
 	if err != nil {
 		return AlertRecord{}, err
 	}
 
-	deviation := alert.DeviationPct.String()
-	threshold := alert.ThresholdPct.String()
-
-	row := pool.QueryRow(ctx, insertAlertSQL,
-		alert.SampleTS,
-		deviation,
-		threshold,
-		alert.Direction,
-		alert.Channels,
-	)
-
-	var rec AlertRecord
-	var deviationStr, thresholdStr string
-	if scanErr := row.Scan(
-		&rec.ID,
-		&rec.SampleTS,
-		&deviationStr,
-		&thresholdStr,
-		&rec.Direction,
-		&rec.Channels,
-		&rec.CreatedAt,
-	); scanErr != nil {
-		return AlertRecord{}, fmt.Errorf("insert alert: %w", scanErr)
-	}
-
-	var convErr error
-	rec.DeviationPct, convErr = decimal.NewFromString(deviationStr)
-	if convErr != nil {
-		return AlertRecord{}, fmt.Errorf("parse deviation pct: %w", convErr)
-	}
-	rec.ThresholdPct, convErr = decimal.NewFromString(thresholdStr)
-	if convErr != nil {
-		return AlertRecord{}, fmt.Errorf("parse threshold pct: %w", convErr)
+	row, queryErr := q.InsertAlert(ctx, sqlc.InsertAlertParams{
+		PairID:           alert.PairID,
+		SampleTs:         timestamptz(alert.SampleTS),
+		DeviationPct:     alert.DeviationPct,
+		ThresholdPct:     alert.ThresholdPct,
+		Direction:        alert.Direction,
+		Channels:         alert.Channels,
+		State:            alert.State,
+		ConfirmedAt:      timestamptzPtr(alert.ConfirmedAt),
+		PeakDeviationPct: alert.PeakDeviationPct,
+	})
+	if queryErr != nil {
+		return AlertRecord{}, fmt.Errorf("insert alert: %w", queryErr)
 	}
-
-	return rec, nil
+	return alertFromRow(row), nil
 }
 
 // ListRecentAlerts lists most recent alerts.
 func (s *Store) ListRecentAlerts(ctx context.Context, limit int) ([]AlertRecord, error) {
-	pool, err := s.getPool()
+	q, err := s.queries()
 	if err != nil {
 		return nil, err
 	}
 
-	rows, queryErr := pool.Query(ctx, listRecentAlertsSQL, limit)
+	rows, queryErr := q.ListRecentAlerts(ctx, int32(limit))
 	if queryErr != nil {
 		return nil, fmt.Errorf("list recent alerts: %w", queryErr)
 	}
-	defer rows.Close()
-
-	alerts := make([]AlertRecord, 0, limit)
-	for rows.Next() {
-		var rec AlertRecord
-		var deviationStr, thresholdStr string
-		if err := rows.Scan(
-			&rec.ID,
-			&rec.SampleTS,
-			&deviationStr,
-			&thresholdStr,
-			&rec.Direction,
-			&rec.Channels,
-			&rec.CreatedAt,
-		); err != nil {
-			return nil, err
-		}
-
-		var convErr error
-		rec.DeviationPct, convErr = decimal.NewFromString(deviationStr)
-		if convErr != nil {
-			return nil, fmt.Errorf("parse deviation pct: %w", convErr)
-		}
-		rec.ThresholdPct, convErr = decimal.NewFromString(thresholdStr)
-		if convErr != nil {
-			return nil, fmt.Errorf("parse threshold pct: %w", convErr)
-		}
+	return alertsFromRows(rows), nil
+}
 
-		alerts = append(alerts, rec)
+// ListAlertsBetween lists alert episodes that started within [from, to).
+func (s *Store) ListAlertsBetween(ctx context.Context, from, to time.Time) ([]AlertRecord, error) {
+	q, err := s.queries()
+	if err != nil {
+		return nil, err
 	}
-	if rows.Err() != nil {
-		return nil, rows.Err()
+
+	rows, queryErr := q.ListAlertsBetween(ctx, sqlc.ListAlertsBetweenParams{
+		SampleTs:   timestamptz(from),
+		SampleTs_2: timestamptz(to),
+	})
+	if queryErr != nil {
+		return nil, fmt.Errorf("list alerts between: %w", queryErr)
 	}
-	return alerts, nil
+	return alertsFromRows(rows), nil
 }
 
-// DeleteAlertsBefore deletes historical alerts.
-func (s *Store) DeleteAlertsBefore(ctx context.Context, olderThan time.Time) error {
-	pool, err := s.getPool()
+// UpdateAlertState moves an existing alert episode to a new state.
+func (s *Store) UpdateAlertState(ctx context.Context, pairID string, sampleTS time.Time, state string) error {
+	q, err := s.queries()
 	if err != nil {
 		return err
 	}
-	if _, execErr := pool.Exec(ctx, deleteAlertsBeforeSQL, olderThan); execErr != nil {
-		return fmt.Errorf("delete alerts before: %w", execErr)
+	if execErr := q.UpdateAlertState(ctx, sqlc.UpdateAlertStateParams{
+		PairID:   pairID,
+		SampleTs: timestamptz(sampleTS),
+		State:    state,
+	}); execErr != nil {
+		return fmt.Errorf("update alert state: %w", execErr)
 	}
 	return nil
 }
 
-func scanRateSample(rows pgx.Rows) (RateSample, error) {
-	var (
-		bucket       time.Time
-		officialStr  string
-		marketStr    string
-		deviationStr string
-		notionalStr  string
-		cowQuality   string
-		cowQuote     json.RawMessage
-		block        sql.NullInt64
-		status       string
-		errMsg       sql.NullString
-		createdAt    time.Time
-	)
-
-	if err := rows.Scan(
-		&bucket,
-		&officialStr,
-		&marketStr,
-		&deviationStr,
-		&notionalStr,
-		&cowQuality,
-		&cowQuote,
-		&block,
-		&status,
-		&errMsg,
-		&createdAt,
-	); err != nil {
-		return RateSample{}, err
-	}
-
-	official, err := decimal.NewFromString(officialStr)
-	if err != nil {
-		return RateSample{}, fmt.Errorf("parse official rate: %w", err)
-	}
-	market, err := decimal.NewFromString(marketStr)
+// ResolveAlert closes a firing episode, recording when it ended and its peak.
+func (s *Store) ResolveAlert(ctx context.Context, pairID string, sampleTS, resolvedAt time.Time, peak decimal.Decimal) error {
+	q, err := s.queries()
 	if err != nil {
-		return RateSample{}, fmt.Errorf("parse market rate: %w", err)
-	}
-	deviation, err := decimal.NewFromString(deviationStr)
-	if err != nil {
-		return RateSample{}, fmt.Errorf("parse deviation pct: %w", err)
-	}
-	notional, err := decimal.NewFromString(notionalStr)
-	if err != nil {
-		return RateSample{}, fmt.Errorf("parse notional: %w", err)
+		return err
 	}
-
-	sample := RateSample{
-		Bucket:       bucket,
-		OfficialRate: official,
-		MarketRate:   market,
-		DeviationPct: deviation,
-		NotionalUSDE: notional,
-		CowQuality:   cowQuality,
-		CowQuote:     cowQuote,
-		Status:       status,
-		CreatedAt:    createdAt,
+	if execErr := q.ResolveAlert(ctx, sqlc.ResolveAlertParams{
+		PairID:           pairID,
+		SampleTs:         timestamptz(sampleTS),
+		ResolvedAt:       timestamptz(resolvedAt),
+		PeakDeviationPct: decimal.NewNullDecimal(peak),
+	}); execErr != nil {
+		return fmt.Errorf("resolve alert: %w", execErr)
 	}
+	return nil
+}
 
-	if block.Valid {
-		value := block.Int64
-		sample.BlockNumber = &value
+// DeleteAlertsBefore deletes historical alerts.
+func (s *Store) DeleteAlertsBefore(ctx context.Context, olderThan time.Time) error {
+	q, err := s.queries()
+	if err != nil {
+		return err
 	}
-	if errMsg.Valid {
-		msg := errMsg.String
-		sample.Error = &msg
+	if execErr := q.DeleteAlertsBefore(ctx, timestamptz(olderThan)); execErr != nil {
+		return fmt.Errorf("delete alerts before: %w", execErr)
 	}
-
-	return sample, nil
+	return nil
 }
+
+var (
+	_ RateSampleStore = (*Store)(nil)
+	_ AlertStore      = (*Store)(nil)
+	_ HistoryStore    = (*Store)(nil)
+	_ AdvisoryLocker  = (*Store)(nil)
+	_ Pinger          = (*Store)(nil)
+)
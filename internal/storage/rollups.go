@@ -0,0 +1,142 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"price-diff-alerts/internal/storage/sqlc"
+)
+
+// RollupStore maintains downsampled aggregates and prunes expired rows.
+type RollupStore interface {
+	// RollupSamples recomputes, for every pair, the buckets of resolution
+	// that start before the given time and may still change: hourly from
+	// every hour with raw samples, daily from hourly rollups of the days from
+	// the pair's oldest raw sample on. Late and backfilled samples are
+	// therefore picked up for as long as raw samples are kept.
+	RollupSamples(ctx context.Context, resolution string, before time.Time) (int64, error)
+	ListRollupsBetween(ctx context.Context, pairID, resolution string, from, to time.Time) ([]SampleRollup, error)
+	// ListRollupsPage lists at most limit aggregates of [from, to), oldest
+	// first; the next page starts from the bucket after the last one returned.
+	ListRollupsPage(ctx context.Context, pairID, resolution string, from, to time.Time, limit int) ([]SampleRollup, error)
+	DeleteRollupsBefore(ctx context.Context, resolution string, before time.Time) (int64, error)
+	// DeleteSamplesBefore prunes raw samples older than before, keeping any
+	// whose hour has no rollup yet.
+	DeleteSamplesBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+// RollupSamples upserts aggregates for resolution and returns the rows written.
+func (s *Store) RollupSamples(ctx context.Context, resolution string, before time.Time) (int64, error) {
+	q, err := s.queries()
+	if err != nil {
+		return 0, err
+	}
+
+	var (
+		written int64
+		execErr error
+	)
+	switch resolution {
+	case RollupHourly:
+		written, execErr = q.RollupHourly(ctx, timestamptz(before))
+	case RollupDaily:
+		written, execErr = q.RollupDaily(ctx, timestamptz(before))
+	default:
+		return 0, fmt.Errorf("unknown rollup resolution %q", resolution)
+	}
+	if execErr != nil {
+		return 0, fmt.Errorf("rollup %s samples: %w", resolution, execErr)
+	}
+	return written, nil
+}
+
+// ListRollupsBetween lists the pair's aggregates of resolution within [from, to).
+func (s *Store) ListRollupsBetween(ctx context.Context, pairID, resolution string, from, to time.Time) ([]SampleRollup, error) {
+	q, err := s.queries()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, queryErr := q.ListRollupsBetween(ctx, sqlc.ListRollupsBetweenParams{
+		PairID:     pairID,
+		Resolution: resolution,
+		BucketTs:   timestamptz(from),
+		BucketTs_2: timestamptz(to),
+	})
+	if queryErr != nil {
+		return nil, fmt.Errorf("list rollups between: %w", queryErr)
+	}
+
+	return rollupsFromRows(rows), nil
+}
+
+// ListRollupsPage lists at most limit of the pair's aggregates in [from, to).
+func (s *Store) ListRollupsPage(ctx context.Context, pairID, resolution string, from, to time.Time, limit int) ([]SampleRollup, error) {
+	q, err := s.queries()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, queryErr := q.ListRollupsPage(ctx, sqlc.ListRollupsPageParams{
+		PairID:     pairID,
+		Resolution: resolution,
+		FromTs:     timestamptz(from),
+		ToTs:       timestamptz(to),
+		RowLimit:   int32(limit),
+	})
+	if queryErr != nil {
+		return nil, fmt.Errorf("list rollups page: %w", queryErr)
+	}
+	return rollupsFromRows(rows), nil
+}
+
+func rollupsFromRows(rows []sqlc.RateSampleRollup) []SampleRollup {
+	rollups := make([]SampleRollup, 0, len(rows))
+	for _, row := range rows {
+		rollups = append(rollups, SampleRollup{
+			PairID:     row.PairID,
+			Resolution: row.Resolution,
+			Bucket:     row.BucketTs.Time,
+			Samples:    int(row.Samples),
+			Complete:   int(row.Complete),
+			Official:   RollupStats{Min: row.OfficialMin, Max: row.OfficialMax, Avg: row.OfficialAvg, Last: row.OfficialLast, Count: int(row.OfficialCount)},
+			Market:     RollupStats{Min: row.MarketMin, Max: row.MarketMax, Avg: row.MarketAvg, Last: row.MarketLast, Count: int(row.MarketCount)},
+			Deviation:  RollupStats{Min: row.DeviationMin, Max: row.DeviationMax, Avg: row.DeviationAvg, Last: row.DeviationLast, Count: int(row.Complete)},
+			UpdatedAt:  row.UpdatedAt.Time,
+		})
+	}
+	return rollups
+}
+
+// DeleteRollupsBefore prunes aggregates of resolution older than before.
+func (s *Store) DeleteRollupsBefore(ctx context.Context, resolution string, before time.Time) (int64, error) {
+	q, err := s.queries()
+	if err != nil {
+		return 0, err
+	}
+	deleted, execErr := q.DeleteRollupsBefore(ctx, sqlc.DeleteRollupsBeforeParams{
+		Resolution: resolution,
+		BucketTs:   timestamptz(before),
+	})
+	if execErr != nil {
+		return 0, fmt.Errorf("delete rollups before: %w", execErr)
+	}
+	return deleted, nil
+}
+
+// DeleteSamplesBefore prunes raw samples older than before whose hour has
+// been rolled up.
+func (s *Store) DeleteSamplesBefore(ctx context.Context, before time.Time) (int64, error) {
+	q, err := s.queries()
+	if err != nil {
+		return 0, err
+	}
+	deleted, execErr := q.DeleteSamplesBefore(ctx, timestamptz(before))
+	if execErr != nil {
+		return 0, fmt.Errorf("delete samples before: %w", execErr)
+	}
+	return deleted, nil
+}
+
+var _ RollupStore = (*Store)(nil)
@@ -24,38 +24,53 @@ func (q *Queries) DeleteAlertsBefore(ctx context.Context, createdAt pgtype.Times
 
 const insertAlert = `-- name: InsertAlert :one
 INSERT INTO alerts (
+    pair_id,
     sample_ts,
     deviation_pct,
     threshold_pct,
     direction,
-    channels
+    channels,
+    state,
+    confirmed_at,
+    peak_deviation_pct
 ) VALUES (
-    $1, $2, $3, $4, $5
+    $1, $2, $3, $4, $5, $6, $7, $8, $9
 )
-ON CONFLICT (sample_ts) DO UPDATE
+ON CONFLICT (pair_id, sample_ts) DO UPDATE
 SET
     deviation_pct = EXCLUDED.deviation_pct,
     threshold_pct = EXCLUDED.threshold_pct,
     direction     = EXCLUDED.direction,
-    channels      = EXCLUDED.channels
-RETURNING id, sample_ts, deviation_pct, threshold_pct, direction, channels, created_at
+    channels      = EXCLUDED.channels,
+    state         = EXCLUDED.state,
+    confirmed_at  = EXCLUDED.confirmed_at,
+    peak_deviation_pct = EXCLUDED.peak_deviation_pct
+RETURNING id, sample_ts, deviation_pct, threshold_pct, direction, channels, created_at, state, confirmed_at, resolved_at, peak_deviation_pct, pair_id
 `
 
 type InsertAlertParams struct {
-	SampleTs     pgtype.Timestamptz `json:"sample_ts"`
-	DeviationPct decimal.Decimal    `json:"deviation_pct"`
-	ThresholdPct decimal.Decimal    `json:"threshold_pct"`
-	Direction    string             `json:"direction"`
-	Channels     []string           `json:"channels"`
+	PairID           string              `json:"pair_id"`
+	SampleTs         pgtype.Timestamptz  `json:"sample_ts"`
+	DeviationPct     decimal.Decimal     `json:"deviation_pct"`
+	ThresholdPct     decimal.Decimal     `json:"threshold_pct"`
+	Direction        string              `json:"direction"`
+	Channels         []string            `json:"channels"`
+	State            string              `json:"state"`
+	ConfirmedAt      pgtype.Timestamptz  `json:"confirmed_at"`
+	PeakDeviationPct decimal.NullDecimal `json:"peak_deviation_pct"`
 }
 
 func (q *Queries) InsertAlert(ctx context.Context, arg InsertAlertParams) (Alert, error) {
 	row := q.db.QueryRow(ctx, insertAlert,
+		arg.PairID,
 		arg.SampleTs,
 		arg.DeviationPct,
 		arg.ThresholdPct,
 		arg.Direction,
 		arg.Channels,
+		arg.State,
+		arg.ConfirmedAt,
+		arg.PeakDeviationPct,
 	)
 	var i Alert
 	err := row.Scan(
@@ -66,10 +81,73 @@ func (q *Queries) InsertAlert(ctx context.Context, arg InsertAlertParams) (Alert
 		&i.Direction,
 		&i.Channels,
 		&i.CreatedAt,
+		&i.State,
+		&i.ConfirmedAt,
+		&i.ResolvedAt,
+		&i.PeakDeviationPct,
+		&i.PairID,
 	)
 	return i, err
 }
 
+const listAlertsBetween = `-- name: ListAlertsBetween :many
+SELECT
+    id,
+    sample_ts,
+    deviation_pct,
+    threshold_pct,
+    direction,
+    channels,
+    created_at,
+    state,
+    confirmed_at,
+    resolved_at,
+    peak_deviation_pct,
+    pair_id
+FROM alerts
+WHERE sample_ts >= $1
+  AND sample_ts < $2
+ORDER BY sample_ts
+`
+
+type ListAlertsBetweenParams struct {
+	SampleTs   pgtype.Timestamptz `json:"sample_ts"`
+	SampleTs_2 pgtype.Timestamptz `json:"sample_ts_2"`
+}
+
+func (q *Queries) ListAlertsBetween(ctx context.Context, arg ListAlertsBetweenParams) ([]Alert, error) {
+	rows, err := q.db.Query(ctx, listAlertsBetween, arg.SampleTs, arg.SampleTs_2)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Alert{}
+	for rows.Next() {
+		var i Alert
+		if err := rows.Scan(
+			&i.ID,
+			&i.SampleTs,
+			&i.DeviationPct,
+			&i.ThresholdPct,
+			&i.Direction,
+			&i.Channels,
+			&i.CreatedAt,
+			&i.State,
+			&i.ConfirmedAt,
+			&i.ResolvedAt,
+			&i.PeakDeviationPct,
+			&i.PairID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listRecentAlerts = `-- name: ListRecentAlerts :many
 SELECT
     id,
@@ -78,7 +156,12 @@ SELECT
     threshold_pct,
     direction,
     channels,
-    created_at
+    created_at,
+    state,
+    confirmed_at,
+    resolved_at,
+    peak_deviation_pct,
+    pair_id
 FROM alerts
 ORDER BY created_at DESC
 LIMIT $1
@@ -101,6 +184,11 @@ func (q *Queries) ListRecentAlerts(ctx context.Context, limit int32) ([]Alert, e
 			&i.Direction,
 			&i.Channels,
 			&i.CreatedAt,
+			&i.State,
+			&i.ConfirmedAt,
+			&i.ResolvedAt,
+			&i.PeakDeviationPct,
+			&i.PairID,
 		); err != nil {
 			return nil, err
 		}
@@ -111,3 +199,48 @@ func (q *Queries) ListRecentAlerts(ctx context.Context, limit int32) ([]Alert, e
 	}
 	return items, nil
 }
+
+const resolveAlert = `-- name: ResolveAlert :exec
+UPDATE alerts
+SET
+    state              = 'resolved',
+    resolved_at        = $3,
+    peak_deviation_pct = $4
+WHERE pair_id = $1
+  AND sample_ts = $2
+`
+
+type ResolveAlertParams struct {
+	PairID           string              `json:"pair_id"`
+	SampleTs         pgtype.Timestamptz  `json:"sample_ts"`
+	ResolvedAt       pgtype.Timestamptz  `json:"resolved_at"`
+	PeakDeviationPct decimal.NullDecimal `json:"peak_deviation_pct"`
+}
+
+func (q *Queries) ResolveAlert(ctx context.Context, arg ResolveAlertParams) error {
+	_, err := q.db.Exec(ctx, resolveAlert,
+		arg.PairID,
+		arg.SampleTs,
+		arg.ResolvedAt,
+		arg.PeakDeviationPct,
+	)
+	return err
+}
+
+const updateAlertState = `-- name: UpdateAlertState :exec
+UPDATE alerts
+SET state = $3
+WHERE pair_id = $1
+  AND sample_ts = $2
+`
+
+type UpdateAlertStateParams struct {
+	PairID   string             `json:"pair_id"`
+	SampleTs pgtype.Timestamptz `json:"sample_ts"`
+	State    string             `json:"state"`
+}
+
+func (q *Queries) UpdateAlertState(ctx context.Context, arg UpdateAlertStateParams) error {
+	_, err := q.db.Exec(ctx, updateAlertState, arg.PairID, arg.SampleTs, arg.State)
+	return err
+}
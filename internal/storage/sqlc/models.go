@@ -10,25 +10,116 @@ import (
 )
 
 type Alert struct {
-	ID           int64              `json:"id"`
-	SampleTs     pgtype.Timestamptz `json:"sample_ts"`
-	DeviationPct decimal.Decimal    `json:"deviation_pct"`
-	ThresholdPct decimal.Decimal    `json:"threshold_pct"`
-	Direction    string             `json:"direction"`
-	Channels     []string           `json:"channels"`
-	CreatedAt    pgtype.Timestamptz `json:"created_at"`
+	ID               int64               `json:"id"`
+	SampleTs         pgtype.Timestamptz  `json:"sample_ts"`
+	DeviationPct     decimal.Decimal     `json:"deviation_pct"`
+	ThresholdPct     decimal.Decimal     `json:"threshold_pct"`
+	Direction        string              `json:"direction"`
+	Channels         []string            `json:"channels"`
+	CreatedAt        pgtype.Timestamptz  `json:"created_at"`
+	State            string              `json:"state"`
+	ConfirmedAt      pgtype.Timestamptz  `json:"confirmed_at"`
+	ResolvedAt       pgtype.Timestamptz  `json:"resolved_at"`
+	PeakDeviationPct decimal.NullDecimal `json:"peak_deviation_pct"`
+	PairID           string              `json:"pair_id"`
+}
+
+type AlertDelivery struct {
+	ID        int64              `json:"id"`
+	AlertID   int64              `json:"alert_id"`
+	Kind      string             `json:"kind"`
+	Channel   string             `json:"channel"`
+	Status    string             `json:"status"`
+	Attempts  int32              `json:"attempts"`
+	Error     pgtype.Text        `json:"error"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+}
+
+type AlertOutbox struct {
+	ID            int64              `json:"id"`
+	EpisodeTs     pgtype.Timestamptz `json:"episode_ts"`
+	Kind          string             `json:"kind"`
+	Payload       []byte             `json:"payload"`
+	Status        string             `json:"status"`
+	Attempts      int32              `json:"attempts"`
+	LastError     pgtype.Text        `json:"last_error"`
+	CreatedAt     pgtype.Timestamptz `json:"created_at"`
+	LastAttemptAt pgtype.Timestamptz `json:"last_attempt_at"`
+	NextAttemptAt pgtype.Timestamptz `json:"next_attempt_at"`
+	Channels      []string           `json:"channels"`
+}
+
+type AlertState struct {
+	StateKey         string              `json:"state_key"`
+	State            string              `json:"state"`
+	Direction        pgtype.Text         `json:"direction"`
+	BreachCount      int32               `json:"breach_count"`
+	PendingSince     pgtype.Timestamptz  `json:"pending_since"`
+	FiringSince      pgtype.Timestamptz  `json:"firing_since"`
+	Notified         bool                `json:"notified"`
+	LastBucket       pgtype.Timestamptz  `json:"last_bucket"`
+	LastAlertUpAt    pgtype.Timestamptz  `json:"last_alert_up_at"`
+	LastAlertDownAt  pgtype.Timestamptz  `json:"last_alert_down_at"`
+	UpdatedAt        pgtype.Timestamptz  `json:"updated_at"`
+	BreachWindow     int64               `json:"breach_window"`
+	PeakDeviationPct decimal.NullDecimal `json:"peak_deviation_pct"`
+}
+
+type BackfillCheckpoint struct {
+	JobKey      string             `json:"job_key"`
+	RangeFrom   pgtype.Timestamptz `json:"range_from"`
+	RangeTo     pgtype.Timestamptz `json:"range_to"`
+	IntervalSec int64              `json:"interval_sec"`
+	Watermark   pgtype.Timestamptz `json:"watermark"`
+	Processed   int64              `json:"processed"`
+	Failed      int64              `json:"failed"`
+	UpdatedAt   pgtype.Timestamptz `json:"updated_at"`
+}
+
+type DigestPeriod struct {
+	PairID    string             `json:"pair_id"`
+	PeriodEnd pgtype.Timestamptz `json:"period_end"`
+	SentAt    pgtype.Timestamptz `json:"sent_at"`
 }
 
 type RateSample struct {
-	BucketTs             pgtype.Timestamptz `json:"bucket_ts"`
-	OfficialSusdePerUsde decimal.Decimal    `json:"official_susde_per_usde"`
-	MarketSusdePerUsde   decimal.Decimal    `json:"market_susde_per_usde"`
-	DeviationPct         decimal.Decimal    `json:"deviation_pct"`
-	NotionalUsde         decimal.Decimal    `json:"notional_usde"`
-	CowQuality           string             `json:"cow_quality"`
-	CowQuote             []byte             `json:"cow_quote"`
-	BlockNumber          pgtype.Int8        `json:"block_number"`
-	Status               string             `json:"status"`
-	Error                pgtype.Text        `json:"error"`
-	CreatedAt            pgtype.Timestamptz `json:"created_at"`
+	BucketTs             pgtype.Timestamptz  `json:"bucket_ts"`
+	OfficialSusdePerUsde decimal.NullDecimal `json:"official_susde_per_usde"`
+	MarketSusdePerUsde   decimal.NullDecimal `json:"market_susde_per_usde"`
+	DeviationPct         decimal.NullDecimal `json:"deviation_pct"`
+	NotionalUsde         decimal.Decimal     `json:"notional_usde"`
+	CowQuality           string              `json:"cow_quality"`
+	CowQuote             []byte              `json:"cow_quote"`
+	BlockNumber          pgtype.Int8         `json:"block_number"`
+	Status               string              `json:"status"`
+	Error                pgtype.Text         `json:"error"`
+	CreatedAt            pgtype.Timestamptz  `json:"created_at"`
+	ErrorPhase           pgtype.Text         `json:"error_phase"`
+	PairID               string              `json:"pair_id"`
+	OfficialMethod       pgtype.Text         `json:"official_method"`
+	BlockHash            pgtype.Text         `json:"block_hash"`
+	BlockTime            pgtype.Timestamptz  `json:"block_time"`
+}
+
+type RateSampleRollup struct {
+	Resolution    string              `json:"resolution"`
+	BucketTs      pgtype.Timestamptz  `json:"bucket_ts"`
+	Samples       int32               `json:"samples"`
+	Complete      int32               `json:"complete"`
+	OfficialMin   decimal.NullDecimal `json:"official_min"`
+	OfficialMax   decimal.NullDecimal `json:"official_max"`
+	OfficialAvg   decimal.NullDecimal `json:"official_avg"`
+	OfficialLast  decimal.NullDecimal `json:"official_last"`
+	MarketMin     decimal.NullDecimal `json:"market_min"`
+	MarketMax     decimal.NullDecimal `json:"market_max"`
+	MarketAvg     decimal.NullDecimal `json:"market_avg"`
+	MarketLast    decimal.NullDecimal `json:"market_last"`
+	DeviationMin  decimal.NullDecimal `json:"deviation_min"`
+	DeviationMax  decimal.NullDecimal `json:"deviation_max"`
+	DeviationAvg  decimal.NullDecimal `json:"deviation_avg"`
+	DeviationLast decimal.NullDecimal `json:"deviation_last"`
+	UpdatedAt     pgtype.Timestamptz  `json:"updated_at"`
+	PairID        string              `json:"pair_id"`
+	OfficialCount int32               `json:"official_count"`
+	MarketCount   int32               `json:"market_count"`
 }
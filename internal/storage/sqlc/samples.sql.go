@@ -24,14 +24,24 @@ func (q *Queries) CountSamples(ctx context.Context) (int64, error) {
 	return count, err
 }
 
-const deleteSamplesBefore = `-- name: DeleteSamplesBefore :exec
-DELETE FROM rate_samples
-WHERE bucket_ts < $1
+const deleteSamplesBefore = `-- name: DeleteSamplesBefore :execrows
+DELETE FROM rate_samples s
+WHERE s.bucket_ts < $1
+  AND EXISTS (
+      SELECT 1
+      FROM rate_sample_rollups r
+      WHERE r.pair_id = s.pair_id
+        AND r.resolution = '1h'
+        AND r.bucket_ts = date_trunc('hour', s.bucket_ts AT TIME ZONE 'UTC') AT TIME ZONE 'UTC'
+  )
 `
 
-func (q *Queries) DeleteSamplesBefore(ctx context.Context, bucketTs pgtype.Timestamptz) error {
-	_, err := q.db.Exec(ctx, deleteSamplesBefore, bucketTs)
-	return err
+func (q *Queries) DeleteSamplesBefore(ctx context.Context, bucketTs pgtype.Timestamptz) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteSamplesBefore, bucketTs)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
 }
 
 const listRecentSamples = `-- name: ListRecentSamples :many
@@ -46,14 +56,25 @@ SELECT
     block_number,
     status,
     error,
-    created_at
+    created_at,
+    error_phase,
+    pair_id,
+    official_method,
+    block_hash,
+    block_time
 FROM rate_samples
+WHERE pair_id = $1
 ORDER BY bucket_ts DESC
-LIMIT $1
+LIMIT $2
 `
 
-func (q *Queries) ListRecentSamples(ctx context.Context, limit int32) ([]RateSample, error) {
-	rows, err := q.db.Query(ctx, listRecentSamples, limit)
+type ListRecentSamplesParams struct {
+	PairID string `json:"pair_id"`
+	Limit  int32  `json:"limit"`
+}
+
+func (q *Queries) ListRecentSamples(ctx context.Context, arg ListRecentSamplesParams) ([]RateSample, error) {
+	rows, err := q.db.Query(ctx, listRecentSamples, arg.PairID, arg.Limit)
 	if err != nil {
 		return nil, err
 	}
@@ -73,6 +94,11 @@ func (q *Queries) ListRecentSamples(ctx context.Context, limit int32) ([]RateSam
 			&i.Status,
 			&i.Error,
 			&i.CreatedAt,
+			&i.ErrorPhase,
+			&i.PairID,
+			&i.OfficialMethod,
+			&i.BlockHash,
+			&i.BlockTime,
 		); err != nil {
 			return nil, err
 		}
@@ -96,20 +122,180 @@ SELECT
     block_number,
     status,
     error,
-    created_at
+    created_at,
+    error_phase,
+    pair_id,
+    official_method,
+    block_hash,
+    block_time
 FROM rate_samples
-WHERE bucket_ts >= $1
-  AND bucket_ts < $2
+WHERE pair_id = $1
+  AND bucket_ts >= $2
+  AND bucket_ts < $3
 ORDER BY bucket_ts
 `
 
 type ListSamplesBetweenParams struct {
+	PairID     string             `json:"pair_id"`
 	BucketTs   pgtype.Timestamptz `json:"bucket_ts"`
 	BucketTs_2 pgtype.Timestamptz `json:"bucket_ts_2"`
 }
 
 func (q *Queries) ListSamplesBetween(ctx context.Context, arg ListSamplesBetweenParams) ([]RateSample, error) {
-	rows, err := q.db.Query(ctx, listSamplesBetween, arg.BucketTs, arg.BucketTs_2)
+	rows, err := q.db.Query(ctx, listSamplesBetween, arg.PairID, arg.BucketTs, arg.BucketTs_2)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []RateSample{}
+	for rows.Next() {
+		var i RateSample
+		if err := rows.Scan(
+			&i.BucketTs,
+			&i.OfficialSusdePerUsde,
+			&i.MarketSusdePerUsde,
+			&i.DeviationPct,
+			&i.NotionalUsde,
+			&i.CowQuality,
+			&i.CowQuote,
+			&i.BlockNumber,
+			&i.Status,
+			&i.Error,
+			&i.CreatedAt,
+			&i.ErrorPhase,
+			&i.PairID,
+			&i.OfficialMethod,
+			&i.BlockHash,
+			&i.BlockTime,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSamplesByDeviation = `-- name: ListSamplesByDeviation :many
+SELECT
+    bucket_ts,
+    official_susde_per_usde,
+    market_susde_per_usde,
+    deviation_pct,
+    notional_usde,
+    cow_quality,
+    cow_quote,
+    block_number,
+    status,
+    error,
+    created_at,
+    error_phase,
+    pair_id,
+    official_method,
+    block_hash,
+    block_time
+FROM rate_samples
+WHERE pair_id = $1
+  AND bucket_ts >= $2
+  AND bucket_ts < $3
+  AND abs(deviation_pct) >= $4::numeric
+ORDER BY abs(deviation_pct) DESC, bucket_ts
+LIMIT $5
+`
+
+type ListSamplesByDeviationParams struct {
+	PairID             string             `json:"pair_id"`
+	FromTs             pgtype.Timestamptz `json:"from_ts"`
+	ToTs               pgtype.Timestamptz `json:"to_ts"`
+	MinAbsDeviationPct pgtype.Numeric     `json:"min_abs_deviation_pct"`
+	RowLimit           int32              `json:"row_limit"`
+}
+
+func (q *Queries) ListSamplesByDeviation(ctx context.Context, arg ListSamplesByDeviationParams) ([]RateSample, error) {
+	rows, err := q.db.Query(ctx, listSamplesByDeviation,
+		arg.PairID,
+		arg.FromTs,
+		arg.ToTs,
+		arg.MinAbsDeviationPct,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []RateSample{}
+	for rows.Next() {
+		var i RateSample
+		if err := rows.Scan(
+			&i.BucketTs,
+			&i.OfficialSusdePerUsde,
+			&i.MarketSusdePerUsde,
+			&i.DeviationPct,
+			&i.NotionalUsde,
+			&i.CowQuality,
+			&i.CowQuote,
+			&i.BlockNumber,
+			&i.Status,
+			&i.Error,
+			&i.CreatedAt,
+			&i.ErrorPhase,
+			&i.PairID,
+			&i.OfficialMethod,
+			&i.BlockHash,
+			&i.BlockTime,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSamplesPage = `-- name: ListSamplesPage :many
+SELECT
+    bucket_ts,
+    official_susde_per_usde,
+    market_susde_per_usde,
+    deviation_pct,
+    notional_usde,
+    cow_quality,
+    cow_quote,
+    block_number,
+    status,
+    error,
+    created_at,
+    error_phase,
+    pair_id,
+    official_method,
+    block_hash,
+    block_time
+FROM rate_samples
+WHERE pair_id = $1
+  AND bucket_ts >= $2
+  AND bucket_ts < $3
+ORDER BY bucket_ts
+LIMIT $4
+`
+
+type ListSamplesPageParams struct {
+	PairID   string             `json:"pair_id"`
+	FromTs   pgtype.Timestamptz `json:"from_ts"`
+	ToTs     pgtype.Timestamptz `json:"to_ts"`
+	RowLimit int32              `json:"row_limit"`
+}
+
+func (q *Queries) ListSamplesPage(ctx context.Context, arg ListSamplesPageParams) ([]RateSample, error) {
+	rows, err := q.db.Query(ctx, listSamplesPage,
+		arg.PairID,
+		arg.FromTs,
+		arg.ToTs,
+		arg.RowLimit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -129,6 +315,11 @@ func (q *Queries) ListSamplesBetween(ctx context.Context, arg ListSamplesBetween
 			&i.Status,
 			&i.Error,
 			&i.CreatedAt,
+			&i.ErrorPhase,
+			&i.PairID,
+			&i.OfficialMethod,
+			&i.BlockHash,
+			&i.BlockTime,
 		); err != nil {
 			return nil, err
 		}
@@ -140,24 +331,36 @@ func (q *Queries) ListSamplesBetween(ctx context.Context, arg ListSamplesBetween
 	return items, nil
 }
 
-const markSampleErrored = `-- name: MarkSampleErrored :exec
+const markSampleErrored = `-- name: MarkSampleErrored :execrows
 UPDATE rate_samples
-SET status = 'errored', error = $2
-WHERE bucket_ts = $1
+SET status = 'errored', error = $3, error_phase = $4
+WHERE pair_id = $1
+  AND bucket_ts = $2
 `
 
 type MarkSampleErroredParams struct {
-	BucketTs pgtype.Timestamptz `json:"bucket_ts"`
-	Error    pgtype.Text        `json:"error"`
+	PairID     string             `json:"pair_id"`
+	BucketTs   pgtype.Timestamptz `json:"bucket_ts"`
+	Error      pgtype.Text        `json:"error"`
+	ErrorPhase pgtype.Text        `json:"error_phase"`
 }
 
-func (q *Queries) MarkSampleErrored(ctx context.Context, arg MarkSampleErroredParams) error {
-	_, err := q.db.Exec(ctx, markSampleErrored, arg.BucketTs, arg.Error)
-	return err
+func (q *Queries) MarkSampleErrored(ctx context.Context, arg MarkSampleErroredParams) (int64, error) {
+	result, err := q.db.Exec(ctx, markSampleErrored,
+		arg.PairID,
+		arg.BucketTs,
+		arg.Error,
+		arg.ErrorPhase,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
 }
 
 const upsertRateSample = `-- name: UpsertRateSample :exec
 INSERT INTO rate_samples (
+    pair_id,
     bucket_ts,
     official_susde_per_usde,
     market_susde_per_usde,
@@ -167,11 +370,15 @@ INSERT INTO rate_samples (
     cow_quote,
     block_number,
     status,
-    error
+    error,
+    error_phase,
+    official_method,
+    block_hash,
+    block_time
 ) VALUES (
-    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15
 )
-ON CONFLICT (bucket_ts) DO UPDATE
+ON CONFLICT (pair_id, bucket_ts) DO UPDATE
 SET
     official_susde_per_usde = EXCLUDED.official_susde_per_usde,
     market_susde_per_usde   = EXCLUDED.market_susde_per_usde,
@@ -181,24 +388,34 @@ SET
     cow_quote               = EXCLUDED.cow_quote,
     block_number            = EXCLUDED.block_number,
     status                  = EXCLUDED.status,
-    error                   = EXCLUDED.error
+    error                   = EXCLUDED.error,
+    error_phase             = EXCLUDED.error_phase,
+    official_method         = EXCLUDED.official_method,
+    block_hash              = EXCLUDED.block_hash,
+    block_time              = EXCLUDED.block_time
 `
 
 type UpsertRateSampleParams struct {
-	BucketTs             pgtype.Timestamptz `json:"bucket_ts"`
-	OfficialSusdePerUsde decimal.Decimal    `json:"official_susde_per_usde"`
-	MarketSusdePerUsde   decimal.Decimal    `json:"market_susde_per_usde"`
-	DeviationPct         decimal.Decimal    `json:"deviation_pct"`
-	NotionalUsde         decimal.Decimal    `json:"notional_usde"`
-	CowQuality           string             `json:"cow_quality"`
-	CowQuote             []byte             `json:"cow_quote"`
-	BlockNumber          pgtype.Int8        `json:"block_number"`
-	Status               string             `json:"status"`
-	Error                pgtype.Text        `json:"error"`
+	PairID               string              `json:"pair_id"`
+	BucketTs             pgtype.Timestamptz  `json:"bucket_ts"`
+	OfficialSusdePerUsde decimal.NullDecimal `json:"official_susde_per_usde"`
+	MarketSusdePerUsde   decimal.NullDecimal `json:"market_susde_per_usde"`
+	DeviationPct         decimal.NullDecimal `json:"deviation_pct"`
+	NotionalUsde         decimal.Decimal     `json:"notional_usde"`
+	CowQuality           string              `json:"cow_quality"`
+	CowQuote             []byte              `json:"cow_quote"`
+	BlockNumber          pgtype.Int8         `json:"block_number"`
+	Status               string              `json:"status"`
+	Error                pgtype.Text         `json:"error"`
+	ErrorPhase           pgtype.Text         `json:"error_phase"`
+	OfficialMethod       pgtype.Text         `json:"official_method"`
+	BlockHash            pgtype.Text         `json:"block_hash"`
+	BlockTime            pgtype.Timestamptz  `json:"block_time"`
 }
 
 func (q *Queries) UpsertRateSample(ctx context.Context, arg UpsertRateSampleParams) error {
 	_, err := q.db.Exec(ctx, upsertRateSample,
+		arg.PairID,
 		arg.BucketTs,
 		arg.OfficialSusdePerUsde,
 		arg.MarketSusdePerUsde,
@@ -209,6 +426,10 @@ func (q *Queries) UpsertRateSample(ctx context.Context, arg UpsertRateSamplePara
 		arg.BlockNumber,
 		arg.Status,
 		arg.Error,
+		arg.ErrorPhase,
+		arg.OfficialMethod,
+		arg.BlockHash,
+		arg.BlockTime,
 	)
 	return err
 }
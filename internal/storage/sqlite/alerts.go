@@ -0,0 +1,296 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"price-diff-alerts/internal/storage"
+)
+
+const alertColumns = `id, pair_id, sample_ts, deviation_pct, threshold_pct, direction, channels,
+    created_at, state, confirmed_at, resolved_at, peak_deviation_pct`
+
+// InsertAlert persists an alert episode, updating the row keyed by
+// (PairID, SampleTS) as the episode moves through its states.
+func (s *Store) InsertAlert(ctx context.Context, alert storage.AlertRecord) (storage.AlertRecord, error) {
+	channels, err := encodeChannels(alert.Channels)
+	if err != nil {
+		return storage.AlertRecord{}, err
+	}
+	row := s.q().QueryRowContext(ctx, `INSERT INTO alerts (
+    pair_id, sample_ts, deviation_pct, threshold_pct, direction, channels, state,
+    confirmed_at, peak_deviation_pct, created_at
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT (pair_id, sample_ts) DO UPDATE
+SET
+    deviation_pct      = excluded.deviation_pct,
+    threshold_pct      = excluded.threshold_pct,
+    direction          = excluded.direction,
+    channels           = excluded.channels,
+    state              = excluded.state,
+    confirmed_at       = excluded.confirmed_at,
+    peak_deviation_pct = excluded.peak_deviation_pct
+RETURNING `+alertColumns,
+		alert.PairID,
+		micros(alert.SampleTS),
+		alert.DeviationPct,
+		alert.ThresholdPct,
+		alert.Direction,
+		channels,
+		alert.State,
+		nullMicros(alert.ConfirmedAt),
+		alert.PeakDeviationPct,
+		micros(time.Now()),
+	)
+	rec, err := scanAlert(row)
+	if err != nil {
+		return storage.AlertRecord{}, fmt.Errorf("insert alert: %w", err)
+	}
+	return rec, nil
+}
+
+// ListRecentAlerts lists most recent alerts.
+func (s *Store) ListRecentAlerts(ctx context.Context, limit int) ([]storage.AlertRecord, error) {
+	rows, err := s.q().QueryContext(ctx, `SELECT `+alertColumns+`
+FROM alerts
+ORDER BY created_at DESC, id DESC
+LIMIT ?`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list recent alerts: %w", err)
+	}
+	return scanAlerts(rows)
+}
+
+// ListAlertsBetween lists alert episodes that started within [from, to).
+func (s *Store) ListAlertsBetween(ctx context.Context, from, to time.Time) ([]storage.AlertRecord, error) {
+	rows, err := s.q().QueryContext(ctx, `SELECT `+alertColumns+`
+FROM alerts
+WHERE sample_ts >= ? AND sample_ts < ?
+ORDER BY sample_ts`, micros(from), micros(to))
+	if err != nil {
+		return nil, fmt.Errorf("list alerts between: %w", err)
+	}
+	return scanAlerts(rows)
+}
+
+// UpdateAlertState moves an existing alert episode to a new state.
+func (s *Store) UpdateAlertState(ctx context.Context, pairID string, sampleTS time.Time, state string) error {
+	if _, err := s.q().ExecContext(ctx, `UPDATE alerts SET state = ? WHERE pair_id = ? AND sample_ts = ?`, state, pairID, micros(sampleTS)); err != nil {
+		return fmt.Errorf("update alert state: %w", err)
+	}
+	return nil
+}
+
+// ResolveAlert closes an episode with its resolution time and peak deviation.
+func (s *Store) ResolveAlert(ctx context.Context, pairID string, sampleTS, resolvedAt time.Time, peak decimal.Decimal) error {
+	if _, err := s.q().ExecContext(ctx, `UPDATE alerts
+SET state = 'resolved', resolved_at = ?, peak_deviation_pct = ?
+WHERE pair_id = ? AND sample_ts = ?`, micros(resolvedAt), peak, pairID, micros(sampleTS)); err != nil {
+		return fmt.Errorf("resolve alert: %w", err)
+	}
+	return nil
+}
+
+// DeleteAlertsBefore removes alerts created before olderThan.
+func (s *Store) DeleteAlertsBefore(ctx context.Context, olderThan time.Time) error {
+	if _, err := s.q().ExecContext(ctx, `DELETE FROM alerts WHERE created_at < ?`, micros(olderThan)); err != nil {
+		return fmt.Errorf("delete alerts before: %w", err)
+	}
+	return nil
+}
+
+// RecordAlertDeliveries stores deliveries for the pair's alert keyed by
+// sampleTS in one transaction. Rows are silently skipped when no such alert
+// exists.
+func (s *Store) RecordAlertDeliveries(ctx context.Context, pairID string, sampleTS time.Time, deliveries []storage.AlertDelivery) error {
+	now := micros(time.Now())
+	return s.withTx(ctx, func(tx *Store) error {
+		for _, d := range deliveries {
+			if _, err := tx.q().ExecContext(ctx, `INSERT INTO alert_deliveries (
+    alert_id, kind, channel, status, attempts, error, created_at
+)
+SELECT a.id, ?, ?, ?, ?, ?, ?
+FROM alerts a
+WHERE a.pair_id = ? AND a.sample_ts = ?`, d.Kind, d.Channel, d.Status, d.Attempts, nullString(d.Error), now, pairID, micros(sampleTS)); err != nil {
+				return fmt.Errorf("record alert delivery %s: %w", d.Channel, err)
+			}
+		}
+		return nil
+	})
+}
+
+// ListAlertDeliveries returns the delivery history of one alert, oldest first.
+func (s *Store) ListAlertDeliveries(ctx context.Context, alertID int64) ([]storage.AlertDelivery, error) {
+	rows, err := s.q().QueryContext(ctx, `SELECT id, alert_id, kind, channel, status, attempts, error, created_at
+FROM alert_deliveries
+WHERE alert_id = ?
+ORDER BY created_at, id`, alertID)
+	if err != nil {
+		return nil, fmt.Errorf("list alert deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	deliveries := []storage.AlertDelivery{}
+	for rows.Next() {
+		var (
+			d         storage.AlertDelivery
+			errMsg    sql.NullString
+			createdAt int64
+		)
+		if err := rows.Scan(&d.ID, &d.AlertID, &d.Kind, &d.Channel, &d.Status, &d.Attempts, &errMsg, &createdAt); err != nil {
+			return nil, fmt.Errorf("scan alert delivery: %w", err)
+		}
+		d.Error = stringPtr(errMsg)
+		d.CreatedAt = fromMicros(createdAt)
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
+
+// GetAlertState loads the state for key; found is false before the first save.
+func (s *Store) GetAlertState(ctx context.Context, key string) (storage.AlertState, bool, error) {
+	var (
+		st          storage.AlertState
+		direction   sql.NullString
+		window      int64
+		pending     sql.NullInt64
+		firing      sql.NullInt64
+		lastBucket  sql.NullInt64
+		lastUp      sql.NullInt64
+		lastDown    sql.NullInt64
+		updatedAtUS int64
+	)
+	err := s.q().QueryRowContext(ctx, `SELECT state_key, state, direction, breach_count, breach_window,
+    pending_since, firing_since, notified, last_bucket, last_alert_up_at, last_alert_down_at,
+    peak_deviation_pct, updated_at
+FROM alert_state
+WHERE state_key = ?`, key).Scan(
+		&st.Key,
+		&st.State,
+		&direction,
+		&st.BreachCount,
+		&window,
+		&pending,
+		&firing,
+		&st.Notified,
+		&lastBucket,
+		&lastUp,
+		&lastDown,
+		&st.PeakDeviationPct,
+		&updatedAtUS,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return storage.AlertState{}, false, nil
+	}
+	if err != nil {
+		return storage.AlertState{}, false, fmt.Errorf("get alert state: %w", err)
+	}
+	st.Direction = direction.String
+	st.BreachWindow = uint64(window)
+	st.PendingSince = timePtr(pending)
+	st.FiringSince = timePtr(firing)
+	st.LastBucket = timePtr(lastBucket)
+	st.LastAlertUpAt = timePtr(lastUp)
+	st.LastAlertDownAt = timePtr(lastDown)
+	st.UpdatedAt = fromMicros(updatedAtUS)
+	return st, true, nil
+}
+
+// SaveAlertState upserts the state for st.Key.
+func (s *Store) SaveAlertState(ctx context.Context, st storage.AlertState) error {
+	_, err := s.q().ExecContext(ctx, `INSERT INTO alert_state (
+    state_key, state, direction, breach_count, breach_window, pending_since, firing_since,
+    notified, last_bucket, last_alert_up_at, last_alert_down_at, peak_deviation_pct, updated_at
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT (state_key) DO UPDATE
+SET
+    state              = excluded.state,
+    direction          = excluded.direction,
+    breach_count       = excluded.breach_count,
+    breach_window      = excluded.breach_window,
+    pending_since      = excluded.pending_since,
+    firing_since       = excluded.firing_since,
+    notified           = excluded.notified,
+    last_bucket        = excluded.last_bucket,
+    last_alert_up_at   = excluded.last_alert_up_at,
+    last_alert_down_at = excluded.last_alert_down_at,
+    peak_deviation_pct = excluded.peak_deviation_pct,
+    updated_at         = excluded.updated_at`,
+		st.Key,
+		st.State,
+		sql.NullString{String: st.Direction, Valid: st.Direction != ""},
+		st.BreachCount,
+		int64(st.BreachWindow),
+		nullMicros(st.PendingSince),
+		nullMicros(st.FiringSince),
+		st.Notified,
+		nullMicros(st.LastBucket),
+		nullMicros(st.LastAlertUpAt),
+		nullMicros(st.LastAlertDownAt),
+		st.PeakDeviationPct,
+		micros(time.Now()),
+	)
+	if err != nil {
+		return fmt.Errorf("save alert state: %w", err)
+	}
+	return nil
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanAlert(row rowScanner) (storage.AlertRecord, error) {
+	var (
+		rec       storage.AlertRecord
+		sampleTS  int64
+		channels  sql.NullString
+		createdAt int64
+		confirmed sql.NullInt64
+		resolved  sql.NullInt64
+	)
+	if err := row.Scan(
+		&rec.ID,
+		&rec.PairID,
+		&sampleTS,
+		&rec.DeviationPct,
+		&rec.ThresholdPct,
+		&rec.Direction,
+		&channels,
+		&createdAt,
+		&rec.State,
+		&confirmed,
+		&resolved,
+		&rec.PeakDeviationPct,
+	); err != nil {
+		return storage.AlertRecord{}, err
+	}
+	decoded, err := decodeChannels(channels)
+	if err != nil {
+		return storage.AlertRecord{}, err
+	}
+	rec.SampleTS = fromMicros(sampleTS)
+	rec.Channels = decoded
+	rec.CreatedAt = fromMicros(createdAt)
+	rec.ConfirmedAt = timePtr(confirmed)
+	rec.ResolvedAt = timePtr(resolved)
+	return rec, nil
+}
+
+func scanAlerts(rows *sql.Rows) ([]storage.AlertRecord, error) {
+	defer rows.Close()
+	alerts := []storage.AlertRecord{}
+	for rows.Next() {
+		rec, err := scanAlert(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan alert: %w", err)
+		}
+		alerts = append(alerts, rec)
+	}
+	return alerts, rows.Err()
+}
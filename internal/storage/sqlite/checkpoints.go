@@ -0,0 +1,65 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"price-diff-alerts/internal/storage"
+)
+
+// GetBackfillCheckpoint loads a checkpoint; found is false when the job never ran.
+func (s *Store) GetBackfillCheckpoint(ctx context.Context, jobKey string) (storage.BackfillCheckpoint, bool, error) {
+	var (
+		cp                             storage.BackfillCheckpoint
+		from, to, watermark, updatedAt int64
+		intervalSec                    int64
+	)
+	err := s.q().QueryRowContext(ctx, `SELECT job_key, range_from, range_to, interval_sec, watermark, processed, failed, updated_at
+FROM backfill_checkpoints
+WHERE job_key = ?`, jobKey).Scan(&cp.JobKey, &from, &to, &intervalSec, &watermark, &cp.Processed, &cp.Failed, &updatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return storage.BackfillCheckpoint{}, false, nil
+	}
+	if err != nil {
+		return storage.BackfillCheckpoint{}, false, fmt.Errorf("get backfill checkpoint: %w", err)
+	}
+	cp.From = fromMicros(from)
+	cp.To = fromMicros(to)
+	cp.Interval = time.Duration(intervalSec) * time.Second
+	cp.Watermark = fromMicros(watermark)
+	cp.UpdatedAt = fromMicros(updatedAt)
+	return cp, true, nil
+}
+
+// SaveBackfillCheckpoint upserts a job's watermark and counters.
+func (s *Store) SaveBackfillCheckpoint(ctx context.Context, cp storage.BackfillCheckpoint) error {
+	if _, err := s.q().ExecContext(ctx, `INSERT INTO backfill_checkpoints (
+    job_key, range_from, range_to, interval_sec, watermark, processed, failed, updated_at
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT (job_key) DO UPDATE
+SET
+    range_from   = excluded.range_from,
+    range_to     = excluded.range_to,
+    interval_sec = excluded.interval_sec,
+    watermark    = excluded.watermark,
+    processed    = excluded.processed,
+    failed       = excluded.failed,
+    updated_at   = excluded.updated_at`,
+		cp.JobKey,
+		micros(cp.From),
+		micros(cp.To),
+		int64(cp.Interval/time.Second),
+		micros(cp.Watermark),
+		cp.Processed,
+		cp.Failed,
+		micros(time.Now()),
+	); err != nil {
+		return fmt.Errorf("save backfill checkpoint: %w", err)
+	}
+	return nil
+}
+
+var _ storage.BackfillCheckpointStore = (*Store)(nil)
@@ -0,0 +1,110 @@
+package sqlite
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Timestamps are stored as unix microseconds, the precision PostgreSQL keeps.
+
+// microsPerHour and microsPerDay truncate stored timestamps to UTC hours and
+// days in SQL.
+const (
+	microsPerHour = int64(time.Hour / time.Microsecond)
+	microsPerDay  = 24 * microsPerHour
+)
+
+func micros(t time.Time) int64 {
+	return t.UTC().UnixMicro()
+}
+
+func nullMicros(t *time.Time) sql.NullInt64 {
+	if t == nil {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: micros(*t), Valid: true}
+}
+
+func fromMicros(v int64) time.Time {
+	return time.UnixMicro(v).UTC()
+}
+
+func timePtr(v sql.NullInt64) *time.Time {
+	if !v.Valid {
+		return nil
+	}
+	t := fromMicros(v.Int64)
+	return &t
+}
+
+func nullString(v *string) sql.NullString {
+	if v == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: *v, Valid: true}
+}
+
+func stringPtr(v sql.NullString) *string {
+	if !v.Valid {
+		return nil
+	}
+	s := v.String
+	return &s
+}
+
+func nullInt64(v *int64) sql.NullInt64 {
+	if v == nil {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: *v, Valid: true}
+}
+
+func int64Ptr(v sql.NullInt64) *int64 {
+	if !v.Valid {
+		return nil
+	}
+	n := v.Int64
+	return &n
+}
+
+// nullJSON maps an empty document to SQL NULL.
+func nullJSON(raw json.RawMessage) sql.NullString {
+	if len(raw) == 0 {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: string(raw), Valid: true}
+}
+
+// Channel lists are stored as JSON arrays.
+
+func encodeChannels(channels []string) (string, error) {
+	if channels == nil {
+		channels = []string{}
+	}
+	raw, err := json.Marshal(channels)
+	if err != nil {
+		return "", fmt.Errorf("encode channels: %w", err)
+	}
+	return string(raw), nil
+}
+
+func nullChannels(channels []string) (sql.NullString, error) {
+	if len(channels) == 0 {
+		return sql.NullString{}, nil
+	}
+	raw, err := encodeChannels(channels)
+	return sql.NullString{String: raw, Valid: err == nil}, err
+}
+
+func decodeChannels(raw sql.NullString) ([]string, error) {
+	if !raw.Valid || raw.String == "" {
+		return nil, nil
+	}
+	var channels []string
+	if err := json.Unmarshal([]byte(raw.String), &channels); err != nil {
+		return nil, fmt.Errorf("decode channels: %w", err)
+	}
+	return channels, nil
+}
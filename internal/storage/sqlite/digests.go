@@ -0,0 +1,43 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"price-diff-alerts/internal/storage"
+)
+
+// LastDigestEnd returns the end of the latest period mailed for pairID.
+func (s *Store) LastDigestEnd(ctx context.Context, pairID string) (time.Time, bool, error) {
+	var periodEnd int64
+	err := s.q().QueryRowContext(ctx, `SELECT period_end FROM digest_periods WHERE pair_id = ?`, pairID).Scan(&periodEnd)
+	if errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, false, nil
+	}
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("get digest period: %w", err)
+	}
+	return fromMicros(periodEnd), true, nil
+}
+
+// MarkDigestSent records that the period ending at periodEnd was mailed.
+func (s *Store) MarkDigestSent(ctx context.Context, pairID string, periodEnd time.Time) error {
+	if _, err := s.q().ExecContext(ctx, `INSERT INTO digest_periods (pair_id, period_end, sent_at)
+VALUES (?, ?, ?)
+ON CONFLICT (pair_id) DO UPDATE
+SET
+    period_end = MAX(digest_periods.period_end, excluded.period_end),
+    sent_at    = excluded.sent_at`,
+		pairID,
+		micros(periodEnd),
+		micros(time.Now()),
+	); err != nil {
+		return fmt.Errorf("mark digest sent: %w", err)
+	}
+	return nil
+}
+
+var _ storage.DigestStore = (*Store)(nil)
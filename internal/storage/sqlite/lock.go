@@ -0,0 +1,57 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// errLockHeld is returned by tryLockFile when another process or Store holds
+// the lock.
+var errLockHeld = errors.New("lock held elsewhere")
+
+// fileLocker stands in for PostgreSQL advisory locks: each key maps to a
+// lock file next to the database, held with an exclusive non-blocking OS
+// lock for as long as the caller keeps it. The OS drops the lock if the
+// process dies, so a crash never leaves a key stuck.
+type fileLocker struct {
+	dbPath string
+}
+
+func newFileLocker(dbPath string) *fileLocker {
+	return &fileLocker{dbPath: dbPath}
+}
+
+func (l *fileLocker) lockPath(key int64) string {
+	return fmt.Sprintf("%s.%x.lock", l.dbPath, uint64(key))
+}
+
+// tryLock acquires the lock for key without waiting.
+func (l *fileLocker) tryLock(key int64) (func(), bool, error) {
+	f, err := os.OpenFile(l.lockPath(key), os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, false, fmt.Errorf("open lock file: %w", err)
+	}
+	if err := tryLockFile(f); err != nil {
+		f.Close()
+		if errors.Is(err, errLockHeld) {
+			return nil, false, nil
+		}
+		return nil, false, fmt.Errorf("lock %s: %w", f.Name(), err)
+	}
+	unlock := func() {
+		_ = unlockFile(f)
+		f.Close()
+	}
+	return unlock, true, nil
+}
+
+// TryAdvisoryLock implements storage.AdvisoryLocker with a lock file per key,
+// so instances sharing the database file never run the same job twice.
+func (s *Store) TryAdvisoryLock(ctx context.Context, key int64) (func(), bool, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, false, err
+	}
+	return s.locks.tryLock(key)
+}
@@ -0,0 +1,16 @@
+//go:build !unix
+
+package sqlite
+
+import (
+	"errors"
+	"os"
+)
+
+func tryLockFile(f *os.File) error {
+	return errors.New("file locks are only supported on unix platforms")
+}
+
+func unlockFile(f *os.File) error {
+	return nil
+}
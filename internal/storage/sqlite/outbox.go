@@ -0,0 +1,157 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"price-diff-alerts/internal/storage"
+)
+
+const outboxColumns = `id, episode_ts, kind, payload, status, attempts, last_error,
+    created_at, last_attempt_at, next_attempt_at, channels`
+
+// EnqueueOutbox stores a pending notification, first due at
+// msg.NextAttemptAt, and returns its id.
+func (s *Store) EnqueueOutbox(ctx context.Context, msg storage.OutboxMessage) (int64, error) {
+	channels, err := nullChannels(msg.Channels)
+	if err != nil {
+		return 0, err
+	}
+	res, err := s.q().ExecContext(ctx, `INSERT INTO alert_outbox (
+    episode_ts, kind, payload, next_attempt_at, created_at, channels
+) VALUES (?, ?, ?, ?, ?, ?)`,
+		nullMicros(msg.EpisodeTS),
+		msg.Kind,
+		string(msg.Payload),
+		micros(msg.NextAttemptAt),
+		micros(time.Now()),
+		channels,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("enqueue outbox: %w", err)
+	}
+	return res.LastInsertId()
+}
+
+// ListDueOutbox returns pending messages due at now, earliest first.
+func (s *Store) ListDueOutbox(ctx context.Context, now time.Time, limit int) ([]storage.OutboxMessage, error) {
+	rows, err := s.q().QueryContext(ctx, `SELECT `+outboxColumns+`
+FROM alert_outbox
+WHERE status = 'pending'
+  AND next_attempt_at <= ?
+ORDER BY next_attempt_at, id
+LIMIT ?`, micros(now), limit)
+	if err != nil {
+		return nil, fmt.Errorf("list due outbox: %w", err)
+	}
+	return scanOutbox(rows)
+}
+
+// MarkOutboxAttempt updates status, attempts, last error and the next attempt
+// time of a message.
+func (s *Store) MarkOutboxAttempt(ctx context.Context, id int64, attempt storage.OutboxAttempt) error {
+	channels, err := nullChannels(attempt.Channels)
+	if err != nil {
+		return err
+	}
+	if _, err := s.q().ExecContext(ctx, `UPDATE alert_outbox
+SET
+    status          = ?,
+    attempts        = attempts + 1,
+    last_error      = ?,
+    channels        = ?,
+    next_attempt_at = ?,
+    last_attempt_at = ?
+WHERE id = ?`,
+		attempt.Status,
+		nullString(attempt.Error),
+		channels,
+		micros(attempt.NextAttemptAt),
+		micros(time.Now()),
+		id,
+	); err != nil {
+		return fmt.Errorf("mark outbox attempt: %w", err)
+	}
+	return nil
+}
+
+// ListOutbox returns the newest messages; an empty status matches all.
+func (s *Store) ListOutbox(ctx context.Context, status string, limit int) ([]storage.OutboxMessage, error) {
+	rows, err := s.q().QueryContext(ctx, `SELECT `+outboxColumns+`
+FROM alert_outbox
+WHERE ? = '' OR status = ?
+ORDER BY id DESC
+LIMIT ?`, status, status, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list outbox: %w", err)
+	}
+	return scanOutbox(rows)
+}
+
+// ReplayOutbox requeues message id unless it was already dispatched.
+func (s *Store) ReplayOutbox(ctx context.Context, id int64) (bool, error) {
+	res, err := s.q().ExecContext(ctx, `UPDATE alert_outbox
+SET status = 'pending', attempts = 0, next_attempt_at = ?
+WHERE id = ? AND status <> 'dispatched'`, micros(time.Now()), id)
+	if err != nil {
+		return false, fmt.Errorf("replay outbox %d: %w", id, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("replay outbox %d: %w", id, err)
+	}
+	return n > 0, nil
+}
+
+// ReplayDeadOutbox requeues every dead message.
+func (s *Store) ReplayDeadOutbox(ctx context.Context) (int64, error) {
+	res, err := s.q().ExecContext(ctx, `UPDATE alert_outbox
+SET status = 'pending', attempts = 0, next_attempt_at = ?
+WHERE status = 'dead'`, micros(time.Now()))
+	if err != nil {
+		return 0, fmt.Errorf("replay dead outbox: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+func scanOutbox(rows *sql.Rows) ([]storage.OutboxMessage, error) {
+	defer rows.Close()
+	messages := []storage.OutboxMessage{}
+	for rows.Next() {
+		var (
+			msg         storage.OutboxMessage
+			episode     sql.NullInt64
+			payload     string
+			lastError   sql.NullString
+			createdAt   int64
+			lastAttempt sql.NullInt64
+			nextAttempt int64
+			channels    sql.NullString
+		)
+		if err := rows.Scan(&msg.ID, &episode, &msg.Kind, &payload, &msg.Status, &msg.Attempts,
+			&lastError, &createdAt, &lastAttempt, &nextAttempt, &channels); err != nil {
+			return nil, fmt.Errorf("scan outbox: %w", err)
+		}
+		decoded, err := decodeChannels(channels)
+		if err != nil {
+			return nil, err
+		}
+		msg.EpisodeTS = timePtr(episode)
+		msg.Payload = []byte(payload)
+		msg.LastError = stringPtr(lastError)
+		msg.CreatedAt = fromMicros(createdAt)
+		msg.LastAttemptAt = timePtr(lastAttempt)
+		msg.NextAttemptAt = fromMicros(nextAttempt)
+		msg.Channels = decoded
+		messages = append(messages, msg)
+	}
+	return messages, rows.Err()
+}
+
+var (
+	_ storage.OutboxStore = (*Store)(nil)
+	_ storage.OutboxAdmin = (*Store)(nil)
+	_ storage.UnitOfWork  = (*Store)(nil)
+)
@@ -0,0 +1,299 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"price-diff-alerts/internal/storage"
+)
+
+// RollupSamples recomputes every bucket of resolution that starts before the
+// given time for every pair. Decimals are TEXT in SQLite, so the aggregates are computed in
+// Go with the same rules as the PostgreSQL queries.
+func (s *Store) RollupSamples(ctx context.Context, resolution string, before time.Time) (int64, error) {
+	var (
+		rollups []storage.SampleRollup
+		err     error
+	)
+	switch resolution {
+	case storage.RollupHourly:
+		rollups, err = s.hourlyRollups(ctx, before)
+	case storage.RollupDaily:
+		rollups, err = s.dailyRollups(ctx, before)
+	default:
+		return 0, fmt.Errorf("unknown rollup resolution %q", resolution)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("rollup %s samples: %w", resolution, err)
+	}
+
+	err = s.withTx(ctx, func(tx *Store) error {
+		now := micros(time.Now())
+		for _, r := range rollups {
+			if err := tx.upsertRollup(ctx, r, now); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("rollup %s samples: %w", resolution, err)
+	}
+	return int64(len(rollups)), nil
+}
+
+// hourlyRollups aggregates every hour of raw samples per pair. Official and
+// market stats cover every sample with that leg, deviation stats only
+// complete samples.
+func (s *Store) hourlyRollups(ctx context.Context, before time.Time) ([]storage.SampleRollup, error) {
+	rows, err := s.q().QueryContext(ctx, `SELECT pair_id, bucket_ts, status, official_susde_per_usde, market_susde_per_usde, deviation_pct
+FROM rate_samples
+WHERE bucket_ts < ?
+ORDER BY pair_id, bucket_ts`, micros(before))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var (
+		out []storage.SampleRollup
+		acc *rollupAcc
+	)
+	for rows.Next() {
+		var (
+			pairID                      string
+			bucket                      int64
+			status                      string
+			official, market, deviation decimal.NullDecimal
+		)
+		if err := rows.Scan(&pairID, &bucket, &status, &official, &market, &deviation); err != nil {
+			return nil, err
+		}
+		hour := fromMicros(bucket).Truncate(time.Hour)
+		if acc == nil || acc.pairID != pairID || !acc.bucket.Equal(hour) {
+			if acc != nil {
+				out = append(out, acc.rollup(storage.RollupHourly))
+			}
+			acc = &rollupAcc{pairID: pairID, bucket: hour}
+		}
+		acc.samples++
+		// A partial or errored sample may still carry an official or market leg.
+		acc.official.add(official, official, official, official, 1)
+		acc.market.add(market, market, market, market, 1)
+		if status != storage.SampleStatusComplete {
+			continue
+		}
+		acc.complete++
+		acc.deviation.add(deviation, deviation, deviation, deviation, 1)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if acc != nil {
+		out = append(out, acc.rollup(storage.RollupHourly))
+	}
+	return out, nil
+}
+
+// dailyRollups folds hourly rollups into days, weighting each average by the
+// number of samples behind it in each hour. Only days from the pair's
+// oldest raw sample on can have changed, so earlier days are left alone.
+func (s *Store) dailyRollups(ctx context.Context, before time.Time) ([]storage.SampleRollup, error) {
+	hourly, err := s.listRollups(ctx, `WHERE resolution = ? AND bucket_ts < ?
+  AND bucket_ts >= (
+      SELECT MIN(s.bucket_ts) / ? * ?
+      FROM rate_samples s
+      WHERE s.pair_id = rate_sample_rollups.pair_id
+  )`, 0, storage.RollupHourly, micros(before), microsPerDay, microsPerDay)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		out []storage.SampleRollup
+		acc *rollupAcc
+	)
+	for _, h := range hourly {
+		day := h.Bucket.Truncate(24 * time.Hour)
+		if acc == nil || acc.pairID != h.PairID || !acc.bucket.Equal(day) {
+			if acc != nil {
+				out = append(out, acc.rollup(storage.RollupDaily))
+			}
+			acc = &rollupAcc{pairID: h.PairID, bucket: day}
+		}
+		acc.samples += h.Samples
+		acc.complete += h.Complete
+		acc.official.add(h.Official.Min, h.Official.Max, h.Official.Avg, h.Official.Last, int64(h.Official.Count))
+		acc.market.add(h.Market.Min, h.Market.Max, h.Market.Avg, h.Market.Last, int64(h.Market.Count))
+		acc.deviation.add(h.Deviation.Min, h.Deviation.Max, h.Deviation.Avg, h.Deviation.Last, int64(h.Deviation.Count))
+	}
+	if acc != nil {
+		out = append(out, acc.rollup(storage.RollupDaily))
+	}
+	return out, nil
+}
+
+func (s *Store) upsertRollup(ctx context.Context, r storage.SampleRollup, updatedAt int64) error {
+	_, err := s.q().ExecContext(ctx, `INSERT INTO rate_sample_rollups (
+    pair_id, resolution, bucket_ts, samples, complete, official_count, market_count,
+    official_min, official_max, official_avg, official_last,
+    market_min, market_max, market_avg, market_last,
+    deviation_min, deviation_max, deviation_avg, deviation_last,
+    updated_at
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT (pair_id, resolution, bucket_ts) DO UPDATE
+SET
+    samples        = excluded.samples,
+    complete       = excluded.complete,
+    official_count = excluded.official_count,
+    market_count   = excluded.market_count,
+    official_min   = excluded.official_min,
+    official_max   = excluded.official_max,
+    official_avg   = excluded.official_avg,
+    official_last  = excluded.official_last,
+    market_min     = excluded.market_min,
+    market_max     = excluded.market_max,
+    market_avg     = excluded.market_avg,
+    market_last    = excluded.market_last,
+    deviation_min  = excluded.deviation_min,
+    deviation_max  = excluded.deviation_max,
+    deviation_avg  = excluded.deviation_avg,
+    deviation_last = excluded.deviation_last,
+    updated_at     = excluded.updated_at`,
+		r.PairID, r.Resolution, micros(r.Bucket), r.Samples, r.Complete, r.Official.Count, r.Market.Count,
+		r.Official.Min, r.Official.Max, r.Official.Avg, r.Official.Last,
+		r.Market.Min, r.Market.Max, r.Market.Avg, r.Market.Last,
+		r.Deviation.Min, r.Deviation.Max, r.Deviation.Avg, r.Deviation.Last,
+		updatedAt,
+	)
+	return err
+}
+
+// ListRollupsBetween lists the pair's aggregates of resolution within [from, to).
+func (s *Store) ListRollupsBetween(ctx context.Context, pairID, resolution string, from, to time.Time) ([]storage.SampleRollup, error) {
+	rollups, err := s.listRollups(ctx, `WHERE pair_id = ? AND resolution = ? AND bucket_ts >= ? AND bucket_ts < ?`, 0, pairID, resolution, micros(from), micros(to))
+	if err != nil {
+		return nil, fmt.Errorf("list rollups between: %w", err)
+	}
+	return rollups, nil
+}
+
+// ListRollupsPage lists at most limit of the pair's aggregates in [from, to).
+func (s *Store) ListRollupsPage(ctx context.Context, pairID, resolution string, from, to time.Time, limit int) ([]storage.SampleRollup, error) {
+	rollups, err := s.listRollups(ctx, `WHERE pair_id = ? AND resolution = ? AND bucket_ts >= ? AND bucket_ts < ?`, limit, pairID, resolution, micros(from), micros(to))
+	if err != nil {
+		return nil, fmt.Errorf("list rollups page: %w", err)
+	}
+	return rollups, nil
+}
+
+// DeleteRollupsBefore prunes aggregates of resolution older than before.
+func (s *Store) DeleteRollupsBefore(ctx context.Context, resolution string, before time.Time) (int64, error) {
+	res, err := s.q().ExecContext(ctx, `DELETE FROM rate_sample_rollups WHERE resolution = ? AND bucket_ts < ?`, resolution, micros(before))
+	if err != nil {
+		return 0, fmt.Errorf("delete rollups before: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+// listRollups reads the aggregates matching where, at most limit of them when
+// limit is positive.
+func (s *Store) listRollups(ctx context.Context, where string, limit int, args ...any) ([]storage.SampleRollup, error) {
+	query := `SELECT pair_id, resolution, bucket_ts, samples, complete, official_count, market_count,
+    official_min, official_max, official_avg, official_last,
+    market_min, market_max, market_avg, market_last,
+    deviation_min, deviation_max, deviation_avg, deviation_last,
+    updated_at
+FROM rate_sample_rollups
+` + where + `
+ORDER BY pair_id, bucket_ts`
+	if limit > 0 {
+		query += "\nLIMIT " + strconv.Itoa(limit)
+	}
+	rows, err := s.q().QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rollups := []storage.SampleRollup{}
+	for rows.Next() {
+		var (
+			r         storage.SampleRollup
+			bucket    int64
+			updatedAt int64
+		)
+		if err := rows.Scan(&r.PairID, &r.Resolution, &bucket, &r.Samples, &r.Complete, &r.Official.Count, &r.Market.Count,
+			&r.Official.Min, &r.Official.Max, &r.Official.Avg, &r.Official.Last,
+			&r.Market.Min, &r.Market.Max, &r.Market.Avg, &r.Market.Last,
+			&r.Deviation.Min, &r.Deviation.Max, &r.Deviation.Avg, &r.Deviation.Last,
+			&updatedAt,
+		); err != nil {
+			return nil, err
+		}
+		r.Bucket = fromMicros(bucket)
+		r.UpdatedAt = fromMicros(updatedAt)
+		r.Deviation.Count = r.Complete
+		rollups = append(rollups, r)
+	}
+	return rollups, rows.Err()
+}
+
+// rollupAcc accumulates one bucket of a resolution for one pair.
+type rollupAcc struct {
+	pairID                      string
+	bucket                      time.Time
+	samples, complete           int
+	official, market, deviation statsAcc
+}
+
+func (a *rollupAcc) rollup(resolution string) storage.SampleRollup {
+	return storage.SampleRollup{
+		PairID:     a.pairID,
+		Resolution: resolution,
+		Bucket:     a.bucket,
+		Samples:    a.samples,
+		Complete:   a.complete,
+		Official:   a.official.stats(),
+		Market:     a.market.stats(),
+		Deviation:  a.deviation.stats(),
+	}
+}
+
+// statsAcc mirrors MIN/MAX/AVG and the latest non-null value over inputs
+// that arrive in bucket order.
+type statsAcc struct {
+	min, max, last decimal.NullDecimal
+	sum            decimal.Decimal
+	weight         int64
+}
+
+func (a *statsAcc) add(min, max, avg, last decimal.NullDecimal, weight int64) {
+	if min.Valid && (!a.min.Valid || min.Decimal.LessThan(a.min.Decimal)) {
+		a.min = min
+	}
+	if max.Valid && (!a.max.Valid || max.Decimal.GreaterThan(a.max.Decimal)) {
+		a.max = max
+	}
+	if avg.Valid && weight > 0 {
+		a.sum = a.sum.Add(avg.Decimal.Mul(decimal.NewFromInt(weight)))
+		a.weight += weight
+	}
+	if last.Valid && weight > 0 {
+		a.last = last
+	}
+}
+
+func (a *statsAcc) stats() storage.RollupStats {
+	stats := storage.RollupStats{Min: a.min, Max: a.max, Last: a.last, Count: int(a.weight)}
+	if a.weight > 0 {
+		stats.Avg = decimal.NewNullDecimal(a.sum.Div(decimal.NewFromInt(a.weight)))
+	}
+	return stats
+}
+
+var _ storage.RollupStore = (*Store)(nil)
@@ -0,0 +1,212 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"price-diff-alerts/internal/storage"
+)
+
+const sampleColumns = `pair_id, bucket_ts, official_susde_per_usde, market_susde_per_usde, deviation_pct,
+    notional_usde, cow_quality, cow_quote, block_number, status, error, error_phase, created_at,
+    official_method, block_hash, block_time`
+
+const upsertSampleSQL = `INSERT INTO rate_samples (
+    pair_id, bucket_ts, official_susde_per_usde, market_susde_per_usde, deviation_pct,
+    notional_usde, cow_quality, cow_quote, block_number, status, error, error_phase, created_at,
+    official_method, block_hash, block_time
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT (pair_id, bucket_ts) DO UPDATE
+SET
+    official_susde_per_usde = excluded.official_susde_per_usde,
+    market_susde_per_usde   = excluded.market_susde_per_usde,
+    deviation_pct           = excluded.deviation_pct,
+    notional_usde           = excluded.notional_usde,
+    cow_quality             = excluded.cow_quality,
+    cow_quote               = excluded.cow_quote,
+    block_number            = excluded.block_number,
+    status                  = excluded.status,
+    error                   = excluded.error,
+    error_phase             = excluded.error_phase,
+    official_method         = excluded.official_method,
+    block_hash              = excluded.block_hash,
+    block_time              = excluded.block_time`
+
+// UpsertRateSample persists or updates a rate sample.
+func (s *Store) UpsertRateSample(ctx context.Context, sample storage.RateSample) error {
+	_, err := s.q().ExecContext(ctx, upsertSampleSQL,
+		sample.PairID,
+		micros(sample.Bucket),
+		sample.OfficialRate,
+		sample.MarketRate,
+		sample.DeviationPct,
+		sample.Notional,
+		sample.CowQuality,
+		nullJSON(sample.CowQuote),
+		nullInt64(sample.BlockNumber),
+		sample.Status,
+		nullString(sample.Error),
+		nullString(sample.ErrorPhase),
+		micros(time.Now()),
+		nullString(sample.OfficialMethod),
+		nullString(sample.BlockHash),
+		nullMicros(sample.BlockTime),
+	)
+	if err != nil {
+		return fmt.Errorf("upsert rate sample: %w", err)
+	}
+	return nil
+}
+
+// ListSamplesBetween lists samples of the pair within [from, to).
+func (s *Store) ListSamplesBetween(ctx context.Context, pairID string, from, to time.Time) ([]storage.RateSample, error) {
+	rows, err := s.q().QueryContext(ctx, `SELECT `+sampleColumns+`
+FROM rate_samples
+WHERE pair_id = ? AND bucket_ts >= ? AND bucket_ts < ?
+ORDER BY bucket_ts`, pairID, micros(from), micros(to))
+	if err != nil {
+		return nil, fmt.Errorf("list samples between: %w", err)
+	}
+	return scanSamples(rows)
+}
+
+// ListRecentSamples lists the pair's most recent samples ordered by
+// descending bucket.
+func (s *Store) ListRecentSamples(ctx context.Context, pairID string, limit int) ([]storage.RateSample, error) {
+	rows, err := s.q().QueryContext(ctx, `SELECT `+sampleColumns+`
+FROM rate_samples
+WHERE pair_id = ?
+ORDER BY bucket_ts DESC
+LIMIT ?`, pairID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list recent samples: %w", err)
+	}
+	return scanSamples(rows)
+}
+
+// ListSamplesByDeviation lists the pair's largest deviations in [from, to).
+// SQLite has no decimal type, so the filter and ordering compare REAL casts.
+func (s *Store) ListSamplesByDeviation(ctx context.Context, pairID string, from, to time.Time, minAbsPct decimal.Decimal, limit int) ([]storage.RateSample, error) {
+	rows, err := s.q().QueryContext(ctx, `SELECT `+sampleColumns+`
+FROM rate_samples
+WHERE pair_id = ? AND bucket_ts >= ? AND bucket_ts < ?
+  AND deviation_pct IS NOT NULL
+  AND abs(CAST(deviation_pct AS REAL)) >= ?
+ORDER BY abs(CAST(deviation_pct AS REAL)) DESC, bucket_ts
+LIMIT ?`, pairID, micros(from), micros(to), minAbsPct.Abs().InexactFloat64(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("list samples by deviation: %w", err)
+	}
+	return scanSamples(rows)
+}
+
+// ListSamplesPage lists at most limit of the pair's samples in [from, to).
+func (s *Store) ListSamplesPage(ctx context.Context, pairID string, from, to time.Time, limit int) ([]storage.RateSample, error) {
+	rows, err := s.q().QueryContext(ctx, `SELECT `+sampleColumns+`
+FROM rate_samples
+WHERE pair_id = ? AND bucket_ts >= ? AND bucket_ts < ?
+ORDER BY bucket_ts
+LIMIT ?`, pairID, micros(from), micros(to), limit)
+	if err != nil {
+		return nil, fmt.Errorf("list samples page: %w", err)
+	}
+	return scanSamples(rows)
+}
+
+// MarkSampleErrored marks an existing sample as errored in the given phase.
+func (s *Store) MarkSampleErrored(ctx context.Context, pairID string, bucket time.Time, phase, errMsg string) error {
+	res, err := s.q().ExecContext(ctx, `UPDATE rate_samples
+SET status = 'errored', error = ?, error_phase = ?
+WHERE pair_id = ? AND bucket_ts = ?`, errMsg, phase, pairID, micros(bucket))
+	if err != nil {
+		return fmt.Errorf("mark sample errored: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// CountSamples counts stored samples.
+func (s *Store) CountSamples(ctx context.Context) (int64, error) {
+	var count int64
+	if err := s.q().QueryRowContext(ctx, `SELECT COUNT(*) FROM rate_samples`).Scan(&count); err != nil {
+		return 0, fmt.Errorf("count samples: %w", err)
+	}
+	return count, nil
+}
+
+// DeleteSamplesBefore prunes raw samples older than before whose hour has
+// been rolled up.
+func (s *Store) DeleteSamplesBefore(ctx context.Context, before time.Time) (int64, error) {
+	res, err := s.q().ExecContext(ctx, `DELETE FROM rate_samples
+WHERE bucket_ts < ?
+  AND EXISTS (
+      SELECT 1
+      FROM rate_sample_rollups r
+      WHERE r.pair_id = rate_samples.pair_id
+        AND r.resolution = ?
+        AND r.bucket_ts = rate_samples.bucket_ts / ? * ?
+  )`, micros(before), storage.RollupHourly, microsPerHour, microsPerHour)
+	if err != nil {
+		return 0, fmt.Errorf("delete samples before: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+func scanSamples(rows *sql.Rows) ([]storage.RateSample, error) {
+	defer rows.Close()
+	samples := []storage.RateSample{}
+	for rows.Next() {
+		var (
+			sample    storage.RateSample
+			bucket    int64
+			quote     sql.NullString
+			block     sql.NullInt64
+			errMsg    sql.NullString
+			phase     sql.NullString
+			createdAt int64
+			method    sql.NullString
+			blockHash sql.NullString
+			blockTime sql.NullInt64
+		)
+		if err := rows.Scan(
+			&sample.PairID,
+			&bucket,
+			&sample.OfficialRate,
+			&sample.MarketRate,
+			&sample.DeviationPct,
+			&sample.Notional,
+			&sample.CowQuality,
+			&quote,
+			&block,
+			&sample.Status,
+			&errMsg,
+			&phase,
+			&createdAt,
+			&method,
+			&blockHash,
+			&blockTime,
+		); err != nil {
+			return nil, fmt.Errorf("scan rate sample: %w", err)
+		}
+		sample.Bucket = fromMicros(bucket)
+		if quote.Valid {
+			sample.CowQuote = json.RawMessage(quote.String)
+		}
+		sample.BlockNumber = int64Ptr(block)
+		sample.OfficialMethod = stringPtr(method)
+		sample.BlockHash = stringPtr(blockHash)
+		sample.BlockTime = timePtr(blockTime)
+		sample.Error = stringPtr(errMsg)
+		sample.ErrorPhase = stringPtr(phase)
+		sample.CreatedAt = fromMicros(createdAt)
+		samples = append(samples, sample)
+	}
+	return samples, rows.Err()
+}
@@ -0,0 +1,217 @@
+// Package sqlite is an embedded single-file storage backend for deployments
+// without PostgreSQL. It implements storage.Backend on top of the pure-Go
+// modernc.org/sqlite driver and replaces advisory locks with file locks.
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	_ "modernc.org/sqlite"
+
+	"price-diff-alerts/db"
+	"price-diff-alerts/internal/storage"
+)
+
+// Scheme prefixes database.dsn values served by this backend, for example
+// sqlite:///var/lib/usdewatcher/usde.db or sqlite:usde.db.
+const Scheme = "sqlite:"
+
+// connParams keep writers waiting instead of failing on a busy file, let
+// readers run alongside the writer and take the write lock when a
+// transaction begins so concurrent transactions never deadlock on upgrade.
+const connParams = "_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_pragma=foreign_keys(1)&_txlock=immediate"
+
+// IsDSN reports whether dsn selects the SQLite backend.
+func IsDSN(dsn string) bool {
+	return strings.HasPrefix(dsn, Scheme)
+}
+
+// PathFromDSN returns the database file named by a sqlite: DSN.
+func PathFromDSN(dsn string) (string, error) {
+	if !IsDSN(dsn) {
+		return "", fmt.Errorf("database.dsn %q is not a sqlite: DSN", dsn)
+	}
+	path := strings.TrimPrefix(dsn, Scheme)
+	path = strings.TrimPrefix(path, "//")
+	if i := strings.IndexByte(path, '?'); i >= 0 {
+		path = path[:i]
+	}
+	if path == "" {
+		return "", fmt.Errorf("database.dsn %q names no database file", dsn)
+	}
+	return filepath.Clean(path), nil
+}
+
+// querier is satisfied by both *sql.DB and *sql.Tx.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// Store persists samples, alerts and the outbox in one SQLite file. A Store
+// handed out by withTx runs every query in that transaction.
+type Store struct {
+	db    *sql.DB
+	tx    *sql.Tx
+	path  string
+	locks *fileLocker
+}
+
+// Open opens or creates the database file named by dsn and brings its schema
+// up to date.
+func Open(ctx context.Context, dsn string) (*Store, error) {
+	path, err := PathFromDSN(dsn)
+	if err != nil {
+		return nil, err
+	}
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("create sqlite directory: %w", err)
+		}
+	}
+
+	conn, err := sql.Open("sqlite", "file:"+path+"?"+connParams)
+	if err != nil {
+		return nil, fmt.Errorf("open sqlite %s: %w", path, err)
+	}
+	if err := conn.PingContext(ctx); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("open sqlite %s: %w", path, err)
+	}
+
+	store := &Store{db: conn, path: path, locks: newFileLocker(path)}
+	if err := store.applySchema(ctx); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return store, nil
+}
+
+// Path returns the database file.
+func (s *Store) Path() string {
+	return s.path
+}
+
+// Close closes the database; it is a no-op on a transactional Store.
+func (s *Store) Close() {
+	if s == nil || s.db == nil || s.tx != nil {
+		return
+	}
+	s.db.Close()
+}
+
+// Ping checks that the database file can still be queried.
+func (s *Store) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+// InTx implements storage.UnitOfWork.
+func (s *Store) InTx(ctx context.Context, fn func(tx storage.Tx) error) error {
+	return s.withTx(ctx, func(tx *Store) error {
+		return fn(tx)
+	})
+}
+
+// withTx runs fn with a Store bound to a single transaction, committing when
+// fn returns nil. Calls on a transactional Store reuse the outer transaction.
+func (s *Store) withTx(ctx context.Context, fn func(tx *Store) error) error {
+	if s.tx != nil {
+		return fn(s)
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if err := fn(&Store{db: s.db, tx: tx, path: s.path, locks: s.locks}); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) q() querier {
+	if s.tx != nil {
+		return s.tx
+	}
+	return s.db
+}
+
+// applySchema runs every db/sqlite/NNNN_*.sql file newer than the file's
+// user_version, each in its own transaction.
+func (s *Store) applySchema(ctx context.Context) error {
+	var current int
+	if err := s.db.QueryRowContext(ctx, `PRAGMA user_version`).Scan(&current); err != nil {
+		return fmt.Errorf("read sqlite schema version: %w", err)
+	}
+
+	steps, err := schemaSteps()
+	if err != nil {
+		return err
+	}
+	for _, step := range steps {
+		if step.version <= current {
+			continue
+		}
+		err := s.withTx(ctx, func(tx *Store) error {
+			if _, err := tx.tx.ExecContext(ctx, step.sql); err != nil {
+				return fmt.Errorf("apply sqlite schema %s: %w", step.name, err)
+			}
+			// PRAGMA does not take bind parameters; version is parsed from the file name.
+			if _, err := tx.tx.ExecContext(ctx, fmt.Sprintf("PRAGMA user_version = %d", step.version)); err != nil {
+				return fmt.Errorf("record sqlite schema %s: %w", step.name, err)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type schemaStep struct {
+	version int
+	name    string
+	sql     string
+}
+
+func schemaSteps() ([]schemaStep, error) {
+	entries, err := fs.ReadDir(db.SQLiteSchema, "sqlite")
+	if err != nil {
+		return nil, fmt.Errorf("read sqlite schema: %w", err)
+	}
+	steps := make([]schemaStep, 0, len(entries))
+	for _, entry := range entries {
+		name := entry.Name()
+		prefix, _, ok := strings.Cut(name, "_")
+		if !ok || !strings.HasSuffix(name, ".sql") {
+			continue
+		}
+		version, err := strconv.Atoi(prefix)
+		if err != nil {
+			return nil, fmt.Errorf("parse sqlite schema version %s: %w", name, err)
+		}
+		body, err := fs.ReadFile(db.SQLiteSchema, "sqlite/"+name)
+		if err != nil {
+			return nil, fmt.Errorf("read sqlite schema %s: %w", name, err)
+		}
+		steps = append(steps, schemaStep{version: version, name: name, sql: string(body)})
+	}
+	sort.Slice(steps, func(i, j int) bool { return steps[i].version < steps[j].version })
+	return steps, nil
+}
+
+var _ storage.Backend = (*Store)(nil)
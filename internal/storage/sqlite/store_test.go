@@ -0,0 +1,81 @@
+package sqlite
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/shopspring/decimal"
+
+	"price-diff-alerts/internal/storage/storagetest"
+)
+
+func TestConformance(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storagetest.Store {
+		store, err := Open(context.Background(), "sqlite:"+filepath.Join(t.TempDir(), "usde.db"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(store.Close)
+		return store
+	})
+}
+
+func TestPathFromDSN(t *testing.T) {
+	cases := map[string]string{
+		"sqlite:///var/lib/usde.db":      "/var/lib/usde.db",
+		"sqlite:usde.db":                 "usde.db",
+		"sqlite://data/usde.db?mode=rwc": "data/usde.db",
+	}
+	for dsn, want := range cases {
+		got, err := PathFromDSN(dsn)
+		if err != nil || got != want {
+			t.Fatalf("%s 应解析为 %s, 实际 %q err=%v", dsn, want, got, err)
+		}
+	}
+	for _, dsn := range []string{"postgres://localhost/usde", "sqlite:", "sqlite://?cache=shared"} {
+		if _, err := PathFromDSN(dsn); err == nil {
+			t.Fatalf("%s 应报错", dsn)
+		}
+	}
+}
+
+func TestFileLockerExcludesSecondHolder(t *testing.T) {
+	locks := newFileLocker(filepath.Join(t.TempDir(), "usde.db"))
+
+	unlock, ok, err := locks.tryLock(42)
+	if err != nil || !ok {
+		t.Fatalf("首次加锁应成功: ok=%v err=%v", ok, err)
+	}
+	if _, ok, err := locks.tryLock(42); err != nil || ok {
+		t.Fatalf("锁被持有时不应再次获得: ok=%v err=%v", ok, err)
+	}
+	if other, ok, err := locks.tryLock(43); err != nil || !ok {
+		t.Fatalf("不同 key 互不影响: ok=%v err=%v", ok, err)
+	} else {
+		other()
+	}
+
+	unlock()
+	again, ok, err := locks.tryLock(42)
+	if err != nil || !ok {
+		t.Fatalf("释放后应能重新加锁: ok=%v err=%v", ok, err)
+	}
+	again()
+}
+
+func TestDailyStatsWeightAveragesByCompleteSamples(t *testing.T) {
+	var acc statsAcc
+	acc.add(decimal.NewNullDecimal(decimal.NewFromInt(1)), decimal.NewNullDecimal(decimal.NewFromInt(2)),
+		decimal.NewNullDecimal(decimal.NewFromInt(1)), decimal.NewNullDecimal(decimal.NewFromInt(2)), 3)
+	acc.add(decimal.NewNullDecimal(decimal.NewFromInt(3)), decimal.NewNullDecimal(decimal.NewFromInt(5)),
+		decimal.NewNullDecimal(decimal.NewFromInt(5)), decimal.NewNullDecimal(decimal.NewFromInt(4)), 1)
+	// 没有完整样本的小时不影响 avg 与 last。
+	acc.add(decimal.NullDecimal{}, decimal.NullDecimal{}, decimal.NullDecimal{}, decimal.NewNullDecimal(decimal.NewFromInt(9)), 0)
+
+	stats := acc.stats()
+	if !stats.Avg.Decimal.Equal(decimal.NewFromInt(2)) || !stats.Min.Decimal.Equal(decimal.NewFromInt(1)) ||
+		!stats.Max.Decimal.Equal(decimal.NewFromInt(5)) || !stats.Last.Decimal.Equal(decimal.NewFromInt(4)) {
+		t.Fatalf("日汇总统计不正确: %+v", stats)
+	}
+}
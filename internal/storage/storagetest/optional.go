@@ -0,0 +1,508 @@
+package storagetest
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"price-diff-alerts/internal/storage"
+)
+
+// capability returns s as T, skipping the test for backends without it.
+func capability[T any](t *testing.T, s Store) T {
+	t.Helper()
+	c, ok := s.(T)
+	if !ok {
+		t.Skipf("backend does not implement %s", reflect.TypeFor[T]())
+	}
+	return c
+}
+
+func testListSamplesPage(t *testing.T, s Store) {
+	history := capability[storage.HistoryStore](t, s)
+	for i := 0; i < 5; i++ {
+		mustUpsert(t, s, completeSample(base.Add(time.Duration(i)*5*time.Minute), "1"))
+	}
+	other := completeSample(base.Add(5*time.Minute), "1")
+	other.PairID = otherPair
+	mustUpsert(t, s, other)
+
+	// 与 API 的 keyset 翻页一致：多取一条，第 limit+1 条的 bucket 作为下一页起点。
+	var seen []time.Time
+	from := base
+	for {
+		page, err := history.ListSamplesPage(context.Background(), pair, from, base.Add(20*time.Minute), 3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(page) > 3 {
+			t.Fatalf("每页最多 3 条, 实际 %d", len(page))
+		}
+		for _, sample := range page {
+			if sample.PairID != pair {
+				t.Fatalf("分页不应混入其他交易对: %+v", sample)
+			}
+		}
+		if len(page) < 3 {
+			for _, sample := range page {
+				seen = append(seen, sample.Bucket)
+			}
+			break
+		}
+		for _, sample := range page[:2] {
+			seen = append(seen, sample.Bucket)
+		}
+		from = page[2].Bucket
+	}
+	if len(seen) != 4 || !seen[0].Equal(base) || !seen[3].Equal(base.Add(15*time.Minute)) {
+		t.Fatalf("翻页应按 bucket 升序不重不漏地覆盖 [from, to): %v", seen)
+	}
+}
+
+func testAlertState(t *testing.T, s Store) {
+	states := capability[storage.AlertStateStore](t, s)
+	ctx := context.Background()
+
+	if _, found, err := states.GetAlertState(ctx, pair); err != nil || found {
+		t.Fatalf("首次保存前不应找到状态: found=%v err=%v", found, err)
+	}
+
+	pending := base.Add(5 * time.Minute)
+	saved := storage.AlertState{
+		Key:              pair,
+		State:            storage.AlertStatePending,
+		Direction:        "up",
+		BreachCount:      1,
+		BreachWindow:     0b101,
+		PeakDeviationPct: decimal.NewNullDecimal(dec("0.7")),
+		PendingSince:     &pending,
+		LastBucket:       &pending,
+	}
+	if err := states.SaveAlertState(ctx, saved); err != nil {
+		t.Fatal(err)
+	}
+	got, found, err := states.GetAlertState(ctx, pair)
+	if err != nil || !found {
+		t.Fatalf("应读回已保存的状态: found=%v err=%v", found, err)
+	}
+	if got.State != saved.State || got.Direction != "up" || got.BreachCount != 1 || got.BreachWindow != 0b101 ||
+		!got.PeakDeviationPct.Decimal.Equal(dec("0.7")) || got.PendingSince == nil || !got.PendingSince.Equal(pending) ||
+		got.FiringSince != nil || got.Notified {
+		t.Fatalf("状态应原样保存: %+v", got)
+	}
+
+	firing := base.Add(10 * time.Minute)
+	saved.State = storage.AlertStateFiring
+	saved.FiringSince = &firing
+	saved.LastAlertUpAt = &firing
+	saved.Notified = true
+	if err := states.SaveAlertState(ctx, saved); err != nil {
+		t.Fatal(err)
+	}
+	got, _, err = states.GetAlertState(ctx, pair)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.State != storage.AlertStateFiring || got.FiringSince == nil || !got.FiringSince.Equal(firing) || !got.Notified ||
+		got.LastAlertUpAt == nil || got.LastAlertDownAt != nil {
+		t.Fatalf("再次保存应覆盖状态: %+v", got)
+	}
+	if _, found, err := states.GetAlertState(ctx, otherPair); err != nil || found {
+		t.Fatalf("其他交易对不应读到该状态: found=%v err=%v", found, err)
+	}
+}
+
+func testOutboxRetryAndDeadLetter(t *testing.T, s Store) {
+	outbox := capability[storage.OutboxStore](t, s)
+	admin := capability[storage.OutboxAdmin](t, s)
+	ctx := context.Background()
+
+	first, err := outbox.EnqueueOutbox(ctx, storage.OutboxMessage{
+		EpisodeTS:     &base,
+		Kind:          "firing",
+		Payload:       []byte(`{"Kind":"firing"}`),
+		NextAttemptAt: base,
+		Channels:      []string{"telegram", "webhook"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	later, err := outbox.EnqueueOutbox(ctx, storage.OutboxMessage{
+		Kind:          "resolved",
+		Payload:       []byte(`{"Kind":"resolved"}`),
+		NextAttemptAt: base.Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	due, err := outbox.ListDueOutbox(ctx, base, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(due) != 1 || due[0].ID != first || due[0].Status != storage.OutboxStatusPending || due[0].EpisodeTS == nil || !due[0].EpisodeTS.Equal(base) {
+		t.Fatalf("只应取出已到期的消息: %+v", due)
+	}
+	if len(due[0].Channels) != 2 || due[0].Channels[1] != "webhook" || string(due[0].Payload) != `{"Kind":"firing"}` {
+		t.Fatalf("渠道与负载应原样保存: %+v", due[0])
+	}
+
+	failure := "webhook: 502"
+	if err := outbox.MarkOutboxAttempt(ctx, first, storage.OutboxAttempt{
+		Status:        storage.OutboxStatusPending,
+		Error:         &failure,
+		Channels:      []string{"webhook"},
+		NextAttemptAt: base.Add(time.Minute),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if due, err := outbox.ListDueOutbox(ctx, base.Add(30*time.Second), 10); err != nil || len(due) != 0 {
+		t.Fatalf("退避期间不应再次取出: due=%+v err=%v", due, err)
+	}
+	due, err = outbox.ListDueOutbox(ctx, base.Add(time.Hour), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(due) != 2 || due[0].ID != first || due[1].ID != later {
+		t.Fatalf("到期消息应按下次尝试时间排序: %+v", due)
+	}
+	if due[0].Attempts != 1 || due[0].LastError == nil || *due[0].LastError != failure || len(due[0].Channels) != 1 || due[0].Channels[0] != "webhook" {
+		t.Fatalf("重试应只保留失败渠道并记录错误: %+v", due[0])
+	}
+
+	if err := outbox.MarkOutboxAttempt(ctx, first, storage.OutboxAttempt{
+		Status:        storage.OutboxStatusDead,
+		Error:         &failure,
+		Channels:      []string{"webhook"},
+		NextAttemptAt: base.Add(time.Hour),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if due, err := outbox.ListDueOutbox(ctx, base.Add(24*time.Hour), 10); err != nil || len(due) != 1 || due[0].ID != later {
+		t.Fatalf("dead 消息不应再被取出: due=%+v err=%v", due, err)
+	}
+	dead, err := admin.ListOutbox(ctx, storage.OutboxStatusDead, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dead) != 1 || dead[0].ID != first || dead[0].Attempts != 2 {
+		t.Fatalf("应按状态列出 dead 消息: %+v", dead)
+	}
+
+	replayed, err := admin.ReplayDeadOutbox(ctx)
+	if err != nil || replayed != 1 {
+		t.Fatalf("应重放 1 条 dead 消息: replayed=%d err=%v", replayed, err)
+	}
+	due, err = outbox.ListDueOutbox(ctx, time.Now().Add(time.Minute), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(due) != 2 || due[1].ID != first || due[1].Attempts != 0 {
+		t.Fatalf("重放后应重新计数并立即到期: %+v", due)
+	}
+
+	if err := outbox.MarkOutboxAttempt(ctx, first, storage.OutboxAttempt{Status: storage.OutboxStatusDispatched, NextAttemptAt: base}); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := admin.ReplayOutbox(ctx, first); err != nil || ok {
+		t.Fatalf("已发送的消息不应被重放: ok=%v err=%v", ok, err)
+	}
+	if ok, err := admin.ReplayOutbox(ctx, later); err != nil || !ok {
+		t.Fatalf("未发送的消息应可重放: ok=%v err=%v", ok, err)
+	}
+	all, err := admin.ListOutbox(ctx, "", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 2 || all[0].ID != later {
+		t.Fatalf("不带状态时应按 id 倒序列出全部消息: %+v", all)
+	}
+}
+
+func testHourlyAndDailyRollups(t *testing.T, s Store) {
+	rollups := capability[storage.RollupStore](t, s)
+	ctx := context.Background()
+
+	other := completeSample(base, "9")
+	other.PairID = otherPair
+	msg, phase := "rpc timeout", storage.SamplePhaseOfficial
+	mustUpsert(t, s,
+		completeSample(base, "1"),
+		completeSample(base.Add(5*time.Minute), "3"),
+		storage.RateSample{PairID: pair, Bucket: base.Add(10 * time.Minute), Notional: dec("10000"), CowQuality: "optimal",
+			Status: storage.SampleStatusErrored, Error: &msg, ErrorPhase: &phase},
+		completeSample(base.Add(time.Hour), "2"),
+		// 不满一小时的 bucket 不参与汇总。
+		completeSample(base.Add(2*time.Hour), "5"),
+		other,
+	)
+
+	if _, err := rollups.RollupSamples(ctx, storage.RollupHourly, base.Add(2*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	hourly, err := rollups.ListRollupsBetween(ctx, pair, storage.RollupHourly, base, base.Add(24*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hourly) != 2 || !hourly[0].Bucket.Equal(base) || !hourly[1].Bucket.Equal(base.Add(time.Hour)) {
+		t.Fatalf("应按小时升序汇总 before 之前的 bucket: %+v", hourly)
+	}
+	if page, err := rollups.ListRollupsPage(ctx, pair, storage.RollupHourly, base.Add(time.Minute), base.Add(24*time.Hour), 1); err != nil ||
+		len(page) != 1 || !page[0].Bucket.Equal(base.Add(time.Hour)) {
+		t.Fatalf("分页应从 from 起最多返回 limit 条: page=%+v err=%v", page, err)
+	}
+	first := hourly[0]
+	if first.Samples != 3 || first.Complete != 2 || first.PairID != pair {
+		t.Fatalf("小时汇总应统计全部样本与完整样本: %+v", first)
+	}
+	dev := first.Deviation
+	if !dev.Min.Decimal.Equal(dec("1")) || !dev.Max.Decimal.Equal(dec("3")) || !dev.Avg.Decimal.Equal(dec("2")) || !dev.Last.Decimal.Equal(dec("3")) {
+		t.Fatalf("小时偏差统计只应来自完整样本: %+v", dev)
+	}
+
+	day := time.Date(base.Year(), base.Month(), base.Day(), 0, 0, 0, 0, time.UTC)
+	if _, err := rollups.RollupSamples(ctx, storage.RollupDaily, day.Add(24*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	daily, err := rollups.ListRollupsBetween(ctx, pair, storage.RollupDaily, day, day.Add(24*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(daily) != 1 || daily[0].Samples != 4 || daily[0].Complete != 3 {
+		t.Fatalf("日汇总应合并小时汇总: %+v", daily)
+	}
+	// (1+3)/2 权重 2 与 2 权重 1 的加权平均。
+	if !daily[0].Deviation.Avg.Decimal.Round(8).Equal(dec("2")) || !daily[0].Deviation.Last.Decimal.Equal(dec("2")) {
+		t.Fatalf("日均值应按完整样本加权: %+v", daily[0].Deviation)
+	}
+
+	deleted, err := rollups.DeleteRollupsBefore(ctx, storage.RollupHourly, base.Add(time.Hour))
+	if err != nil || deleted != 2 {
+		t.Fatalf("应删除所有交易对早于 before 的小时汇总: deleted=%d err=%v", deleted, err)
+	}
+}
+
+func testRollupsPickUpLateWrites(t *testing.T, s Store) {
+	rollups := capability[storage.RollupStore](t, s)
+	ctx := context.Background()
+
+	mustUpsert(t, s, completeSample(base, "1"), completeSample(base.Add(time.Hour), "2"))
+	if _, err := rollups.RollupSamples(ctx, storage.RollupHourly, base.Add(2*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	// 回填或重试写入的样本落在已汇总小时之前，下一轮仍应汇总进去。
+	other := completeSample(base, "7")
+	other.PairID = otherPair
+	mustUpsert(t, s, completeSample(base.Add(5*time.Minute), "9"), other)
+	if _, err := rollups.RollupSamples(ctx, storage.RollupHourly, base.Add(2*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	hourly, err := rollups.ListRollupsBetween(ctx, pair, storage.RollupHourly, base, base.Add(24*time.Hour))
+	if err != nil || len(hourly) != 2 {
+		t.Fatalf("应有 2 个小时汇总: %+v err=%v", hourly, err)
+	}
+	if hourly[0].Samples != 2 || !hourly[0].Deviation.Max.Decimal.Equal(dec("9")) {
+		t.Fatalf("晚到的样本应计入已汇总过的小时: %+v", hourly[0])
+	}
+
+	day := time.Date(base.Year(), base.Month(), base.Day(), 0, 0, 0, 0, time.UTC)
+	if _, err := rollups.RollupSamples(ctx, storage.RollupDaily, day.Add(24*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	daily, err := rollups.ListRollupsBetween(ctx, pair, storage.RollupDaily, day, day.Add(24*time.Hour))
+	if err != nil || len(daily) != 1 || daily[0].Samples != 3 {
+		t.Fatalf("日汇总应包含晚到的样本: %+v err=%v", daily, err)
+	}
+
+	// 尚未汇总的小时不应被清理。
+	mustUpsert(t, s, completeSample(base.Add(2*time.Hour), "4"))
+	deleted, err := rollups.DeleteSamplesBefore(ctx, base.Add(3*time.Hour))
+	if err != nil || deleted != 4 {
+		t.Fatalf("应只清理已有小时汇总的 4 条样本: deleted=%d err=%v", deleted, err)
+	}
+	left, err := s.ListSamplesBetween(ctx, pair, base, base.Add(24*time.Hour))
+	if err != nil || len(left) != 1 || !left[0].Bucket.Equal(base.Add(2*time.Hour)) {
+		t.Fatalf("未汇总的样本应保留: %+v err=%v", left, err)
+	}
+
+	// 原始样本清理后，更早的日汇总保持不变。
+	if _, err := rollups.RollupSamples(ctx, storage.RollupDaily, day.Add(24*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if daily, err := rollups.ListRollupsBetween(ctx, otherPair, storage.RollupDaily, day, day.Add(24*time.Hour)); err != nil ||
+		len(daily) != 1 || daily[0].Samples != 1 {
+		t.Fatalf("原始样本已清理的交易对应保留原有日汇总: %+v err=%v", daily, err)
+	}
+}
+
+func testRollupsKeepPartialLegs(t *testing.T, s Store) {
+	rollups := capability[storage.RollupStore](t, s)
+	ctx := context.Background()
+
+	// 回填的 bucket 没有 CoW 报价时只有官方价，汇总仍应保留这一腿。
+	partial := func(bucket time.Time, official string) storage.RateSample {
+		sample := completeSample(bucket, "0")
+		sample.OfficialRate = decimal.NewNullDecimal(dec(official))
+		sample.MarketRate = decimal.NullDecimal{}
+		sample.DeviationPct = decimal.NullDecimal{}
+		sample.CowQuote = nil
+		sample.Status = storage.SampleStatusPartial
+		return sample
+	}
+	mustUpsert(t, s, completeSample(base, "1"), partial(base.Add(5*time.Minute), "1.5"), partial(base.Add(time.Hour), "1.6"))
+
+	if _, err := rollups.RollupSamples(ctx, storage.RollupHourly, base.Add(2*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	hourly, err := rollups.ListRollupsBetween(ctx, pair, storage.RollupHourly, base, base.Add(24*time.Hour))
+	if err != nil || len(hourly) != 2 {
+		t.Fatalf("应有 2 个小时汇总: %+v err=%v", hourly, err)
+	}
+	first := hourly[0]
+	if first.Complete != 1 || first.Official.Count != 2 || first.Market.Count != 1 || first.Deviation.Count != 1 {
+		t.Fatalf("各腿应按各自的非空样本计数: %+v", first)
+	}
+	if !first.Official.Max.Decimal.Equal(dec("1.5")) || !first.Official.Avg.Decimal.Equal(dec("1.3")) || !first.Official.Last.Decimal.Equal(dec("1.5")) {
+		t.Fatalf("partial 样本的官方价应计入官方价统计: %+v", first.Official)
+	}
+	if !first.Deviation.Max.Decimal.Equal(dec("1")) {
+		t.Fatalf("偏差统计只应来自完整样本: %+v", first.Deviation)
+	}
+	if second := hourly[1]; second.Complete != 0 || second.Official.Count != 1 || !second.Official.Last.Decimal.Equal(dec("1.6")) || second.Market.Avg.Valid {
+		t.Fatalf("只有 partial 样本的小时也应保留官方价: %+v", second)
+	}
+
+	day := time.Date(base.Year(), base.Month(), base.Day(), 0, 0, 0, 0, time.UTC)
+	if _, err := rollups.RollupSamples(ctx, storage.RollupDaily, day.Add(24*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	daily, err := rollups.ListRollupsBetween(ctx, pair, storage.RollupDaily, day, day.Add(24*time.Hour))
+	if err != nil || len(daily) != 1 {
+		t.Fatalf("应有 1 个日汇总: %+v err=%v", daily, err)
+	}
+	// 1.3 权重 2 与 1.6 权重 1 的加权平均。
+	official := daily[0].Official
+	if official.Count != 3 || !official.Avg.Decimal.Round(8).Equal(dec("1.4")) || !official.Last.Decimal.Equal(dec("1.6")) {
+		t.Fatalf("日汇总的官方价应按官方价样本数加权: %+v", official)
+	}
+}
+
+func testBackfillCheckpoint(t *testing.T, s Store) {
+	checkpoints := capability[storage.BackfillCheckpointStore](t, s)
+	ctx := context.Background()
+	const job = "usde-susde:2025-09-01:2025-09-02"
+
+	if _, found, err := checkpoints.GetBackfillCheckpoint(ctx, job); err != nil || found {
+		t.Fatalf("任务从未运行时不应有检查点: found=%v err=%v", found, err)
+	}
+	cp := storage.BackfillCheckpoint{
+		JobKey:    job,
+		From:      base,
+		To:        base.Add(24 * time.Hour),
+		Interval:  5 * time.Minute,
+		Watermark: base.Add(time.Hour),
+		Processed: 12,
+	}
+	if err := checkpoints.SaveBackfillCheckpoint(ctx, cp); err != nil {
+		t.Fatal(err)
+	}
+	cp.Watermark = base.Add(2 * time.Hour)
+	cp.Processed, cp.Failed = 24, 1
+	if err := checkpoints.SaveBackfillCheckpoint(ctx, cp); err != nil {
+		t.Fatal(err)
+	}
+
+	got, found, err := checkpoints.GetBackfillCheckpoint(ctx, job)
+	if err != nil || !found {
+		t.Fatalf("应读回检查点: found=%v err=%v", found, err)
+	}
+	if !got.From.Equal(cp.From) || !got.To.Equal(cp.To) || got.Interval != cp.Interval || !got.Watermark.Equal(cp.Watermark) ||
+		got.Processed != 24 || got.Failed != 1 {
+		t.Fatalf("再次保存应推进检查点: %+v", got)
+	}
+}
+
+func testDigestPeriods(t *testing.T, s Store) {
+	digests := capability[storage.DigestStore](t, s)
+	ctx := context.Background()
+
+	if _, found, err := digests.LastDigestEnd(ctx, pair); err != nil || found {
+		t.Fatalf("从未发送过摘要时不应有记录: found=%v err=%v", found, err)
+	}
+	if err := digests.MarkDigestSent(ctx, pair, base.Add(24*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	// 晚到的实例补记更早的周期时不应让记录倒退。
+	if err := digests.MarkDigestSent(ctx, pair, base); err != nil {
+		t.Fatal(err)
+	}
+	end, found, err := digests.LastDigestEnd(ctx, pair)
+	if err != nil || !found || !end.Equal(base.Add(24*time.Hour)) {
+		t.Fatalf("应读回最近发送的周期终点: end=%s found=%v err=%v", end, found, err)
+	}
+	if _, found, err := digests.LastDigestEnd(ctx, "other"); err != nil || found {
+		t.Fatalf("摘要记录应按交易对区分: found=%v err=%v", found, err)
+	}
+}
+
+func testInTxRollsBack(t *testing.T, s Store) {
+	uow := capability[storage.UnitOfWork](t, s)
+	ctx := context.Background()
+	errAbort := errors.New("abort")
+
+	write := func(tx storage.Tx, bucket time.Time) error {
+		sample := completeSample(bucket, "1")
+		if err := tx.UpsertRateSample(ctx, sample); err != nil {
+			return err
+		}
+		if _, err := tx.InsertAlert(ctx, pendingAlert(bucket)); err != nil {
+			return err
+		}
+		if err := tx.SaveAlertState(ctx, storage.AlertState{Key: pair, State: storage.AlertStatePending, LastBucket: &bucket}); err != nil {
+			return err
+		}
+		_, err := tx.EnqueueOutbox(ctx, storage.OutboxMessage{EpisodeTS: &bucket, Kind: "firing", Payload: []byte(`{}`), NextAttemptAt: bucket})
+		return err
+	}
+
+	err := uow.InTx(ctx, func(tx storage.Tx) error {
+		if err := write(tx, base); err != nil {
+			return err
+		}
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) {
+		t.Fatalf("InTx 应返回 fn 的错误: %v", err)
+	}
+	if count, err := s.CountSamples(ctx); err != nil || count != 0 {
+		t.Fatalf("回滚后不应留下样本: count=%d err=%v", count, err)
+	}
+	if alerts, err := s.ListRecentAlerts(ctx, 10); err != nil || len(alerts) != 0 {
+		t.Fatalf("回滚后不应留下告警: alerts=%+v err=%v", alerts, err)
+	}
+	if states, ok := s.(storage.AlertStateStore); ok {
+		if _, found, err := states.GetAlertState(ctx, pair); err != nil || found {
+			t.Fatalf("回滚后不应留下告警状态: found=%v err=%v", found, err)
+		}
+	}
+	if outbox, ok := s.(storage.OutboxStore); ok {
+		if due, err := outbox.ListDueOutbox(ctx, base.Add(time.Hour), 10); err != nil || len(due) != 0 {
+			t.Fatalf("回滚后不应留下 outbox 消息: due=%+v err=%v", due, err)
+		}
+	}
+
+	if err := uow.InTx(ctx, func(tx storage.Tx) error { return write(tx, base) }); err != nil {
+		t.Fatal(err)
+	}
+	if count, err := s.CountSamples(ctx); err != nil || count != 1 {
+		t.Fatalf("提交后应保存样本: count=%d err=%v", count, err)
+	}
+	if alerts, err := s.ListRecentAlerts(ctx, 10); err != nil || len(alerts) != 1 {
+		t.Fatalf("提交后应保存告警: alerts=%+v err=%v", alerts, err)
+	}
+}
@@ -0,0 +1,412 @@
+// Package storagetest is the conformance suite every storage backend must
+// pass, so the in-memory store used by tests and dry runs cannot drift from
+// PostgreSQL.
+package storagetest
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"price-diff-alerts/internal/storage"
+)
+
+// Store is the surface every backend must provide. Alert state, outbox,
+// rollup, checkpoint and transaction cases run when the backend implements
+// the matching storage interface and are skipped otherwise.
+type Store interface {
+	storage.RateSampleStore
+	storage.AlertStore
+	storage.AdvisoryLocker
+}
+
+// Run executes the suite. open must return an empty store for every call.
+func Run(t *testing.T, open func(t *testing.T) Store) {
+	cases := []struct {
+		name string
+		fn   func(t *testing.T, s Store)
+	}{
+		{"UpsertReplacesSampleForBucket", testUpsertReplacesSample},
+		{"ListSamplesBetweenIsHalfOpen", testListSamplesBetween},
+		{"ListRecentSamplesNewestFirst", testListRecentSamples},
+		{"ErroredSampleKeepsNullRates", testErroredSample},
+		{"MarkSampleErroredNeedsExistingRow", testMarkSampleErrored},
+		{"SamplesArePartitionedByPair", testSamplesPerPair},
+		{"InsertAlertIsUniquePerSampleTS", testInsertAlertUnique},
+		{"AlertsArePartitionedByPair", testAlertsPerPair},
+		{"UpdateAndResolveAlert", testUpdateAndResolveAlert},
+		{"DeleteAlertsBefore", testDeleteAlertsBefore},
+		{"AdvisoryLockIsExclusive", testAdvisoryLock},
+		{"ListSamplesPageIsBounded", testListSamplesPage},
+		{"AlertStateRoundTrip", testAlertState},
+		{"OutboxRetryAndDeadLetter", testOutboxRetryAndDeadLetter},
+		{"HourlyAndDailyRollups", testHourlyAndDailyRollups},
+		{"RollupsPickUpLateWrites", testRollupsPickUpLateWrites},
+		{"RollupsKeepPartialLegs", testRollupsKeepPartialLegs},
+		{"BackfillCheckpointAdvances", testBackfillCheckpoint},
+		{"DigestPeriodsAdvance", testDigestPeriods},
+		{"InTxRollsBackOnError", testInTxRollsBack},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.fn(t, open(t))
+		})
+	}
+}
+
+var base = time.Date(2025, 9, 22, 10, 0, 0, 0, time.UTC)
+
+// pair keys every row the suite writes; otherPair checks that pairs never see
+// each other's rows.
+const (
+	pair      = "usde-susde"
+	otherPair = "dai-sdai"
+)
+
+func dec(v string) decimal.Decimal {
+	return decimal.RequireFromString(v)
+}
+
+func completeSample(bucket time.Time, deviation string) storage.RateSample {
+	block := int64(20_000_000)
+	method := "previewDeposit"
+	hash := "0x5ca1ab1e"
+	blockTime := bucket.Add(-7 * time.Second)
+	return storage.RateSample{
+		PairID:         pair,
+		Bucket:         bucket,
+		OfficialRate:   decimal.NewNullDecimal(dec("1.1")),
+		MarketRate:     decimal.NewNullDecimal(dec("1.2")),
+		DeviationPct:   decimal.NewNullDecimal(dec(deviation)),
+		Notional:       dec("10000"),
+		CowQuality:     "optimal",
+		CowQuote:       json.RawMessage(`{"id":1}`),
+		BlockNumber:    &block,
+		BlockHash:      &hash,
+		BlockTime:      &blockTime,
+		OfficialMethod: &method,
+		Status:         storage.SampleStatusComplete,
+	}
+}
+
+func mustUpsert(t *testing.T, s Store, samples ...storage.RateSample) {
+	t.Helper()
+	for _, sample := range samples {
+		if err := s.UpsertRateSample(context.Background(), sample); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func testUpsertReplacesSample(t *testing.T, s Store) {
+	ctx := context.Background()
+	mustUpsert(t, s, completeSample(base, "1"))
+
+	replaced := completeSample(base, "2.5")
+	replaced.CowQuality = "fast"
+	mustUpsert(t, s, replaced)
+
+	count, err := s.CountSamples(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	samples, err := s.ListSamplesBetween(ctx, pair, base, base.Add(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 || len(samples) != 1 {
+		t.Fatalf("同一 bucket 应只保留一行: count=%d samples=%d", count, len(samples))
+	}
+	got := samples[0]
+	if !got.Bucket.Equal(base) || !got.DeviationPct.Decimal.Equal(dec("2.5")) || got.CowQuality != "fast" {
+		t.Fatalf("upsert 应覆盖已有样本: %+v", got)
+	}
+	if got.BlockNumber == nil || *got.BlockNumber != 20_000_000 || string(got.CowQuote) == "" || got.OfficialMethod == nil || *got.OfficialMethod != "previewDeposit" {
+		t.Fatalf("可空列应原样保存: %+v", got)
+	}
+	if got.BlockHash == nil || *got.BlockHash != "0x5ca1ab1e" || got.BlockTime == nil || !got.BlockTime.Equal(base.Add(-7*time.Second)) {
+		t.Fatalf("区块哈希与时间应原样保存: %v %v", got.BlockHash, got.BlockTime)
+	}
+}
+
+func testListSamplesBetween(t *testing.T, s Store) {
+	for i := 3; i >= 0; i-- {
+		mustUpsert(t, s, completeSample(base.Add(time.Duration(i)*5*time.Minute), "1"))
+	}
+
+	samples, err := s.ListSamplesBetween(context.Background(), pair, base.Add(5*time.Minute), base.Add(15*time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(samples) != 2 || !samples[0].Bucket.Equal(base.Add(5*time.Minute)) || !samples[1].Bucket.Equal(base.Add(10*time.Minute)) {
+		t.Fatalf("区间应为 [from, to) 且按 bucket 升序: %+v", samples)
+	}
+}
+
+func testListRecentSamples(t *testing.T, s Store) {
+	for i := 0; i < 4; i++ {
+		mustUpsert(t, s, completeSample(base.Add(time.Duration(i)*5*time.Minute), "1"))
+	}
+
+	samples, err := s.ListRecentSamples(context.Background(), pair, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(samples) != 2 || !samples[0].Bucket.Equal(base.Add(15*time.Minute)) || !samples[1].Bucket.Equal(base.Add(10*time.Minute)) {
+		t.Fatalf("应按 bucket 倒序返回最近 2 条: %+v", samples)
+	}
+}
+
+func testErroredSample(t *testing.T, s Store) {
+	msg, phase := "rpc timeout", storage.SamplePhaseOfficial
+	mustUpsert(t, s, storage.RateSample{
+		PairID:     pair,
+		Bucket:     base,
+		Notional:   dec("10000"),
+		CowQuality: "optimal",
+		Status:     storage.SampleStatusErrored,
+		Error:      &msg,
+		ErrorPhase: &phase,
+	})
+
+	samples, err := s.ListSamplesBetween(context.Background(), pair, base, base.Add(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(samples) != 1 {
+		t.Fatalf("应读回 1 条样本, 实际 %d", len(samples))
+	}
+	got := samples[0]
+	if got.OfficialRate.Valid || got.MarketRate.Valid || got.DeviationPct.Valid || got.BlockNumber != nil || got.BlockHash != nil || got.BlockTime != nil || got.OfficialMethod != nil || len(got.CowQuote) != 0 {
+		t.Fatalf("失败样本的空列应保持为空: %+v", got)
+	}
+	if got.Error == nil || *got.Error != msg || got.ErrorPhase == nil || *got.ErrorPhase != phase {
+		t.Fatalf("错误信息应原样保存: %+v", got)
+	}
+}
+
+func testMarkSampleErrored(t *testing.T, s Store) {
+	ctx := context.Background()
+	if err := s.MarkSampleErrored(ctx, pair, base, storage.SamplePhaseMarket, "boom"); err == nil {
+		t.Fatal("样本不存在时 MarkSampleErrored 应报错")
+	}
+
+	mustUpsert(t, s, completeSample(base, "1"))
+	if err := s.MarkSampleErrored(ctx, otherPair, base, storage.SamplePhaseMarket, "boom"); err == nil {
+		t.Fatal("其他交易对没有该样本时 MarkSampleErrored 应报错")
+	}
+	if err := s.MarkSampleErrored(ctx, pair, base, storage.SamplePhaseMarket, "boom"); err != nil {
+		t.Fatal(err)
+	}
+	samples, err := s.ListRecentSamples(ctx, pair, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := samples[0]
+	if got.Status != storage.SampleStatusErrored || got.ErrorPhase == nil || *got.ErrorPhase != storage.SamplePhaseMarket {
+		t.Fatalf("样本应标记为 errored: %+v", got)
+	}
+}
+
+func testSamplesPerPair(t *testing.T, s Store) {
+	ctx := context.Background()
+	other := completeSample(base, "3")
+	other.PairID = otherPair
+	mustUpsert(t, s, completeSample(base, "1"), other)
+
+	count, err := s.CountSamples(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 {
+		t.Fatalf("不同交易对的同一 bucket 应各占一行, 实际 %d", count)
+	}
+	for _, want := range []struct {
+		pairID    string
+		deviation string
+	}{{pair, "1"}, {otherPair, "3"}} {
+		samples, err := s.ListSamplesBetween(ctx, want.pairID, base, base.Add(time.Minute))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(samples) != 1 || samples[0].PairID != want.pairID || !samples[0].DeviationPct.Decimal.Equal(dec(want.deviation)) {
+			t.Fatalf("%s 只应读到自己的样本: %+v", want.pairID, samples)
+		}
+		recent, err := s.ListRecentSamples(ctx, want.pairID, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(recent) != 1 || recent[0].PairID != want.pairID {
+			t.Fatalf("%s 的最近样本不应混入其他交易对: %+v", want.pairID, recent)
+		}
+	}
+}
+
+func pendingAlert(sampleTS time.Time) storage.AlertRecord {
+	return storage.AlertRecord{
+		PairID:       pair,
+		SampleTS:     sampleTS,
+		DeviationPct: dec("0.6"),
+		ThresholdPct: dec("0.5"),
+		Direction:    "up",
+		Channels:     []string{"telegram"},
+		State:        storage.AlertStatePending,
+	}
+}
+
+func testInsertAlertUnique(t *testing.T, s Store) {
+	ctx := context.Background()
+	first, err := s.InsertAlert(ctx, pendingAlert(base))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	confirmed := base.Add(5 * time.Minute)
+	firing := pendingAlert(base)
+	firing.State = storage.AlertStateFiring
+	firing.ConfirmedAt = &confirmed
+	firing.DeviationPct = dec("0.8")
+	second, err := s.InsertAlert(ctx, firing)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.ID != first.ID {
+		t.Fatalf("同一 sample_ts 应更新同一行: first=%d second=%d", first.ID, second.ID)
+	}
+
+	other, err := s.InsertAlert(ctx, pendingAlert(base.Add(time.Hour)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other.ID == first.ID {
+		t.Fatal("不同 sample_ts 应插入新行")
+	}
+
+	alerts, err := s.ListRecentAlerts(ctx, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(alerts) != 2 {
+		t.Fatalf("应有 2 个告警, 实际 %d", len(alerts))
+	}
+	for _, alert := range alerts {
+		if alert.ID != first.ID {
+			continue
+		}
+		if alert.State != storage.AlertStateFiring || alert.ConfirmedAt == nil || !alert.ConfirmedAt.Equal(confirmed) || !alert.DeviationPct.Equal(dec("0.8")) {
+			t.Fatalf("告警应更新为 firing: %+v", alert)
+		}
+		if len(alert.Channels) != 1 || alert.Channels[0] != "telegram" {
+			t.Fatalf("渠道应原样保存: %+v", alert.Channels)
+		}
+	}
+}
+
+func testAlertsPerPair(t *testing.T, s Store) {
+	ctx := context.Background()
+	first, err := s.InsertAlert(ctx, pendingAlert(base))
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherAlert := pendingAlert(base)
+	otherAlert.PairID = otherPair
+	other, err := s.InsertAlert(ctx, otherAlert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other.ID == first.ID || other.PairID != otherPair {
+		t.Fatalf("不同交易对的同一 sample_ts 应插入新行: first=%+v other=%+v", first, other)
+	}
+
+	if err := s.ResolveAlert(ctx, otherPair, base, base.Add(time.Hour), dec("1.3")); err != nil {
+		t.Fatal(err)
+	}
+	alerts, err := s.ListRecentAlerts(ctx, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(alerts) != 2 {
+		t.Fatalf("应有 2 个告警, 实际 %d", len(alerts))
+	}
+	for _, alert := range alerts {
+		resolved := alert.State == storage.AlertStateResolved
+		if resolved != (alert.PairID == otherPair) {
+			t.Fatalf("恢复只应作用于对应交易对: %+v", alert)
+		}
+	}
+}
+
+func testUpdateAndResolveAlert(t *testing.T, s Store) {
+	ctx := context.Background()
+	if _, err := s.InsertAlert(ctx, pendingAlert(base)); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.UpdateAlertState(ctx, pair, base, storage.AlertStateFiring); err != nil {
+		t.Fatal(err)
+	}
+	resolvedAt := base.Add(time.Hour)
+	if err := s.ResolveAlert(ctx, pair, base, resolvedAt, dec("1.3")); err != nil {
+		t.Fatal(err)
+	}
+
+	alerts, err := s.ListRecentAlerts(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := alerts[0]
+	if got.State != storage.AlertStateResolved || got.ResolvedAt == nil || !got.ResolvedAt.Equal(resolvedAt) {
+		t.Fatalf("告警应已恢复: %+v", got)
+	}
+	if !got.PeakDeviationPct.Valid || !got.PeakDeviationPct.Decimal.Equal(dec("1.3")) {
+		t.Fatalf("应记录峰值偏离: %+v", got.PeakDeviationPct)
+	}
+}
+
+func testDeleteAlertsBefore(t *testing.T, s Store) {
+	ctx := context.Background()
+	if _, err := s.InsertAlert(ctx, pendingAlert(base)); err != nil {
+		t.Fatal(err)
+	}
+
+	// created_at 由存储写入，这里只用远早于/远晚于当前的时间判断。
+	if err := s.DeleteAlertsBefore(ctx, time.Now().Add(-24*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if alerts, err := s.ListRecentAlerts(ctx, 10); err != nil || len(alerts) != 1 {
+		t.Fatalf("较新的告警不应被删除: alerts=%d err=%v", len(alerts), err)
+	}
+	if err := s.DeleteAlertsBefore(ctx, time.Now().Add(24*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if alerts, err := s.ListRecentAlerts(ctx, 10); err != nil || len(alerts) != 0 {
+		t.Fatalf("过期告警应被删除: alerts=%d err=%v", len(alerts), err)
+	}
+}
+
+func testAdvisoryLock(t *testing.T, s Store) {
+	ctx := context.Background()
+	const key = 0x5e5de
+
+	unlock, ok, err := s.TryAdvisoryLock(ctx, key)
+	if err != nil || !ok {
+		t.Fatalf("首次加锁应成功: ok=%v err=%v", ok, err)
+	}
+	if _, ok, err := s.TryAdvisoryLock(ctx, key); err != nil || ok {
+		t.Fatalf("锁被持有时不应再次获得: ok=%v err=%v", ok, err)
+	}
+	other, ok, err := s.TryAdvisoryLock(ctx, key+1)
+	if err != nil || !ok {
+		t.Fatalf("不同 key 互不影响: ok=%v err=%v", ok, err)
+	}
+	other()
+
+	unlock()
+	again, ok, err := s.TryAdvisoryLock(ctx, key)
+	if err != nil || !ok {
+		t.Fatalf("释放后应能重新加锁: ok=%v err=%v", ok, err)
+	}
+	again()
+}
@@ -4,13 +4,14 @@ set -euo pipefail
 # 构建并以后台方式启动 usdewatcher 服务
 # 可在服务器上执行：
 #   REPO_DIR=/srv/price-diff-alerts ./scripts/start_service.sh
-# 可通过 ENV 控制：GO_FLAGS, CONFIG_PATH, LOG_FILE
+# 可通过 ENV 控制：GO_FLAGS, CONFIG_PATH, LOG_FILE, PID_FILE
 
 REPO_DIR=${REPO_DIR:-$(pwd)}
 BIN_DIR=${BIN_DIR:-$REPO_DIR/bin}
 BIN_PATH=${BIN_PATH:-$BIN_DIR/usdewatcher}
 CONFIG_PATH=${CONFIG_PATH:-$REPO_DIR/config.yaml}
 LOG_FILE=${LOG_FILE:-$REPO_DIR/logs/usdewatcher.log}
+PID_FILE=${PID_FILE:-$REPO_DIR/logs/usdewatcher.pid}
 GO_FLAGS=${GO_FLAGS:-}
 
 if [[ ! -f "$CONFIG_PATH" ]]; then
@@ -18,7 +19,7 @@ if [[ ! -f "$CONFIG_PATH" ]]; then
   exit 1
 fi
 
-mkdir -p "$BIN_DIR" "$(dirname "$LOG_FILE")"
+mkdir -p "$BIN_DIR" "$(dirname "$LOG_FILE")" "$(dirname "$PID_FILE")"
 
 cd "$REPO_DIR"
 
@@ -28,5 +29,6 @@ go build $GO_FLAGS -o "$BIN_PATH" ./cmd/usdewatcher
 echo "以 nohup 方式启动 (usdewatcher run)..."
 nohup "$BIN_PATH" run --config "$CONFIG_PATH" >>"$LOG_FILE" 2>&1 &
 PID=$!
+echo "$PID" >"$PID_FILE"
 
-echo "服务已启动，PID=$PID，日志输出到 $LOG_FILE"
+echo "服务已启动，PID=$PID（记录在 $PID_FILE），日志输出到 $LOG_FILE"
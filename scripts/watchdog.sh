@@ -0,0 +1,46 @@
+#!/usr/bin/env bash
+set -euo pipefail
+
+# nohup 部署的看门狗：探测 /healthz，失败则重启 usdewatcher。
+# 建议用 cron 每分钟执行一次，例如：
+#   * * * * * REPO_DIR=/srv/price-diff-alerts /srv/price-diff-alerts/scripts/watchdog.sh >>/srv/price-diff-alerts/logs/watchdog.log 2>&1
+# 可通过 ENV 控制：CONFIG_PATH, PID_FILE, BIN_PATH, HEALTH_URL, READY(=1 时探测 /readyz)
+# 重启通过 start_service.sh 完成，因此 LOG_FILE、GO_FLAGS 等变量同样生效。
+
+REPO_DIR=${REPO_DIR:-$(cd "$(dirname "$0")/.." && pwd)}
+BIN_PATH=${BIN_PATH:-$REPO_DIR/bin/usdewatcher}
+CONFIG_PATH=${CONFIG_PATH:-$REPO_DIR/config.yaml}
+PID_FILE=${PID_FILE:-$REPO_DIR/logs/usdewatcher.pid}
+HEALTH_URL=${HEALTH_URL:-}
+READY=${READY:-0}
+
+export REPO_DIR BIN_PATH CONFIG_PATH PID_FILE
+
+args=(healthcheck --config "$CONFIG_PATH")
+if [[ -n "$HEALTH_URL" ]]; then
+  args+=(--url "$HEALTH_URL")
+fi
+if [[ "$READY" == "1" ]]; then
+  args+=(--ready)
+fi
+
+if [[ -x "$BIN_PATH" ]] && "$BIN_PATH" "${args[@]}" >/dev/null; then
+  exit 0
+fi
+
+echo "$(date -u +%FT%TZ) 健康检查失败，重启 usdewatcher"
+
+if [[ -f "$PID_FILE" ]]; then
+  PID=$(cat "$PID_FILE")
+  if kill -0 "$PID" 2>/dev/null; then
+    kill "$PID"
+    # 等待优雅退出，超时后强制结束
+    for _ in $(seq 1 30); do
+      kill -0 "$PID" 2>/dev/null || break
+      sleep 1
+    done
+    kill -0 "$PID" 2>/dev/null && kill -9 "$PID"
+  fi
+fi
+
+"$REPO_DIR/scripts/start_service.sh"